@@ -0,0 +1,108 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package analysis
+
+import (
+	"istio.io/istio/galley/pkg/config/collection"
+	"istio.io/istio/galley/pkg/config/scope"
+)
+
+// Analyzer is an interface for analyzing configuration.
+type Analyzer interface {
+	// Name of the analyzer, unique among all the analyzers.
+	Name() string
+
+	// Inputs returns the collections the analyzer reads.
+	Inputs() collection.Names
+
+	// Analyze the configuration, and report the findings to the context.
+	Analyze(c Context)
+}
+
+// CombinedAnalyzer is a special Analyzer that combines multiple analyzers into one
+type CombinedAnalyzer struct {
+	name      string
+	analyzers []Analyzer
+}
+
+var _ Analyzer = &CombinedAnalyzer{}
+
+// Combine multiple analyzers into a single one.
+func Combine(name string, analyzers ...Analyzer) *CombinedAnalyzer {
+	return &CombinedAnalyzer{
+		name:      name,
+		analyzers: analyzers,
+	}
+}
+
+// Name implements Analyzer
+func (c *CombinedAnalyzer) Name() string {
+	return c.name
+}
+
+// Inputs implements Analyzer
+func (c *CombinedAnalyzer) Inputs() collection.Names {
+	seen := make(map[collection.Name]struct{})
+	var result collection.Names
+	for _, a := range c.analyzers {
+		for _, in := range a.Inputs() {
+			if _, found := seen[in]; !found {
+				seen[in] = struct{}{}
+				result = append(result, in)
+			}
+		}
+	}
+	return result
+}
+
+// Analyze implements Analyzer
+func (c *CombinedAnalyzer) Analyze(ctx Context) {
+	for _, a := range c.analyzers {
+		if ctx.Canceled() {
+			return
+		}
+		scope.Analysis.Debugf("Started analyzer %q...", a.Name())
+		a.Analyze(ctx)
+		scope.Analysis.Debugf("Completed analyzer %q...", a.Name())
+	}
+}
+
+// Analyzers returns the analyzers that are combined.
+func (c *CombinedAnalyzer) Analyzers() []Analyzer {
+	result := make([]Analyzer, len(c.analyzers))
+	copy(result, c.analyzers)
+	return result
+}
+
+// WithoutInputs returns a CombinedAnalyzer with only the analyzers that do not read any of the given
+// collections, e.g. because they are not available in the analyzed configuration. It also returns the
+// names of the analyzers that were removed.
+func (c *CombinedAnalyzer) WithoutInputs(unavailable collection.Names) (*CombinedAnalyzer, []string) {
+	var kept []Analyzer
+	var removed []string
+loop:
+	for _, a := range c.analyzers {
+		for _, in := range a.Inputs() {
+			for _, u := range unavailable {
+				if in == u {
+					removed = append(removed, a.Name())
+					continue loop
+				}
+			}
+		}
+		kept = append(kept, a)
+	}
+	return Combine(c.name, kept...), removed
+}
@@ -0,0 +1,117 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package analyzers
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/auth"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/destinationrule"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/gateway"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/virtualservice"
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/galley/pkg/config/analysis/local"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/galley/pkg/config/processor/metadata"
+)
+
+type message struct {
+	messageType *diag.MessageType
+	origin      string
+}
+
+type testCase struct {
+	name       string
+	inputFiles []string
+	analyzer   analysis.Analyzer
+	expected   []message
+}
+
+var testGrid = []testCase{
+	{
+		name:       "serviceRoleBindings",
+		inputFiles: []string{"testdata/serviceroles.yaml"},
+		analyzer:   &auth.ServiceRoleAnalyzer{},
+		expected: []message{
+			{msg.UnreferencedResource, "ServiceRole editor"},
+		},
+	},
+	{
+		name:       "destinationRuleConflicts",
+		inputFiles: []string{"testdata/destinationrule_conflict.yaml"},
+		analyzer:   &destinationrule.ConflictAnalyzer{},
+		expected: []message{
+			{msg.ConflictingDestinationRules, "DestinationRule reviews-second"},
+			{msg.ConflictingDestinationRules, "DestinationRule reviews-second"},
+		},
+	},
+	{
+		// The pods are not available from files, so the analyzer is skipped.
+		name:       "gatewaySelectorFromFiles",
+		inputFiles: []string{"testdata/gateway_selector.yaml"},
+		analyzer:   &gateway.SelectorAnalyzer{},
+		expected:   []message{},
+	},
+	{
+		name:       "virtualServiceDestinationRules",
+		inputFiles: []string{"testdata/virtualservice_destinationrules.yaml"},
+		analyzer:   &virtualservice.DestinationRuleAnalyzer{},
+		expected: []message{
+			{msg.ReferencedResourceNotFound, "VirtualService reviews"},
+			{msg.ReferencedResourceNotFound, "VirtualService reviews-tcp"},
+		},
+	},
+}
+
+func TestAnalyzers(t *testing.T) {
+	for _, tc := range testGrid {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			sa := local.NewSourceAnalyzer(metadata.MustGet(), analysis.Combine("testCombined", tc.analyzer), "")
+			g.Expect(sa.AddFileKubeSource(tc.inputFiles)).To(Succeed())
+
+			cancel := make(chan struct{})
+			msgs, err := sa.Analyze(cancel)
+			g.Expect(err).To(BeNil())
+
+			actual := make([]message, 0)
+			for _, m := range msgs {
+				actual = append(actual, message{m.Type, m.Origin()})
+			}
+			g.Expect(actual).To(ConsistOf(tc.expected))
+		})
+	}
+}
+
+func TestAll_InputsAreCollections(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	names := make(map[string]bool)
+	for _, a := range All() {
+		g.Expect(names[a.Name()]).To(BeFalse(), "duplicate analyzer name %q", a.Name())
+		names[a.Name()] = true
+
+		for _, in := range a.Inputs() {
+			_, found := metadata.MustGet().Collections().Lookup(in.String())
+			g.Expect(found).To(BeTrue(), "unknown input %q of analyzer %q", in, a.Name())
+		}
+	}
+	g.Expect(AllCombined().Analyzers()).To(HaveLen(len(All())))
+}
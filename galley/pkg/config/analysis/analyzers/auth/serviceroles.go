@@ -0,0 +1,70 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"istio.io/api/rbac/v1alpha1"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/galley/pkg/config/collection"
+	"istio.io/istio/galley/pkg/config/processor/metadata"
+	"istio.io/istio/galley/pkg/config/resource"
+)
+
+// ServiceRoleAnalyzer checks that each ServiceRole is referenced by a ServiceRoleBinding of its namespace.
+type ServiceRoleAnalyzer struct{}
+
+var _ analysis.Analyzer = &ServiceRoleAnalyzer{}
+
+// Name implements Analyzer
+func (s *ServiceRoleAnalyzer) Name() string {
+	return "auth.ServiceRoleAnalyzer"
+}
+
+// Inputs implements Analyzer
+func (s *ServiceRoleAnalyzer) Inputs() collection.Names {
+	return collection.Names{
+		metadata.IstioRbacV1Alpha1Serviceroles,
+		metadata.IstioRbacV1Alpha1Servicerolebindings,
+	}
+}
+
+// Analyze implements Analyzer
+func (s *ServiceRoleAnalyzer) Analyze(ctx analysis.Context) {
+	// The names of the referenced roles, keyed by namespace.
+	referenced := make(map[string]map[string]bool)
+	ctx.ForEach(metadata.IstioRbacV1Alpha1Servicerolebindings, func(e *resource.Entry) bool {
+		ref := e.Item.(*v1alpha1.ServiceRoleBinding).GetRoleRef()
+		if ref == nil || ref.Name == "" {
+			return true
+		}
+		ns := util.GetResourceNamespace(e)
+		if referenced[ns] == nil {
+			referenced[ns] = make(map[string]bool)
+		}
+		referenced[ns][ref.Name] = true
+		return true
+	})
+
+	ctx.ForEach(metadata.IstioRbacV1Alpha1Serviceroles, func(e *resource.Entry) bool {
+		_, name := e.Metadata.Name.InterpretAsNamespaceAndName()
+		if !referenced[util.GetResourceNamespace(e)][name] {
+			ctx.Report(metadata.IstioRbacV1Alpha1Serviceroles, msg.NewUnreferencedResource(e.Metadata.Name, "ServiceRoleBinding"))
+		}
+		return true
+	})
+}
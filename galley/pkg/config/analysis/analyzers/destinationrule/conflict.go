@@ -0,0 +1,106 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package destinationrule
+
+import (
+	"fmt"
+	"sort"
+
+	"istio.io/api/networking/v1alpha3"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/galley/pkg/config/collection"
+	"istio.io/istio/galley/pkg/config/processor/metadata"
+	"istio.io/istio/galley/pkg/config/resource"
+	"istio.io/istio/pkg/config/host"
+)
+
+// ConflictAnalyzer checks the destination rules of a namespace that apply to the same host. Pilot merges
+// them in creation order: the subsets are concatenated, and only the oldest top level traffic policy is
+// used. The definitions that are dropped by the merge are reported.
+type ConflictAnalyzer struct{}
+
+var _ analysis.Analyzer = &ConflictAnalyzer{}
+
+type namespacedHost struct {
+	namespace string
+	host      host.Name
+}
+
+// Name implements Analyzer
+func (c *ConflictAnalyzer) Name() string {
+	return "destinationrule.ConflictAnalyzer"
+}
+
+// Inputs implements Analyzer
+func (c *ConflictAnalyzer) Inputs() collection.Names {
+	return collection.Names{
+		metadata.IstioNetworkingV1Alpha3Destinationrules,
+	}
+}
+
+// Analyze implements Analyzer
+func (c *ConflictAnalyzer) Analyze(ctx analysis.Context) {
+	byHost := make(map[namespacedHost][]*resource.Entry)
+	ctx.ForEach(metadata.IstioNetworkingV1Alpha3Destinationrules, func(e *resource.Entry) bool {
+		ns := util.GetResourceNamespace(e)
+		key := namespacedHost{
+			namespace: ns,
+			host:      util.ConvertHostToFQDN(ns, e.Item.(*v1alpha3.DestinationRule).Host),
+		}
+		byHost[key] = append(byHost[key], e)
+		return true
+	})
+
+	for key, entries := range byHost {
+		if len(entries) < 2 {
+			continue
+		}
+
+		// Same order as Pilot, which keeps the definitions of the oldest destination rule.
+		sort.SliceStable(entries, func(i, j int) bool {
+			if !entries[i].Metadata.CreateTime.Equal(entries[j].Metadata.CreateTime) {
+				return entries[i].Metadata.CreateTime.Before(entries[j].Metadata.CreateTime)
+			}
+			return entries[i].Metadata.Name.String() < entries[j].Metadata.Name.String()
+		})
+
+		var trafficPolicyOwner *resource.Entry
+		subsetOwners := make(map[string]*resource.Entry)
+		for _, e := range entries {
+			dr := e.Item.(*v1alpha3.DestinationRule)
+			if dr.TrafficPolicy != nil {
+				if trafficPolicyOwner == nil {
+					trafficPolicyOwner = e
+				} else {
+					ctx.Report(metadata.IstioNetworkingV1Alpha3Destinationrules,
+						msg.NewConflictingDestinationRules(e.Metadata.Name, "The top level traffic policy",
+							string(key.host), trafficPolicyOwner.Metadata.Name.String()))
+				}
+			}
+			for _, ss := range dr.Subsets {
+				if owner, found := subsetOwners[ss.Name]; found {
+					ctx.Report(metadata.IstioNetworkingV1Alpha3Destinationrules,
+						msg.NewConflictingDestinationRules(e.Metadata.Name, fmt.Sprintf("Subset %q", ss.Name),
+							string(key.host), owner.Metadata.Name.String()))
+				} else {
+					subsetOwners[ss.Name] = e
+				}
+			}
+		}
+	}
+}
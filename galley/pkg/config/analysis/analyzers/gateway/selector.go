@@ -0,0 +1,70 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gateway
+
+import (
+	"istio.io/api/networking/v1alpha3"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/galley/pkg/config/collection"
+	"istio.io/istio/galley/pkg/config/processor/metadata"
+	"istio.io/istio/galley/pkg/config/resource"
+	"istio.io/istio/pkg/config/labels"
+)
+
+// SelectorAnalyzer checks that the selector of each gateway matches the labels of at least one pod.
+// Gateways select their workloads in all namespaces.
+type SelectorAnalyzer struct{}
+
+var _ analysis.Analyzer = &SelectorAnalyzer{}
+
+// Name implements Analyzer
+func (s *SelectorAnalyzer) Name() string {
+	return "gateway.SelectorAnalyzer"
+}
+
+// Inputs implements Analyzer
+func (s *SelectorAnalyzer) Inputs() collection.Names {
+	return collection.Names{
+		metadata.IstioNetworkingV1Alpha3Gateways,
+		metadata.K8SCoreV1Pods,
+	}
+}
+
+// Analyze implements Analyzer
+func (s *SelectorAnalyzer) Analyze(ctx analysis.Context) {
+	var podLabels []labels.Instance
+	ctx.ForEach(metadata.K8SCoreV1Pods, func(e *resource.Entry) bool {
+		podLabels = append(podLabels, labels.Instance(e.Metadata.Labels))
+		return true
+	})
+
+	ctx.ForEach(metadata.IstioNetworkingV1Alpha3Gateways, func(e *resource.Entry) bool {
+		gw := e.Item.(*v1alpha3.Gateway)
+		selector := labels.Instance(gw.Selector)
+		if len(selector) == 0 {
+			return true
+		}
+
+		for _, l := range podLabels {
+			if selector.SubsetOf(l) {
+				return true
+			}
+		}
+		ctx.Report(metadata.IstioNetworkingV1Alpha3Gateways, msg.NewNoMatchingWorkloadsFound(e.Metadata.Name, selector.String()))
+		return true
+	})
+}
@@ -0,0 +1,90 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gateway
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+
+	"istio.io/api/networking/v1alpha3"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/galley/pkg/config/collection"
+	"istio.io/istio/galley/pkg/config/processor/metadata"
+	"istio.io/istio/galley/pkg/config/resource"
+)
+
+type fakeContext struct {
+	entries  map[collection.Name][]*resource.Entry
+	messages diag.Messages
+}
+
+var _ analysis.Context = &fakeContext{}
+
+func (c *fakeContext) Report(_ collection.Name, m diag.Message)            { c.messages.Add(m) }
+func (c *fakeContext) Find(collection.Name, resource.Name) *resource.Entry { return nil }
+func (c *fakeContext) Exists(collection.Name, resource.Name) bool          { return false }
+func (c *fakeContext) Canceled() bool                                      { return false }
+func (c *fakeContext) ForEach(col collection.Name, fn analysis.IteratorFn) {
+	for _, e := range c.entries[col] {
+		if !fn(e) {
+			return
+		}
+	}
+}
+
+func gatewayEntry(name string, selector map[string]string) *resource.Entry {
+	return &resource.Entry{
+		Metadata: resource.Metadata{Name: resource.NewName("default", name)},
+		Item:     &v1alpha3.Gateway{Selector: selector},
+	}
+}
+
+func podEntry(ns, name string, labels map[string]string) *resource.Entry {
+	return &resource.Entry{
+		Metadata: resource.Metadata{Name: resource.NewName(ns, name), Labels: labels},
+		Item:     &v1.Pod{},
+	}
+}
+
+func TestSelectorAnalyzer(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	ctx := &fakeContext{
+		entries: map[collection.Name][]*resource.Entry{
+			metadata.IstioNetworkingV1Alpha3Gateways: {
+				gatewayEntry("ingress", map[string]string{"istio": "ingressgateway"}),
+				gatewayEntry("missing", map[string]string{"istio": "missing"}),
+				gatewayEntry("partial", map[string]string{"istio": "ingressgateway", "app": "other"}),
+				gatewayEntry("all", nil),
+			},
+			metadata.K8SCoreV1Pods: {
+				podEntry("istio-system", "ingressgateway", map[string]string{"istio": "ingressgateway", "app": "gw"}),
+				podEntry("default", "productpage", map[string]string{"app": "productpage"}),
+			},
+		},
+	}
+
+	(&SelectorAnalyzer{}).Analyze(ctx)
+
+	g.Expect(ctx.messages).To(HaveLen(2))
+	g.Expect(ctx.messages[0].Type).To(Equal(msg.NoMatchingWorkloadsFound))
+	g.Expect(ctx.messages[0].Resource).To(Equal(resource.NewName("default", "missing")))
+	g.Expect(ctx.messages[1].Resource).To(Equal(resource.NewName("default", "partial")))
+}
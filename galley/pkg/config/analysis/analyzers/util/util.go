@@ -0,0 +1,49 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"strings"
+
+	"istio.io/istio/galley/pkg/config/resource"
+	"istio.io/istio/pkg/config/host"
+)
+
+const (
+	// DefaultKubernetesDomain is the default domain suffix of the Kubernetes services.
+	DefaultKubernetesDomain = "cluster.local"
+
+	// DefaultNamespace is the namespace of the resources that do not specify one, e.g. in local files.
+	DefaultNamespace = "default"
+)
+
+// GetResourceNamespace returns the namespace of the resource.
+func GetResourceNamespace(e *resource.Entry) string {
+	ns, _ := e.Metadata.Name.InterpretAsNamespaceAndName()
+	if ns == "" {
+		return DefaultNamespace
+	}
+	return ns
+}
+
+// ConvertHostToFQDN returns the given host as a FQDN, resolving short names relative to the given namespace,
+// the same way Pilot does.
+func ConvertHostToFQDN(namespace string, h string) host.Name {
+	// Wildcards and hosts containing a '.' are treated as fully qualified.
+	if h == "*" || strings.Contains(h, ".") {
+		return host.Name(h)
+	}
+	return host.Name(h + "." + namespace + ".svc." + DefaultKubernetesDomain)
+}
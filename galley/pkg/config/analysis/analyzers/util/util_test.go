@@ -0,0 +1,43 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"istio.io/istio/galley/pkg/config/resource"
+	"istio.io/istio/pkg/config/host"
+)
+
+func TestConvertHostToFQDN(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	g.Expect(ConvertHostToFQDN("ns", "reviews")).To(Equal(host.Name("reviews.ns.svc.cluster.local")))
+	g.Expect(ConvertHostToFQDN("ns", "reviews.other")).To(Equal(host.Name("reviews.other")))
+	g.Expect(ConvertHostToFQDN("ns", "*.example.com")).To(Equal(host.Name("*.example.com")))
+	g.Expect(ConvertHostToFQDN("ns", "*")).To(Equal(host.Name("*")))
+}
+
+func TestGetResourceNamespace(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	e := &resource.Entry{Metadata: resource.Metadata{Name: resource.NewName("ns", "name")}}
+	g.Expect(GetResourceNamespace(e)).To(Equal("ns"))
+
+	e = &resource.Entry{Metadata: resource.Metadata{Name: resource.NewName("", "name")}}
+	g.Expect(GetResourceNamespace(e)).To(Equal(DefaultNamespace))
+}
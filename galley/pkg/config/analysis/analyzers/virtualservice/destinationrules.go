@@ -0,0 +1,123 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package virtualservice
+
+import (
+	"fmt"
+
+	"istio.io/api/networking/v1alpha3"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/galley/pkg/config/collection"
+	"istio.io/istio/galley/pkg/config/processor/metadata"
+	"istio.io/istio/galley/pkg/config/resource"
+	"istio.io/istio/pkg/config/host"
+)
+
+// DestinationRuleAnalyzer checks that the subsets referenced by the virtual services are defined by a
+// destination rule of the destination host.
+type DestinationRuleAnalyzer struct{}
+
+var _ analysis.Analyzer = &DestinationRuleAnalyzer{}
+
+// Name implements Analyzer
+func (d *DestinationRuleAnalyzer) Name() string {
+	return "virtualservice.DestinationRuleAnalyzer"
+}
+
+// Inputs implements Analyzer
+func (d *DestinationRuleAnalyzer) Inputs() collection.Names {
+	return collection.Names{
+		metadata.IstioNetworkingV1Alpha3Virtualservices,
+		metadata.IstioNetworkingV1Alpha3Destinationrules,
+	}
+}
+
+// Analyze implements Analyzer
+func (d *DestinationRuleAnalyzer) Analyze(ctx analysis.Context) {
+	// The subsets defined for each destination rule host. The destination rules for the same host are merged by Pilot.
+	subsets := make(map[host.Name]map[string]bool)
+	ctx.ForEach(metadata.IstioNetworkingV1Alpha3Destinationrules, func(e *resource.Entry) bool {
+		dr := e.Item.(*v1alpha3.DestinationRule)
+		h := util.ConvertHostToFQDN(util.GetResourceNamespace(e), dr.Host)
+		if subsets[h] == nil {
+			subsets[h] = make(map[string]bool)
+		}
+		for _, ss := range dr.Subsets {
+			subsets[h][ss.Name] = true
+		}
+		return true
+	})
+
+	ctx.ForEach(metadata.IstioNetworkingV1Alpha3Virtualservices, func(e *resource.Entry) bool {
+		vs := e.Item.(*v1alpha3.VirtualService)
+		ns := util.GetResourceNamespace(e)
+
+		for _, dest := range getRouteDestinations(vs) {
+			if dest.Subset == "" {
+				continue
+			}
+			if !hasSubset(subsets, util.ConvertHostToFQDN(ns, dest.Host), dest.Subset) {
+				ctx.Report(metadata.IstioNetworkingV1Alpha3Virtualservices,
+					msg.NewReferencedResourceNotFound(e.Metadata.Name, "host+subset in destinationrule",
+						fmt.Sprintf("%s+%s", dest.Host, dest.Subset)))
+			}
+		}
+		return true
+	})
+}
+
+// hasSubset returns true if a destination rule applying to the host defines the subset.
+func hasSubset(subsets map[host.Name]map[string]bool, h host.Name, subset string) bool {
+	for drHost, names := range subsets {
+		if h.SubsetOf(drHost) && names[subset] {
+			return true
+		}
+	}
+	return false
+}
+
+func getRouteDestinations(vs *v1alpha3.VirtualService) []*v1alpha3.Destination {
+	destinations := make([]*v1alpha3.Destination, 0)
+
+	for _, r := range vs.GetTcp() {
+		for _, rd := range r.GetRoute() {
+			destinations = append(destinations, rd.GetDestination())
+		}
+	}
+	for _, r := range vs.GetTls() {
+		for _, rd := range r.GetRoute() {
+			destinations = append(destinations, rd.GetDestination())
+		}
+	}
+	for _, r := range vs.GetHttp() {
+		for _, rd := range r.GetRoute() {
+			destinations = append(destinations, rd.GetDestination())
+		}
+		if r.GetMirror() != nil {
+			destinations = append(destinations, r.GetMirror())
+		}
+	}
+
+	result := destinations[:0]
+	for _, d := range destinations {
+		if d != nil {
+			result = append(result, d)
+		}
+	}
+	return result
+}
@@ -0,0 +1,88 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"fmt"
+
+	"istio.io/istio/galley/pkg/config/resource"
+)
+
+// MessageType is a type of diagnostic message
+type MessageType struct {
+	// The level of the message.
+	level Level
+
+	// The error code of the message
+	code string
+
+	// TODO: Make this localizable
+	template string
+}
+
+// Level returns the level of the MessageType
+func (m *MessageType) Level() Level { return m.level }
+
+// Code returns the code of the MessageType
+func (m *MessageType) Code() string { return m.code }
+
+// Template returns the message template used by the MessageType
+func (m *MessageType) Template() string { return m.template }
+
+// NewMessageType returns a new MessageType instance.
+func NewMessageType(level Level, code, template string) *MessageType {
+	return &MessageType{
+		level:    level,
+		code:     code,
+		template: template,
+	}
+}
+
+// Message is a specific diagnostic message
+type Message struct {
+	Type *MessageType
+
+	// The Parameters to the message
+	Parameters []interface{}
+
+	// Resource is the name of the resource the message is about.
+	Resource resource.Name
+
+	// Kind of the resource the message is about, e.g. "VirtualService". It is set when the message is reported.
+	Kind string
+}
+
+// Origin returns a description of the resource the message is about.
+func (m *Message) Origin() string {
+	if m.Kind == "" {
+		return m.Resource.String()
+	}
+	return m.Kind + " " + m.Resource.String()
+}
+
+// String implements io.Stringer
+func (m *Message) String() string {
+	return fmt.Sprintf("%v [%v] (%s) %s",
+		m.Type.Level(), m.Type.Code(), m.Origin(), fmt.Sprintf(m.Type.Template(), m.Parameters...))
+}
+
+// NewMessage returns a new Message instance for the given resource.
+func NewMessage(mt *MessageType, r resource.Name, p ...interface{}) Message {
+	return Message{
+		Type:       mt,
+		Resource:   r,
+		Parameters: p,
+	}
+}
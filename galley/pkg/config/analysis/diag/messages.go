@@ -0,0 +1,63 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"sort"
+	"strings"
+)
+
+// Messages is a slice of Message items.
+type Messages []Message
+
+// Add a new message to the messages
+func (ms *Messages) Add(m Message) {
+	*ms = append(*ms, m)
+}
+
+// Sort the messages by level first, then by origin and code.
+func (ms Messages) Sort() {
+	sort.SliceStable(ms, func(i, j int) bool {
+		a, b := ms[i], ms[j]
+		switch {
+		case a.Type.Level() != b.Type.Level():
+			return a.Type.Level().sortOrder < b.Type.Level().sortOrder
+		case a.Origin() != b.Origin():
+			return a.Origin() < b.Origin()
+		default:
+			return a.Type.Code() < b.Type.Code()
+		}
+	})
+}
+
+// HasLevel returns true if any of the messages has the given level.
+func (ms Messages) HasLevel(l Level) bool {
+	for _, m := range ms {
+		if m.Type.Level() == l {
+			return true
+		}
+	}
+	return false
+}
+
+// String implements io.Stringer
+func (ms Messages) String() string {
+	var b strings.Builder
+	for _, m := range ms {
+		b.WriteString(m.String())
+		b.WriteString("\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,60 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"istio.io/istio/galley/pkg/config/resource"
+)
+
+func TestMessage_String(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	mt := NewMessageType(Error, "IST-0042", "Cheese type not found: %q")
+	m := NewMessage(mt, resource.NewName("default", "foo"), "Feta")
+	g.Expect(m.String()).To(Equal(`Error [IST-0042] (default/foo) Cheese type not found: "Feta"`))
+
+	m.Kind = "Cheese"
+	g.Expect(m.String()).To(Equal(`Error [IST-0042] (Cheese default/foo) Cheese type not found: "Feta"`))
+}
+
+func TestMessages_Sort(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	info := NewMessageType(Info, "B1", "Template: %q")
+	warning := NewMessageType(Warning, "A1", "Template: %q")
+	err1 := NewMessageType(Error, "C1", "Template: %q")
+	err2 := NewMessageType(Error, "C2", "Template: %q")
+
+	ms := Messages{}
+	ms.Add(NewMessage(info, resource.NewName("ns", "a"), "info"))
+	ms.Add(NewMessage(err2, resource.NewName("ns", "a"), "err2"))
+	ms.Add(NewMessage(warning, resource.NewName("ns", "a"), "warning"))
+	ms.Add(NewMessage(err1, resource.NewName("ns", "b"), "err1-b"))
+	ms.Add(NewMessage(err1, resource.NewName("ns", "a"), "err1-a"))
+	ms.Sort()
+
+	var params []interface{}
+	for _, m := range ms {
+		params = append(params, m.Parameters...)
+	}
+	g.Expect(params).To(Equal([]interface{}{"err1-a", "err2", "err1-b", "warning", "info"}))
+
+	g.Expect(ms.HasLevel(Error)).To(BeTrue())
+	g.Expect(Messages{ms[3], ms[4]}.HasLevel(Error)).To(BeFalse())
+}
@@ -0,0 +1,202 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package local contains the support for analyzing configuration without a running Galley, e.g. from
+// the command line.
+package local
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/galley/pkg/config/collection"
+	"istio.io/istio/galley/pkg/config/event"
+	"istio.io/istio/galley/pkg/config/meshcfg"
+	"istio.io/istio/galley/pkg/config/processing"
+	"istio.io/istio/galley/pkg/config/processor"
+	"istio.io/istio/galley/pkg/config/processor/metadata"
+	"istio.io/istio/galley/pkg/config/processor/transforms/direct"
+	"istio.io/istio/galley/pkg/config/schema"
+	"istio.io/istio/galley/pkg/config/scope"
+	"istio.io/istio/galley/pkg/config/source/inmemory"
+	"istio.io/istio/galley/pkg/config/source/kube"
+	"istio.io/istio/galley/pkg/config/source/kube/apiserver"
+	kubeinmemory "istio.io/istio/galley/pkg/config/source/kube/inmemory"
+	"istio.io/istio/galley/pkg/config/source/kube/rt"
+)
+
+// SourceAnalyzer handles local analysis of k8s event sources, both live and file-based
+type SourceAnalyzer struct {
+	m            *schema.Metadata
+	analyzer     *analysis.CombinedAnalyzer
+	domainSuffix string
+
+	source event.Source
+
+	// Collections that are not available in the source, e.g. pods for file-based sources. The analyzers
+	// that read them are skipped.
+	unavailable collection.Names
+
+	// The kind of the source resources of each collection, for reporting.
+	kinds map[collection.Name]string
+}
+
+// NewSourceAnalyzer creates a new SourceAnalyzer with no sources. Use the Add*Source methods to add one.
+func NewSourceAnalyzer(m *schema.Metadata, analyzer *analysis.CombinedAnalyzer, domainSuffix string) *SourceAnalyzer {
+	kinds := make(map[collection.Name]string)
+	for _, r := range m.KubeSource().Resources() {
+		kinds[r.Collection.Name] = r.Kind
+	}
+	for from, to := range m.DirectTransform().Mapping() {
+		if k, found := kinds[from]; found {
+			kinds[to] = k
+		}
+	}
+
+	return &SourceAnalyzer{
+		m:            m,
+		analyzer:     analyzer,
+		domainSuffix: domainSuffix,
+		kinds:        kinds,
+	}
+}
+
+// AddFileKubeSource adds a source based on the given Kubernetes yaml files. Pods can not be analyzed from
+// files, so the analyzers that need them are skipped.
+func (sa *SourceAnalyzer) AddFileKubeSource(files []string) error {
+	if sa.source != nil {
+		return errors.New("only one source can be analyzed")
+	}
+
+	src := kubeinmemory.NewKubeSource(sa.m.KubeSource().Resources())
+	for _, file := range files {
+		by, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		if err = src.ApplyContent(file, string(by)); err != nil {
+			return err
+		}
+	}
+
+	sa.source = src
+	sa.unavailable = collection.Names{metadata.K8SCoreV1Pods}
+	return nil
+}
+
+// AddRunningKubeSource adds a source based on a running Kubernetes cluster. The resources whose CRDs are
+// not installed in the cluster are analyzed as empty.
+func (sa *SourceAnalyzer) AddRunningKubeSource(k kube.Interfaces) error {
+	if sa.source != nil {
+		return errors.New("only one source can be analyzed")
+	}
+
+	ext, err := k.APIExtensionsClientset()
+	if err != nil {
+		return err
+	}
+	crds, err := ext.ApiextensionsV1beta1().CustomResourceDefinitions().List(metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("unable to list the CRDs of the cluster: %v", err)
+	}
+	installed := make(map[string]bool)
+	for _, crd := range crds.Items {
+		installed[crd.Spec.Group+"/"+crd.Spec.Names.Kind] = true
+	}
+
+	// The API server source does not send a FullSync for the resources without a CRD. Serve them from an
+	// empty in-memory source instead, so that the processing does not wait for them forever.
+	provider := rt.NewProvider(k, 0)
+	var resources schema.KubeResources
+	var missing collection.Names
+	for _, r := range sa.m.KubeSource().Resources() {
+		if r.Disabled || (!provider.GetAdapter(r).IsBuiltIn() && !installed[r.Group+"/"+r.Kind]) {
+			scope.Analysis.Debugf("Resource is not available in the cluster: %s", r.CanonicalResourceName())
+			r.Disabled = true
+			missing = append(missing, r.Collection.Name)
+		}
+		resources = append(resources, r)
+	}
+
+	src := apiserver.New(apiserver.Options{
+		Client:    k,
+		Resources: resources,
+	})
+	sa.source = event.CombineSources(src, inmemory.New(missing))
+	return nil
+}
+
+// Analyze loads the sources and executes the analysis. It returns early with no messages if cancel is closed.
+func (sa *SourceAnalyzer) Analyze(cancel chan struct{}) (diag.Messages, error) {
+	if sa.source == nil {
+		return nil, errors.New("no source to analyze")
+	}
+
+	analyzer := sa.analyzer
+	if len(sa.unavailable) > 0 {
+		var removed []string
+		analyzer, removed = analyzer.WithoutInputs(sa.unavailable)
+		for _, name := range removed {
+			scope.Analysis.Infof("Skipping analyzer %q: its inputs are not available in the source", name)
+		}
+	}
+
+	meshsrc := meshcfg.NewInmemory()
+	meshsrc.Set(meshcfg.Default())
+
+	res := &result{done: make(chan struct{})}
+	provider := func(o processing.ProcessorOptions) event.Processor {
+		xforms := processor.Transforms(o, sa.m)
+		// The pods are not part of the processed output, but some analyzers need them.
+		xforms = append(xforms, direct.Create(map[collection.Name]collection.Name{
+			metadata.K8SCoreV1Pods: metadata.K8SCoreV1Pods,
+		})...)
+		return newAnalyzingProcessor(xforms, analyzer, sa.kinds, cancel, res)
+	}
+
+	rt := processing.NewRuntime(processing.RuntimeOptions{
+		Source:            event.CombineSources(meshsrc, sa.source),
+		ProcessorProvider: provider,
+		DomainSuffix:      sa.domainSuffix,
+	})
+	rt.Start()
+	defer rt.Stop()
+
+	select {
+	case <-cancel:
+		return nil, nil
+	case <-res.done:
+		return res.messages, nil
+	}
+}
+
+// result of an analysis, shared by the processors of all the processing sessions.
+type result struct {
+	once     sync.Once
+	done     chan struct{}
+	messages diag.Messages
+}
+
+func (r *result) set(m diag.Messages) {
+	r.once.Do(func() {
+		r.messages = m
+		close(r.done)
+	})
+}
@@ -0,0 +1,128 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package local
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/galley/pkg/config/collection"
+	"istio.io/istio/galley/pkg/config/processor/metadata"
+	"istio.io/istio/galley/pkg/config/resource"
+)
+
+const serviceRoleYaml = `
+apiVersion: rbac.istio.io/v1alpha1
+kind: ServiceRole
+metadata:
+  name: viewer
+  namespace: ns
+spec:
+  rules:
+  - services: ["*"]
+    methods: ["GET"]
+`
+
+var testMessageType = diag.NewMessageType(diag.Info, "IST9999", "Seen %d")
+
+// testAnalyzer reports the number of service roles, and the inputs it was given.
+type testAnalyzer struct {
+	inputs collection.Names
+}
+
+func (a *testAnalyzer) Name() string             { return "testAnalyzer" }
+func (a *testAnalyzer) Inputs() collection.Names { return a.inputs }
+func (a *testAnalyzer) Analyze(ctx analysis.Context) {
+	count := 0
+	ctx.ForEach(metadata.IstioRbacV1Alpha1Serviceroles, func(e *resource.Entry) bool {
+		count++
+		return true
+	})
+	ctx.Report(metadata.IstioRbacV1Alpha1Serviceroles, diag.NewMessage(testMessageType, resource.NewName("ns", "viewer"), count))
+}
+
+func writeFile(t *testing.T, content string) (string, func()) {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "analyze")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := filepath.Join(dir, "config.yaml")
+	if err = ioutil.WriteFile(p, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return p, func() { _ = os.RemoveAll(dir) }
+}
+
+func TestSourceAnalyzer_Files(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	a := &testAnalyzer{inputs: collection.Names{metadata.IstioRbacV1Alpha1Serviceroles}}
+	sa := NewSourceAnalyzer(metadata.MustGet(), analysis.Combine("test", a), "")
+	file, cleanup := writeFile(t, serviceRoleYaml)
+	defer cleanup()
+	g.Expect(sa.AddFileKubeSource([]string{file})).To(Succeed())
+
+	msgs, err := sa.Analyze(make(chan struct{}))
+	g.Expect(err).To(BeNil())
+	g.Expect(msgs).To(HaveLen(1))
+	g.Expect(msgs[0].Parameters).To(Equal([]interface{}{1}))
+	// The kind is inferred from the Kubernetes source of the collection.
+	g.Expect(msgs[0].Origin()).To(Equal("ServiceRole ns/viewer"))
+}
+
+func TestSourceAnalyzer_FilesSkipPodAnalyzers(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	a := &testAnalyzer{inputs: collection.Names{metadata.IstioRbacV1Alpha1Serviceroles, metadata.K8SCoreV1Pods}}
+	sa := NewSourceAnalyzer(metadata.MustGet(), analysis.Combine("test", a), "")
+	file, cleanup := writeFile(t, serviceRoleYaml)
+	defer cleanup()
+	g.Expect(sa.AddFileKubeSource([]string{file})).To(Succeed())
+
+	msgs, err := sa.Analyze(make(chan struct{}))
+	g.Expect(err).To(BeNil())
+	g.Expect(msgs).To(BeEmpty())
+}
+
+func TestSourceAnalyzer_MissingFile(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	sa := NewSourceAnalyzer(metadata.MustGet(), analysis.Combine("test"), "")
+	g.Expect(sa.AddFileKubeSource([]string{"/does/not/exist.yaml"})).NotTo(Succeed())
+}
+
+func TestSourceAnalyzer_SingleSource(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	sa := NewSourceAnalyzer(metadata.MustGet(), analysis.Combine("test"), "")
+	g.Expect(sa.AddFileKubeSource(nil)).To(Succeed())
+	g.Expect(sa.AddFileKubeSource(nil)).NotTo(Succeed())
+}
+
+func TestSourceAnalyzer_NoSource(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	sa := NewSourceAnalyzer(metadata.MustGet(), analysis.Combine("test"), "")
+	_, err := sa.Analyze(make(chan struct{}))
+	g.Expect(err).NotTo(BeNil())
+}
@@ -0,0 +1,77 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package local
+
+import (
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/galley/pkg/config/collection"
+	"istio.io/istio/galley/pkg/config/resource"
+)
+
+// context is an analysis.Context on top of the accumulated transform outputs.
+type context struct {
+	outputs  map[collection.Name]*accumulator
+	kinds    map[collection.Name]string
+	cancel   chan struct{}
+	messages diag.Messages
+}
+
+var _ analysis.Context = &context{}
+
+// Report implements analysis.Context
+func (c *context) Report(col collection.Name, m diag.Message) {
+	if m.Kind == "" {
+		m.Kind = c.kinds[col]
+	}
+	c.messages.Add(m)
+}
+
+// Find implements analysis.Context
+func (c *context) Find(col collection.Name, name resource.Name) *resource.Entry {
+	if a, found := c.outputs[col]; found {
+		return a.collection.Get(name)
+	}
+	return nil
+}
+
+// Exists implements analysis.Context
+func (c *context) Exists(col collection.Name, name resource.Name) bool {
+	return c.Find(col, name) != nil
+}
+
+// ForEach implements analysis.Context
+func (c *context) ForEach(col collection.Name, fn analysis.IteratorFn) {
+	a, found := c.outputs[col]
+	if !found {
+		return
+	}
+	stopped := false
+	a.collection.ForEach(func(e *resource.Entry) {
+		if !stopped {
+			stopped = !fn(e)
+		}
+	})
+}
+
+// Canceled implements analysis.Context
+func (c *context) Canceled() bool {
+	select {
+	case <-c.cancel:
+		return true
+	default:
+		return false
+	}
+}
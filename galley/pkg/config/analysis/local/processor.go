@@ -0,0 +1,134 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package local
+
+import (
+	"fmt"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/collection"
+	"istio.io/istio/galley/pkg/config/event"
+	"istio.io/istio/galley/pkg/config/scope"
+)
+
+// analyzingProcessor accumulates the output of the transforms, and runs the analyzer once all of them
+// are fully synced.
+type analyzingProcessor struct {
+	router   event.Router
+	xforms   []event.Transformer
+	outputs  map[collection.Name]*accumulator
+	analyzer analysis.Analyzer
+	kinds    map[collection.Name]string
+	cancel   chan struct{}
+	res      *result
+	analyzed bool
+}
+
+var _ event.Processor = &analyzingProcessor{}
+
+type accumulator struct {
+	reqSyncCount int
+	syncCount    int
+	collection   *collection.Instance
+}
+
+func newAnalyzingProcessor(xforms []event.Transformer, analyzer analysis.Analyzer, kinds map[collection.Name]string,
+	cancel chan struct{}, res *result) *analyzingProcessor {
+	p := &analyzingProcessor{
+		router:   event.NewRouter(),
+		xforms:   xforms,
+		outputs:  make(map[collection.Name]*accumulator),
+		analyzer: analyzer,
+		kinds:    kinds,
+		cancel:   cancel,
+		res:      res,
+	}
+
+	for _, xform := range xforms {
+		for _, i := range xform.Inputs() {
+			p.router = event.AddToRouter(p.router, i, xform)
+		}
+
+		for _, o := range xform.Outputs() {
+			a, found := p.outputs[o]
+			if !found {
+				a = &accumulator{
+					collection: collection.New(o),
+				}
+				p.outputs[o] = a
+			}
+			a.reqSyncCount++
+			xform.DispatchFor(o, event.HandlerFromFn(p.handleOutput(a)))
+		}
+	}
+
+	return p
+}
+
+// Start implements event.Processor
+func (p *analyzingProcessor) Start() {
+	for _, x := range p.xforms {
+		x.Start()
+	}
+}
+
+// Stop implements event.Processor
+func (p *analyzingProcessor) Stop() {
+	for _, x := range p.xforms {
+		x.Stop()
+	}
+}
+
+// Handle implements event.Processor
+func (p *analyzingProcessor) Handle(e event.Event) {
+	p.router.Handle(e)
+}
+
+func (p *analyzingProcessor) handleOutput(a *accumulator) func(e event.Event) {
+	return func(e event.Event) {
+		switch e.Kind {
+		case event.Added, event.Updated:
+			a.collection.Set(e.Entry)
+		case event.Deleted:
+			a.collection.Remove(e.Entry.Metadata.Name)
+		case event.FullSync:
+			a.syncCount++
+			p.analyzeIfSynced()
+		default:
+			panic(fmt.Errorf("analyzingProcessor.handleOutput: unhandled event type: %v", e.Kind))
+		}
+	}
+}
+
+func (p *analyzingProcessor) analyzeIfSynced() {
+	if p.analyzed {
+		return
+	}
+	for _, a := range p.outputs {
+		if a.syncCount < a.reqSyncCount {
+			return
+		}
+	}
+	p.analyzed = true
+
+	scope.Analysis.Debugf("All inputs are synced, starting analysis with %q", p.analyzer.Name())
+	ctx := &context{
+		outputs: p.outputs,
+		kinds:   p.kinds,
+		cancel:  p.cancel,
+	}
+	p.analyzer.Analyze(ctx)
+	p.res.set(ctx.messages)
+}
@@ -0,0 +1,73 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package msg contains the message types reported by the configuration analyzers. Message codes are
+// never reused: IST00xx codes are for analysis infrastructure problems, IST01xx codes are for
+// configuration problems.
+package msg
+
+import (
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/galley/pkg/config/resource"
+)
+
+var (
+	// InternalError defines a diag.MessageType for message "InternalError".
+	// Description: There was an internal error in the toolchain. This is almost always a bug in the implementation.
+	InternalError = diag.NewMessageType(diag.Error, "IST0001", "Internal error: %v")
+
+	// ReferencedResourceNotFound defines a diag.MessageType for message "ReferencedResourceNotFound".
+	// Description: A resource being referenced does not exist.
+	ReferencedResourceNotFound = diag.NewMessageType(diag.Error, "IST0101", "Referenced %s not found: %q")
+
+	// NoMatchingWorkloadsFound defines a diag.MessageType for message "NoMatchingWorkloadsFound".
+	// Description: A resource selects workloads, but none of the workloads match its selector.
+	NoMatchingWorkloadsFound = diag.NewMessageType(diag.Warning, "IST0102",
+		"No matching workloads for this resource with the following labels: %s")
+
+	// ConflictingDestinationRules defines a diag.MessageType for message "ConflictingDestinationRules".
+	// Description: DestinationRules in a namespace for the same host are merged, the definitions already made by
+	// an older DestinationRule are ignored.
+	ConflictingDestinationRules = diag.NewMessageType(diag.Warning, "IST0103",
+		"%s for host %q is already defined by DestinationRule %s and is ignored")
+
+	// UnreferencedResource defines a diag.MessageType for message "UnreferencedResource".
+	// Description: A resource only takes effect when it is referenced, but nothing references it.
+	UnreferencedResource = diag.NewMessageType(diag.Warning, "IST0104", "The resource is not referenced by any %s")
+)
+
+// NewInternalError returns a new diag.Message based on InternalError.
+func NewInternalError(r resource.Name, detail string) diag.Message {
+	return diag.NewMessage(InternalError, r, detail)
+}
+
+// NewReferencedResourceNotFound returns a new diag.Message based on ReferencedResourceNotFound.
+func NewReferencedResourceNotFound(r resource.Name, reftype string, refval string) diag.Message {
+	return diag.NewMessage(ReferencedResourceNotFound, r, reftype, refval)
+}
+
+// NewNoMatchingWorkloadsFound returns a new diag.Message based on NoMatchingWorkloadsFound.
+func NewNoMatchingWorkloadsFound(r resource.Name, labels string) diag.Message {
+	return diag.NewMessage(NoMatchingWorkloadsFound, r, labels)
+}
+
+// NewConflictingDestinationRules returns a new diag.Message based on ConflictingDestinationRules.
+func NewConflictingDestinationRules(r resource.Name, definition string, host string, destinationRule string) diag.Message {
+	return diag.NewMessage(ConflictingDestinationRules, r, definition, host, destinationRule)
+}
+
+// NewUnreferencedResource returns a new diag.Message based on UnreferencedResource.
+func NewUnreferencedResource(r resource.Name, referrerKind string) diag.Message {
+	return diag.NewMessage(UnreferencedResource, r, referrerKind)
+}
@@ -63,6 +63,13 @@ func (c *Instance) ForEach(fn func(e *resource.Entry)) {
 	}
 }
 
+// Get the entry with the given name, or nil if it does not exist.
+func (c *Instance) Get(n resource.Name) *resource.Entry {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.entries[n]
+}
+
 // Set an entry in the collection
 func (c *Instance) Set(r *resource.Entry) {
 	c.mu.Lock()
@@ -52,9 +52,12 @@ func TestInstance_Basics(t *testing.T) {
 
 	g.Expect(inst.Generation()).To(Equal(int64(2)))
 
+	g.Expect(inst.Get(data.EntryN1I1V2.Metadata.Name)).To(Equal(data.EntryN1I1V2))
+
 	inst.Remove(data.EntryN1I1V1.Metadata.Name)
 
 	g.Expect(inst.Size()).To(Equal(1))
+	g.Expect(inst.Get(data.EntryN1I1V1.Metadata.Name)).To(BeNil())
 
 	fe = nil
 	inst.ForEach(func(e *resource.Entry) {
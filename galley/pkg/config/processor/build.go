@@ -52,7 +52,7 @@ func Initialize(
 	// TODO: Add a precondition test here to ensure the panic below will not fire during runtime.
 
 	provider := func(o processing.ProcessorOptions) event.Processor {
-		xforms := createTransforms(o, m)
+		xforms := Transforms(o, m)
 		s, err := snapshotter.NewSnapshotter(xforms, options)
 		if err != nil {
 			panic(err)
@@ -69,7 +69,8 @@ func Initialize(
 	return processing.NewRuntime(rtOpt), nil
 }
 
-func createTransforms(o processing.ProcessorOptions, m *schema.Metadata) []event.Transformer {
+// Transforms returns the transformers of the Galley processing pipeline, for the given options and metadata.
+func Transforms(o processing.ProcessorOptions, m *schema.Metadata) []event.Transformer {
 	var xforms []event.Transformer
 
 	xf := direct.Create(m.DirectTransform().Mapping())
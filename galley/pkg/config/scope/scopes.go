@@ -22,4 +22,7 @@ var (
 
 	// Source is a logging scope for config event sources.
 	Source = log.RegisterScope("source", "Scope for configuration event sources", 0)
+
+	// Analysis is a logging scope used by configuration analysis component.
+	Analysis = log.RegisterScope("analysis", "Scope for configuration analysis runtime", 0)
 )
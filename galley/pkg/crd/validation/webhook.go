@@ -364,6 +364,12 @@ func (wh *Webhook) admitPilot(request *admissionv1beta1.AdmissionRequest) *admis
 		return toAdmissionResponse(fmt.Errorf("configuration is invalid: %v", err))
 	}
 
+	if err := model.ValidateConfigAnnotations(*out); err != nil {
+		scope.Infof("configuration annotations are invalid: %v", err)
+		reportValidationFailed(request, reasonInvalidConfig)
+		return toAdmissionResponse(fmt.Errorf("configuration is invalid: %v", err))
+	}
+
 	if reason, err := checkFields(request.Object.Raw, request.Kind.Kind, request.Namespace, obj.Name); err != nil {
 		reportValidationFailed(request, reason)
 		return toAdmissionResponse(err)
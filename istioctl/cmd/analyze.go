@@ -0,0 +1,130 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"istio.io/istio/galley/pkg/config/analysis/analyzers"
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/galley/pkg/config/analysis/local"
+	"istio.io/istio/galley/pkg/config/processor/metadata"
+	"istio.io/istio/galley/pkg/config/scope"
+	"istio.io/istio/galley/pkg/config/source/kube"
+	"istio.io/pkg/log"
+)
+
+var (
+	errAnalyzeFoundErrors = errors.New("analyzers found issues with Error level")
+)
+
+// Analyze command
+func Analyze() *cobra.Command {
+	var (
+		useKube       bool
+		listAnalyzers bool
+	)
+
+	analysisCmd := &cobra.Command{
+		Use:   "analyze <file>...",
+		Short: "Analyze Istio configuration",
+		Long: `Analyze checks Istio configuration for problems that span multiple resources, such as a
+VirtualService referencing a subset that no DestinationRule defines, or a Gateway whose selector
+matches no pods. Each finding has a message code and a level (Error, Warn or Info).
+
+The configuration is read either from local files, or from the current Kubernetes cluster. The
+analyzers that need pods are skipped when analyzing files.
+
+THIS COMMAND IS STILL UNDER ACTIVE DEVELOPMENT AND NOT READY FOR PRODUCTION USE.
+`,
+		Example: `  # Analyze yaml files
+  istioctl experimental analyze a.yaml b.yaml
+
+  # Analyze the current live cluster
+  istioctl experimental analyze -k
+
+  # List the available analyzers
+  istioctl experimental analyze -L`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if listAnalyzers {
+				for _, a := range analyzers.All() {
+					fmt.Fprintf(cmd.OutOrStdout(), "* %s\n", a.Name())
+				}
+				return nil
+			}
+
+			// The processing pipeline logs its progress at info level, which is too verbose for a command line tool.
+			if !cmd.Flags().Changed("log_output_level") {
+				for _, s := range []*log.Scope{scope.Processing, scope.Source, scope.Analysis} {
+					s.SetOutputLevel(log.WarnLevel)
+				}
+			}
+
+			sa := local.NewSourceAnalyzer(metadata.MustGet(), analyzers.AllCombined(), "")
+			switch {
+			case useKube && len(args) > 0:
+				return errors.New("files can not be analyzed together with the live cluster")
+			case useKube:
+				k, err := kube.NewInterfacesFromConfigFile(kubeconfig)
+				if err != nil {
+					return err
+				}
+				if err = sa.AddRunningKubeSource(k); err != nil {
+					return err
+				}
+			case len(args) > 0:
+				if err := sa.AddFileKubeSource(args); err != nil {
+					return err
+				}
+			default:
+				return errors.New("expecting files to analyze, or --use-kube to analyze the live cluster")
+			}
+
+			cancel := make(chan struct{})
+			messages, err := sa.Analyze(cancel)
+			if err != nil {
+				return err
+			}
+			return printMessages(cmd, messages)
+		},
+	}
+
+	analysisCmd.PersistentFlags().BoolVarP(&useKube, "use-kube", "k", false,
+		"Use live Kubernetes cluster for analysis")
+	analysisCmd.PersistentFlags().BoolVarP(&listAnalyzers, "list-analyzers", "L", false,
+		"List the analyzers available to run. Suppresses normal execution.")
+
+	return analysisCmd
+}
+
+func printMessages(cmd *cobra.Command, messages diag.Messages) error {
+	if len(messages) == 0 {
+		fmt.Fprintln(cmd.OutOrStdout(), "No validation issues found.")
+		return nil
+	}
+
+	messages.Sort()
+	for _, m := range messages {
+		fmt.Fprintln(cmd.OutOrStdout(), m.String())
+	}
+
+	if messages.HasLevel(diag.Error) {
+		return errAnalyzeFoundErrors
+	}
+	return nil
+}
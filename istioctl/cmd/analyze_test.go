@@ -0,0 +1,78 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestAnalyze(t *testing.T) {
+	cases := []struct {
+		name        string
+		args        string
+		expected    string
+		expectError bool
+	}{
+		{
+			name:     "no issues",
+			args:     "experimental analyze testdata/analyze/clean.yaml",
+			expected: "No validation issues found.\n",
+		},
+		{
+			name:     "warnings only",
+			args:     "experimental analyze testdata/analyze/unreferenced-role.yaml",
+			expected: "Warn [IST0104] (ServiceRole bookinfo/viewer) The resource is not referenced by any ServiceRoleBinding\n",
+		},
+		{
+			name: "errors",
+			args: "experimental analyze testdata/analyze/unreferenced-role.yaml testdata/analyze/missing-subset.yaml",
+			expected: "Error [IST0101] (VirtualService bookinfo/ratings) Referenced host+subset in destinationrule not found: \"ratings+v2\"\n" +
+				"Warn [IST0104] (ServiceRole bookinfo/viewer) The resource is not referenced by any ServiceRoleBinding\n",
+			expectError: true,
+		},
+		{
+			name:        "no input",
+			args:        "experimental analyze",
+			expectError: true,
+		},
+		{
+			name:     "list analyzers",
+			args:     "experimental analyze -L",
+			expected: "* auth.ServiceRoleAnalyzer\n* destinationrule.ConflictAnalyzer\n* gateway.SelectorAnalyzer\n* virtualservice.DestinationRuleAnalyzer\n",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var out bytes.Buffer
+			rootCmd := GetRootCmd(strings.Split(c.args, " "))
+			rootCmd.SetOutput(&out)
+
+			err := rootCmd.Execute()
+			if c.expectError && err == nil {
+				t.Fatalf("expected an error, got none. Output:\n%s", out.String())
+			}
+			if !c.expectError && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			// Cobra appends the returned error to the output.
+			if c.expected != "" && !strings.HasPrefix(out.String(), c.expected) {
+				t.Errorf("unexpected output.\nExpected:\n%s\nGot:\n%s", c.expected, out.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,511 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	xdsapi "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	"github.com/spf13/cobra"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	networking "istio.io/api/networking/v1alpha3"
+	rbac "istio.io/api/rbac/v1alpha1"
+
+	"istio.io/istio/istioctl/pkg/util/configdump"
+	"istio.io/istio/istioctl/pkg/util/handlers"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/networking/util"
+	v2 "istio.io/istio/pilot/pkg/proxy/envoy/v2"
+	"istio.io/istio/pkg/config/kube"
+	istiolabels "istio.io/istio/pkg/config/labels"
+)
+
+var (
+	// Create a kubernetes.Interface (or fake clientset)
+	interfaceFactory = createInterface
+)
+
+// configRef identifies the Istio config that Pilot used to generate an Envoy cluster or route.
+type configRef struct {
+	Type      string
+	Name      string
+	Namespace string
+}
+
+func (r configRef) String() string {
+	return r.Name + "." + r.Namespace
+}
+
+// describeInputs holds everything known about a pod, gathered from Kubernetes, Pilot and the pod's Envoy.
+type describeInputs struct {
+	pod      *v1.Pod
+	services []v1.Service
+	configs  model.ConfigStore
+	// envoy is nil when the config dump of the pod's Envoy could not be retrieved.
+	envoy *configdump.Wrapper
+	authn []v2.AuthenticationDebug
+}
+
+func describe() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "describe",
+		Short: "Describe resource and related Istio configuration",
+		Long: `
+A group of commands used to explain the Istio configuration that applies to a resource.
+  pod
+`,
+		Example: `# Describe the Istio configuration that applies to the pod "productpage-v1-c7765c886-7zzd4":
+istioctl experimental describe pod productpage-v1-c7765c886-7zzd4`,
+	}
+
+	cmd.AddCommand(describePodCmd())
+	return cmd
+}
+
+func describePodCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "pod <pod-name[.namespace]>",
+		Short: "Describe pods and their Istio configuration [kube-only]",
+		Long: `
+Analyzes the pod, its Services, DestinationRules, VirtualServices, Gateways, authentication
+policies and RBAC rules, and reports the configuration that is in effect for the pod.
+
+The DestinationRules and VirtualServices are the ones Pilot sent to the pod's Envoy, and the
+mTLS settings are the ones reported by Pilot for each port of the pod's Services.
+`,
+		Example: `# Describe the pod "productpage-v1-c7765c886-7zzd4" in namespace default:
+istioctl experimental describe pod productpage-v1-c7765c886-7zzd4.default`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			podName, ns := handlers.InferPodInfo(args[0], handlers.HandleNamespace(namespace, defaultNamespace))
+			in, err := gatherDescribeInputs(cmd.OutOrStdout(), podName, ns)
+			if err != nil {
+				return err
+			}
+			printPod(cmd.OutOrStdout(), in)
+			return nil
+		},
+	}
+	return cmd
+}
+
+// gatherDescribeInputs retrieves the pod, its Services and the related configuration. The data coming from
+// Pilot and Envoy is optional: a warning is written instead of failing, so that the Kubernetes side can
+// still be described.
+func gatherDescribeInputs(writer io.Writer, podName, ns string) (*describeInputs, error) {
+	client, err := interfaceFactory(kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	pod, err := client.CoreV1().Pods(ns).Get(podName, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	services, err := client.CoreV1().Services(ns).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	configs, err := clientFactory()
+	if err != nil {
+		return nil, err
+	}
+
+	in := &describeInputs{
+		pod:     pod,
+		configs: configs,
+	}
+	podLabels := istiolabels.Instance(pod.Labels)
+	for _, svc := range services.Items {
+		if len(svc.Spec.Selector) > 0 && istiolabels.Instance(svc.Spec.Selector).SubsetOf(podLabels) {
+			in.services = append(in.services, svc)
+		}
+	}
+
+	if !hasSidecar(pod) {
+		return in, nil
+	}
+
+	execClient, err := clientExecFactory(kubeconfig, configContext)
+	if err != nil {
+		return nil, err
+	}
+	if dump, err := execClient.EnvoyDo(podName, ns, "GET", "config_dump", nil); err != nil {
+		fmt.Fprintf(writer, "WARNING: unable to retrieve the Envoy config dump of %s.%s: %v\n", podName, ns, err)
+	} else {
+		in.envoy = &configdump.Wrapper{}
+		if err := json.Unmarshal(dump, in.envoy); err != nil {
+			return nil, fmt.Errorf("unable to parse the Envoy config dump of %s.%s: %v", podName, ns, err)
+		}
+	}
+
+	results, err := execClient.AllPilotsDiscoveryDo(istioNamespace, "GET",
+		fmt.Sprintf("/debug/authenticationz?proxyID=%s.%s", podName, ns), nil)
+	if err != nil {
+		fmt.Fprintf(writer, "WARNING: unable to retrieve the authentication info of %s.%s from Pilot: %v\n", podName, ns, err)
+		return in, nil
+	}
+	// Only the Pilot instance the pod is connected to reports it.
+	for _, result := range results {
+		var debug []v2.AuthenticationDebug
+		if err := json.Unmarshal(result, &debug); err != nil {
+			return nil, fmt.Errorf("JSON response invalid: %v", err)
+		}
+		if len(debug) > 0 {
+			in.authn = debug
+			break
+		}
+	}
+	return in, nil
+}
+
+func hasSidecar(pod *v1.Pod) bool {
+	for _, c := range pod.Spec.Containers {
+		if c.Name == "istio-proxy" {
+			return true
+		}
+	}
+	return false
+}
+
+func printPod(writer io.Writer, in *describeInputs) {
+	pod := in.pod
+	fmt.Fprintf(writer, "Pod: %s\n", pod.Name)
+	for _, c := range pod.Spec.Containers {
+		if len(c.Ports) == 0 {
+			continue
+		}
+		ports := make([]string, 0, len(c.Ports))
+		for _, p := range c.Ports {
+			ports = append(ports, fmt.Sprintf("%d/%s", p.ContainerPort, p.Protocol))
+		}
+		fmt.Fprintf(writer, "   Pod Ports: %s (%s)\n", strings.Join(ports, ", "), c.Name)
+	}
+	if !hasSidecar(pod) {
+		fmt.Fprintf(writer, "WARNING: %s is not part of the mesh, it has no Istio sidecar\n", pod.Name)
+	}
+	if len(in.services) == 0 {
+		fmt.Fprintf(writer, "Suggestion: add a Service selecting the labels of %s to route traffic to it\n", pod.Name)
+	}
+
+	for _, svc := range in.services {
+		fmt.Fprintln(writer, "--------------------")
+		printService(writer, in, svc)
+	}
+
+	printGateways(writer, in)
+}
+
+func printService(writer io.Writer, in *describeInputs, svc v1.Service) {
+	fmt.Fprintf(writer, "Service: %s\n", svc.Name)
+	for _, port := range svc.Spec.Ports {
+		fmt.Fprintf(writer, "   Port: %s %d/%s targets pod port %s\n",
+			port.Name, port.Port, kube.ConvertProtocol(port.Name, port.Protocol), targetPort(in.pod, port))
+	}
+
+	var clusters []*xdsapi.Cluster
+	var routes []*xdsapi.RouteConfiguration
+	if in.envoy != nil {
+		clusters, routes = envoyConfigs(writer, in.envoy)
+	}
+
+	hostname := serviceHostname(in, clusters, svc)
+	printedDR := false
+	printedVS := map[configRef]bool{}
+	for _, port := range svc.Spec.Ports {
+		clusterName := fmt.Sprintf("outbound|%d||%s", port.Port, hostname)
+		for _, cluster := range clusters {
+			if cluster.Name != clusterName {
+				continue
+			}
+			if ref, ok := configRefFromMetadata(cluster.Metadata); ok && !printedDR {
+				printDestinationRule(writer, in, ref, hostname)
+				printedDR = true
+			}
+		}
+
+		if kube.ConvertProtocol(port.Name, port.Protocol).IsHTTP() {
+			for _, ref := range virtualServicesForPort(routes, hostname, port) {
+				if !printedVS[ref] {
+					printedVS[ref] = true
+					printVirtualService(writer, in, ref)
+				}
+			}
+		}
+
+		printAuthn(writer, in, hostname, port)
+	}
+
+	printRBAC(writer, in, hostname)
+}
+
+// serviceHostname returns the hostname of the service used by Pilot. The domain suffix is taken from the
+// Envoy clusters or the authentication info when available.
+func serviceHostname(in *describeInputs, clusters []*xdsapi.Cluster, svc v1.Service) string {
+	prefix := fmt.Sprintf("%s.%s.svc.", svc.Name, svc.Namespace)
+	for _, cluster := range clusters {
+		parts := strings.Split(cluster.Name, "|")
+		if len(parts) == 4 && strings.HasPrefix(parts[3], prefix) {
+			return parts[3]
+		}
+	}
+	for _, debug := range in.authn {
+		if strings.HasPrefix(debug.Host, prefix) {
+			return debug.Host
+		}
+	}
+	return prefix + "cluster.local"
+}
+
+// targetPort returns the pod port that receives the traffic of the service port.
+func targetPort(pod *v1.Pod, port v1.ServicePort) string {
+	if port.TargetPort.Type == intstr.String {
+		for _, c := range pod.Spec.Containers {
+			for _, p := range c.Ports {
+				if p.Name == port.TargetPort.StrVal {
+					return strconv.Itoa(int(p.ContainerPort))
+				}
+			}
+		}
+		return port.TargetPort.StrVal + " (not found)"
+	}
+	if port.TargetPort.IntVal == 0 {
+		return strconv.Itoa(int(port.Port))
+	}
+	return strconv.Itoa(int(port.TargetPort.IntVal))
+}
+
+// envoyConfigs returns the dynamic clusters and routes of the Envoy config dump.
+func envoyConfigs(writer io.Writer, dump *configdump.Wrapper) ([]*xdsapi.Cluster, []*xdsapi.RouteConfiguration) {
+	var clusters []*xdsapi.Cluster
+	if clusterDump, err := dump.GetDynamicClusterDump(true); err != nil {
+		fmt.Fprintf(writer, "WARNING: unable to read the Envoy clusters: %v\n", err)
+	} else {
+		for _, dc := range clusterDump.DynamicActiveClusters {
+			if dc.Cluster != nil {
+				clusters = append(clusters, dc.Cluster)
+			}
+		}
+	}
+
+	var routes []*xdsapi.RouteConfiguration
+	if routeDump, err := dump.GetDynamicRouteDump(true); err != nil {
+		fmt.Fprintf(writer, "WARNING: unable to read the Envoy routes: %v\n", err)
+	} else {
+		for _, drc := range routeDump.DynamicRouteConfigs {
+			if drc.RouteConfig != nil {
+				routes = append(routes, drc.RouteConfig)
+			}
+		}
+	}
+	return clusters, routes
+}
+
+// configRefFromMetadata parses the config added by Pilot to the Envoy metadata, in the form
+// /apis/<group>/<version>/namespaces/<namespace>/<type>/<name>.
+func configRefFromMetadata(md *core.Metadata) (configRef, bool) {
+	if md == nil || md.FilterMetadata[util.IstioMetadataKey] == nil {
+		return configRef{}, false
+	}
+	value := md.FilterMetadata[util.IstioMetadataKey].Fields["config"]
+	if value == nil {
+		return configRef{}, false
+	}
+	parts := strings.Split(value.GetStringValue(), "/")
+	if len(parts) < 4 || parts[len(parts)-4] != "namespaces" {
+		return configRef{}, false
+	}
+	return configRef{
+		Namespace: parts[len(parts)-3],
+		Type:      parts[len(parts)-2],
+		Name:      parts[len(parts)-1],
+	}, true
+}
+
+// virtualServicesForPort returns the VirtualServices that generated the routes to the service port.
+func virtualServicesForPort(routes []*xdsapi.RouteConfiguration, hostname string, port v1.ServicePort) []configRef {
+	vhName := fmt.Sprintf("%s:%d", hostname, port.Port)
+	var out []configRef
+	for _, rc := range routes {
+		// Outbound HTTP routes are named after the port.
+		if rc.Name != strconv.Itoa(int(port.Port)) {
+			continue
+		}
+		for _, vh := range rc.VirtualHosts {
+			if vh.Name != vhName {
+				continue
+			}
+			for _, r := range vh.Routes {
+				if ref, ok := configRefFromMetadata(r.Metadata); ok {
+					out = append(out, ref)
+				}
+			}
+		}
+	}
+	return out
+}
+
+func printDestinationRule(writer io.Writer, in *describeInputs, ref configRef, hostname string) {
+	fmt.Fprintf(writer, "DestinationRule: %s for %q\n", ref, hostname)
+	cfg := in.configs.Get(model.DestinationRule.Type, ref.Name, ref.Namespace)
+	if cfg == nil {
+		return
+	}
+	dr := cfg.Spec.(*networking.DestinationRule)
+
+	podLabels := istiolabels.Instance(in.pod.Labels)
+	var matching, nonMatching []string
+	for _, ss := range dr.Subsets {
+		if istiolabels.Instance(ss.Labels).SubsetOf(podLabels) {
+			matching = append(matching, ss.Name)
+		} else {
+			nonMatching = append(nonMatching, ss.Name)
+		}
+	}
+	if len(matching) > 0 {
+		fmt.Fprintf(writer, "   Matching subsets: %s\n", strings.Join(matching, ","))
+	}
+	if len(nonMatching) > 0 {
+		fmt.Fprintf(writer, "      (Non-matching subsets %s)\n", strings.Join(nonMatching, ","))
+	}
+	if len(dr.Subsets) > 0 && len(matching) == 0 {
+		fmt.Fprintf(writer, "   WARNING: no subset of %s selects the pod\n", ref)
+	}
+
+	if tls := dr.GetTrafficPolicy().GetTls(); tls != nil {
+		fmt.Fprintf(writer, "   Traffic Policy TLS Mode: %s\n", tls.Mode)
+	} else {
+		fmt.Fprintf(writer, "   No Traffic Policy\n")
+	}
+}
+
+func printVirtualService(writer io.Writer, in *describeInputs, ref configRef) {
+	fmt.Fprintf(writer, "VirtualService: %s\n", ref)
+	cfg := in.configs.Get(model.VirtualService.Type, ref.Name, ref.Namespace)
+	if cfg == nil {
+		return
+	}
+	vs := cfg.Spec.(*networking.VirtualService)
+	fmt.Fprintf(writer, "   %d HTTP route(s)\n", len(vs.Http))
+	if len(vs.Gateways) > 0 {
+		fmt.Fprintf(writer, "   Gateways: %s\n", strings.Join(vs.Gateways, ","))
+	}
+}
+
+// printAuthn prints the TLS settings Pilot reports for the port, as seen by the server and by its clients.
+func printAuthn(writer io.Writer, in *describeInputs, hostname string, port v1.ServicePort) {
+	for _, debug := range in.authn {
+		if debug.Host != hostname || debug.Port != int(port.Port) {
+			continue
+		}
+		fmt.Fprintf(writer, "Pilot reports that port %d enforces %s and clients speak %s\n",
+			port.Port, debug.ServerProtocol, debug.ClientProtocol)
+		fmt.Fprintf(writer, "   Authentication policy: %s\n", debug.AuthenticationPolicyName)
+		if debug.TLSConflictStatus != "OK" {
+			fmt.Fprintf(writer, "   WARNING: the TLS settings of the authentication policy and of DestinationRule %s are %s\n",
+				debug.DestinationRuleName, debug.TLSConflictStatus)
+		}
+		return
+	}
+}
+
+// printRBAC prints the ServiceRoles of the pod's namespace that apply to the service, and their bindings.
+func printRBAC(writer io.Writer, in *describeInputs, hostname string) {
+	ns := in.pod.Namespace
+	roles, err := in.configs.List(model.ServiceRole.Type, ns)
+	if err != nil {
+		fmt.Fprintf(writer, "WARNING: unable to list the ServiceRoles: %v\n", err)
+		return
+	}
+	bindings, err := in.configs.List(model.ServiceRoleBinding.Type, ns)
+	if err != nil {
+		fmt.Fprintf(writer, "WARNING: unable to list the ServiceRoleBindings: %v\n", err)
+		return
+	}
+
+	var policies []string
+	for _, role := range roles {
+		if !serviceRoleAppliesTo(role.Spec.(*rbac.ServiceRole), hostname) {
+			continue
+		}
+		var boundBy []string
+		for _, binding := range bindings {
+			if binding.Spec.(*rbac.ServiceRoleBinding).GetRoleRef().GetName() == role.Name {
+				boundBy = append(boundBy, binding.Name)
+			}
+		}
+		if len(boundBy) == 0 {
+			policies = append(policies, fmt.Sprintf("%s (not bound)", role.Name))
+		} else {
+			policies = append(policies, fmt.Sprintf("%s (bound by %s)", role.Name, strings.Join(boundBy, ",")))
+		}
+	}
+	if len(policies) > 0 {
+		sort.Strings(policies)
+		fmt.Fprintf(writer, "RBAC policies: %s\n", strings.Join(policies, ", "))
+	}
+}
+
+// serviceRoleAppliesTo returns true if a rule of the role matches the hostname. The services of a rule
+// are matched the same way as by the RBAC filter: exact, "*", prefix or suffix match.
+func serviceRoleAppliesTo(role *rbac.ServiceRole, hostname string) bool {
+	for _, rule := range role.Rules {
+		for _, svc := range rule.Services {
+			switch {
+			case svc == "*" || svc == hostname:
+				return true
+			case strings.HasPrefix(svc, "*") && strings.HasSuffix(hostname, svc[1:]):
+				return true
+			case strings.HasSuffix(svc, "*") && strings.HasPrefix(hostname, svc[:len(svc)-1]):
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// printGateways prints the Gateways served by the pod, e.g. when it is an ingress gateway.
+func printGateways(writer io.Writer, in *describeInputs) {
+	gateways, err := in.configs.List(model.Gateway.Type, "")
+	if err != nil {
+		fmt.Fprintf(writer, "WARNING: unable to list the Gateways: %v\n", err)
+		return
+	}
+	podLabels := istiolabels.Instance(in.pod.Labels)
+	for _, cfg := range gateways {
+		gw := cfg.Spec.(*networking.Gateway)
+		if len(gw.Selector) == 0 || !istiolabels.Instance(gw.Selector).SubsetOf(podLabels) {
+			continue
+		}
+		fmt.Fprintln(writer, "--------------------")
+		fmt.Fprintf(writer, "Exposed on Gateway %s.%s\n", cfg.Name, cfg.Namespace)
+		for _, server := range gw.Servers {
+			if server.Port == nil {
+				continue
+			}
+			fmt.Fprintf(writer, "   Port: %s %d/%s for hosts %s\n", server.Port.Name, server.Port.Number,
+				server.Port.Protocol, strings.Join(server.Hosts, ","))
+		}
+	}
+}
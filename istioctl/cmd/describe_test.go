@@ -0,0 +1,269 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+
+	networking "istio.io/api/networking/v1alpha3"
+	rbac "istio.io/api/rbac/v1alpha1"
+
+	istioctlkube "istio.io/istio/istioctl/pkg/kubernetes"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/test/util"
+)
+
+// mockDescribeExecConfig returns the Envoy config dump of the pods and the Pilot debug results separately.
+type mockDescribeExecConfig struct {
+	mockExecConfig
+	pilotResults map[string][]byte
+}
+
+func (client mockDescribeExecConfig) AllPilotsDiscoveryDo(pilotNamespace, method, path string, body []byte) (map[string][]byte, error) {
+	return client.pilotResults, nil
+}
+
+var (
+	ratingsPod = &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "ratings-v1-7dc98c7588-8jsbw",
+			Namespace: "default",
+			Labels:    map[string]string{"app": "ratings", "version": "v1"},
+		},
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{
+				{
+					Name:  "ratings",
+					Ports: []v1.ContainerPort{{Name: "http", ContainerPort: 9080, Protocol: v1.ProtocolTCP}},
+				},
+				{
+					Name:  "istio-proxy",
+					Ports: []v1.ContainerPort{{Name: "http-envoy-prom", ContainerPort: 15090, Protocol: v1.ProtocolTCP}},
+				},
+			},
+		},
+	}
+
+	ratingsService = &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "ratings",
+			Namespace: "default",
+		},
+		Spec: v1.ServiceSpec{
+			Selector: map[string]string{"app": "ratings"},
+			Ports: []v1.ServicePort{
+				{Name: "http", Port: 9080, Protocol: v1.ProtocolTCP, TargetPort: intstr.FromString("http")},
+			},
+		},
+	}
+
+	otherService = &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "reviews",
+			Namespace: "default",
+		},
+		Spec: v1.ServiceSpec{
+			Selector: map[string]string{"app": "reviews"},
+			Ports:    []v1.ServicePort{{Name: "http", Port: 9080, Protocol: v1.ProtocolTCP}},
+		},
+	}
+
+	ratingsConfigs = []model.Config{
+		{
+			ConfigMeta: model.ConfigMeta{
+				Name:      "ratings",
+				Namespace: "default",
+				Type:      model.DestinationRule.Type,
+				Group:     model.DestinationRule.Group,
+				Version:   model.DestinationRule.Version,
+			},
+			Spec: &networking.DestinationRule{
+				Host: "ratings",
+				Subsets: []*networking.Subset{
+					{Name: "v1", Labels: map[string]string{"version": "v1"}},
+					{Name: "v2", Labels: map[string]string{"version": "v2"}},
+				},
+			},
+		},
+		{
+			ConfigMeta: model.ConfigMeta{
+				Name:      "ratings",
+				Namespace: "default",
+				Type:      model.VirtualService.Type,
+				Group:     model.VirtualService.Group,
+				Version:   model.VirtualService.Version,
+			},
+			Spec: &networking.VirtualService{
+				Hosts: []string{"ratings"},
+				Http: []*networking.HTTPRoute{
+					{
+						Route: []*networking.HTTPRouteDestination{
+							{Destination: &networking.Destination{Host: "ratings", Subset: "v1"}},
+						},
+					},
+				},
+			},
+		},
+		{
+			ConfigMeta: model.ConfigMeta{
+				Name:      "ratings-viewer",
+				Namespace: "default",
+				Type:      model.ServiceRole.Type,
+				Group:     model.ServiceRole.Group,
+				Version:   model.ServiceRole.Version,
+			},
+			Spec: &rbac.ServiceRole{
+				Rules: []*rbac.AccessRule{
+					{Services: []string{"ratings.*"}, Methods: []string{"GET"}},
+				},
+			},
+		},
+		{
+			ConfigMeta: model.ConfigMeta{
+				Name:      "reviews-viewer",
+				Namespace: "default",
+				Type:      model.ServiceRole.Type,
+				Group:     model.ServiceRole.Group,
+				Version:   model.ServiceRole.Version,
+			},
+			Spec: &rbac.ServiceRole{
+				Rules: []*rbac.AccessRule{
+					{Services: []string{"reviews.default.svc.cluster.local"}, Methods: []string{"GET"}},
+				},
+			},
+		},
+		{
+			ConfigMeta: model.ConfigMeta{
+				Name:      "bind-ratings-viewer",
+				Namespace: "default",
+				Type:      model.ServiceRoleBinding.Type,
+				Group:     model.ServiceRoleBinding.Group,
+				Version:   model.ServiceRoleBinding.Version,
+			},
+			Spec: &rbac.ServiceRoleBinding{
+				Subjects: []*rbac.Subject{{User: "*"}},
+				RoleRef:  &rbac.RoleRef{Kind: "ServiceRole", Name: "ratings-viewer"},
+			},
+		},
+	}
+)
+
+func TestDescribePod(t *testing.T) {
+	configDump := util.ReadFile("testdata/describe/config_dump.json", t)
+	authn := util.ReadFile("testdata/describe/authenticationz.json", t)
+
+	cases := []struct {
+		name           string
+		objects        []runtime.Object
+		configs        []model.Config
+		envoyResults   map[string][]byte
+		pilotResults   map[string][]byte
+		args           []string
+		expectedOutput string
+		wantException  bool
+	}{
+		{
+			name:          "pod not found",
+			args:          strings.Split("x describe pod ratings-v1-7dc98c7588-8jsbw", " "),
+			wantException: true,
+		},
+		{
+			name:         "sidecar pod",
+			objects:      []runtime.Object{ratingsPod, ratingsService, otherService},
+			configs:      ratingsConfigs,
+			envoyResults: map[string][]byte{"ratings-v1-7dc98c7588-8jsbw": configDump},
+			pilotResults: map[string][]byte{"istio-pilot-7c5b5b9b8-q6xvk": authn},
+			args:         strings.Split("x describe pod ratings-v1-7dc98c7588-8jsbw.default", " "),
+			expectedOutput: `Pod: ratings-v1-7dc98c7588-8jsbw
+   Pod Ports: 9080/TCP (ratings)
+   Pod Ports: 15090/TCP (istio-proxy)
+--------------------
+Service: ratings
+   Port: http 9080/HTTP targets pod port 9080
+DestinationRule: ratings.default for "ratings.default.svc.cluster.local"
+   Matching subsets: v1
+      (Non-matching subsets v2)
+   No Traffic Policy
+VirtualService: ratings.default
+   1 HTTP route(s)
+Pilot reports that port 9080 enforces mTLS and clients speak HTTP
+   Authentication policy: default/
+   WARNING: the TLS settings of the authentication policy and of DestinationRule ratings/default are CONFLICT
+RBAC policies: ratings-viewer (bound by bind-ratings-viewer)
+`,
+		},
+		{
+			name: "pod without sidecar",
+			objects: []runtime.Object{
+				&v1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "legacy",
+						Namespace: "default",
+						Labels:    map[string]string{"app": "legacy"},
+					},
+					Spec: v1.PodSpec{
+						Containers: []v1.Container{{Name: "legacy"}},
+					},
+				},
+			},
+			args: strings.Split("x describe pod legacy", " "),
+			expectedOutput: `Pod: legacy
+WARNING: legacy is not part of the mesh, it has no Istio sidecar
+Suggestion: add a Service selecting the labels of legacy to route traffic to it
+`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			interfaceFactory = func(string) (kubernetes.Interface, error) {
+				return fake.NewSimpleClientset(c.objects...), nil
+			}
+			clientFactory = mockClientFactoryGenerator(c.configs)
+			clientExecFactory = func(string, string) (istioctlkube.ExecClient, error) {
+				return mockDescribeExecConfig{
+					mockExecConfig: mockExecConfig{results: c.envoyResults},
+					pilotResults:   c.pilotResults,
+				}, nil
+			}
+
+			var out bytes.Buffer
+			rootCmd := GetRootCmd(c.args)
+			rootCmd.SetOutput(&out)
+			err := rootCmd.Execute()
+			if c.wantException {
+				if err == nil {
+					t.Fatalf("Wanted an exception for 'istioctl %s', didn't get one", strings.Join(c.args, " "))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unwanted exception for 'istioctl %s': %v", strings.Join(c.args, " "), err)
+			}
+			if out.String() != c.expectedOutput {
+				t.Fatalf("Unexpected output for 'istioctl %s'\n got: %s\nwant: %s", strings.Join(c.args, " "), out.String(), c.expectedOutput)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"istio.io/istio/istioctl/pkg/util/handlers"
+	"istio.io/istio/istioctl/pkg/writer/compare"
+	"istio.io/istio/istioctl/pkg/writer/pilot"
+	v2 "istio.io/istio/pilot/pkg/proxy/envoy/v2"
+)
+
+var (
+	dryRunDiff bool
+)
+
+func dryRun() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "dry-run <pod-name[.namespace]> -f <file>",
+		Short: "Preview the Envoy config changes caused by Istio configs before applying them [kube only]",
+		Long: `
+Sends candidate Istio configs to the Pilot the proxy is connected to. Pilot overlays them on the
+current configs, regenerates the listeners, routes and clusters of the proxy, and reports the
+ones that would be added, removed or modified if the configs were applied.
+
+The candidate configs do not add services to the registry: the changes caused by ServiceEntries
+are not previewed. Endpoints are not compared.
+`,
+		Example: `# Preview the changes a VirtualService causes to the pod "productpage-v1-c7765c886-7zzd4":
+istioctl experimental dry-run productpage-v1-c7765c886-7zzd4.default -f reviews-v2.yaml
+
+# Print the diff of the listeners, routes and clusters:
+istioctl experimental dry-run productpage-v1-c7765c886-7zzd4.default -f reviews-v2.yaml --diff
+`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if file == "" {
+				return errors.New("the candidate configs must be given with -f")
+			}
+			configs, err := readDryRunConfigs(file)
+			if err != nil {
+				return err
+			}
+			kubeClient, err := clientExecFactory(kubeconfig, configContext)
+			if err != nil {
+				return err
+			}
+			podName, ns := handlers.InferPodInfo(args[0], handlers.HandleNamespace(namespace, defaultNamespace))
+			results, err := kubeClient.AllPilotsDiscoveryDo(istioNamespace, "POST",
+				fmt.Sprintf("/debug/config_dryrun?proxyID=%s.%s", podName, ns), configs)
+			if err != nil {
+				return err
+			}
+
+			// Only the Pilot instance the proxy is connected to returns a result.
+			var result *v2.ConfigDryRun
+			for _, resp := range results {
+				r := &v2.ConfigDryRun{}
+				if err := json.Unmarshal(resp, r); err == nil && r.ProxyID != "" {
+					result = r
+					break
+				}
+			}
+			if result == nil {
+				return fmt.Errorf("checked %d pilot instances and found no dry run result for %s.%s, check proxy status",
+					len(results), podName, ns)
+			}
+
+			if dryRunDiff {
+				c, err := compare.NewConfigDumpComparator(cmd.OutOrStdout(), "Current", result.Current, "Proposed", result.Proposed)
+				if err != nil {
+					return err
+				}
+				return c.Diff()
+			}
+			dw := pilot.DryRunWriter{Writer: cmd.OutOrStdout()}
+			return dw.PrintSummary(result)
+		},
+	}
+
+	cmd.PersistentFlags().StringVarP(&file, "file", "f", "",
+		"Input file with the candidate Istio configs, or - to read them from stdin")
+	cmd.PersistentFlags().BoolVar(&dryRunDiff, "diff", false,
+		"Print the diff of the current and the proposed listeners, routes and clusters")
+	return cmd
+}
+
+func readDryRunConfigs(filename string) ([]byte, error) {
+	if filename == "-" {
+		return ioutil.ReadAll(os.Stdin)
+	}
+	return ioutil.ReadFile(filename)
+}
@@ -0,0 +1,99 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	v2 "istio.io/istio/pilot/pkg/proxy/envoy/v2"
+	"istio.io/istio/pilot/test/util"
+)
+
+func TestDryRun(t *testing.T) {
+	dump := util.ReadFile("../pkg/writer/compare/testdata/envoyconfigdump.json", t)
+	result, err := json.Marshal(&v2.ConfigDryRun{
+		ProxyID: "details-v1-5b7f94f9bc-wp5tb.default",
+		Routes:  v2.ResourceDiff{Modified: []string{"9080"}},
+		Clusters: v2.ResourceDiff{
+			Added:   []string{"outbound|9080|v2|reviews.default.svc.cluster.local"},
+			Removed: []string{"outbound|9080|v1|reviews.default.svc.cluster.local"},
+		},
+		Current:  dump,
+		Proposed: dump,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	unchanged, err := json.Marshal(&v2.ConfigDryRun{
+		ProxyID:  "details-v1-5b7f94f9bc-wp5tb.default",
+		Current:  dump,
+		Proposed: dump,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []execTestCase{
+		{ // case 0 no file
+			args:           strings.Split("x dry-run details-v1-5b7f94f9bc-wp5tb", " "),
+			expectedString: "the candidate configs must be given with -f",
+			wantException:  true,
+		},
+		{ // case 1 proxy not connected to Pilot
+			execClientConfig: map[string][]byte{
+				"istio-pilot-7bd6f5bd48-jvn5d": []byte("Proxy not connected to this Pilot instance"),
+			},
+			args:           strings.Split("x dry-run details-v1-5b7f94f9bc-wp5tb -f testdata/dryrun/virtualservice.yaml", " "),
+			expectedString: "checked 1 pilot instances and found no dry run result for details-v1-5b7f94f9bc-wp5tb",
+			wantException:  true,
+		},
+		{ // case 2 summary
+			execClientConfig: map[string][]byte{
+				"istio-pilot-7bd6f5bd48-jvn5d": []byte("Proxy not connected to this Pilot instance"),
+				"istio-pilot-7bd6f5bd48-kxvz2": result,
+			},
+			args: strings.Split("x dry-run details-v1-5b7f94f9bc-wp5tb -f testdata/dryrun/virtualservice.yaml", " "),
+			expectedOutput: `TYPE        CHANGE       NAME
+Cluster     ADDED        outbound|9080|v2|reviews.default.svc.cluster.local
+Cluster     REMOVED      outbound|9080|v1|reviews.default.svc.cluster.local
+Route       MODIFIED     9080
+`,
+		},
+		{ // case 3 no changes
+			execClientConfig: map[string][]byte{
+				"istio-pilot-7bd6f5bd48-kxvz2": unchanged,
+			},
+			args:           strings.Split("x dry-run details-v1-5b7f94f9bc-wp5tb -f testdata/dryrun/virtualservice.yaml", " "),
+			expectedOutput: "No changes for details-v1-5b7f94f9bc-wp5tb.default\n",
+		},
+		{ // case 4 diff
+			execClientConfig: map[string][]byte{
+				"istio-pilot-7bd6f5bd48-kxvz2": result,
+			},
+			args:           strings.Split("x dry-run details-v1-5b7f94f9bc-wp5tb -f testdata/dryrun/virtualservice.yaml --diff", " "),
+			expectedOutput: "Clusters Match\nListeners Match\nRoutes Match\n",
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("case %d %s", i, strings.Join(c.args, " ")), func(t *testing.T) {
+			dryRunDiff = false
+			verifyExecTestOutput(t, c)
+		})
+	}
+}
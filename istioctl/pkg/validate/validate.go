@@ -95,7 +95,10 @@ func (v *validator) validateResource(istioNamespace string, un *unstructured.Uns
 		if err = checkFields(un); err != nil {
 			return err
 		}
-		return schema.Validate(obj.Name, obj.Namespace, obj.Spec)
+		if err = schema.Validate(obj.Name, obj.Namespace, obj.Spec); err != nil {
+			return err
+		}
+		return model.ValidateConfigAnnotations(*obj)
 	}
 
 	if v.mixerValidator != nil && un.GetAPIVersion() == mixerAPIVersion {
@@ -24,10 +24,11 @@ import (
 
 // Comparator diffs between a config dump from Pilot and one from Envoy
 type Comparator struct {
-	envoy, pilot *configdump.Wrapper
-	w            io.Writer
-	context      int
-	location     string
+	envoy, pilot           *configdump.Wrapper
+	envoyLabel, pilotLabel string
+	w                      io.Writer
+	context                int
+	location               string
 }
 
 // NewComparator is a comparator constructor
@@ -51,12 +52,35 @@ func NewComparator(w io.Writer, pilotResponses map[string][]byte, envoyResponse
 		return nil, err
 	}
 	c.envoy = envoyDump
+	c.pilotLabel = "Pilot"
+	c.envoyLabel = "Envoy"
 	c.w = w
 	c.context = 7
 	c.location = "Local" // the time.Location for formatting time.Time instances
 	return c, nil
 }
 
+// NewConfigDumpComparator diffs between two config dumps, such as the current and the proposed config of a
+// proxy. The labels name the config dumps in the diff headers.
+func NewConfigDumpComparator(w io.Writer, fromLabel string, from []byte, toLabel string, to []byte) (*Comparator, error) {
+	fromDump, toDump := &configdump.Wrapper{}, &configdump.Wrapper{}
+	if err := json.Unmarshal(from, fromDump); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(to, toDump); err != nil {
+		return nil, err
+	}
+	return &Comparator{
+		pilot:      fromDump,
+		pilotLabel: fromLabel,
+		envoy:      toDump,
+		envoyLabel: toLabel,
+		w:          w,
+		context:    7,
+		location:   "Local",
+	}, nil
+}
+
 // Diff prints a diff between Pilot and Envoy to the passed writer
 func (c *Comparator) Diff() error {
 	if err := c.ClusterDiff(); err != nil {
@@ -17,6 +17,7 @@ package compare
 import (
 	"bytes"
 	"io/ioutil"
+	"strings"
 	"testing"
 )
 
@@ -89,3 +90,47 @@ func TestNewComparator(t *testing.T) {
 		})
 	}
 }
+
+func TestNewConfigDumpComparator(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to []byte
+		wantErr  bool
+	}{
+		{
+			name: "populates both wrappers",
+			from: loadPilotDump(),
+			to:   loadDiffEnvoyDump(),
+		},
+		{
+			name:    "errors if the first config dump is invalid",
+			from:    []byte("nope"),
+			to:      loadEnvoyDump(),
+			wantErr: true,
+		},
+		{
+			name:    "errors if the second config dump is invalid",
+			from:    loadPilotDump(),
+			to:      []byte("nope"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &bytes.Buffer{}
+			got, err := NewConfigDumpComparator(w, "Current", tt.from, "Proposed", tt.to)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewConfigDumpComparator() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if err := got.ClusterDiff(); err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(w.String(), "--- Current Clusters\n+++ Proposed Clusters\n") {
+				t.Errorf("ClusterDiff() output does not use the labels:\n%s", w.String())
+			}
+		})
+	}
+}
@@ -39,9 +39,9 @@ func (c *Comparator) ListenerDiff() error {
 		return err
 	}
 	diff := difflib.UnifiedDiff{
-		FromFile: "Pilot Listeners",
+		FromFile: c.pilotLabel + " Listeners",
 		A:        difflib.SplitLines(pilotBytes.String()),
-		ToFile:   "Envoy Listeners",
+		ToFile:   c.envoyLabel + " Listeners",
 		B:        difflib.SplitLines(envoyBytes.String()),
 		Context:  c.context,
 	}
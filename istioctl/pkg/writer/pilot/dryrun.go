@@ -0,0 +1,54 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pilot
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	v2 "istio.io/istio/pilot/pkg/proxy/envoy/v2"
+)
+
+// DryRunWriter enables printing of the xDS resources a config dry run would change
+type DryRunWriter struct {
+	Writer io.Writer
+}
+
+// PrintSummary takes a Pilot config_dryrun response and outputs the changed resources using a tabwriter
+func (d *DryRunWriter) PrintSummary(result *v2.ConfigDryRun) error {
+	w := new(tabwriter.Writer).Init(d.Writer, 0, 8, 5, ' ', 0)
+	if result.Listeners.Empty() && result.Routes.Empty() && result.Clusters.Empty() {
+		fmt.Fprintf(w, "No changes for %s\n", result.ProxyID)
+		return w.Flush()
+	}
+	fmt.Fprintln(w, "TYPE\tCHANGE\tNAME")
+	dryRunPrintln(w, "Cluster", result.Clusters)
+	dryRunPrintln(w, "Listener", result.Listeners)
+	dryRunPrintln(w, "Route", result.Routes)
+	return w.Flush()
+}
+
+func dryRunPrintln(w io.Writer, typ string, diff v2.ResourceDiff) {
+	for _, name := range diff.Added {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", typ, "ADDED", name)
+	}
+	for _, name := range diff.Modified {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", typ, "MODIFIED", name)
+	}
+	for _, name := range diff.Removed {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", typ, "REMOVED", name)
+	}
+}
@@ -17,6 +17,7 @@ package main
 import (
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"istio.io/istio/pkg/spiffe"
@@ -26,7 +27,9 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"istio.io/istio/pilot/pkg/bootstrap"
+	"istio.io/istio/pilot/pkg/features"
 	"istio.io/istio/pilot/pkg/serviceregistry"
+	"istio.io/istio/pilot/pkg/serviceregistry/zookeeper"
 	"istio.io/istio/pkg/cmd"
 	"istio.io/istio/pkg/keepalive"
 	"istio.io/pkg/collateral"
@@ -61,6 +64,7 @@ var (
 			}
 
 			spiffe.SetTrustDomain(spiffe.DetermineTrustDomain(serverArgs.Config.ControllerOptions.TrustDomain, hasKubeRegistry()))
+			spiffe.SetFederatedTrustDomains(strings.Split(features.FederatedTrustDomains.Get(), ","))
 
 			// Create the stop channel for all of the servers.
 			stop := make(chan struct{})
@@ -95,8 +99,9 @@ func hasKubeRegistry() bool {
 func init() {
 	discoveryCmd.PersistentFlags().StringSliceVar(&serverArgs.Service.Registries, "registries",
 		[]string{string(serviceregistry.KubernetesRegistry)},
-		fmt.Sprintf("Comma separated list of platform service registries to read from (choose one or more from {%s, %s, %s, %s})",
-			serviceregistry.KubernetesRegistry, serviceregistry.ConsulRegistry, serviceregistry.MCPRegistry, serviceregistry.MockRegistry))
+		fmt.Sprintf("Comma separated list of platform service registries to read from (choose one or more from {%s, %s, %s, %s, %s, %s})",
+			serviceregistry.KubernetesRegistry, serviceregistry.ConsulRegistry, serviceregistry.EurekaRegistry,
+			serviceregistry.ZookeeperRegistry, serviceregistry.MCPRegistry, serviceregistry.MockRegistry))
 	discoveryCmd.PersistentFlags().StringVar(&serverArgs.Config.ClusterRegistriesNamespace, "clusterRegistriesNamespace", metav1.NamespaceAll,
 		"Namespace for ConfigMap which stores clusters configs")
 	discoveryCmd.PersistentFlags().StringVar(&serverArgs.Config.KubeConfig, "kubeconfig", "",
@@ -136,7 +141,23 @@ func init() {
 	discoveryCmd.PersistentFlags().StringVar(&serverArgs.Service.Consul.ServerURL, "consulserverURL", "",
 		"URL for the Consul server")
 	discoveryCmd.PersistentFlags().DurationVar(&serverArgs.Service.Consul.Interval, "consulserverInterval", 2*time.Second,
-		"Interval (in seconds) for polling the Consul service registry")
+		"Delay before retrying a failed watch on the Consul service registry")
+	discoveryCmd.PersistentFlags().StringVar(&serverArgs.Service.Consul.TokenFile, "consulserverTokenFile", "",
+		"File containing the ACL token for the Consul server. Defaults to the CONSUL_HTTP_TOKEN environment variable")
+	discoveryCmd.PersistentFlags().StringVar(&serverArgs.Service.Consul.CAFile, "consulserverCACert", "",
+		"CA certificate file to verify the Consul server, when its URL uses the https scheme")
+	discoveryCmd.PersistentFlags().StringVar(&serverArgs.Service.Consul.CertFile, "consulserverClientCert", "",
+		"Client certificate file for the TLS connection to the Consul server")
+	discoveryCmd.PersistentFlags().StringVar(&serverArgs.Service.Consul.KeyFile, "consulserverClientKey", "",
+		"Client key file for the TLS connection to the Consul server")
+	discoveryCmd.PersistentFlags().StringVar(&serverArgs.Service.Eureka.ServerURL, "eurekaserverURL", "",
+		"URL of the Eureka server REST API, e.g. http://eureka:8761/eureka")
+	discoveryCmd.PersistentFlags().DurationVar(&serverArgs.Service.Eureka.Interval, "eurekaserverInterval", 2*time.Second,
+		"Interval between two polls of the applications registered in Eureka")
+	discoveryCmd.PersistentFlags().StringSliceVar(&serverArgs.Service.Zookeeper.Servers, "zookeeperServers", nil,
+		"Comma separated list of the ZooKeeper servers holding the Dubbo registry")
+	discoveryCmd.PersistentFlags().StringVar(&serverArgs.Service.Zookeeper.Root, "zookeeperRoot", zookeeper.DefaultRoot,
+		"Root znode of the Dubbo registry in ZooKeeper")
 
 	// using address, so it can be configured as localhost:.. (possibly UDS in future)
 	discoveryCmd.PersistentFlags().StringVar(&serverArgs.DiscoveryOptions.HTTPAddr, "httpAddr", ":8080",
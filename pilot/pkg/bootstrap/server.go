@@ -34,6 +34,7 @@ import (
 	"github.com/gogo/protobuf/types"
 	middleware "github.com/grpc-ecosystem/go-grpc-middleware"
 	prometheus "github.com/grpc-ecosystem/go-grpc-prometheus"
+	consulapi "github.com/hashicorp/consul/api"
 	"github.com/hashicorp/go-multierror"
 	prom "github.com/prometheus/client_golang/prometheus"
 
@@ -69,9 +70,11 @@ import (
 	"istio.io/istio/pilot/pkg/serviceregistry"
 	"istio.io/istio/pilot/pkg/serviceregistry/aggregate"
 	"istio.io/istio/pilot/pkg/serviceregistry/consul"
+	"istio.io/istio/pilot/pkg/serviceregistry/eureka"
 	"istio.io/istio/pilot/pkg/serviceregistry/external"
 	controller2 "istio.io/istio/pilot/pkg/serviceregistry/kube/controller"
 	srmemory "istio.io/istio/pilot/pkg/serviceregistry/memory"
+	"istio.io/istio/pilot/pkg/serviceregistry/zookeeper"
 	"istio.io/istio/pkg/config/constants"
 	"istio.io/istio/pkg/config/host"
 	"istio.io/istio/pkg/config/mesh"
@@ -168,12 +171,33 @@ type ConsulArgs struct {
 	Config    string
 	ServerURL string
 	Interval  time.Duration
+	// TokenFile is the path to the file containing the ACL token.
+	TokenFile string
+	// CAFile, CertFile and KeyFile configure the TLS connection to the Consul agent.
+	CAFile   string
+	CertFile string
+	KeyFile  string
+}
+
+// EurekaArgs provides configuration for the Eureka service registry.
+type EurekaArgs struct {
+	ServerURL string
+	Interval  time.Duration
+}
+
+// ZookeeperArgs provides configuration for the ZooKeeper service registry.
+type ZookeeperArgs struct {
+	Servers []string
+	// Root is the root znode of the Dubbo registry.
+	Root string
 }
 
 // ServiceArgs provides the composite configuration for all service registries in the system.
 type ServiceArgs struct {
 	Registries []string
 	Consul     ConsulArgs
+	Eureka     EurekaArgs
+	Zookeeper  ZookeeperArgs
 }
 
 // PilotArgs provides all of the configuration parameters for the Pilot discovery service.
@@ -834,6 +858,14 @@ func (s *Server) initServiceControllers(args *PilotArgs) error {
 			if err := s.initConsulRegistry(serviceControllers, args); err != nil {
 				return err
 			}
+		case serviceregistry.EurekaRegistry:
+			if err := s.initEurekaRegistry(serviceControllers, args); err != nil {
+				return err
+			}
+		case serviceregistry.ZookeeperRegistry:
+			if err := s.initZookeeperRegistry(serviceControllers, args); err != nil {
+				return err
+			}
 		case serviceregistry.MCPRegistry:
 			log.Infof("no-op: get service info from MCP ServiceEntries.")
 		default:
@@ -1040,8 +1072,23 @@ func (s *Server) initDiscoveryService(args *PilotArgs) error {
 
 func (s *Server) initConsulRegistry(serviceControllers *aggregate.Controller, args *PilotArgs) error {
 	log.Infof("Consul url: %v", args.Service.Consul.ServerURL)
-	conctl, conerr := consul.NewController(
-		args.Service.Consul.ServerURL, args.Service.Consul.Interval)
+	options := consul.ControllerOptions{
+		ServerURL: args.Service.Consul.ServerURL,
+		Interval:  args.Service.Consul.Interval,
+		TLS: consulapi.TLSConfig{
+			CAFile:   args.Service.Consul.CAFile,
+			CertFile: args.Service.Consul.CertFile,
+			KeyFile:  args.Service.Consul.KeyFile,
+		},
+	}
+	if args.Service.Consul.TokenFile != "" {
+		token, err := ioutil.ReadFile(args.Service.Consul.TokenFile)
+		if err != nil {
+			return fmt.Errorf("failed to read the Consul ACL token: %v", err)
+		}
+		options.Token = strings.TrimSpace(string(token))
+	}
+	conctl, conerr := consul.NewController(options)
 	if conerr != nil {
 		return fmt.Errorf("failed to create Consul controller: %v", conerr)
 	}
@@ -1055,6 +1102,44 @@ func (s *Server) initConsulRegistry(serviceControllers *aggregate.Controller, ar
 	return nil
 }
 
+func (s *Server) initEurekaRegistry(serviceControllers *aggregate.Controller, args *PilotArgs) error {
+	log.Infof("Eureka url: %v", args.Service.Eureka.ServerURL)
+	eurekactl, err := eureka.NewController(eureka.ControllerOptions{
+		ServerURL: args.Service.Eureka.ServerURL,
+		Interval:  args.Service.Eureka.Interval,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create Eureka controller: %v", err)
+	}
+	serviceControllers.AddRegistry(
+		aggregate.Registry{
+			Name:             serviceregistry.EurekaRegistry,
+			ServiceDiscovery: eurekactl,
+			Controller:       eurekactl,
+		})
+
+	return nil
+}
+
+func (s *Server) initZookeeperRegistry(serviceControllers *aggregate.Controller, args *PilotArgs) error {
+	log.Infof("ZooKeeper servers: %v", args.Service.Zookeeper.Servers)
+	zkctl, err := zookeeper.NewController(zookeeper.ControllerOptions{
+		Servers: args.Service.Zookeeper.Servers,
+		Root:    args.Service.Zookeeper.Root,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create ZooKeeper controller: %v", err)
+	}
+	serviceControllers.AddRegistry(
+		aggregate.Registry{
+			Name:             serviceregistry.ZookeeperRegistry,
+			ServiceDiscovery: zkctl,
+			Controller:       zkctl,
+		})
+
+	return nil
+}
+
 func (s *Server) initGrpcServer(options *istiokeepalive.Options) {
 	grpcOptions := s.grpcServerOptions(options)
 	s.grpcServer = grpc.NewServer(grpcOptions...)
@@ -199,6 +199,9 @@ func parseInputsImpl(inputs string, withValidate bool) ([]model.Config, []IstioK
 			if err := schema.Validate(cfg.Name, cfg.Namespace, cfg.Spec); err != nil {
 				return nil, nil, fmt.Errorf("configuration is invalid: %v", err)
 			}
+			if err := model.ValidateConfigAnnotations(*cfg); err != nil {
+				return nil, nil, fmt.Errorf("configuration is invalid: %v", err)
+			}
 		}
 
 		varr = append(varr, *cfg)
@@ -47,6 +47,22 @@ var (
 		"Limits the number of concurrent pushes allowed. On larger machines this can be increased for faster pushes",
 	).Get()
 
+	PushQueueEDSWeight = env.RegisterIntVar(
+		"PILOT_PUSH_QUEUE_EDS_WEIGHT",
+		4,
+		"Number of proxies dequeued from the incremental EDS lane of the push queue for each proxy dequeued from "+
+			"the full push lane, when both lanes have pending proxies. Higher values favor endpoint updates during "+
+			"large configuration changes.",
+	).Get()
+
+	PushThrottleEDSReserved = env.RegisterIntVar(
+		"PILOT_PUSH_THROTTLE_EDS_RESERVED",
+		10,
+		"Number of the concurrent pushes allowed by PILOT_PUSH_THROTTLE that are reserved for incremental EDS "+
+			"pushes, so that endpoint updates are not stuck behind full pushes. At least one concurrent push is "+
+			"left to full pushes.",
+	).Get()
+
 	// DebugConfigs controls saving snapshots of configs for /debug/adsz.
 	// Defaults to false, can be enabled with PILOT_DEBUG_ADSZ_CONFIG=1
 	// For larger clusters it can increase memory use and GC - useful for small tests.
@@ -139,6 +155,38 @@ var (
 		"EnableRedisFilter enables injection of `envoy.filters.network.redis_proxy` in the filter chain.",
 	)
 
+	// EnableKafkaFilter enables injection of `envoy.filters.network.kafka_broker` in the filter chain.
+	// Pilot injects this filter if the service port name is `kafka`.
+	EnableKafkaFilter = env.RegisterBoolVar(
+		"PILOT_ENABLE_KAFKA_FILTER",
+		false,
+		"EnableKafkaFilter enables injection of `envoy.filters.network.kafka_broker` in the filter chain.",
+	)
+
+	// EnableProtocolSniffingForOutbound enables protocol detection for outbound ports that do not declare a protocol.
+	// HTTP/1.x and h2c traffic is routed through the HTTP connection manager, everything else is proxied as TCP.
+	EnableProtocolSniffingForOutbound = env.RegisterBoolVar(
+		"PILOT_ENABLE_PROTOCOL_SNIFFING_FOR_OUTBOUND",
+		false,
+		"If enabled, protocol sniffing will be used for outbound listeners whose port protocol is not specified or unsupported",
+	)
+
+	// EnableProtocolSniffingForInbound enables protocol detection for inbound ports that do not declare a protocol.
+	EnableProtocolSniffingForInbound = env.RegisterBoolVar(
+		"PILOT_ENABLE_PROTOCOL_SNIFFING_FOR_INBOUND",
+		false,
+		"If enabled, protocol sniffing will be used for inbound listeners whose port protocol is not specified or unsupported",
+	)
+
+	// CRLFile is the path of the certificate revocation list published by Citadel, as mounted in the proxies.
+	// Citadel publishes the CRL under the `crl.pem` key of the istio-security ConfigMap.
+	CRLFile = env.RegisterStringVar(
+		"PILOT_CRL_FILE",
+		"",
+		"If set, the peer certificates of Istio mutual TLS connections are checked against the CRL at this path "+
+			"in the proxy. Can be overridden per proxy with the TLS_CRL node metadata.",
+	)
+
 	// UseRemoteAddress sets useRemoteAddress to true for side car outbound listeners so that it picks up the localhost
 	// address of the sender, which is an internal address, so that trusted headers are not sanitized.
 	UseRemoteAddress = env.RegisterBoolVar(
@@ -163,6 +211,15 @@ var (
 			"and will be removed in the near future.",
 	)
 
+	// FederatedTrustDomains is the comma separated list of the foreign trust domains whose identities are accepted
+	// by the mesh. The node agents serve the roots of these trust domains.
+	FederatedTrustDomains = env.RegisterStringVar(
+		"PILOT_FEDERATED_TRUST_DOMAINS",
+		"",
+		"Comma separated list of the foreign trust domains whose identities are accepted by the mesh, e.g. "+
+			"\"partner.org\". This requires SDS.",
+	)
+
 	ScopePushes = env.RegisterBoolVar(
 		"PILOT_SCOPE_PUSHES",
 		true,
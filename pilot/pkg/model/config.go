@@ -643,6 +643,15 @@ type istioConfigStore struct {
 	ConfigStore
 }
 
+// ValidateConfigAnnotations validates the annotations that extend the API of the config type.
+func ValidateConfigAnnotations(config Config) error {
+	switch config.Type {
+	case DestinationRule.Type:
+		return ValidateDestinationRuleAnnotations(config.Annotations)
+	}
+	return nil
+}
+
 // MakeIstioStore creates a wrapper around a store.
 // In pilot it is initialized with a ConfigStoreCache, tests only use
 // a regular ConfigStore.
@@ -1133,3 +1133,37 @@ func TestDeepCopy(t *testing.T) {
 	}
 
 }
+
+func TestValidateConfigAnnotations(t *testing.T) {
+	config := func(typ, annotation string) model.Config {
+		return model.Config{
+			ConfigMeta: model.ConfigMeta{
+				Type:        typ,
+				Name:        "reviews",
+				Namespace:   "default",
+				Annotations: map[string]string{model.LocalityLbSettingAnnotation: annotation},
+			},
+		}
+	}
+
+	cases := []struct {
+		name   string
+		config model.Config
+		valid  bool
+	}{
+		{"no annotation", config(model.DestinationRule.Type, ""), true},
+		{"valid locality setting",
+			config(model.DestinationRule.Type, `{"distribute": [{"from": "region1/*", "to": {"region1/*": 80, "region2/*": 20}}]}`), true},
+		{"unparsable locality setting", config(model.DestinationRule.Type, "distribute: foo"), false},
+		{"inconsistent locality weights",
+			config(model.DestinationRule.Type, `{"distribute": [{"from": "region1/*", "to": {"region1/*": 80, "region2/*": 30}}]}`), false},
+		{"annotation of another type", config(model.VirtualService.Type, "distribute: foo"), true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if err := model.ValidateConfigAnnotations(c.config); (err == nil) != c.valid {
+				t.Errorf("ValidateConfigAnnotations() => got %v, want valid %v", err, c.valid)
+			}
+		})
+	}
+}
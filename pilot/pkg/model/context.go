@@ -526,6 +526,10 @@ const (
 	// NodeMetadataTLSClientRootCert is the absolute path to client root cert file
 	NodeMetadataTLSClientRootCert = "TLS_CLIENT_ROOT_CERT"
 
+	// NodeMetadataTLSCRL is the absolute path to the CRL file used to validate Istio mutual TLS peers.
+	// If not set, Pilot uses the path configured by PILOT_CRL_FILE.
+	NodeMetadataTLSCRL = "TLS_CRL"
+
 	// NodeMetadataIdleTimeout specifies the idle timeout for the proxy, in duration format (10s).
 	// If not set, no timeout is set.
 	NodeMetadataIdleTimeout = "IDLE_TIMEOUT"
@@ -17,11 +17,19 @@ package model
 import (
 	"fmt"
 
+	meshconfig "istio.io/api/mesh/v1alpha1"
 	networking "istio.io/api/networking/v1alpha3"
 
+	"istio.io/istio/pkg/config"
 	"istio.io/istio/pkg/config/host"
+	"istio.io/istio/pkg/util/protomarshal"
 )
 
+// LocalityLbSettingAnnotation sets the locality load balancer setting for the hosts of a DestinationRule,
+// overriding the mesh wide setting, until the DestinationRule API has its own field. The value is a
+// LocalityLoadBalancerSetting in YAML or JSON.
+const LocalityLbSettingAnnotation = "networking.istio.io/localityLbSetting"
+
 // This function merges one or more destination rules for a given host string
 // into a single destination rule. Note that it does not perform inheritance style merging.
 // IOW, given three dest rules (*.foo.com, *.foo.com, *.com), calling this function for
@@ -66,3 +74,64 @@ func (ps *PushContext) combineSingleDestinationRule(
 
 	return combinedDestRuleHosts
 }
+
+// HasLocalityLbSettings returns true if locality load balancing is configured, for the mesh or for some destination rules.
+func (ps *PushContext) HasLocalityLbSettings() bool {
+	return ps.destRuleLocalityLbSettings || (ps.Env != nil && ps.Env.Mesh != nil && ps.Env.Mesh.LocalityLbSetting != nil)
+}
+
+// LocalityLbSetting returns the locality load balancer setting for the hosts of the destination rule: the one
+// of its LocalityLbSettingAnnotation, or the mesh wide setting if the destination rule has none or an invalid
+// one. A nil result disables locality load balancing.
+func (ps *PushContext) LocalityLbSetting(destinationRule *Config) *meshconfig.LocalityLoadBalancerSetting {
+	var meshSetting *meshconfig.LocalityLoadBalancerSetting
+	if ps.Env != nil && ps.Env.Mesh != nil {
+		meshSetting = ps.Env.Mesh.LocalityLbSetting
+	}
+	if destinationRule == nil || destinationRule.Annotations[LocalityLbSettingAnnotation] == "" {
+		return meshSetting
+	}
+
+	if cached, found := ps.localityLbSettings.Load(destinationRule); found {
+		if setting := cached.(*meshconfig.LocalityLoadBalancerSetting); setting != nil {
+			return setting
+		}
+		return meshSetting
+	}
+
+	setting, err := parseLocalityLbSetting(destinationRule.Annotations[LocalityLbSettingAnnotation])
+	if err != nil {
+		key := destinationRule.Namespace + "/" + destinationRule.Name
+		ps.Add(InvalidLocalityLbSettings, key, nil,
+			fmt.Sprintf("Invalid %s annotation of destination rule %s, using the mesh setting: %v",
+				LocalityLbSettingAnnotation, key, err))
+		// Cache the failure so that it is reported once per push.
+		ps.localityLbSettings.Store(destinationRule, (*meshconfig.LocalityLoadBalancerSetting)(nil))
+		return meshSetting
+	}
+	ps.localityLbSettings.Store(destinationRule, setting)
+	return setting
+}
+
+// parseLocalityLbSetting parses and validates the value of a LocalityLbSettingAnnotation.
+func parseLocalityLbSetting(value string) (*meshconfig.LocalityLoadBalancerSetting, error) {
+	setting := &meshconfig.LocalityLoadBalancerSetting{}
+	if err := protomarshal.ApplyYAML(value, setting); err != nil {
+		return nil, err
+	}
+	if err := config.ValidateLocalityLbSetting(setting); err != nil {
+		return nil, err
+	}
+	return setting, nil
+}
+
+// ValidateDestinationRuleAnnotations validates the annotations that extend the DestinationRule API, so that
+// invalid ones are rejected at admission instead of being ignored by Pilot.
+func ValidateDestinationRuleAnnotations(annotations map[string]string) error {
+	if value := annotations[LocalityLbSettingAnnotation]; value != "" {
+		if _, err := parseLocalityLbSetting(value); err != nil {
+			return fmt.Errorf("invalid %s annotation: %v", LocalityLbSettingAnnotation, err)
+		}
+	}
+	return nil
+}
@@ -72,6 +72,10 @@ type PushContext struct {
 	sidecarsByNamespace map[string][]*SidecarScope
 	// envoy filters for each namespace including global config namespace
 	envoyFiltersByNamespace map[string][]*EnvoyFilterWrapper
+	// locality load balancer settings of the destination rules, parsed from their annotation on first use
+	localityLbSettings sync.Map
+	// true if a destination rule has a locality load balancer setting annotation
+	destRuleLocalityLbSettings bool
 	////////// END ////////
 
 	// The following data is either a global index or used in the inbound path.
@@ -151,6 +155,38 @@ type UpdateRequest struct {
 	// If this is empty, then proxies in all namespaces will get an update
 	// If this is present, then only proxies that import this namespace will get an update
 	TargetNamespaces map[string]struct{}
+
+	// ConfigsUpdated contains the configs that were changed in the update, for a full push.
+	// The new PushContext is derived from the previous one, and only the indexes depending on the
+	// types of the changed configs are recomputed.
+	// If this is empty, all the indexes are recomputed.
+	ConfigsUpdated map[ConfigKey]struct{}
+}
+
+// ConfigKey identifies a config by its type, name and namespace.
+type ConfigKey struct {
+	Type      string
+	Name      string
+	Namespace string
+}
+
+// mergeConfigsUpdated returns the configs changed by two full push requests. An empty result means that
+// any config may have changed.
+func mergeConfigsUpdated(first, other *UpdateRequest) map[ConfigKey]struct{} {
+	if (first.Full && len(first.ConfigsUpdated) == 0) || (other.Full && len(other.ConfigsUpdated) == 0) {
+		return nil
+	}
+	merged := make(map[ConfigKey]struct{}, len(first.ConfigsUpdated)+len(other.ConfigsUpdated))
+	for conf := range first.ConfigsUpdated {
+		merged[conf] = struct{}{}
+	}
+	for conf := range other.ConfigsUpdated {
+		merged[conf] = struct{}{}
+	}
+	if len(merged) == 0 {
+		return nil
+	}
+	return merged
 }
 
 // Merge two update requests together
@@ -165,6 +201,7 @@ func (first *UpdateRequest) Merge(other *UpdateRequest) UpdateRequest {
 	merged := UpdateRequest{}
 	merged.Full = first.Full || other.Full
 	merged.TargetNamespaces = map[string]struct{}{}
+	merged.ConfigsUpdated = mergeConfigsUpdated(first, other)
 
 	// If either does not specify only namespaces, this means update all namespaces
 	if len(first.TargetNamespaces) == 0 || len(other.TargetNamespaces) == 0 {
@@ -296,6 +333,12 @@ var (
 		"Duplicate subsets across destination rules for same host",
 	)
 
+	// InvalidLocalityLbSettings tracks destination rules with an invalid locality load balancer setting annotation
+	InvalidLocalityLbSettings = monitoring.NewGauge(
+		"pilot_destrule_invalid_locality_lb_settings",
+		"Destination rules with an invalid locality load balancer setting.",
+	)
+
 	// totalVirtualServices tracks the total number of virtual service
 	totalVirtualServices = monitoring.NewGauge(
 		"pilot_virt_services",
@@ -322,6 +365,7 @@ var (
 		ProxyStatusClusterNoInstances,
 		DuplicatedDomains,
 		DuplicatedSubsets,
+		InvalidLocalityLbSettings,
 	}
 )
 
@@ -596,47 +640,144 @@ func (ps *PushContext) SubsetToLabels(proxy *Proxy, subsetName string, hostname
 // InitContext will initialize the data structures used for code generation.
 // This should be called before starting the push, from the thread creating
 // the push context.
-func (ps *PushContext) InitContext(env *Environment) error {
+// If the push request lists the changed configs, the indexes that do not depend
+// on them are reused from the previous push context instead of being recomputed.
+func (ps *PushContext) InitContext(env *Environment, oldPushContext *PushContext, pushReq *UpdateRequest) error {
 	ps.Mutex.Lock()
 	defer ps.Mutex.Unlock()
 	if ps.initDone {
 		return nil
 	}
 	ps.Env = env
-	var err error
 
 	// Must be initialized first
 	// as initServiceRegistry/VirtualServices/Destrules
 	// use the default export map
 	ps.initDefaultExportMaps()
 
-	if err = ps.initServiceRegistry(env); err != nil {
+	// The previous push context is only reused for full pushes listing the changed configs: any other
+	// change, e.g. to the services of a registry or to the mesh config, requires a full rebuild.
+	if pushReq == nil || oldPushContext == nil || !pushReq.Full || len(pushReq.ConfigsUpdated) == 0 {
+		if err := ps.createNewContext(env); err != nil {
+			return err
+		}
+	} else {
+		if err := ps.updateContext(env, oldPushContext, pushReq); err != nil {
+			return err
+		}
+	}
+
+	ps.initDone = true
+	return nil
+}
+
+func (ps *PushContext) createNewContext(env *Environment) error {
+	if err := ps.initServiceRegistry(env); err != nil {
 		return err
 	}
 
-	if err = ps.initVirtualServices(env); err != nil {
+	if err := ps.initVirtualServices(env); err != nil {
 		return err
 	}
 
-	if err = ps.initDestinationRules(env); err != nil {
+	if err := ps.initDestinationRules(env); err != nil {
 		return err
 	}
 
-	if err = ps.initAuthorizationPolicies(env); err != nil {
+	if err := ps.initAuthorizationPolicies(env); err != nil {
 		rbacLog.Errorf("failed to initialize authorization policies: %v", err)
 		return err
 	}
 
-	if err = ps.initEnvoyFilters(env); err != nil {
+	if err := ps.initEnvoyFilters(env); err != nil {
 		return err
 	}
 
 	// Must be initialized in the end
-	if err = ps.initSidecarScopes(env); err != nil {
-		return err
+	return ps.initSidecarScopes(env)
+}
+
+// updateContext recomputes the indexes depending on the changed configs, and copies the other
+// indexes from the previous push context. The indexes are never modified once initialized, so
+// they can be shared.
+func (ps *PushContext) updateContext(env *Environment, oldPushContext *PushContext, pushReq *UpdateRequest) error {
+	var servicesChanged, virtualServicesChanged, destinationRulesChanged, authzChanged,
+		envoyFiltersChanged, sidecarsChanged bool
+	for conf := range pushReq.ConfigsUpdated {
+		switch conf.Type {
+		case ServiceEntry.Type:
+			servicesChanged = true
+		case VirtualService.Type:
+			virtualServicesChanged = true
+		case DestinationRule.Type:
+			destinationRulesChanged = true
+		case ServiceRole.Type, ServiceRoleBinding.Type, RbacConfig.Type, ClusterRbacConfig.Type,
+			AuthorizationPolicy.Type:
+			authzChanged = true
+		case EnvoyFilter.Type:
+			envoyFiltersChanged = true
+		case Sidecar.Type:
+			sidecarsChanged = true
+		}
 	}
 
-	ps.initDone = true
+	oldPushContext.Mutex.Lock()
+	defer oldPushContext.Mutex.Unlock()
+
+	if servicesChanged {
+		if err := ps.initServiceRegistry(env); err != nil {
+			return err
+		}
+	} else {
+		ps.privateServicesByNamespace = oldPushContext.privateServicesByNamespace
+		ps.publicServices = oldPushContext.publicServices
+		ps.ServiceByHostnameAndNamespace = oldPushContext.ServiceByHostnameAndNamespace
+		ps.ServiceAccounts = oldPushContext.ServiceAccounts
+	}
+
+	if virtualServicesChanged {
+		if err := ps.initVirtualServices(env); err != nil {
+			return err
+		}
+	} else {
+		ps.privateVirtualServicesByNamespace = oldPushContext.privateVirtualServicesByNamespace
+		ps.publicVirtualServices = oldPushContext.publicVirtualServices
+	}
+
+	if destinationRulesChanged {
+		if err := ps.initDestinationRules(env); err != nil {
+			return err
+		}
+	} else {
+		ps.namespaceLocalDestRules = oldPushContext.namespaceLocalDestRules
+		ps.namespaceExportedDestRules = oldPushContext.namespaceExportedDestRules
+		ps.allExportedDestRules = oldPushContext.allExportedDestRules
+		ps.destRuleLocalityLbSettings = oldPushContext.destRuleLocalityLbSettings
+	}
+
+	if authzChanged {
+		if err := ps.initAuthorizationPolicies(env); err != nil {
+			rbacLog.Errorf("failed to initialize authorization policies: %v", err)
+			return err
+		}
+	} else {
+		ps.AuthzPolicies = oldPushContext.AuthzPolicies
+	}
+
+	if envoyFiltersChanged {
+		if err := ps.initEnvoyFilters(env); err != nil {
+			return err
+		}
+	} else {
+		ps.envoyFiltersByNamespace = oldPushContext.envoyFiltersByNamespace
+	}
+
+	// The sidecar scopes are computed from the services, virtual services and destination rules
+	// visible to each namespace.
+	if servicesChanged || virtualServicesChanged || destinationRulesChanged || sidecarsChanged {
+		return ps.initSidecarScopes(env)
+	}
+	ps.sidecarsByNamespace = oldPushContext.sidecarsByNamespace
 	return nil
 }
 
@@ -932,9 +1073,14 @@ func (ps *PushContext) SetDestinationRules(configs []Config) {
 		destRule: map[host.Name]*combinedDestinationRule{},
 	}
 
+	destRuleLocalityLbSettings := false
+
 	for i := range configs {
 		rule := configs[i].Spec.(*networking.DestinationRule)
 		rule.Host = string(ResolveShortnameToFQDN(rule.Host, configs[i].ConfigMeta))
+		if configs[i].Annotations[LocalityLbSettingAnnotation] != "" {
+			destRuleLocalityLbSettings = true
+		}
 		// Store in an index for the config's namespace
 		// a proxy from this namespace will first look here for the destination rule for a given service
 		// This pool consists of both public/private destination rules.
@@ -1004,6 +1150,7 @@ func (ps *PushContext) SetDestinationRules(configs []Config) {
 	ps.namespaceLocalDestRules = namespaceLocalDestRules
 	ps.namespaceExportedDestRules = namespaceExportedDestRules
 	ps.allExportedDestRules = allExportedDestRules
+	ps.destRuleLocalityLbSettings = destRuleLocalityLbSettings
 }
 
 func (ps *PushContext) initAuthorizationPolicies(env *Environment) error {
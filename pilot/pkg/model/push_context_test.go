@@ -17,6 +17,18 @@ package model
 import (
 	"reflect"
 	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+
+	meshconfig "istio.io/api/mesh/v1alpha1"
+	networking "istio.io/api/networking/v1alpha3"
+	rbacproto "istio.io/api/rbac/v1alpha1"
+
+	"istio.io/istio/pkg/config/host"
+	"istio.io/istio/pkg/config/labels"
+	"istio.io/istio/pkg/config/mesh"
+	"istio.io/istio/pkg/config/protocol"
 )
 
 func TestMergeUpdateRequest(t *testing.T) {
@@ -29,26 +41,47 @@ func TestMergeUpdateRequest(t *testing.T) {
 		{
 			"left nil",
 			nil,
-			&UpdateRequest{true, nil},
-			UpdateRequest{true, nil},
+			&UpdateRequest{true, nil, nil},
+			UpdateRequest{true, nil, nil},
 		},
 		{
 			"right nil",
-			&UpdateRequest{true, nil},
+			&UpdateRequest{true, nil, nil},
 			nil,
-			UpdateRequest{true, nil},
+			UpdateRequest{true, nil, nil},
 		},
 		{
 			"simple merge",
-			&UpdateRequest{true, map[string]struct{}{"ns1": {}}},
-			&UpdateRequest{false, map[string]struct{}{"ns2": {}}},
-			UpdateRequest{true, map[string]struct{}{"ns1": {}, "ns2": {}}},
+			&UpdateRequest{true, map[string]struct{}{"ns1": {}}, nil},
+			&UpdateRequest{false, map[string]struct{}{"ns2": {}}, nil},
+			UpdateRequest{true, map[string]struct{}{"ns1": {}, "ns2": {}}, nil},
 		},
 		{
 			"incremental merge",
-			&UpdateRequest{false, map[string]struct{}{"ns1": {}}},
-			&UpdateRequest{false, map[string]struct{}{"ns2": {}}},
-			UpdateRequest{false, map[string]struct{}{"ns1": {}, "ns2": {}}},
+			&UpdateRequest{false, map[string]struct{}{"ns1": {}}, nil},
+			&UpdateRequest{false, map[string]struct{}{"ns2": {}}, nil},
+			UpdateRequest{false, map[string]struct{}{"ns1": {}, "ns2": {}}, nil},
+		},
+		{
+			"configs merge",
+			&UpdateRequest{true, nil, map[ConfigKey]struct{}{{Type: "virtual-service", Name: "a"}: {}}},
+			&UpdateRequest{true, nil, map[ConfigKey]struct{}{{Type: "destination-rule", Name: "b"}: {}}},
+			UpdateRequest{true, map[string]struct{}{}, map[ConfigKey]struct{}{
+				{Type: "virtual-service", Name: "a"}:  {},
+				{Type: "destination-rule", Name: "b"}: {},
+			}},
+		},
+		{
+			"configs merge with incremental",
+			&UpdateRequest{true, nil, map[ConfigKey]struct{}{{Type: "virtual-service", Name: "a"}: {}}},
+			&UpdateRequest{false, nil, nil},
+			UpdateRequest{true, map[string]struct{}{}, map[ConfigKey]struct{}{{Type: "virtual-service", Name: "a"}: {}}},
+		},
+		{
+			"configs merge with full",
+			&UpdateRequest{true, nil, map[ConfigKey]struct{}{{Type: "virtual-service", Name: "a"}: {}}},
+			&UpdateRequest{true, nil, nil},
+			UpdateRequest{true, map[string]struct{}{}, nil},
 		},
 	}
 
@@ -61,3 +94,379 @@ func TestMergeUpdateRequest(t *testing.T) {
 		})
 	}
 }
+
+// localServiceDiscovery is a ServiceDiscovery returning a fixed list of services.
+type localServiceDiscovery struct {
+	services []*Service
+}
+
+var _ ServiceDiscovery = &localServiceDiscovery{}
+
+func (l *localServiceDiscovery) Services() ([]*Service, error) {
+	return l.services, nil
+}
+
+func (l *localServiceDiscovery) GetService(hostname host.Name) (*Service, error) {
+	for _, svc := range l.services {
+		if svc.Hostname == hostname {
+			return svc, nil
+		}
+	}
+	return nil, nil
+}
+
+func (l *localServiceDiscovery) InstancesByPort(*Service, int, labels.Collection) ([]*ServiceInstance, error) {
+	return nil, nil
+}
+
+func (l *localServiceDiscovery) GetProxyServiceInstances(*Proxy) ([]*ServiceInstance, error) {
+	return nil, nil
+}
+
+func (l *localServiceDiscovery) GetProxyWorkloadLabels(*Proxy) (labels.Collection, error) {
+	return nil, nil
+}
+
+func (l *localServiceDiscovery) ManagementPorts(string) PortList {
+	return nil
+}
+
+func (l *localServiceDiscovery) WorkloadHealthCheckInfo(string) ProbeList {
+	return nil
+}
+
+func (l *localServiceDiscovery) GetIstioServiceAccounts(svc *Service, ports []int) []string {
+	return []string{"spiffe://cluster.local/ns/" + svc.Attributes.Namespace + "/sa/default"}
+}
+
+// localConfigStore is a ConfigStore keeping the configs in memory, without validation.
+type localConfigStore struct {
+	configs map[ConfigKey]Config
+}
+
+var _ ConfigStore = &localConfigStore{}
+
+func (l *localConfigStore) ConfigDescriptor() ConfigDescriptor {
+	return IstioConfigTypes
+}
+
+func (l *localConfigStore) Get(typ, name, namespace string) *Config {
+	if c, ok := l.configs[ConfigKey{Type: typ, Name: name, Namespace: namespace}]; ok {
+		return &c
+	}
+	return nil
+}
+
+func (l *localConfigStore) List(typ, namespace string) ([]Config, error) {
+	var out []Config
+	for key, c := range l.configs {
+		if key.Type == typ && (namespace == NamespaceAll || key.Namespace == namespace) {
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+func (l *localConfigStore) Create(config Config) (string, error) {
+	l.configs[ConfigKey{Type: config.Type, Name: config.Name, Namespace: config.Namespace}] = config
+	return "", nil
+}
+
+func (l *localConfigStore) Update(config Config) (string, error) {
+	return l.Create(config)
+}
+
+func (l *localConfigStore) Delete(typ, name, namespace string) error {
+	delete(l.configs, ConfigKey{Type: typ, Name: name, Namespace: namespace})
+	return nil
+}
+
+func makeTestService(hostname host.Name, namespace string) *Service {
+	return &Service{
+		Hostname: hostname,
+		Ports:    PortList{{Name: "http", Port: 80, Protocol: protocol.HTTP}},
+		Attributes: ServiceAttributes{
+			Name:      string(hostname),
+			Namespace: namespace,
+		},
+	}
+}
+
+func makeTestConfig(typ, name, namespace string, created int, spec proto.Message) Config {
+	return Config{
+		ConfigMeta: ConfigMeta{
+			Type:              typ,
+			Name:              name,
+			Namespace:         namespace,
+			Domain:            "cluster.local",
+			CreationTimestamp: time.Unix(int64(created), 0),
+		},
+		Spec: spec,
+	}
+}
+
+// pushContextIndexes returns the indexes of a push context, by name.
+func pushContextIndexes(ps *PushContext) map[string]interface{} {
+	return map[string]interface{}{
+		"privateServicesByNamespace":        ps.privateServicesByNamespace,
+		"publicServices":                    ps.publicServices,
+		"ServiceByHostnameAndNamespace":     ps.ServiceByHostnameAndNamespace,
+		"ServiceAccounts":                   ps.ServiceAccounts,
+		"privateVirtualServicesByNamespace": ps.privateVirtualServicesByNamespace,
+		"publicVirtualServices":             ps.publicVirtualServices,
+		"namespaceLocalDestRules":           ps.namespaceLocalDestRules,
+		"namespaceExportedDestRules":        ps.namespaceExportedDestRules,
+		"allExportedDestRules":              ps.allExportedDestRules,
+		"AuthzPolicies":                     ps.AuthzPolicies,
+		"envoyFiltersByNamespace":           ps.envoyFiltersByNamespace,
+		"sidecarsByNamespace":               ps.sidecarsByNamespace,
+	}
+}
+
+func TestInitContextIncremental(t *testing.T) {
+	meshConfig := mesh.DefaultMeshConfig()
+
+	newEnv := func() (*Environment, *localServiceDiscovery, *localConfigStore) {
+		discovery := &localServiceDiscovery{services: []*Service{
+			makeTestService("a.default.svc.cluster.local", "default"),
+			makeTestService("b.ns1.svc.cluster.local", "ns1"),
+		}}
+		store := &localConfigStore{configs: map[ConfigKey]Config{}}
+		for _, c := range []Config{
+			makeTestConfig(VirtualService.Type, "vs", "default", 1, &networking.VirtualService{
+				Hosts: []string{"a"},
+				Http: []*networking.HTTPRoute{{
+					Route: []*networking.HTTPRouteDestination{{Destination: &networking.Destination{Host: "a", Subset: "v1"}}},
+				}},
+			}),
+			makeTestConfig(DestinationRule.Type, "dr", "default", 1, &networking.DestinationRule{
+				Host:    "a",
+				Subsets: []*networking.Subset{{Name: "v1", Labels: map[string]string{"version": "v1"}}},
+			}),
+			makeTestConfig(Sidecar.Type, "sidecar", "ns1", 1, &networking.Sidecar{
+				Egress: []*networking.IstioEgressListener{{Hosts: []string{"./*"}}},
+			}),
+			makeTestConfig(EnvoyFilter.Type, "filter", "ns1", 1, &networking.EnvoyFilter{
+				WorkloadSelector: &networking.WorkloadSelector{Labels: map[string]string{"app": "b"}},
+			}),
+			makeTestConfig(ClusterRbacConfig.Type, "default", "", 1, &rbacproto.RbacConfig{Mode: rbacproto.RbacConfig_ON}),
+			makeTestConfig(ServiceRole.Type, "role", "default", 1, &rbacproto.ServiceRole{
+				Rules: []*rbacproto.AccessRule{{Services: []string{"*"}, Methods: []string{"GET"}}},
+			}),
+		} {
+			_, _ = store.Create(c)
+		}
+		return &Environment{
+			ServiceDiscovery: discovery,
+			IstioConfigStore: MakeIstioStore(store),
+			Mesh:             &meshConfig,
+		}, discovery, store
+	}
+
+	cases := []struct {
+		name string
+		// change updates the services or configs, and returns the changed config.
+		change func(*localServiceDiscovery, *localConfigStore) ConfigKey
+		// reused are the indexes expected to be shared with the previous push context.
+		reused []string
+	}{
+		{
+			name: "service entry",
+			change: func(d *localServiceDiscovery, _ *localConfigStore) ConfigKey {
+				d.services = append(d.services, makeTestService("c.example.com", "ns1"))
+				return ConfigKey{Type: ServiceEntry.Type, Name: "c", Namespace: "ns1"}
+			},
+			reused: []string{"publicVirtualServices", "allExportedDestRules", "AuthzPolicies", "envoyFiltersByNamespace"},
+		},
+		{
+			name: "virtual service",
+			change: func(_ *localServiceDiscovery, s *localConfigStore) ConfigKey {
+				c := makeTestConfig(VirtualService.Type, "vs2", "ns1", 2, &networking.VirtualService{
+					Hosts: []string{"b"},
+					Http: []*networking.HTTPRoute{{
+						Route: []*networking.HTTPRouteDestination{{Destination: &networking.Destination{Host: "b"}}},
+					}},
+				})
+				_, _ = s.Create(c)
+				return ConfigKey{Type: c.Type, Name: c.Name, Namespace: c.Namespace}
+			},
+			reused: []string{"publicServices", "ServiceAccounts", "allExportedDestRules", "AuthzPolicies", "envoyFiltersByNamespace"},
+		},
+		{
+			name: "destination rule",
+			change: func(_ *localServiceDiscovery, s *localConfigStore) ConfigKey {
+				c := makeTestConfig(DestinationRule.Type, "dr2", "ns1", 2, &networking.DestinationRule{
+					Host:          "b",
+					TrafficPolicy: &networking.TrafficPolicy{},
+				})
+				_, _ = s.Create(c)
+				return ConfigKey{Type: c.Type, Name: c.Name, Namespace: c.Namespace}
+			},
+			reused: []string{"publicServices", "ServiceAccounts", "publicVirtualServices", "AuthzPolicies", "envoyFiltersByNamespace"},
+		},
+		{
+			name: "sidecar",
+			change: func(_ *localServiceDiscovery, s *localConfigStore) ConfigKey {
+				_ = s.Delete(Sidecar.Type, "sidecar", "ns1")
+				return ConfigKey{Type: Sidecar.Type, Name: "sidecar", Namespace: "ns1"}
+			},
+			reused: []string{"publicServices", "publicVirtualServices", "allExportedDestRules", "AuthzPolicies",
+				"envoyFiltersByNamespace"},
+		},
+		{
+			name: "envoy filter",
+			change: func(_ *localServiceDiscovery, s *localConfigStore) ConfigKey {
+				c := makeTestConfig(EnvoyFilter.Type, "filter2", "default", 2, &networking.EnvoyFilter{})
+				_, _ = s.Create(c)
+				return ConfigKey{Type: c.Type, Name: c.Name, Namespace: c.Namespace}
+			},
+			reused: []string{"publicServices", "publicVirtualServices", "allExportedDestRules", "AuthzPolicies",
+				"sidecarsByNamespace"},
+		},
+		{
+			name: "service role binding",
+			change: func(_ *localServiceDiscovery, s *localConfigStore) ConfigKey {
+				c := makeTestConfig(ServiceRoleBinding.Type, "binding", "default", 2, &rbacproto.ServiceRoleBinding{
+					Subjects: []*rbacproto.Subject{{User: "*"}},
+					RoleRef:  &rbacproto.RoleRef{Kind: "ServiceRole", Name: "role"},
+				})
+				_, _ = s.Create(c)
+				return ConfigKey{Type: c.Type, Name: c.Name, Namespace: c.Namespace}
+			},
+			reused: []string{"publicServices", "publicVirtualServices", "allExportedDestRules", "envoyFiltersByNamespace",
+				"sidecarsByNamespace"},
+		},
+		{
+			name: "config without index",
+			change: func(_ *localServiceDiscovery, s *localConfigStore) ConfigKey {
+				c := makeTestConfig(Gateway.Type, "gateway", "default", 2, &networking.Gateway{})
+				_, _ = s.Create(c)
+				return ConfigKey{Type: c.Type, Name: c.Name, Namespace: c.Namespace}
+			},
+			reused: []string{"publicServices", "ServiceAccounts", "publicVirtualServices", "allExportedDestRules",
+				"AuthzPolicies", "envoyFiltersByNamespace", "sidecarsByNamespace"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			env, discovery, store := newEnv()
+			old := NewPushContext()
+			if err := old.InitContext(env, nil, nil); err != nil {
+				t.Fatalf("InitContext() => error %v", err)
+			}
+
+			key := c.change(discovery, store)
+			incremental := NewPushContext()
+			if err := incremental.InitContext(env, old, &UpdateRequest{
+				Full:           true,
+				ConfigsUpdated: map[ConfigKey]struct{}{key: {}},
+			}); err != nil {
+				t.Fatalf("InitContext() => error %v", err)
+			}
+			full := NewPushContext()
+			if err := full.InitContext(env, old, &UpdateRequest{Full: true}); err != nil {
+				t.Fatalf("InitContext() => error %v", err)
+			}
+
+			// The incremental push context must be equivalent to a full rebuild.
+			incrementalIndexes, fullIndexes := pushContextIndexes(incremental), pushContextIndexes(full)
+			for name, index := range fullIndexes {
+				if !reflect.DeepEqual(incrementalIndexes[name], index) {
+					t.Errorf("index %s => %+v, want %+v", name, incrementalIndexes[name], index)
+				}
+			}
+
+			oldIndexes := pushContextIndexes(old)
+			for _, name := range c.reused {
+				if reflect.ValueOf(incrementalIndexes[name]).Pointer() != reflect.ValueOf(oldIndexes[name]).Pointer() {
+					t.Errorf("index %s was recomputed", name)
+				}
+			}
+		})
+	}
+}
+
+func TestInitContextIncrementalFallback(t *testing.T) {
+	meshConfig := mesh.DefaultMeshConfig()
+	discovery := &localServiceDiscovery{services: []*Service{makeTestService("a.default.svc.cluster.local", "default")}}
+	env := &Environment{
+		ServiceDiscovery: discovery,
+		IstioConfigStore: MakeIstioStore(&localConfigStore{configs: map[ConfigKey]Config{}}),
+		Mesh:             &meshConfig,
+	}
+	old := NewPushContext()
+	if err := old.InitContext(env, nil, nil); err != nil {
+		t.Fatalf("InitContext() => error %v", err)
+	}
+
+	// A change of the services of a registry does not list the changed configs: everything is recomputed.
+	discovery.services = append(discovery.services, makeTestService("b.default.svc.cluster.local", "default"))
+	for _, req := range []*UpdateRequest{nil, {Full: true}} {
+		ps := NewPushContext()
+		if err := ps.InitContext(env, old, req); err != nil {
+			t.Fatalf("InitContext() => error %v", err)
+		}
+		if len(ps.publicServices) != 2 {
+			t.Errorf("InitContext(%v) => %d services, want 2", req, len(ps.publicServices))
+		}
+	}
+}
+
+func TestLocalityLbSetting(t *testing.T) {
+	meshConfig := mesh.DefaultMeshConfig()
+	meshConfig.LocalityLbSetting = &meshconfig.LocalityLoadBalancerSetting{
+		Failover: []*meshconfig.LocalityLoadBalancerSetting_Failover{{From: "region1", To: "region2"}},
+	}
+	ps := NewPushContext()
+	ps.Env = &Environment{Mesh: &meshConfig}
+
+	destinationRule := func(annotation string) *Config {
+		cfg := &Config{
+			ConfigMeta: ConfigMeta{
+				Type:      DestinationRule.Type,
+				Name:      "reviews",
+				Namespace: "default",
+			},
+			Spec: &networking.DestinationRule{Host: "reviews"},
+		}
+		if annotation != "" {
+			cfg.Annotations = map[string]string{LocalityLbSettingAnnotation: annotation}
+		}
+		return cfg
+	}
+	distribute := &meshconfig.LocalityLoadBalancerSetting{
+		Distribute: []*meshconfig.LocalityLoadBalancerSetting_Distribute{
+			{From: "region1/*", To: map[string]uint32{"region1/*": 80, "region2/*": 20}},
+		},
+	}
+
+	cases := []struct {
+		name            string
+		destinationRule *Config
+		want            *meshconfig.LocalityLoadBalancerSetting
+	}{
+		{"no destination rule", nil, meshConfig.LocalityLbSetting},
+		{"no annotation", destinationRule(""), meshConfig.LocalityLbSetting},
+		{"annotation", destinationRule(`{"distribute": [{"from": "region1/*", "to": {"region1/*": 80, "region2/*": 20}}]}`), distribute},
+		{"unparsable annotation", destinationRule("distribute: foo"), meshConfig.LocalityLbSetting},
+		{"invalid annotation", destinationRule(`{"failover": [{"from": "region1", "to": "region1"}]}`), meshConfig.LocalityLbSetting},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// The second lookup is served from the cache.
+			for i := 0; i < 2; i++ {
+				if got := ps.LocalityLbSetting(c.destinationRule); !reflect.DeepEqual(got, c.want) {
+					t.Errorf("LocalityLbSetting() => got %v, want %v", got, c.want)
+				}
+			}
+		})
+	}
+
+	if !ps.HasLocalityLbSettings() {
+		t.Errorf("HasLocalityLbSettings() => got false with a mesh setting")
+	}
+	if _, found := ps.ProxyStatus[InvalidLocalityLbSettings.Name()]["default/reviews"]; !found {
+		t.Errorf("the invalid annotations are not reported: %v", ps.ProxyStatus)
+	}
+}
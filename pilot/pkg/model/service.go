@@ -35,6 +35,7 @@ import (
 
 	authn "istio.io/api/authentication/v1alpha1"
 
+	"istio.io/istio/pilot/pkg/features"
 	"istio.io/istio/pkg/config/host"
 	"istio.io/istio/pkg/config/labels"
 	"istio.io/istio/pkg/config/protocol"
@@ -438,6 +439,26 @@ type ServiceDiscovery interface {
 	GetIstioServiceAccounts(svc *Service, ports []int) []string
 }
 
+// NetworkGateway is an address of the gateway of a mesh network, through which the endpoints of the network
+// are reached from the other networks.
+type NetworkGateway struct {
+	// Network is the name of the network of the gateway.
+	Network string
+	// ClusterID is the cluster of the registry the gateway was discovered in.
+	ClusterID string
+	// Addr is the IP address of the gateway.
+	Addr string
+	// Port is the port of the gateway.
+	Port uint32
+}
+
+// NetworkGatewayDiscovery is implemented by the service registries that discover the gateways of the mesh
+// networks, in addition to the ones of the MeshNetworks configuration.
+type NetworkGatewayDiscovery interface {
+	// NetworkGateways returns the discovered gateways, keyed by network name.
+	NetworkGateways() map[string][]*NetworkGateway
+}
+
 // Match returns true if port matches with authentication port selector criteria.
 func (port Port) Match(portSelector *authn.PortSelector) bool {
 	if portSelector == nil {
@@ -472,11 +493,13 @@ func (ports PortList) Get(name string) (*Port, bool) {
 	return nil, false
 }
 
-// GetByPort retrieves a port declaration by port value
+// GetByPort retrieves a port declaration by port value. The ports of unsupported protocols are only
+// returned when protocol sniffing is enabled, as they are otherwise not served.
 func (ports PortList) GetByPort(num int) (*Port, bool) {
+	sniffing := features.EnableProtocolSniffingForOutbound.Get() || features.EnableProtocolSniffingForInbound.Get()
 	for _, port := range ports {
 		if port.Port == num && port.Protocol != protocol.UDP &&
-			port.Protocol != protocol.Unsupported {
+			(port.Protocol != protocol.Unsupported || sniffing) {
 			return port, true
 		}
 	}
@@ -15,10 +15,12 @@
 package model
 
 import (
+	"os"
 	"testing"
 
 	"istio.io/istio/pkg/config/host"
 	"istio.io/istio/pkg/config/labels"
+	"istio.io/istio/pkg/config/protocol"
 )
 
 var validServiceKeys = map[string]struct {
@@ -126,6 +128,24 @@ func TestGetByPort(t *testing.T) {
 	}
 }
 
+func TestGetByPortUnsupportedProtocol(t *testing.T) {
+	ports := PortList{{
+		Name:     "foo",
+		Port:     8080,
+		Protocol: protocol.Unsupported,
+	}}
+
+	if port, exists := ports.GetByPort(8080); exists || port != nil {
+		t.Errorf("GetByPort(8080) => want none without protocol sniffing but got %v, %t", port, exists)
+	}
+
+	_ = os.Setenv("PILOT_ENABLE_PROTOCOL_SNIFFING_FOR_OUTBOUND", "true")
+	defer func() { _ = os.Unsetenv("PILOT_ENABLE_PROTOCOL_SNIFFING_FOR_OUTBOUND") }()
+	if port, exists := ports.GetByPort(8080); !exists || port == nil || port.Name != "foo" {
+		t.Errorf("GetByPort(8080) => want foo with protocol sniffing but got %v, %t", port, exists)
+	}
+}
+
 func BenchmarkParseSubsetKey(b *testing.B) {
 	for n := 0; n < b.N; n++ {
 		ParseSubsetKey("outbound|80|v1|example.com")
@@ -39,6 +39,7 @@ import (
 	"istio.io/istio/pilot/pkg/networking/util"
 	authn_model "istio.io/istio/pilot/pkg/security/model"
 	"istio.io/istio/pkg/config/constants"
+	"istio.io/istio/pkg/config/host"
 	"istio.io/istio/pkg/config/labels"
 	"istio.io/istio/pkg/config/protocol"
 )
@@ -85,10 +86,6 @@ func (configgen *ConfigGeneratorImpl) BuildClusters(env *model.Environment, prox
 
 	outboundClusters := configgen.buildOutboundClusters(env, proxy, push)
 
-	if env.Mesh.LocalityLbSetting != nil {
-		// apply load balancer setting fot cluster endpoints
-		applyLocalityLBSetting(proxy.Locality, outboundClusters, env.Mesh.LocalityLbSetting)
-	}
 	// Add a blackhole and passthrough cluster for catching traffic to unresolved routes
 	// DO NOT CALL PLUGINS for these two clusters.
 	outboundClusters = append(outboundClusters, buildBlackHoleCluster(env), buildDefaultPassthroughCluster(env))
@@ -151,6 +148,7 @@ func (configgen *ConfigGeneratorImpl) buildOutboundClusters(env *model.Environme
 
 	for _, service := range push.Services(proxy) {
 		destRule := push.DestinationRule(proxy, service)
+		serviceClusters := len(clusters)
 		for _, port := range service.Ports {
 			if port.Protocol == protocol.UDP {
 				continue
@@ -242,6 +240,9 @@ func (configgen *ConfigGeneratorImpl) buildOutboundClusters(env *model.Environme
 				p.OnOutboundCluster(inputParams, defaultCluster)
 			}
 		}
+
+		// apply load balancer setting for the cluster endpoints of the service
+		applyLocalityLBSetting(proxy.Locality, clusters[serviceClusters:], push.LocalityLbSetting(destRule))
 	}
 
 	return clusters
@@ -498,9 +499,6 @@ func (configgen *ConfigGeneratorImpl) buildInboundClusters(env *model.Environmen
 			clusters = append(clusters, mgmtCluster)
 		}
 	} else {
-		if len(instances) == 0 {
-			return clusters
-		}
 		rule := sidecarScope.Config.Spec.(*networking.Sidecar)
 		for _, ingressListener := range rule.Ingress {
 			// LDS would have setup the inbound clusters
@@ -533,12 +531,11 @@ func (configgen *ConfigGeneratorImpl) buildInboundClusters(env *model.Environmen
 
 			// Find the service instance that corresponds to this ingress listener by looking
 			// for a service instance that either matches this ingress port or one that has
-			// a port with same name as this ingress port
+			// a port with same name as this ingress port. Ports not exposed by a service get
+			// a service instance made up from the sidecar config.
 			instance := configgen.findServiceInstanceForIngressListener(instances, ingressListener)
-
 			if instance == nil {
-				// We didn't find a matching instance
-				continue
+				instance = buildSidecarIngressServiceInstance(proxy, sidecarScope.Config, ingressListener)
 			}
 
 			// Update the values here so that the plugins use the right ports
@@ -600,6 +597,45 @@ func (configgen *ConfigGeneratorImpl) findServiceInstanceForIngressListener(inst
 	return instance
 }
 
+// buildSidecarIngressServiceInstance returns a service instance for a sidecar ingress listener on a port that is
+// not exposed by a service of the workload. The hostname of the service is built from the name and namespace of
+// the sidecar config, so that the inbound listener and cluster names of the port are unique.
+func buildSidecarIngressServiceInstance(proxy *model.Proxy, sidecarConfig *model.Config,
+	ingressListener *networking.IstioIngressListener) *model.ServiceInstance {
+	port := &model.Port{
+		Port:     int(ingressListener.Port.Number),
+		Protocol: protocol.Parse(ingressListener.Port.Protocol),
+		Name:     ingressListener.Port.Name,
+	}
+
+	var address string
+	if len(proxy.IPAddresses) > 0 {
+		address = proxy.IPAddresses[0]
+	}
+	var workloadLabels labels.Instance
+	if len(proxy.WorkloadLabels) > 0 {
+		workloadLabels = proxy.WorkloadLabels[0]
+	}
+
+	return &model.ServiceInstance{
+		Endpoint: model.NetworkEndpoint{
+			Address:     address,
+			Port:        port.Port,
+			ServicePort: port,
+		},
+		Service: &model.Service{
+			Hostname:     host.Name(sidecarConfig.Name + "." + sidecarConfig.Namespace),
+			Ports:        model.PortList{port},
+			CreationTime: sidecarConfig.CreationTimestamp,
+			Attributes: model.ServiceAttributes{
+				Name:      sidecarConfig.Name,
+				Namespace: sidecarConfig.Namespace,
+			},
+		},
+		Labels: workloadLabels,
+	}
+}
+
 func (configgen *ConfigGeneratorImpl) buildInboundClusterForPortOrUDS(pluginParams *plugin.InputParams) *apiv2.Cluster {
 	instance := pluginParams.ServiceInstance
 	clusterName := model.BuildSubsetKey(model.TrafficDirectionInbound, instance.Endpoint.ServicePort.Name,
@@ -956,6 +992,15 @@ func applyLoadBalancer(cluster *apiv2.Cluster, lb *networking.LoadBalancerSettin
 	}
 }
 
+// defaultFailoverOutlierDetection is the outlier detection of the clusters with locality failover and no
+// outlier detection of their own.
+var defaultFailoverOutlierDetection = &networking.OutlierDetection{
+	ConsecutiveErrors:  5,
+	Interval:           &types.Duration{Seconds: 10},
+	BaseEjectionTime:   &types.Duration{Seconds: 30},
+	MaxEjectionPercent: 100,
+}
+
 func applyLocalityLBSetting(
 	locality *core.Locality,
 	clusters []*apiv2.Cluster,
@@ -966,6 +1011,10 @@ func applyLocalityLBSetting(
 	}
 	for _, cluster := range clusters {
 		// Failover should only be applied with outlier detection, or traffic will never failover.
+		// Default outlier detection is added to the clusters without any, so that failover works out of the box.
+		if cluster.OutlierDetection == nil && loadbalancer.FailoverEnabled(localityLB) {
+			applyOutlierDetection(cluster, defaultFailoverOutlierDetection)
+		}
 		enabledFailover := cluster.OutlierDetection != nil
 		if cluster.LoadAssignment != nil {
 			loadbalancer.ApplyLocalityLBSetting(locality, cluster.LoadAssignment, localityLB, enabledFailover)
@@ -1026,6 +1075,9 @@ func applyUpstreamTLSSettings(env *model.Environment, cluster *apiv2.Cluster, tl
 
 		// Fallback to file mount secret instead of SDS if meshConfig.sdsUdsPath isn't set or tls.mode is TLSSettings_MUTUAL.
 		if env.Mesh.SdsUdsPath == "" || tls.Mode == networking.TLSSettings_MUTUAL {
+			if tls.Mode == networking.TLSSettings_ISTIO_MUTUAL && certValidationContext != nil {
+				certValidationContext.Crl = authn_model.ConstructCRL(metadata)
+			}
 			cluster.TlsContext.CommonTlsContext.ValidationContextType = &auth.CommonTlsContext_ValidationContext{
 				ValidationContext: certValidationContext,
 			}
@@ -1050,8 +1102,12 @@ func applyUpstreamTLSSettings(env *model.Environment, cluster *apiv2.Cluster, tl
 
 			cluster.TlsContext.CommonTlsContext.ValidationContextType = &auth.CommonTlsContext_CombinedValidationContext{
 				CombinedValidationContext: &auth.CommonTlsContext_CombinedCertificateValidationContext{
-					DefaultValidationContext: &auth.CertificateValidationContext{VerifySubjectAltName: tls.SubjectAltNames},
-					ValidationContextSdsSecretConfig: authn_model.ConstructSdsSecretConfig(authn_model.SDSRootResourceName, env.Mesh.SdsUdsPath,
+					DefaultValidationContext: &auth.CertificateValidationContext{
+						VerifySubjectAltName: tls.SubjectAltNames,
+						Crl:                  authn_model.ConstructCRL(metadata),
+					},
+					ValidationContextSdsSecretConfig: authn_model.ConstructSdsSecretConfig(
+						authn_model.GetSdsRootResourceNameForOutbound(tls.SubjectAltNames), env.Mesh.SdsUdsPath,
 						env.Mesh.EnableSdsTokenMount, env.Mesh.SdsUseK8SSaJwt, metadata),
 				},
 			}
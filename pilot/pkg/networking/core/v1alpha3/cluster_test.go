@@ -24,6 +24,7 @@ import (
 
 	apiv2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
 	"github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/endpoint"
 	"github.com/gogo/protobuf/proto"
 	"github.com/gogo/protobuf/types"
 	. "github.com/onsi/gomega"
@@ -359,7 +360,7 @@ func newTestEnvironment(serviceDiscovery model.ServiceDiscovery, mesh meshconfig
 	}
 
 	env.PushContext = model.NewPushContext()
-	_ = env.PushContext.InitContext(env)
+	_ = env.PushContext.InitContext(env, nil, nil)
 
 	return env
 }
@@ -452,6 +453,47 @@ func TestBuildClustersWithMutualTlsAndNodeMetadataCertfileOverrides(t *testing.T
 	g.Expect(actualOutboundClusterCount).To(Equal(expectedOutboundClusterCount))
 }
 
+func TestBuildClustersWithIstioMutualTlsAndCRL(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	destRule := &networking.DestinationRule{
+		Host: "*.example.org",
+		TrafficPolicy: &networking.TrafficPolicy{
+			Tls: &networking.TLSSettings{
+				Mode: networking.TLSSettings_ISTIO_MUTUAL,
+			},
+		},
+	}
+	outboundCRLs := func(metadata map[string]string) []string {
+		clusters, err := buildTestClustersWithProxyMetadata("foo.example.org", model.ClientSideLB, model.SidecarProxy,
+			nil, testMesh, destRule, metadata)
+		g.Expect(err).NotTo(HaveOccurred())
+		var crls []string
+		for _, c := range clusters {
+			if strings.Contains(c.Name, "outbound") {
+				crls = append(crls, c.TlsContext.CommonTlsContext.GetValidationContext().GetCrl().GetFilename())
+			}
+		}
+		g.Expect(crls).NotTo(BeEmpty())
+		return crls
+	}
+
+	// No CRL is referenced by default.
+	for _, crl := range outboundCRLs(map[string]string{}) {
+		g.Expect(crl).To(BeEmpty())
+	}
+
+	_ = os.Setenv(features.CRLFile.Name, "/etc/istio/crl/crl.pem")
+	defer func() { _ = os.Unsetenv(features.CRLFile.Name) }()
+
+	for _, crl := range outboundCRLs(map[string]string{}) {
+		g.Expect(crl).To(Equal("/etc/istio/crl/crl.pem"))
+	}
+	for _, crl := range outboundCRLs(map[string]string{model.NodeMetadataTLSCRL: "/custom/crl.pem"}) {
+		g.Expect(crl).To(Equal("/custom/crl.pem"))
+	}
+}
+
 func buildSniTestClusters(sniValue string) ([]*apiv2.Cluster, error) {
 	return buildSniTestClustersWithMetadata(sniValue, make(map[string]string))
 }
@@ -807,6 +849,102 @@ func TestLocalityLB(t *testing.T) {
 	}
 }
 
+func TestLocalityLBFailoverDefaultOutlierDetection(t *testing.T) {
+	g := NewGomegaWithT(t)
+	locality := &core.Locality{
+		Region:  "region1",
+		Zone:    "zone1",
+		SubZone: "subzone1",
+	}
+	buildCluster := func() *apiv2.Cluster {
+		return &apiv2.Cluster{
+			Name: "outbound|8080||test.example.org",
+			LoadAssignment: &apiv2.ClusterLoadAssignment{
+				ClusterName: "outbound|8080||test.example.org",
+				Endpoints: []*endpoint.LocalityLbEndpoints{
+					{Locality: &core.Locality{Region: "region2", Zone: "zone1"}},
+					{Locality: &core.Locality{Region: "region1", Zone: "zone1", SubZone: "subzone1"}},
+				},
+			},
+		}
+	}
+
+	failover := &meshconfig.LocalityLoadBalancerSetting{
+		Failover: []*meshconfig.LocalityLoadBalancerSetting_Failover{{From: "region1", To: "region2"}},
+	}
+	cluster := buildCluster()
+	applyLocalityLBSetting(locality, []*apiv2.Cluster{cluster}, failover)
+	g.Expect(cluster.OutlierDetection).NotTo(BeNil())
+	g.Expect(cluster.OutlierDetection.ConsecutiveGatewayFailure.GetValue()).To(Equal(uint32(5)))
+	g.Expect(cluster.OutlierDetection.MaxEjectionPercent.GetValue()).To(Equal(uint32(100)))
+	g.Expect(cluster.LoadAssignment.Endpoints[0].Priority).To(Equal(uint32(1)))
+	g.Expect(cluster.LoadAssignment.Endpoints[1].Priority).To(Equal(uint32(0)))
+
+	// Without a proxy locality, nothing is applied.
+	cluster = buildCluster()
+	applyLocalityLBSetting(nil, []*apiv2.Cluster{cluster}, failover)
+	g.Expect(cluster.OutlierDetection).To(BeNil())
+
+	// Distribute does not need outlier detection.
+	cluster = buildCluster()
+	applyLocalityLBSetting(locality, []*apiv2.Cluster{cluster}, &meshconfig.LocalityLoadBalancerSetting{
+		Distribute: []*meshconfig.LocalityLoadBalancerSetting_Distribute{
+			{From: "region1/*", To: map[string]uint32{"region1/*": 80, "region2/*": 20}},
+		},
+	})
+	g.Expect(cluster.OutlierDetection).To(BeNil())
+}
+
+func TestBuildInboundClustersWithSidecarIngress(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	configgen := NewConfigGenerator([]plugin.Plugin{})
+	env := buildListenerEnv(nil)
+	g.Expect(env.PushContext.InitContext(&env, nil, nil)).To(Succeed())
+
+	sidecarConfig := &model.Config{
+		ConfigMeta: model.ConfigMeta{
+			Name:      "foo",
+			Namespace: "not-default",
+		},
+		Spec: &networking.Sidecar{
+			Ingress: []*networking.IstioIngressListener{
+				{
+					// A port that is not exposed by any service
+					Port:            &networking.Port{Number: 9090, Protocol: "HTTP", Name: "http"},
+					DefaultEndpoint: "127.0.0.1:9091",
+				},
+				{
+					Port:            &networking.Port{Number: 0, Protocol: "TCP", Name: "uds"},
+					Bind:            "unix:///var/run/proxy.sock",
+					DefaultEndpoint: "unix:///var/run/app.sock",
+				},
+			},
+		},
+	}
+	proxy := &model.Proxy{
+		Type:            model.SidecarProxy,
+		IPAddresses:     []string{"1.1.1.1"},
+		ID:              "v0.not-default",
+		DNSDomain:       "not-default.example.org",
+		Metadata:        map[string]string{},
+		ConfigNamespace: "not-default",
+		SidecarScope:    model.ConvertToSidecarScope(env.PushContext, sidecarConfig, sidecarConfig.Namespace),
+	}
+
+	clusters := configgen.buildInboundClusters(&env, proxy, env.PushContext, nil, nil)
+	g.Expect(clusters).To(HaveLen(2))
+
+	g.Expect(clusters[0].Name).To(Equal("inbound|9090|http|foo.not-default"))
+	address := clusters[0].LoadAssignment.Endpoints[0].LbEndpoints[0].GetEndpoint().Address.GetSocketAddress()
+	g.Expect(address.Address).To(Equal("127.0.0.1"))
+	g.Expect(address.GetPortValue()).To(Equal(uint32(9091)))
+
+	g.Expect(clusters[1].Name).To(Equal("inbound|0|uds|foo.not-default"))
+	pipe := clusters[1].LoadAssignment.Endpoints[0].LbEndpoints[0].GetEndpoint().Address.GetPipe()
+	g.Expect(pipe.GetPath()).To(Equal("/var/run/app.sock"))
+}
+
 func TestBuildLocalityLbEndpoints(t *testing.T) {
 	g := NewGomegaWithT(t)
 	serviceDiscovery := &fakes.ServiceDiscovery{}
@@ -26,6 +26,10 @@ import (
 	"istio.io/istio/pilot/pkg/model"
 )
 
+// ApplyRouteConfigurationPatches applies patches to RDS route configurations and their virtual hosts.
+// TODO: patch the HTTP routes of the virtual hosts and the EDS cluster load assignments on their own.
+// This needs HTTP_ROUTE and CLUSTER_LOAD_ASSIGNMENT targets in the EnvoyFilter API, which the
+// vendored istio.io/api does not define yet.
 func ApplyRouteConfigurationPatches(patchContext networking.EnvoyFilter_PatchContext,
 	proxy *model.Proxy, push *model.PushContext,
 	routeConfiguration *xdsapi.RouteConfiguration) *xdsapi.RouteConfiguration {
@@ -100,9 +100,17 @@ const (
 	// Used in xds config. Metavalue bind to this key is used by pilot as xds server but not by envoy.
 	// So the meta data can be erased when pushing to envoy.
 	PilotMetaKey = "pilot_meta"
+
+	// envoyListenerHTTPInspector is the listener filter that detects plaintext HTTP traffic and sets the
+	// application protocol of the connection accordingly.
+	envoyListenerHTTPInspector = "envoy.listener.http_inspector"
 )
 
 var (
+	// sniffedHTTPApplicationProtocols are the application protocols set by the HTTP inspector for
+	// connections that carry HTTP traffic.
+	sniffedHTTPApplicationProtocols = []string{"http/1.0", "http/1.1", "h2c"}
+
 	// EnvoyJSONLogFormat map of values for envoy json based access logs
 	EnvoyJSONLogFormat = &google_protobuf.Struct{
 		Fields: map[string]*google_protobuf.Value{
@@ -279,7 +287,7 @@ func (configgen *ConfigGeneratorImpl) buildSidecarInboundListeners(
 			}
 
 			pluginParams := &plugin.InputParams{
-				ListenerProtocol:           plugin.ModelProtocolToListenerProtocol(endpoint.ServicePort.Protocol),
+				ListenerProtocol:           plugin.ModelProtocolToListenerProtocol(endpoint.ServicePort.Protocol, model.TrafficDirectionInbound),
 				DeprecatedListenerCategory: networking.EnvoyFilter_DeprecatedListenerMatch_SIDECAR_INBOUND,
 				Env:                        env,
 				Node:                       node,
@@ -302,13 +310,15 @@ func (configgen *ConfigGeneratorImpl) buildSidecarInboundListeners(
 			if noneMode {
 				// dont care what the listener's capture mode setting is. The proxy does not use iptables
 				bindToPort = true
-			} else if ingressListener.CaptureMode == networking.CaptureMode_NONE {
+			} else if ingressListener.CaptureMode == networking.CaptureMode_NONE ||
+				strings.HasPrefix(ingressListener.Bind, model.UnixAddressPrefix) {
 				// proxy uses iptables redirect or tproxy. IF mode is not set
 				// for older proxies, it defaults to iptables redirect.  If the
 				// listener's capture mode specifies NONE, then the proxy wants
 				// this listener alone to be on a physical port. If the
 				// listener's capture mode is default, then its same as
-				// iptables i.e. bindToPort is false.
+				// iptables i.e. bindToPort is false. Unix domain sockets are never
+				// captured, the proxy always listens on them.
 				bindToPort = true
 			}
 
@@ -318,14 +328,12 @@ func (configgen *ConfigGeneratorImpl) buildSidecarInboundListeners(
 				Name:     ingressListener.Port.Name,
 			}
 
-			// if app doesn't have a declared ServicePort, but a sidecar ingress is defined - we can't generate a listener
-			// for that port since we don't know what policies or configs apply to it ( many are based on service matching).
-			// Sidecar doesn't include all the info needed to configure a port.
+			// if app doesn't have a declared ServicePort, but a sidecar ingress is defined - the listener is configured
+			// from a service instance made up from the sidecar config: only the policies and configs applying to the
+			// namespace or workload apply to it, as no service matches it.
 			instance := configgen.findServiceInstanceForIngressListener(node.ServiceInstances, ingressListener)
-
 			if instance == nil {
-				// We didn't find a matching service instance. Skip this ingress listener
-				continue
+				instance = buildSidecarIngressServiceInstance(node, sidecarScope.Config, ingressListener)
 			}
 
 			bind := ingressListener.Bind
@@ -361,7 +369,7 @@ func (configgen *ConfigGeneratorImpl) buildSidecarInboundListeners(
 			instance.Endpoint.Port = listenPort.Port
 
 			pluginParams := &plugin.InputParams{
-				ListenerProtocol:           plugin.ModelProtocolToListenerProtocol(listenPort.Protocol),
+				ListenerProtocol:           plugin.ModelProtocolToListenerProtocol(listenPort.Protocol, model.TrafficDirectionInbound),
 				DeprecatedListenerCategory: networking.EnvoyFilter_DeprecatedListenerMatch_SIDECAR_INBOUND,
 				Env:                        env,
 				Node:                       node,
@@ -465,6 +473,19 @@ func (configgen *ConfigGeneratorImpl) buildSidecarInboundListenerForPortOrUDS(no
 		case plugin.ListenerProtocolTCP:
 			tcpNetworkFilters = buildInboundNetworkFilters(pluginParams.Env, pluginParams.Node, pluginParams.ServiceInstance)
 
+		case plugin.ListenerProtocolAuto:
+			// Connections that are terminated with TLS by this chain, e.g. mTLS, cannot be inspected
+			// before the handshake, so they are not sniffed and always use the TCP chain below. Only the
+			// plaintext chain of a permissive port is sniffed.
+			if chain.TLSContext == nil {
+				listenerOpts.filterChainOpts = append(listenerOpts.filterChainOpts, &filterChainOpts{
+					httpOpts:        configgen.buildSidecarInboundHTTPListenerOptsForPortOrUDS(node, pluginParams),
+					match:           buildSniffedHTTPFilterChainMatch(chain.FilterChainMatch),
+					listenerFilters: append([]*listener.ListenerFilter{{Name: envoyListenerHTTPInspector}}, chain.ListenerFilters...),
+				})
+			}
+			tcpNetworkFilters = buildInboundNetworkFilters(pluginParams.Env, pluginParams.Node, pluginParams.ServiceInstance)
+
 		default:
 			log.Warnf("Unsupported inbound protocol %v for port %#v", pluginParams.ListenerProtocol,
 				pluginParams.ServiceInstance.Endpoint.ServicePort)
@@ -486,6 +507,7 @@ func (configgen *ConfigGeneratorImpl) buildSidecarInboundListenerForPortOrUDS(no
 		Listener:     l,
 		FilterChains: make([]plugin.FilterChain, len(l.FilterChains)),
 	}
+	setFilterChainProtocols(mutable, listenerOpts.filterChainOpts)
 	for _, p := range configgen.Plugins {
 		if err := p.OnInboundListener(pluginParams, mutable); err != nil {
 			log.Warn(err.Error())
@@ -518,11 +540,13 @@ type outboundListenerEntry struct {
 }
 
 func protocolName(p protocol.Instance) string {
-	switch plugin.ModelProtocolToListenerProtocol(p) {
+	switch plugin.ModelProtocolToListenerProtocol(p, model.TrafficDirectionOutbound) {
 	case plugin.ListenerProtocolHTTP:
 		return "HTTP"
 	case plugin.ListenerProtocolTCP:
 		return "TCP"
+	case plugin.ListenerProtocolAuto:
+		return "AUTO"
 	default:
 		return "UNKNOWN"
 	}
@@ -620,6 +644,10 @@ func (configgen *ConfigGeneratorImpl) buildSidecarOutboundListeners(env *model.E
 			// iptables i.e. bindToPort is false.
 			egressListener.IstioListener.CaptureMode == networking.CaptureMode_NONE {
 			bindToPort = true
+		} else if egressListener.IstioListener != nil &&
+			// traffic to unix domain sockets is never captured, the proxy has to listen on the socket.
+			strings.HasPrefix(egressListener.IstioListener.Bind, model.UnixAddressPrefix) {
+			bindToPort = true
 		}
 
 		if egressListener.IstioListener != nil &&
@@ -671,7 +699,7 @@ func (configgen *ConfigGeneratorImpl) buildSidecarOutboundListeners(env *model.E
 				}
 
 				pluginParams := &plugin.InputParams{
-					ListenerProtocol:           plugin.ModelProtocolToListenerProtocol(listenPort.Protocol),
+					ListenerProtocol:           plugin.ModelProtocolToListenerProtocol(listenPort.Protocol, model.TrafficDirectionOutbound),
 					DeprecatedListenerCategory: networking.EnvoyFilter_DeprecatedListenerMatch_SIDECAR_OUTBOUND,
 					Env:                        env,
 					Node:                       node,
@@ -735,7 +763,7 @@ func (configgen *ConfigGeneratorImpl) buildSidecarOutboundListeners(env *model.E
 					}
 
 					pluginParams := &plugin.InputParams{
-						ListenerProtocol:           plugin.ModelProtocolToListenerProtocol(servicePort.Protocol),
+						ListenerProtocol:           plugin.ModelProtocolToListenerProtocol(servicePort.Protocol, model.TrafficDirectionOutbound),
 						DeprecatedListenerCategory: networking.EnvoyFilter_DeprecatedListenerMatch_SIDECAR_OUTBOUND,
 						Env:                        env,
 						Node:                       node,
@@ -913,6 +941,14 @@ func (configgen *ConfigGeneratorImpl) buildSidecarOutboundHTTPListenerOptsForPor
 	}
 
 	// No conflicts. Add a http filter chain option to the listenerOpts
+	return true, []*filterChainOpts{{
+		httpOpts: buildSidecarOutboundHTTPListenerOpts(listenerOpts, pluginParams),
+	}}
+}
+
+// buildSidecarOutboundHTTPListenerOpts builds the HTTP connection manager options for an outbound
+// listener. Routes are fetched through RDS, keyed by the port or the unix domain socket.
+func buildSidecarOutboundHTTPListenerOpts(listenerOpts *buildListenerOpts, pluginParams *plugin.InputParams) *httpListenerOpts {
 	var rdsName string
 	if pluginParams.Port.Port == 0 {
 		rdsName = listenerOpts.bind // use the UDS as a rds name
@@ -936,9 +972,7 @@ func (configgen *ConfigGeneratorImpl) buildSidecarOutboundHTTPListenerOptsForPor
 		}
 	}
 
-	return true, []*filterChainOpts{{
-		httpOpts: httpOpts,
-	}}
+	return httpOpts
 }
 
 func (configgen *ConfigGeneratorImpl) buildSidecarOutboundTCPListenerOptsForPortOrUDS(destinationCIDR *string, listenerMapKey *string,
@@ -999,7 +1033,7 @@ func (configgen *ConfigGeneratorImpl) buildSidecarOutboundTCPListenerOptsForPort
 		// configured correctly, TCP/TLS ports may not collide. We'll
 		// need to do additional work to find out if there is a
 		// collision within TCP/TLS.
-		if !(*currentListenerEntry).servicePort.Protocol.IsTCP() {
+		if (*currentListenerEntry).servicePort.Protocol.IsHTTP() {
 			// NOTE: While pluginParams.Service can be nil,
 			// this code cannot be reached if Service is nil because a pluginParams.Service can be nil only
 			// for user defined Egress listeners with ports. And these should occur in the API before
@@ -1071,6 +1105,20 @@ func (configgen *ConfigGeneratorImpl) buildSidecarOutboundListenerForPortOrUDS(l
 		}
 
 		listenerOpts.filterChainOpts = opts
+	case plugin.ListenerProtocolAuto:
+		// The listener is bound like a TCP listener. Connections detected as HTTP by the HTTP inspector
+		// are routed through RDS, everything else goes through the TCP filter chains.
+		if ret, opts = configgen.buildSidecarOutboundTCPListenerOptsForPortOrUDS(&destinationCIDR, &listenerMapKey, &currentListenerEntry,
+			&listenerOpts, pluginParams, listenerMap, virtualServices, actualWildcard); !ret {
+			return
+		}
+
+		listenerOpts.filterChainOpts = append([]*filterChainOpts{{
+			httpOpts:         buildSidecarOutboundHTTPListenerOpts(&listenerOpts, pluginParams),
+			destinationCIDRs: []string{destinationCIDR},
+			match:            buildSniffedHTTPFilterChainMatch(nil),
+			listenerFilters:  []*listener.ListenerFilter{{Name: envoyListenerHTTPInspector}},
+		}}, opts...)
 	default:
 		// UDP or other protocols: no need to log, it's too noisy
 		return
@@ -1101,6 +1149,7 @@ func (configgen *ConfigGeneratorImpl) buildSidecarOutboundListenerForPortOrUDS(l
 		Listener:     l,
 		FilterChains: make([]plugin.FilterChain, len(l.FilterChains)),
 	}
+	setFilterChainProtocols(mutable, listenerOpts.filterChainOpts)
 
 	for _, p := range configgen.Plugins {
 		if err := p.OnOutboundListener(pluginParams, mutable); err != nil {
@@ -1360,7 +1409,7 @@ func buildSidecarInboundMgmtListeners(node *model.Proxy, env *model.Environment,
 	for _, mPort := range managementPorts {
 		switch mPort.Protocol {
 		case protocol.HTTP, protocol.HTTP2, protocol.GRPC, protocol.GRPCWeb, protocol.TCP,
-			protocol.HTTPS, protocol.TLS, protocol.Mongo, protocol.Redis, protocol.MySQL:
+			protocol.HTTPS, protocol.TLS, protocol.Mongo, protocol.Redis, protocol.MySQL, protocol.Kafka, protocol.Thrift:
 
 			instance := &model.ServiceInstance{
 				Endpoint: model.NetworkEndpoint{
@@ -1713,6 +1762,29 @@ func appendListenerFallthroughRoute(l *xdsapi.Listener, opts *buildListenerOpts,
 	}
 }
 
+// buildSniffedHTTPFilterChainMatch returns a copy of match that only selects connections the HTTP
+// inspector detected as HTTP.
+func buildSniffedHTTPFilterChainMatch(match *listener.FilterChainMatch) *listener.FilterChainMatch {
+	out := &listener.FilterChainMatch{}
+	if match != nil {
+		*out = *match
+	}
+	out.ApplicationProtocols = sniffedHTTPApplicationProtocols
+	return out
+}
+
+// setFilterChainProtocols marks every filter chain as HTTP or TCP, depending on whether it terminates in
+// an HTTP connection manager, so that plugins can attach the right filters to listeners mixing both.
+func setFilterChainProtocols(mutable *plugin.MutableObjects, opts []*filterChainOpts) {
+	for i := range mutable.FilterChains {
+		if i < len(opts) && opts[i].httpOpts != nil {
+			mutable.FilterChains[i].ListenerProtocol = plugin.ListenerProtocolHTTP
+		} else {
+			mutable.FilterChains[i].ListenerProtocol = plugin.ListenerProtocolTCP
+		}
+	}
+}
+
 // buildCompleteFilterChain adds the provided TCP and HTTP filters to the provided Listener and serializes them.
 //
 // TODO: should we change this from []plugins.FilterChains to [][]listener.Filter, [][]*http_conn.HttpFilter?
@@ -22,6 +22,7 @@ import (
 	"time"
 
 	xdsapi "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/auth"
 	"github.com/envoyproxy/go-control-plane/envoy/api/v2/listener"
 	tcp_proxy "github.com/envoyproxy/go-control-plane/envoy/config/filter/network/tcp_proxy/v2"
 	"github.com/envoyproxy/go-control-plane/pkg/util"
@@ -238,6 +239,7 @@ func TestInboundListenerConfig_HTTP(t *testing.T) {
 		testInboundListenerConfigWithSidecar(t, p,
 			buildService("test.com", wildcardIP, protocol.HTTP, tnow))
 		testInboundListenerConfigWithSidecarWithoutServices(t, p)
+		testInboundListenerConfigWithSidecarUDS(t, p)
 	}
 }
 
@@ -250,6 +252,126 @@ func TestOutboundListenerConfig_WithSidecar(t *testing.T) {
 	testOutboundListenerConfigWithSidecar(t, services...)
 	testOutboundListenerConfigWithSidecarWithCaptureModeNone(t, services...)
 	testOutboundListenerConfigWithSidecarWithUseRemoteAddress(t, services...)
+	testOutboundListenerConfigWithSidecarUDS(t, services...)
+}
+
+func TestOutboundListenerConfig_ProtocolSniffing(t *testing.T) {
+	_ = os.Setenv("PILOT_ENABLE_FALLTHROUGH_ROUTE", "false")
+	defer func() { _ = os.Unsetenv("PILOT_ENABLE_FALLTHROUGH_ROUTE") }()
+
+	services := []*model.Service{buildService("test.com", "10.10.0.1", protocol.Unsupported, tnow)}
+
+	// Without sniffing, ports without a declared protocol are of unknown protocol, and get no listener.
+	listeners := buildOutboundListeners(&fakePlugin{}, nil, nil, services...)
+	if len(listeners) != 0 {
+		t.Fatalf("expected %d listeners, found %d", 0, len(listeners))
+	}
+
+	_ = os.Setenv("PILOT_ENABLE_PROTOCOL_SNIFFING_FOR_OUTBOUND", "true")
+	defer func() { _ = os.Unsetenv("PILOT_ENABLE_PROTOCOL_SNIFFING_FOR_OUTBOUND") }()
+
+	p := &fakePlugin{}
+	listeners = buildOutboundListeners(p, nil, nil, services...)
+	if len(listeners) != 1 {
+		t.Fatalf("expected %d listeners, found %d", 1, len(listeners))
+	}
+	if p.outboundListenerParams[0].ListenerProtocol != plugin.ListenerProtocolAuto {
+		t.Fatalf("expected auto listener protocol, found %v", p.outboundListenerParams[0].ListenerProtocol)
+	}
+	verifySniffingListener(t, listeners[0], 1)
+}
+
+func TestInboundListenerConfig_ProtocolSniffing(t *testing.T) {
+	_ = os.Setenv("PILOT_ENABLE_PROTOCOL_SNIFFING_FOR_INBOUND", "true")
+	defer func() { _ = os.Unsetenv("PILOT_ENABLE_PROTOCOL_SNIFFING_FOR_INBOUND") }()
+
+	listeners := buildInboundListeners(&fakePlugin{}, &proxy, nil,
+		buildService("test.com", wildcardIP, protocol.Unsupported, tnow))
+	if len(listeners) != 1 {
+		t.Fatalf("expected %d listeners, found %d", 1, len(listeners))
+	}
+	// fakePlugin sets up two inbound filter chains, each is split into an HTTP and a TCP chain.
+	verifySniffingListener(t, listeners[0], 2)
+}
+
+// fakeMTLSPlugin sets up the inbound filter chains of a permissive mTLS port: an mTLS chain and a plaintext
+// chain.
+type fakeMTLSPlugin struct {
+	fakePlugin
+}
+
+func (p *fakeMTLSPlugin) OnInboundFilterChains(in *plugin.InputParams) []plugin.FilterChain {
+	return []plugin.FilterChain{
+		{
+			FilterChainMatch: &listener.FilterChainMatch{ApplicationProtocols: []string{"istio"}},
+			TLSContext:       &auth.DownstreamTlsContext{},
+			ListenerFilters:  []*listener.ListenerFilter{{Name: xdsutil.TlsInspector}},
+		},
+		{},
+	}
+}
+
+func TestInboundListenerConfig_ProtocolSniffingMTLS(t *testing.T) {
+	_ = os.Setenv("PILOT_ENABLE_PROTOCOL_SNIFFING_FOR_INBOUND", "true")
+	defer func() { _ = os.Unsetenv("PILOT_ENABLE_PROTOCOL_SNIFFING_FOR_INBOUND") }()
+
+	listeners := buildInboundListeners(&fakeMTLSPlugin{}, &proxy, nil,
+		buildService("test.com", wildcardIP, protocol.Unsupported, tnow))
+	if len(listeners) != 1 {
+		t.Fatalf("expected %d listeners, found %d", 1, len(listeners))
+	}
+	// The mTLS connections cannot be inspected before the handshake, so that only the plaintext chain is
+	// sniffed and the mTLS chain is always TCP.
+	httpChains, tcpChains := 0, 0
+	for _, fc := range listeners[0].FilterChains {
+		switch fc.Filters[len(fc.Filters)-1].Name {
+		case xdsutil.HTTPConnectionManager:
+			httpChains++
+			if fc.TlsContext != nil {
+				t.Errorf("expected the sniffed HTTP filter chain to be plaintext, found %v", fc.TlsContext)
+			}
+		case xdsutil.TCPProxy:
+			tcpChains++
+		}
+	}
+	if httpChains != 1 || tcpChains != 2 {
+		t.Fatalf("expected 1 HTTP and 2 TCP filter chains, found %d HTTP and %d TCP", httpChains, tcpChains)
+	}
+}
+
+func verifySniffingListener(t *testing.T, l *xdsapi.Listener, expectedHTTPChains int) {
+	t.Helper()
+	if !hasListenerFilter(l, envoyListenerHTTPInspector) {
+		t.Fatalf("expected listener filter %s, found %v", envoyListenerHTTPInspector, l.ListenerFilters)
+	}
+	httpChains, tcpChains := 0, 0
+	for _, fc := range l.FilterChains {
+		switch fc.Filters[len(fc.Filters)-1].Name {
+		case xdsutil.HTTPConnectionManager:
+			httpChains++
+			if fc.FilterChainMatch == nil ||
+				!reflect.DeepEqual(fc.FilterChainMatch.ApplicationProtocols, sniffedHTTPApplicationProtocols) {
+				t.Fatalf("expected HTTP filter chain to match %v, found %v", sniffedHTTPApplicationProtocols, fc.FilterChainMatch)
+			}
+		case xdsutil.TCPProxy:
+			tcpChains++
+			if len(fc.FilterChainMatch.GetApplicationProtocols()) != 0 {
+				t.Fatalf("expected TCP filter chain without application protocols, found %v", fc.FilterChainMatch)
+			}
+		}
+	}
+	if httpChains != expectedHTTPChains || tcpChains != expectedHTTPChains {
+		t.Fatalf("expected %d HTTP and TCP filter chains, found %d HTTP and %d TCP", expectedHTTPChains, httpChains, tcpChains)
+	}
+}
+
+func hasListenerFilter(l *xdsapi.Listener, name string) bool {
+	for _, f := range l.ListenerFilters {
+		if f.Name == name {
+			return true
+		}
+	}
+	return false
 }
 
 func TestGetActualWildcardAndLocalHost(t *testing.T) {
@@ -408,9 +530,55 @@ func testInboundListenerConfigWithSidecarWithoutServices(t *testing.T, proxy *mo
 		},
 	}
 	listeners := buildInboundListeners(p, proxy, sidecarConfig)
-	if expected := 0; len(listeners) != expected {
+	if expected := 1; len(listeners) != expected {
 		t.Fatalf("expected %d listeners, found %d", expected, len(listeners))
 	}
+
+	if !isHTTPListener(listeners[0]) {
+		t.Fatal("expected HTTP listener, found TCP")
+	}
+	if address := listeners[0].Address.GetSocketAddress(); address.Address != "1.1.1.1" || address.GetPortValue() != 8080 {
+		t.Fatalf("expected listener on 1.1.1.1:8080, found %v", address)
+	}
+	verifyInboundHTTPListenerServerName(t, listeners[0])
+	for _, l := range listeners {
+		verifyInboundHTTP10(t, isNodeHTTP10(proxy), l)
+	}
+}
+
+func testInboundListenerConfigWithSidecarUDS(t *testing.T, proxy *model.Proxy) {
+	t.Helper()
+	p := &fakePlugin{}
+	sidecarConfig := &model.Config{
+		ConfigMeta: model.ConfigMeta{
+			Name:      "foo-uds",
+			Namespace: "not-default",
+		},
+		Spec: &networking.Sidecar{
+			Ingress: []*networking.IstioIngressListener{
+				{
+					Port: &networking.Port{
+						Number:   0,
+						Protocol: "TCP",
+						Name:     "uds",
+					},
+					Bind:            "unix:///var/run/proxy.sock",
+					DefaultEndpoint: "unix:///var/run/app.sock",
+				},
+			},
+		},
+	}
+	listeners := buildInboundListeners(p, proxy, sidecarConfig)
+	if expected := 1; len(listeners) != expected {
+		t.Fatalf("expected %d listeners, found %d", expected, len(listeners))
+	}
+	if path := listeners[0].Address.GetPipe().GetPath(); path != "/var/run/proxy.sock" {
+		t.Fatalf("expected listener on /var/run/proxy.sock, found %v", listeners[0].Address)
+	}
+	// The proxy always listens on unix domain sockets, they are not captured.
+	if listeners[0].DeprecatedV1 != nil {
+		t.Fatalf("expected listener bound to the socket, found %v", listeners[0].DeprecatedV1)
+	}
 }
 
 func testOutboundListenerConfigWithSidecar(t *testing.T, services ...*model.Service) {
@@ -522,6 +690,46 @@ func testOutboundListenerConfigWithSidecarWithUseRemoteAddress(t *testing.T, ser
 	}
 }
 
+func testOutboundListenerConfigWithSidecarUDS(t *testing.T, services ...*model.Service) {
+	t.Helper()
+	p := &fakePlugin{}
+	sidecarConfig := &model.Config{
+		ConfigMeta: model.ConfigMeta{
+			Name:      "foo",
+			Namespace: "not-default",
+		},
+		Spec: &networking.Sidecar{
+			Egress: []*networking.IstioEgressListener{
+				{
+					Port: &networking.Port{
+						Number:   0,
+						Protocol: "HTTP",
+						Name:     "uds",
+					},
+					Bind:  "unix:///var/run/egress.sock",
+					Hosts: []string{"*/*"},
+				},
+			},
+		},
+	}
+	listeners := buildOutboundListeners(p, sidecarConfig, nil, services...)
+	if len(listeners) != 1 {
+		t.Fatalf("expected %d listeners, found %d", 1, len(listeners))
+	}
+
+	l := listeners[0]
+	if path := l.Address.GetPipe().GetPath(); path != "/var/run/egress.sock" {
+		t.Fatalf("expected listener on /var/run/egress.sock, found %v", l.Address)
+	}
+	if !isHTTPListener(l) {
+		t.Fatalf("expected HTTP listener, found TCP\n%v", l)
+	}
+	// The proxy always listens on unix domain sockets, they are not captured.
+	if l.DeprecatedV1 != nil {
+		t.Fatalf("expected listener bound to the socket, found %v", l.DeprecatedV1)
+	}
+}
+
 func testOutboundListenerConfigWithSidecarWithCaptureModeNone(t *testing.T, services ...*model.Service) {
 	t.Helper()
 	p := &fakePlugin{}
@@ -757,7 +965,7 @@ func buildAllListeners(p plugin.Plugin, sidecarConfig *model.Config, services ..
 
 	env := buildListenerEnv(services)
 
-	if err := env.PushContext.InitContext(&env); err != nil {
+	if err := env.PushContext.InitContext(&env, nil, nil); err != nil {
 		return nil
 	}
 
@@ -796,7 +1004,7 @@ func buildOutboundListeners(p plugin.Plugin, sidecarConfig *model.Config,
 		env = buildListenerEnv(services)
 	}
 
-	if err := env.PushContext.InitContext(&env); err != nil {
+	if err := env.PushContext.InitContext(&env, nil, nil); err != nil {
 		return nil
 	}
 
@@ -813,7 +1021,7 @@ func buildOutboundListeners(p plugin.Plugin, sidecarConfig *model.Config,
 func buildInboundListeners(p plugin.Plugin, proxy *model.Proxy, sidecarConfig *model.Config, services ...*model.Service) []*xdsapi.Listener {
 	configgen := NewConfigGenerator([]plugin.Plugin{p})
 	env := buildListenerEnv(services)
-	if err := env.PushContext.InitContext(&env); err != nil {
+	if err := env.PushContext.InitContext(&env, nil, nil); err != nil {
 		return nil
 	}
 	instances := make([]*model.ServiceInstance, len(services))
@@ -27,6 +27,14 @@ import (
 	"istio.io/istio/pilot/pkg/networking/util"
 )
 
+// FailoverEnabled returns true if the locality load balancer setting fails over across regions,
+// which needs outlier detection to happen.
+func FailoverEnabled(localityLB *meshconfig.LocalityLoadBalancerSetting) bool {
+	return len(localityLB.GetDistribute()) == 0 && len(localityLB.GetFailover()) > 0
+}
+
+// ApplyLocalityLBSetting applies the locality load balancer setting to the endpoints of the load assignment,
+// relative to the given locality of the proxy.
 func ApplyLocalityLBSetting(
 	locality *core.Locality,
 	loadAssignment *apiv2.ClusterLoadAssignment,
@@ -118,17 +126,11 @@ func applyLocalityFailover(
 		// if region matches, the priority is 2.
 		// if locality not match, the priority is 3.
 		priority := util.LbPriority(locality, localityEndpoint.Locality)
-		// region not match, apply failover settings when specified
-		// update localityLbEndpoints' priority to 4 if failover not match
+		// region not match, apply failover settings when specified:
+		// the regions the proxy region fails over to get the priorities 3, 4, ... in the order of
+		// the failover settings, the other regions get the lowest priority.
 		if priority == 3 {
-			for _, failoverSetting := range failover {
-				if failoverSetting.From == locality.Region {
-					if localityEndpoint.Locality == nil || localityEndpoint.Locality.Region != failoverSetting.To {
-						priority = 4
-					}
-					break
-				}
-			}
+			priority = failoverPriority(locality.Region, localityEndpoint.Locality, failover)
 		}
 		loadAssignment.Endpoints[i].Priority = uint32(priority)
 		priorityMap[priority] = append(priorityMap[priority], i)
@@ -152,5 +154,19 @@ func applyLocalityFailover(
 			}
 		}
 	}
+}
 
+// failoverPriority returns the priority of endpoints in the given locality, outside of the region of the proxy.
+func failoverPriority(region string, endpointLocality *core.Locality, failover []*meshconfig.LocalityLoadBalancerSetting_Failover) int {
+	priority := 3
+	for _, failoverSetting := range failover {
+		if failoverSetting.From != region {
+			continue
+		}
+		if endpointLocality != nil && endpointLocality.Region == failoverSetting.To {
+			return priority
+		}
+		priority++
+	}
+	return priority
 }
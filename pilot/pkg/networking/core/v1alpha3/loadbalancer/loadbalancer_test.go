@@ -101,6 +101,41 @@ func TestApplyLocalitySetting(t *testing.T) {
 		}
 	})
 
+	t.Run("Failover: multi level", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		failover := &meshconfig.LocalityLoadBalancerSetting{
+			Failover: []*meshconfig.LocalityLoadBalancerSetting_Failover{
+				{
+					From: "region1",
+					To:   "region3",
+				},
+				{
+					From: "region1",
+					To:   "region2",
+				},
+			},
+		}
+		g.Expect(FailoverEnabled(failover)).To(BeTrue())
+		cluster := buildFakeCluster()
+		ApplyLocalityLBSetting(locality, cluster.LoadAssignment, failover, true)
+		priorities := make([]uint32, 0)
+		for _, localityEndpoint := range cluster.LoadAssignment.Endpoints {
+			priorities = append(priorities, localityEndpoint.Priority)
+		}
+		g.Expect(priorities).To(Equal([]uint32{0, 0, 1, 1, 2, 4, 3}))
+	})
+
+	t.Run("Failover: disabled without outlier detection", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		env := buildEnvForClustersWithFailover()
+		cluster := buildFakeCluster()
+		ApplyLocalityLBSetting(locality, cluster.LoadAssignment, env.Mesh.LocalityLbSetting, false)
+		for _, localityEndpoint := range cluster.LoadAssignment.Endpoints {
+			g.Expect(localityEndpoint.Priority).To(Equal(uint32(0)))
+		}
+		g.Expect(FailoverEnabled(nil)).To(BeFalse())
+	})
+
 	t.Run("Failover: priorities with gaps", func(t *testing.T) {
 		g := NewGomegaWithT(t)
 		env := buildEnvForClustersWithFailover()
@@ -193,7 +228,7 @@ func buildEnvForClustersWithDistribute(distribute []*meshconfig.LocalityLoadBala
 	}
 
 	env.PushContext = model.NewPushContext()
-	_ = env.PushContext.InitContext(env)
+	_ = env.PushContext.InitContext(env, nil, nil)
 	env.PushContext.SetDestinationRules([]model.Config{
 		{ConfigMeta: model.ConfigMeta{
 			Type:    model.DestinationRule.Type,
@@ -256,7 +291,7 @@ func buildEnvForClustersWithFailover() *model.Environment {
 	}
 
 	env.PushContext = model.NewPushContext()
-	_ = env.PushContext.InitContext(env)
+	_ = env.PushContext.InitContext(env, nil, nil)
 	env.PushContext.SetDestinationRules([]model.Config{
 		{ConfigMeta: model.ConfigMeta{
 			Type:    model.DestinationRule.Type,
@@ -26,6 +26,7 @@ import (
 	redis_proxy "github.com/envoyproxy/go-control-plane/envoy/config/filter/network/redis_proxy/v2"
 	tcp_proxy "github.com/envoyproxy/go-control-plane/envoy/config/filter/network/tcp_proxy/v2"
 	xdsutil "github.com/envoyproxy/go-control-plane/pkg/util"
+	"github.com/gogo/protobuf/types"
 
 	networking "istio.io/api/networking/v1alpha3"
 
@@ -170,6 +171,15 @@ func buildNetworkFiltersStack(node *model.Proxy, port *model.Port, tcpFilter *li
 			filterstack = append(filterstack, buildMySQLFilter(statPrefix, util.IsXDSMarshalingToAnyEnabled(node)))
 		}
 		filterstack = append(filterstack, tcpFilter)
+	case protocol.Kafka:
+		if features.EnableKafkaFilter.Get() {
+			filterstack = append(filterstack, buildKafkaFilter(statPrefix))
+		}
+		filterstack = append(filterstack, tcpFilter)
+	case protocol.Thrift:
+		// thrift filter has route config, it is a terminating filter, no need append tcp filter.
+		routes := []*thriftRoute{buildThriftCatchAllRoute(&thriftRouteAction{Cluster: clusterName})}
+		filterstack = append(filterstack, buildThriftFilter(statPrefix, routes))
 	default:
 		filterstack = append(filterstack, tcpFilter)
 	}
@@ -180,6 +190,9 @@ func buildNetworkFiltersStack(node *model.Proxy, port *model.Port, tcpFilter *li
 // buildOutboundNetworkFilters generates a TCP proxy network filter for outbound
 // connections. In addition, it generates protocol specific filters (e.g., Mongo
 // filter).
+// TODO: let egress gateways tunnel the connections to a ServiceEntry host through an upstream
+// HTTP CONNECT proxy. This needs the tunneling_config of the TcpProxy filter, which neither the
+// bundled Envoy nor the vendored go-control-plane support yet.
 func buildOutboundNetworkFilters(env *model.Environment, node *model.Proxy,
 	routes []*networking.RouteDestination, push *model.PushContext,
 	port *model.Port, configMeta model.ConfigMeta) []*listener.Filter {
@@ -274,3 +287,20 @@ func buildMySQLFilter(statPrefix string, isXDSMarshalingToAnyEnabled bool) *list
 
 	return out
 }
+
+// buildKafkaFilter builds an Envoy KafkaBroker filter. The filter decodes the Kafka protocol and records
+// per API key request and response metrics, it does not alter the traffic. The KafkaBroker config is
+// not part of the vendored go-control-plane, so it is sent as a Struct.
+func buildKafkaFilter(statPrefix string) *listener.Filter {
+	kafkaBroker := &types.Struct{
+		Fields: map[string]*types.Value{
+			// Kafka stats are prefixed with kafka.<statPrefix> by Envoy.
+			"stat_prefix": {Kind: &types.Value_StringValue{StringValue: statPrefix}},
+		},
+	}
+
+	return &listener.Filter{
+		Name:       util.KafkaBrokerFilter,
+		ConfigType: &listener.Filter_Config{Config: kafkaBroker},
+	}
+}
@@ -15,12 +15,18 @@
 package v1alpha3
 
 import (
+	"os"
 	"testing"
 
 	"github.com/envoyproxy/go-control-plane/envoy/api/v2/listener"
 	redis_proxy "github.com/envoyproxy/go-control-plane/envoy/config/filter/network/redis_proxy/v2"
 	xdsutil "github.com/envoyproxy/go-control-plane/pkg/util"
 	"github.com/gogo/protobuf/types"
+
+	"istio.io/istio/pilot/pkg/features"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/networking/util"
+	"istio.io/istio/pkg/config/protocol"
 )
 
 func TestBuildRedisFilter(t *testing.T) {
@@ -54,3 +60,31 @@ func TestBuildRedisFilter(t *testing.T) {
 		t.Errorf("redis filter type is %T not listener.Filter_Config ", redisFilter.ConfigType)
 	}
 }
+
+func TestBuildKafkaFilterStack(t *testing.T) {
+	port := &model.Port{Name: "kafka", Port: 9092, Protocol: protocol.Kafka}
+	tcpFilter := &listener.Filter{Name: xdsutil.TCPProxy}
+
+	filters := buildNetworkFiltersStack(&proxy, port, tcpFilter, "kafka", "kafka-cluster")
+	if len(filters) != 1 || filters[0] != tcpFilter {
+		t.Fatalf("expected only the tcp proxy filter when the kafka filter is disabled, got %v", filters)
+	}
+
+	_ = os.Setenv(features.EnableKafkaFilter.Name, "true")
+	defer func() { _ = os.Unsetenv(features.EnableKafkaFilter.Name) }()
+
+	filters = buildNetworkFiltersStack(&proxy, port, tcpFilter, "kafka", "kafka-cluster")
+	if len(filters) != 2 || filters[1] != tcpFilter {
+		t.Fatalf("expected the kafka filter in front of the tcp proxy filter, got %v", filters)
+	}
+	if filters[0].Name != util.KafkaBrokerFilter {
+		t.Errorf("kafka filter name is %s not %s", filters[0].Name, util.KafkaBrokerFilter)
+	}
+	config, ok := filters[0].ConfigType.(*listener.Filter_Config)
+	if !ok {
+		t.Fatalf("kafka filter type is %T not listener.Filter_Config", filters[0].ConfigType)
+	}
+	if statPrefix := config.Config.Fields["stat_prefix"].GetStringValue(); statPrefix != "kafka" {
+		t.Errorf("kafka filter statPrefix is %s", statPrefix)
+	}
+}
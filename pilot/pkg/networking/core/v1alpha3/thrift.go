@@ -0,0 +1,299 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha3
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/listener"
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/gogo/protobuf/types"
+
+	networking "istio.io/api/networking/v1alpha3"
+
+	"istio.io/istio/pilot/pkg/model"
+	istio_route "istio.io/istio/pilot/pkg/networking/core/v1alpha3/route"
+	"istio.io/istio/pilot/pkg/networking/util"
+	"istio.io/istio/pkg/config/host"
+	"istio.io/istio/pkg/config/labels"
+	"istio.io/pkg/log"
+)
+
+// The types below mirror envoy.config.filter.network.thrift_proxy.v2alpha1, which is not part of
+// the vendored go-control-plane. The filter config is therefore always sent as a Struct.
+
+type thriftProxy struct {
+	StatPrefix    string                    `json:"stat_prefix"`
+	RouteConfig   *thriftRouteConfiguration `json:"route_config"`
+	ThriftFilters []*thriftFilter           `json:"thrift_filters"`
+}
+
+type thriftFilter struct {
+	Name string `json:"name"`
+}
+
+type thriftRouteConfiguration struct {
+	Name   string         `json:"name"`
+	Routes []*thriftRoute `json:"routes"`
+}
+
+type thriftRoute struct {
+	Match *thriftRouteMatch  `json:"match"`
+	Route *thriftRouteAction `json:"route"`
+}
+
+// thriftRouteMatch matches on either the method name or the service name. An empty method name
+// matches all requests.
+type thriftRouteMatch struct {
+	MethodName  *string                `json:"method_name,omitempty"`
+	ServiceName *string                `json:"service_name,omitempty"`
+	Headers     []*thriftHeaderMatcher `json:"headers,omitempty"`
+}
+
+type thriftHeaderMatcher struct {
+	Name        string `json:"name"`
+	ExactMatch  string `json:"exact_match,omitempty"`
+	PrefixMatch string `json:"prefix_match,omitempty"`
+	RegexMatch  string `json:"regex_match,omitempty"`
+}
+
+type thriftRouteAction struct {
+	Cluster          string                  `json:"cluster,omitempty"`
+	WeightedClusters *thriftWeightedClusters `json:"weighted_clusters,omitempty"`
+}
+
+type thriftWeightedClusters struct {
+	Clusters []*thriftClusterWeight `json:"clusters"`
+}
+
+type thriftClusterWeight struct {
+	Name   string `json:"name"`
+	Weight uint32 `json:"weight"`
+}
+
+// buildThriftFilter builds an Envoy ThriftProxy filter with the given routes, matched in order.
+func buildThriftFilter(statPrefix string, routes []*thriftRoute) *listener.Filter {
+	thriftProxy := &thriftProxy{
+		StatPrefix: statPrefix, // thrift stats are prefixed with thrift.<statPrefix> by Envoy
+		RouteConfig: &thriftRouteConfiguration{
+			Name:   statPrefix,
+			Routes: routes,
+		},
+		ThriftFilters: []*thriftFilter{{Name: util.ThriftRouterFilter}},
+	}
+
+	config := &types.Struct{}
+	b, err := json.Marshal(thriftProxy)
+	if err == nil {
+		err = jsonpb.Unmarshal(bytes.NewReader(b), config)
+	}
+	if err != nil {
+		log.Errorf("failed to build the thrift proxy config: %v", err)
+	}
+
+	return &listener.Filter{
+		Name:       util.ThriftProxyFilter,
+		ConfigType: &listener.Filter_Config{Config: config},
+	}
+}
+
+// buildThriftCatchAllRoute returns a route sending all Thrift requests to the given route action.
+func buildThriftCatchAllRoute(action *thriftRouteAction) *thriftRoute {
+	methodName := ""
+	return &thriftRoute{
+		Match: &thriftRouteMatch{MethodName: &methodName},
+		Route: action,
+	}
+}
+
+// buildSidecarOutboundThriftFilterChainOpts builds the filter chain of an outbound Thrift listener. The
+// Thrift routes are derived from the HTTP routes of the virtual services, see translateThriftRouteMatch,
+// followed by the TCP routes without a match condition. Requests not matched by any of them are sent
+// to the service.
+func buildSidecarOutboundThriftFilterChainOpts(node *model.Proxy, push *model.PushContext, destinationCIDR string,
+	service *model.Service, listenPort *model.Port, proxyLabels labels.Collection,
+	gateways map[string]bool, configs []model.Config) []*filterChainOpts {
+
+	port := listenPort.Port
+	if service != nil && len(service.Ports) == 1 {
+		port = service.Ports[0].Port
+	}
+	var defaultCluster string
+	if service != nil {
+		defaultCluster = model.BuildSubsetKey(model.TrafficDirectionOutbound, "", service.Hostname, port)
+	} else {
+		defaultCluster = util.BlackHoleCluster
+	}
+
+	routes := make([]*thriftRoute, 0)
+	defaultRouteAdded := false
+ThriftLoop:
+	for _, cfg := range configs {
+		virtualService := cfg.Spec.(*networking.VirtualService)
+		for _, http := range virtualService.Http {
+			action := buildThriftRouteAction(node, push, http.Route, listenPort.Port)
+			if action == nil {
+				continue
+			}
+			if len(http.Match) == 0 {
+				routes = append(routes, buildThriftCatchAllRoute(action))
+				defaultRouteAdded = true
+				break ThriftLoop
+			}
+			for _, match := range http.Match {
+				if !sourceMatchThrift(match, proxyLabels, gateways, listenPort.Port) {
+					continue
+				}
+				routeMatch, ok := translateThriftRouteMatch(match)
+				if !ok {
+					log.Debugf("Ignoring match of virtual service %s/%s not supported for thrift: %v",
+						cfg.Namespace, cfg.Name, match)
+					continue
+				}
+				routes = append(routes, &thriftRoute{Match: routeMatch, Route: action})
+			}
+		}
+		for _, tcp := range virtualService.Tcp {
+			matched := len(tcp.Match) == 0
+			for _, match := range tcp.Match {
+				if len(match.DestinationSubnets) == 0 && matchTCP(match, proxyLabels, gateways, listenPort.Port) {
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				continue
+			}
+			routeDestinations := make([]*networking.HTTPRouteDestination, 0, len(tcp.Route))
+			for _, dst := range tcp.Route {
+				routeDestinations = append(routeDestinations, &networking.HTTPRouteDestination{
+					Destination: dst.Destination,
+					Weight:      dst.Weight,
+				})
+			}
+			if action := buildThriftRouteAction(node, push, routeDestinations, listenPort.Port); action != nil {
+				routes = append(routes, buildThriftCatchAllRoute(action))
+				defaultRouteAdded = true
+				break ThriftLoop
+			}
+		}
+	}
+
+	if !defaultRouteAdded {
+		routes = append(routes, buildThriftCatchAllRoute(&thriftRouteAction{Cluster: defaultCluster}))
+	}
+
+	return []*filterChainOpts{{
+		destinationCIDRs: []string{destinationCIDR},
+		networkFilters:   []*listener.Filter{buildThriftFilter(defaultCluster, routes)},
+	}}
+}
+
+// sourceMatchThrift checks if the HTTP match request applies to the proxy and the listener port.
+func sourceMatchThrift(match *networking.HTTPMatchRequest, proxyLabels labels.Collection,
+	gateways map[string]bool, port int) bool {
+	if match.Port != 0 && match.Port != uint32(port) {
+		return false
+	}
+	if len(match.Gateways) > 0 {
+		for _, gateway := range match.Gateways {
+			if gateways[gateway] {
+				return true
+			}
+		}
+		return false
+	}
+	return proxyLabels.IsSupersetOf(match.SourceLabels)
+}
+
+// translateThriftRouteMatch converts an HTTP match request to a Thrift route match. The exact `method`
+// match selects the Thrift method name, the exact `authority` match selects the service name of the
+// multiplexed protocol, and `headers` match the Thrift header transport headers. It returns false for
+// match requests that cannot be expressed for Thrift.
+func translateThriftRouteMatch(in *networking.HTTPMatchRequest) (*thriftRouteMatch, bool) {
+	if in.Uri != nil || in.Scheme != nil || len(in.QueryParams) > 0 {
+		return nil, false
+	}
+
+	out := &thriftRouteMatch{}
+	switch {
+	case in.Method != nil && in.Authority != nil:
+		return nil, false
+	case in.Method != nil:
+		m, ok := in.Method.MatchType.(*networking.StringMatch_Exact)
+		if !ok {
+			return nil, false
+		}
+		out.MethodName = &m.Exact
+	case in.Authority != nil:
+		m, ok := in.Authority.MatchType.(*networking.StringMatch_Exact)
+		if !ok {
+			return nil, false
+		}
+		out.ServiceName = &m.Exact
+	default:
+		methodName := ""
+		out.MethodName = &methodName
+	}
+
+	for name, stringMatch := range in.Headers {
+		matcher := &thriftHeaderMatcher{Name: name}
+		switch m := stringMatch.MatchType.(type) {
+		case *networking.StringMatch_Exact:
+			matcher.ExactMatch = m.Exact
+		case *networking.StringMatch_Prefix:
+			matcher.PrefixMatch = m.Prefix
+		case *networking.StringMatch_Regex:
+			matcher.RegexMatch = m.Regex
+		default:
+			return nil, false
+		}
+		out.Headers = append(out.Headers, matcher)
+	}
+	// Keep the generated config stable across pushes.
+	sort.Slice(out.Headers, func(i, j int) bool {
+		return out.Headers[i].Name < out.Headers[j].Name
+	})
+
+	return out, true
+}
+
+// buildThriftRouteAction returns the route action sending requests to the given destinations, or nil
+// if there is no destination.
+func buildThriftRouteAction(node *model.Proxy, push *model.PushContext,
+	destinations []*networking.HTTPRouteDestination, listenPort int) *thriftRouteAction {
+	clusters := make([]*thriftClusterWeight, 0, len(destinations))
+	for _, dst := range destinations {
+		if dst.Destination == nil || (len(destinations) > 1 && dst.Weight <= 0) {
+			continue
+		}
+		service := node.SidecarScope.ServiceForHostname(host.Name(dst.Destination.Host), push.ServiceByHostnameAndNamespace)
+		clusters = append(clusters, &thriftClusterWeight{
+			Name:   istio_route.GetDestinationCluster(dst.Destination, service, listenPort),
+			Weight: uint32(dst.Weight),
+		})
+	}
+
+	switch len(clusters) {
+	case 0:
+		return nil
+	case 1:
+		return &thriftRouteAction{Cluster: clusters[0].Name}
+	default:
+		return &thriftRouteAction{WeightedClusters: &thriftWeightedClusters{Clusters: clusters}}
+	}
+}
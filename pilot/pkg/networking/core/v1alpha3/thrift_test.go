@@ -0,0 +1,166 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha3
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/listener"
+	"github.com/gogo/protobuf/jsonpb"
+
+	networking "istio.io/api/networking/v1alpha3"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/networking/util"
+	"istio.io/istio/pkg/config/protocol"
+)
+
+func getThriftProxyConfig(t *testing.T, filter *listener.Filter) *thriftProxy {
+	t.Helper()
+	if filter.Name != util.ThriftProxyFilter {
+		t.Fatalf("filter name is %s not %s", filter.Name, util.ThriftProxyFilter)
+	}
+	config, ok := filter.ConfigType.(*listener.Filter_Config)
+	if !ok {
+		t.Fatalf("thrift filter type is %T not listener.Filter_Config", filter.ConfigType)
+	}
+	js, err := (&jsonpb.Marshaler{}).MarshalToString(config.Config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := &thriftProxy{}
+	if err := json.Unmarshal([]byte(js), out); err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func thriftMethod(name string) *thriftRouteMatch {
+	return &thriftRouteMatch{MethodName: &name}
+}
+
+func TestBuildThriftFilterStack(t *testing.T) {
+	port := &model.Port{Name: "thrift", Port: 9090, Protocol: protocol.Thrift}
+	filters := buildNetworkFiltersStack(&proxy, port, &listener.Filter{Name: "tcp"}, "thrift-stats", "thrift-cluster")
+	if len(filters) != 1 {
+		t.Fatalf("expected only the terminating thrift filter, got %d filters", len(filters))
+	}
+	thriftProxy := getThriftProxyConfig(t, filters[0])
+	if thriftProxy.StatPrefix != "thrift-stats" {
+		t.Errorf("thrift proxy statPrefix is %s", thriftProxy.StatPrefix)
+	}
+	expected := []*thriftRoute{{Match: thriftMethod(""), Route: &thriftRouteAction{Cluster: "thrift-cluster"}}}
+	if !reflect.DeepEqual(thriftProxy.RouteConfig.Routes, expected) {
+		t.Errorf("unexpected routes %+v", thriftProxy.RouteConfig.Routes)
+	}
+	if len(thriftProxy.ThriftFilters) != 1 || thriftProxy.ThriftFilters[0].Name != util.ThriftRouterFilter {
+		t.Errorf("unexpected thrift filters %+v", thriftProxy.ThriftFilters)
+	}
+}
+
+func TestOutboundListenerThriftWithVS(t *testing.T) {
+	destination := func(host string) *networking.Destination {
+		return &networking.Destination{
+			Host: host,
+			Port: &networking.PortSelector{Port: &networking.PortSelector_Number{Number: 9090}},
+		}
+	}
+	virtualService := model.Config{
+		ConfigMeta: model.ConfigMeta{
+			Type:      model.VirtualService.Type,
+			Version:   model.VirtualService.Version,
+			Name:      "thrift_vs",
+			Namespace: "default",
+		},
+		Spec: &networking.VirtualService{
+			Hosts: []string{"test.com"},
+			Http: []*networking.HTTPRoute{
+				{
+					Match: []*networking.HTTPMatchRequest{
+						{
+							Method: &networking.StringMatch{MatchType: &networking.StringMatch_Exact{Exact: "ping"}},
+							Headers: map[string]*networking.StringMatch{
+								"version": {MatchType: &networking.StringMatch_Prefix{Prefix: "v2"}},
+							},
+						},
+						// Not applicable to Thrift.
+						{Uri: &networking.StringMatch{MatchType: &networking.StringMatch_Prefix{Prefix: "/"}}},
+						// Not applicable to the listener port.
+						{Port: 1234},
+					},
+					Route: []*networking.HTTPRouteDestination{
+						{Destination: destination("a.com"), Weight: 80},
+						{Destination: destination("b.com"), Weight: 20},
+					},
+				},
+				{
+					Match: []*networking.HTTPMatchRequest{
+						{Authority: &networking.StringMatch{MatchType: &networking.StringMatch_Exact{Exact: "Calculator"}}},
+					},
+					Route: []*networking.HTTPRouteDestination{{Destination: destination("a.com")}},
+				},
+			},
+		},
+	}
+
+	services := []*model.Service{
+		buildService("test.com", "10.10.0.0/24", protocol.Thrift, tnow),
+		buildService("a.com", "10.10.1.0/24", protocol.Thrift, tnow),
+		buildService("b.com", "10.10.2.0/24", protocol.Thrift, tnow),
+	}
+	listeners := buildOutboundListeners(&fakePlugin{}, nil, &virtualService, services[0])
+	if len(listeners) != 1 {
+		t.Fatalf("expected %d listeners, found %d", 1, len(listeners))
+	}
+
+	var thriftProxy *thriftProxy
+	for _, fc := range listeners[0].FilterChains {
+		for _, cidr := range fc.FilterChainMatch.PrefixRanges {
+			if cidr.AddressPrefix == "10.10.0.0" {
+				thriftProxy = getThriftProxyConfig(t, fc.Filters[len(fc.Filters)-1])
+			}
+		}
+	}
+	if thriftProxy == nil {
+		t.Fatalf("no filter chain found for the service CIDR")
+	}
+
+	pingMatch := thriftMethod("ping")
+	pingMatch.Headers = []*thriftHeaderMatcher{{Name: "version", PrefixMatch: "v2"}}
+	calculator := "Calculator"
+	expected := []*thriftRoute{
+		{
+			Match: pingMatch,
+			Route: &thriftRouteAction{WeightedClusters: &thriftWeightedClusters{Clusters: []*thriftClusterWeight{
+				{Name: "outbound|9090||a.com", Weight: 80},
+				{Name: "outbound|9090||b.com", Weight: 20},
+			}}},
+		},
+		{
+			Match: &thriftRouteMatch{ServiceName: &calculator},
+			Route: &thriftRouteAction{Cluster: "outbound|9090||a.com"},
+		},
+		{
+			Match: thriftMethod(""),
+			Route: &thriftRouteAction{Cluster: "outbound|8080||test.com"},
+		},
+	}
+	if !reflect.DeepEqual(thriftProxy.RouteConfig.Routes, expected) {
+		got, _ := json.Marshal(thriftProxy.RouteConfig.Routes)
+		t.Errorf("unexpected routes %s", got)
+	}
+}
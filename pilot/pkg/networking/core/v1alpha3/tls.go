@@ -25,6 +25,7 @@ import (
 	"istio.io/istio/pilot/pkg/networking/util"
 	"istio.io/istio/pkg/config/host"
 	"istio.io/istio/pkg/config/labels"
+	"istio.io/istio/pkg/config/protocol"
 
 	"istio.io/pkg/log"
 )
@@ -202,6 +203,12 @@ func buildSidecarOutboundTCPFilterChainOpts(env *model.Environment, node *model.
 		return nil
 	}
 
+	// Thrift requests are routed per method by the thrift proxy
+	if listenPort.Protocol == protocol.Thrift {
+		return buildSidecarOutboundThriftFilterChainOpts(node, push, destinationCIDR, service, listenPort,
+			proxyLabels, gateways, configs)
+	}
+
 	out := make([]*filterChainOpts, 0)
 
 	// very basic TCP
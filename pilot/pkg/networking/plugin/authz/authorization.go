@@ -85,20 +85,21 @@ func buildFilter(in *plugin.InputParams, mutable *plugin.MutableObjects) {
 	}
 
 	switch in.ListenerProtocol {
-	case plugin.ListenerProtocolTCP:
+	case plugin.ListenerProtocolTCP, plugin.ListenerProtocolAuto:
 		rbacLog.Debugf("building filter for TCP listener protocol")
 		tcpFilter := builder.BuildTCPFilter()
-		if in.Node.Type == model.Router {
+		if in.Node.Type == model.Router || in.ListenerProtocol == plugin.ListenerProtocolAuto {
 			// For gateways, due to TLS termination, a listener marked as TCP could very well
-			// be using a HTTP connection manager. So check the filterChain.listenerProtocol
+			// be using a HTTP connection manager. Listeners that sniff the protocol have both
+			// HTTP and TCP filter chains. So check the filterChain.listenerProtocol
 			// to decide the type of filter to attach
 			httpFilter := builder.BuildHTTPFilter()
 			for cnum := range mutable.FilterChains {
 				if mutable.FilterChains[cnum].ListenerProtocol == plugin.ListenerProtocolHTTP {
-					rbacLog.Infof("added HTTP filter to gateway filter chain %d", cnum)
+					rbacLog.Infof("added HTTP filter to filter chain %d", cnum)
 					mutable.FilterChains[cnum].HTTP = append(mutable.FilterChains[cnum].HTTP, httpFilter)
 				} else {
-					rbacLog.Infof("added TCP filter to gateway filter chain %d", cnum)
+					rbacLog.Infof("added TCP filter to filter chain %d", cnum)
 					mutable.FilterChains[cnum].TCP = append(mutable.FilterChains[cnum].TCP, tcpFilter)
 				}
 			}
@@ -113,11 +113,12 @@ func (mixerplugin) OnOutboundListener(in *plugin.InputParams, mutable *plugin.Mu
 			mutable.FilterChains[cnum].HTTP = append(mutable.FilterChains[cnum].HTTP, httpFilter)
 		}
 		return nil
-	case plugin.ListenerProtocolTCP:
+	case plugin.ListenerProtocolTCP, plugin.ListenerProtocolAuto:
 		tcpFilter := buildOutboundTCPFilter(in.Env.Mesh, attrs, in.Node, in.Service)
-		if in.Node.Type == model.Router {
+		if in.Node.Type == model.Router || in.ListenerProtocol == plugin.ListenerProtocolAuto {
 			// For gateways, due to TLS termination, a listener marked as TCP could very well
-			// be using a HTTP connection manager. So check the filterChain.listenerProtocol
+			// be using a HTTP connection manager. Listeners that sniff the protocol have both
+			// HTTP and TCP filter chains. So check the filterChain.listenerProtocol
 			// to decide the type of filter to attach
 			httpFilter := buildOutboundHTTPFilter(in.Env.Mesh, attrs, in.Node)
 			for cnum := range mutable.FilterChains {
@@ -177,6 +178,18 @@ func (mixerplugin) OnInboundListener(in *plugin.InputParams, mutable *plugin.Mut
 			mutable.FilterChains[cnum].TCP = append(mutable.FilterChains[cnum].TCP, filter)
 		}
 		return nil
+	case plugin.ListenerProtocolAuto:
+		// Listeners that sniff the protocol have both HTTP and TCP filter chains.
+		httpFilter := buildInboundHTTPFilter(in.Env.Mesh, attrs, in.Node)
+		tcpFilter := buildInboundTCPFilter(in.Env.Mesh, attrs, in.Node)
+		for cnum := range mutable.FilterChains {
+			if mutable.FilterChains[cnum].ListenerProtocol == plugin.ListenerProtocolHTTP {
+				mutable.FilterChains[cnum].HTTP = append(mutable.FilterChains[cnum].HTTP, httpFilter)
+			} else {
+				mutable.FilterChains[cnum].TCP = append(mutable.FilterChains[cnum].TCP, tcpFilter)
+			}
+		}
+		return nil
 	}
 
 	return fmt.Errorf("unknown listener type %v in mixer.OnOutboundListener", in.ListenerProtocol)
@@ -22,6 +22,7 @@ import (
 
 	networking "istio.io/api/networking/v1alpha3"
 	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/networking/util"
 	"istio.io/istio/pkg/config/protocol"
 )
 
@@ -35,6 +36,9 @@ const (
 	ListenerProtocolTCP
 	// ListenerProtocolHTTP is an HTTP listener.
 	ListenerProtocolHTTP
+	// ListenerProtocolAuto is a listener that detects the protocol of each connection. It has an HTTP
+	// filter chain for HTTP/1.x and h2c traffic, and a TCP filter chain for everything else.
+	ListenerProtocolAuto
 
 	// Authn is the name of the authentication plugin passed through the command line
 	Authn = "authn"
@@ -46,14 +50,22 @@ const (
 	Mixer = "mixer"
 )
 
-// ModelProtocolToListenerProtocol converts from a config.Instance to its corresponding plugin.ListenerProtocol
-func ModelProtocolToListenerProtocol(p protocol.Instance) ListenerProtocol {
+// ModelProtocolToListenerProtocol converts from a config.Instance to its corresponding plugin.ListenerProtocol.
+// Ports that do not declare a protocol use ListenerProtocolAuto if protocol sniffing is enabled for the traffic
+// direction, and ListenerProtocolUnknown otherwise.
+func ModelProtocolToListenerProtocol(p protocol.Instance, trafficDirection model.TrafficDirection) ListenerProtocol {
 	switch p {
 	case protocol.HTTP, protocol.HTTP2, protocol.GRPC, protocol.GRPCWeb:
 		return ListenerProtocolHTTP
 	case protocol.TCP, protocol.HTTPS, protocol.TLS,
-		protocol.Mongo, protocol.Redis, protocol.MySQL:
+		protocol.Mongo, protocol.Redis, protocol.MySQL, protocol.Kafka, protocol.Thrift:
 		return ListenerProtocolTCP
+	case protocol.Unsupported:
+		if trafficDirection == model.TrafficDirectionInbound && util.IsProtocolSniffingEnabledForInbound(p) ||
+			trafficDirection == model.TrafficDirectionOutbound && util.IsProtocolSniffingEnabledForOutbound(p) {
+			return ListenerProtocolAuto
+		}
+		return ListenerProtocolUnknown
 	default:
 		return ListenerProtocolUnknown
 	}
@@ -38,6 +38,7 @@ import (
 
 	"istio.io/istio/pilot/pkg/features"
 	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/config/protocol"
 )
 
 const (
@@ -61,6 +62,15 @@ const (
 
 	// SniClusterFilter is the name of the sni_cluster envoy filter
 	SniClusterFilter = "envoy.filters.network.sni_cluster"
+
+	// KafkaBrokerFilter is the name of the kafka_broker envoy filter
+	KafkaBrokerFilter = "envoy.filters.network.kafka_broker"
+
+	// ThriftProxyFilter is the name of the thrift_proxy envoy filter
+	ThriftProxyFilter = "envoy.filters.network.thrift_proxy"
+
+	// ThriftRouterFilter is the name of the terminal filter of the thrift_proxy filter chain
+	ThriftRouterFilter = "envoy.filters.thrift.router"
 	// IstioMetadataKey is the key under which metadata is added to a route or cluster
 	// regarding the virtual service or destination rule used for each
 	IstioMetadataKey = "istio"
@@ -116,12 +126,13 @@ func ConvertAddressToCidr(addr string) *core.CidrRange {
 }
 
 // BuildAddress returns a SocketAddress with the given ip and port or uds.
+// Unix domain sockets are given as unix:///path/to/socket, the prefix is stripped from the pipe path.
 func BuildAddress(bind string, port uint32) *core.Address {
 	if len(bind) > 0 && strings.HasPrefix(bind, model.UnixAddressPrefix) {
 		return &core.Address{
 			Address: &core.Address_Pipe{
 				Pipe: &core.Pipe{
-					Path: bind,
+					Path: strings.TrimPrefix(bind, model.UnixAddressPrefix),
 				},
 			},
 		}
@@ -276,6 +287,16 @@ func IsXDSMarshalingToAnyEnabled(node *model.Proxy) bool {
 	return !features.DisableXDSMarshalingToAny
 }
 
+// IsProtocolSniffingEnabledForInbound checks whether the proxy should detect the protocol of an inbound port.
+func IsProtocolSniffingEnabledForInbound(p protocol.Instance) bool {
+	return features.EnableProtocolSniffingForInbound.Get() && p.IsUnsupported()
+}
+
+// IsProtocolSniffingEnabledForOutbound checks whether the proxy should detect the protocol of an outbound port.
+func IsProtocolSniffingEnabledForOutbound(p protocol.Instance) bool {
+	return features.EnableProtocolSniffingForOutbound.Get() && p.IsUnsupported()
+}
+
 // ResolveHostsInNetworksConfig will go through the Gateways addresses for all
 // networks in the config and if it's not an IP address it will try to lookup
 // that hostname and replace it with the IP address in the config
@@ -95,6 +95,25 @@ func TestConvertAddressToCidr(t *testing.T) {
 	}
 }
 
+func TestBuildAddress(t *testing.T) {
+	aUnix := BuildAddress("unix:///var/run/test/test.sock", 0)
+	if aUnix.GetPipe() == nil {
+		t.Fatalf("BuildAddress() => want Pipe, got %s", aUnix.String())
+	}
+	if aUnix.GetPipe().GetPath() != "/var/run/test/test.sock" {
+		t.Fatalf("BuildAddress() => want path /var/run/test/test.sock, got %s", aUnix.GetPipe().GetPath())
+	}
+
+	aIP := BuildAddress("10.0.0.1", 8080)
+	sock := aIP.GetSocketAddress()
+	if sock == nil {
+		t.Fatalf("BuildAddress() => want SocketAddress, got %s", aIP.String())
+	}
+	if sock.GetAddress() != "10.0.0.1" || sock.GetPortValue() != 8080 {
+		t.Fatalf("BuildAddress() => want 10.0.0.1:8080, got %s:%d", sock.GetAddress(), sock.GetPortValue())
+	}
+}
+
 func TestGetNetworkEndpointAddress(t *testing.T) {
 	neUnix := &model.NetworkEndpoint{
 		Family:  model.AddressFamilyUnix,
@@ -15,6 +15,7 @@
 package v2
 
 import (
+	"context"
 	"errors"
 	"io"
 	"reflect"
@@ -24,6 +25,7 @@ import (
 
 	adminapi "github.com/envoyproxy/go-control-plane/envoy/admin/v2alpha"
 	xdsapi "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
 	ads "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v2"
 	"github.com/gogo/protobuf/types"
 	"google.golang.org/grpc"
@@ -92,6 +94,9 @@ type XdsConnection struct {
 	RouteConfigs map[string]*xdsapi.RouteConfiguration `json:"-"`
 	CDSClusters  []*xdsapi.Cluster
 
+	// lastPush is the push context of the last configuration pushed to the proxy.
+	lastPush *model.PushContext
+
 	// Last nonce sent and ack'd (timestamps) used for debugging
 	ClusterNonceSent, ClusterNonceAcked   string
 	ListenerNonceSent, ListenerNonceAcked string
@@ -106,6 +111,13 @@ type XdsConnection struct {
 	// Both ADS and EDS streams implement this interface
 	stream DiscoveryStream
 
+	// deltaStream is set instead of stream for incremental xDS connections.
+	deltaStream DeltaDiscoveryStream
+
+	// deltaWatches has the per type subscription state of incremental xDS connections,
+	// keyed by type URL.
+	deltaWatches map[string]*deltaWatch
+
 	// Routes is the list of watched Routes.
 	Routes []string
 
@@ -122,9 +134,16 @@ type XdsConnection struct {
 // configDump converts the connection internal state into an Envoy Admin API config dump proto
 // It is used in debugging to create a consistent object for comparison between Envoy and Pilot outputs
 func (s *DiscoveryServer) configDump(conn *XdsConnection) (*adminapi.ConfigDump, error) {
-	dynamicActiveClusters := []*adminapi.ClustersConfigDump_DynamicCluster{}
 	clusters := s.generateRawClusters(conn.modelNode, s.globalPushContext())
+	listeners := s.generateRawListeners(conn, s.globalPushContext())
+	routes := s.generateRawRoutes(conn, s.globalPushContext())
+	return buildConfigDump(clusters, listeners, routes)
+}
 
+// buildConfigDump returns the resources in the form of the Envoy admin API config dump.
+func buildConfigDump(clusters []*xdsapi.Cluster, listeners []*xdsapi.Listener,
+	routes []*xdsapi.RouteConfiguration) (*adminapi.ConfigDump, error) {
+	dynamicActiveClusters := []*adminapi.ClustersConfigDump_DynamicCluster{}
 	for _, cs := range clusters {
 		dynamicActiveClusters = append(dynamicActiveClusters, &adminapi.ClustersConfigDump_DynamicCluster{Cluster: cs})
 	}
@@ -137,7 +156,6 @@ func (s *DiscoveryServer) configDump(conn *XdsConnection) (*adminapi.ConfigDump,
 	}
 
 	dynamicActiveListeners := []*adminapi.ListenersConfigDump_DynamicListener{}
-	listeners := s.generateRawListeners(conn, s.globalPushContext())
 	for _, cs := range listeners {
 		dynamicActiveListeners = append(dynamicActiveListeners, &adminapi.ListenersConfigDump_DynamicListener{Listener: cs})
 	}
@@ -149,7 +167,6 @@ func (s *DiscoveryServer) configDump(conn *XdsConnection) (*adminapi.ConfigDump,
 		return nil, err
 	}
 
-	routes := s.generateRawRoutes(conn, s.globalPushContext())
 	routeConfigAny, _ := types.MarshalAny(&adminapi.RoutesConfigDump{})
 	if len(routes) > 0 {
 		dynamicRouteConfig := []*adminapi.RoutesConfigDump_DynamicRouteConfig{}
@@ -197,6 +214,32 @@ func newXdsConnection(peerAddr string, stream DiscoveryStream) *XdsConnection {
 	}
 }
 
+func newDeltaXdsConnection(peerAddr string, stream DeltaDiscoveryStream) *XdsConnection {
+	return &XdsConnection{
+		pushChannel:  make(chan *XdsEvent),
+		PeerAddr:     peerAddr,
+		Clusters:     []string{},
+		Connect:      time.Now(),
+		deltaStream:  stream,
+		deltaWatches: map[string]*deltaWatch{},
+		LDSListeners: []*xdsapi.Listener{},
+		RouteConfigs: map[string]*xdsapi.RouteConfiguration{},
+	}
+}
+
+// isDelta returns true if the connection uses the incremental xDS protocol.
+func (conn *XdsConnection) isDelta() bool {
+	return conn.deltaStream != nil
+}
+
+// context returns the context of the underlying stream.
+func (conn *XdsConnection) context() context.Context {
+	if conn.deltaStream != nil {
+		return conn.deltaStream.Context()
+	}
+	return conn.stream.Context()
+}
+
 func receiveThread(con *XdsConnection, reqChannel chan *xdsapi.DiscoveryRequest, errP *error) {
 	defer close(reqChannel) // indicates close of the remote side.
 	for {
@@ -236,7 +279,7 @@ func (s *DiscoveryServer) StreamAggregatedResources(stream ads.AggregatedDiscove
 	// check works, since it assumes ClearCache is called (and as such PushContext
 	// is initialized)
 	// InitContext returns immediately if the context was already initialized.
-	err := s.globalPushContext().InitContext(s.Env)
+	err := s.globalPushContext().InitContext(s.Env, nil, nil)
 	if err != nil {
 		// Error accessing the data - log and close, maybe a different pilot replica
 		// has more luck
@@ -267,7 +310,7 @@ func (s *DiscoveryServer) StreamAggregatedResources(stream ads.AggregatedDiscove
 				// Remote side closed connection.
 				return receiveError
 			}
-			err = s.initConnectionNode(discReq, con)
+			err = s.initConnectionNode(discReq.Node, con)
 			if err != nil {
 				return err
 			}
@@ -464,7 +507,7 @@ func (s *DiscoveryServer) StreamAggregatedResources(stream ads.AggregatedDiscove
 }
 
 // update the node associated with the connection, after receiving a a packet from envoy.
-func (s *DiscoveryServer) initConnectionNode(discReq *xdsapi.DiscoveryRequest, con *XdsConnection) error {
+func (s *DiscoveryServer) initConnectionNode(node *core.Node, con *XdsConnection) error {
 	con.mu.RLock() // may not be needed - once per connection, but locking for consistency.
 	if con.modelNode != nil {
 		con.mu.RUnlock()
@@ -472,10 +515,10 @@ func (s *DiscoveryServer) initConnectionNode(discReq *xdsapi.DiscoveryRequest, c
 	}
 	con.mu.RUnlock()
 
-	if discReq.Node == nil || discReq.Node.Id == "" {
+	if node == nil || node.Id == "" {
 		return errors.New("missing node id")
 	}
-	nt, err := model.ParseServiceNodeWithMetadata(discReq.Node.Id, model.ParseMetadata(discReq.Node.Metadata))
+	nt, err := model.ParseServiceNodeWithMetadata(node.Id, model.ParseMetadata(node.Metadata))
 	if err != nil {
 		return err
 	}
@@ -496,7 +539,7 @@ func (s *DiscoveryServer) initConnectionNode(discReq *xdsapi.DiscoveryRequest, c
 	// This is not preferable as only the connected Pilot is aware of this proxies location, but it
 	// can still help provide some client-side Envoy context when load balancing based on location.
 	if util.IsLocalityEmpty(nt.Locality) {
-		nt.Locality = discReq.Node.Locality
+		nt.Locality = node.Locality
 	}
 
 	if err := nt.SetWorkloadLabels(s.Env); err != nil {
@@ -510,18 +553,13 @@ func (s *DiscoveryServer) initConnectionNode(discReq *xdsapi.DiscoveryRequest, c
 	con.modelNode = nt
 	if con.ConID == "" {
 		// first request
-		con.ConID = connectionID(discReq.Node.Id)
+		con.ConID = connectionID(node.Id)
 	}
 	con.mu.Unlock()
 
 	return nil
 }
 
-// DeltaAggregatedResources is not implemented.
-func (s *DiscoveryServer) DeltaAggregatedResources(stream ads.AggregatedDiscoveryService_DeltaAggregatedResourcesServer) error {
-	return status.Errorf(codes.Unimplemented, "not implemented")
-}
-
 // Compute and send the new configuration for a connection. This is blocking and may be slow
 // for large configs. The method will hold a lock on con.pushMutex.
 func (s *DiscoveryServer) pushConnection(con *XdsConnection, pushEv *XdsEvent) error {
@@ -49,11 +49,18 @@ func (s *DiscoveryServer) pushCds(con *XdsConnection, push *model.PushContext, v
 	// TODO: Modify interface to take services, and config instead of making library query registry
 	rawClusters := s.generateRawClusters(con.modelNode, push)
 
+	con.mu.Lock()
+	con.lastPush = push
 	if s.DebugConfigs {
 		con.CDSClusters = rawClusters
 	}
-	response := con.clusters(rawClusters)
-	err := con.send(response)
+	con.mu.Unlock()
+	var err error
+	if con.isDelta() {
+		err = con.sendDelta(ClusterType, clusterResources(rawClusters), version, true)
+	} else {
+		err = con.send(con.clusters(rawClusters))
+	}
 	if err != nil {
 		adsLog.Warnf("CDS: Send failure %s: %v", con.ConID, err)
 		recordSendError(cdsSendErrPushes, err)
@@ -64,6 +64,7 @@ func (s *DiscoveryServer) InitDebug(mux *http.ServeMux, sctl *aggregate.Controll
 
 	mux.HandleFunc("/debug/authenticationz", s.authenticationz)
 	mux.HandleFunc("/debug/config_dump", s.ConfigDump)
+	mux.HandleFunc("/debug/config_dryrun", s.configDryRun)
 	mux.HandleFunc("/debug/push_status", s.PushStatusHandler)
 }
 
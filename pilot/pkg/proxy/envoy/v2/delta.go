@@ -0,0 +1,399 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"errors"
+	"hash/fnv"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	xdsapi "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	ads "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v2"
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// DeltaDiscoveryStream is the incremental xDS counterpart of DiscoveryStream.
+type DeltaDiscoveryStream interface {
+	Send(*xdsapi.DeltaDiscoveryResponse) error
+	Recv() (*xdsapi.DeltaDiscoveryRequest, error)
+	grpc.ServerStream
+}
+
+// deltaWatch tracks the state of a single resource type on an incremental xDS connection.
+type deltaWatch struct {
+	// subscribed is the set of resource names the client explicitly asked for. It is not used
+	// for CDS and LDS, where pilot always sends all resources for the proxy.
+	subscribed map[string]struct{}
+
+	// versions has the version of each resource last sent to the client, keyed by resource name.
+	// It is seeded from the initial_resource_versions of the first request, so a reconnecting
+	// client only receives what changed while it was away.
+	versions map[string]string
+
+	// sent is set once at least one response was sent for the type. The first response is
+	// always sent, even if empty, so the client can finish its initial fetch.
+	sent bool
+}
+
+func newDeltaWatch() *deltaWatch {
+	return &deltaWatch{
+		subscribed: map[string]struct{}{},
+		versions:   map[string]string{},
+	}
+}
+
+// names returns the sorted list of explicitly subscribed resources.
+func (w *deltaWatch) names() []string {
+	out := make([]string, 0, len(w.subscribed))
+	for n := range w.subscribed {
+		out = append(out, n)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func receiveDeltaThread(con *XdsConnection, reqChannel chan *xdsapi.DeltaDiscoveryRequest, errP *error) {
+	defer close(reqChannel) // indicates close of the remote side.
+	for {
+		req, err := con.deltaStream.Recv()
+		if err != nil {
+			if status.Code(err) == codes.Canceled || err == io.EOF {
+				adsLog.Infof("ADS:DELTA: %q %s terminated %v", con.PeerAddr, con.ConID, err)
+				return
+			}
+			*errP = err
+			adsLog.Errorf("ADS:DELTA: %q %s terminated with error: %v", con.PeerAddr, con.ConID, err)
+			totalXDSInternalErrors.Increment()
+			return
+		}
+		select {
+		case reqChannel <- req:
+		case <-con.deltaStream.Context().Done():
+			adsLog.Errorf("ADS:DELTA: %q %s terminated with stream closed", con.PeerAddr, con.ConID)
+			return
+		}
+	}
+}
+
+// DeltaAggregatedResources implements the incremental ADS interface. Connections share the
+// push queue, debouncing and generators with StreamAggregatedResources, but only resources
+// whose content changed since the last response are sent, together with the names of
+// resources that no longer exist.
+func (s *DiscoveryServer) DeltaAggregatedResources(stream ads.AggregatedDiscoveryService_DeltaAggregatedResourcesServer) error {
+	peerInfo, ok := peer.FromContext(stream.Context())
+	peerAddr := "0.0.0.0"
+	if ok {
+		peerAddr = peerInfo.Addr.String()
+	}
+
+	err := s.globalPushContext().InitContext(s.Env, nil, nil)
+	if err != nil {
+		adsLog.Warnf("Error reading config %v", err)
+		return err
+	}
+	con := newDeltaXdsConnection(peerAddr, stream)
+
+	var receiveError error
+	reqChannel := make(chan *xdsapi.DeltaDiscoveryRequest, 1)
+	go receiveDeltaThread(con, reqChannel, &receiveError)
+
+	for {
+		select {
+		case discReq, ok := <-reqChannel:
+			if !ok {
+				// Remote side closed connection.
+				return receiveError
+			}
+			err = s.initConnectionNode(discReq.Node, con)
+			if err != nil {
+				return err
+			}
+
+			if err := s.processDeltaRequest(con, discReq); err != nil {
+				return err
+			}
+
+			con.mu.Lock()
+			if !con.added {
+				con.added = true
+				con.mu.Unlock()
+				s.addCon(con.ConID, con)
+				defer s.removeCon(con.ConID, con)
+			} else {
+				con.mu.Unlock()
+			}
+		case pushEv := <-con.pushChannel:
+			err := s.pushConnection(con, pushEv)
+			pushEv.done()
+			if err != nil {
+				return nil
+			}
+		}
+	}
+}
+
+// processDeltaRequest handles ACK/NACKs and subscription changes for a single incremental request.
+func (s *DiscoveryServer) processDeltaRequest(con *XdsConnection, req *xdsapi.DeltaDiscoveryRequest) error {
+	con.mu.Lock()
+	w, exists := con.deltaWatches[req.TypeUrl]
+	if !exists {
+		w = newDeltaWatch()
+		con.deltaWatches[req.TypeUrl] = w
+		for name, version := range req.InitialResourceVersions {
+			w.versions[name] = version
+		}
+	}
+	con.mu.Unlock()
+
+	if req.ErrorDetail != nil {
+		adsLog.Warnf("ADS:DELTA: ACK ERROR %v %s (%s) %v", con.PeerAddr, con.ConID, con.modelNode.ID, req.String())
+		errCode := codes.Code(req.ErrorDetail.Code)
+		switch req.TypeUrl {
+		case ClusterType:
+			incrementXDSRejects(cdsReject, con.modelNode.ID, errCode.String())
+		case ListenerType:
+			incrementXDSRejects(ldsReject, con.modelNode.ID, errCode.String())
+		case RouteType:
+			incrementXDSRejects(rdsReject, con.modelNode.ID, errCode.String())
+		case EndpointType:
+			incrementXDSRejects(edsReject, con.modelNode.ID, errCode.String())
+		}
+		// The client kept its previous state, which we no longer know precisely. Invalidate the
+		// sent versions so the next push resends everything of this type, but keep the names so
+		// that the resources deleted meanwhile are still reported as removed.
+		con.mu.Lock()
+		for name := range w.versions {
+			w.versions[name] = ""
+		}
+		con.mu.Unlock()
+		return nil
+	}
+
+	if exists && len(req.ResourceNamesSubscribe) == 0 && len(req.ResourceNamesUnsubscribe) == 0 {
+		// No change in subscriptions, this is an ACK.
+		con.mu.Lock()
+		switch req.TypeUrl {
+		case ClusterType:
+			con.ClusterNonceAcked = req.ResponseNonce
+		case ListenerType:
+			con.ListenerNonceAcked = req.ResponseNonce
+		case RouteType:
+			con.RouteNonceAcked = req.ResponseNonce
+		case EndpointType:
+			con.EndpointNonceAcked = req.ResponseNonce
+		}
+		con.mu.Unlock()
+		adsLog.Debugf("ADS:DELTA: ACK %s %s (%s) %s %s", con.PeerAddr, con.ConID, con.modelNode.ID, req.TypeUrl, req.ResponseNonce)
+		return nil
+	}
+
+	con.mu.Lock()
+	for _, name := range req.ResourceNamesSubscribe {
+		w.subscribed[name] = struct{}{}
+	}
+	for _, name := range req.ResourceNamesUnsubscribe {
+		delete(w.subscribed, name)
+		// The client dropped the resource, no need to tell it about the removal.
+		delete(w.versions, name)
+	}
+	names := w.names()
+	con.mu.Unlock()
+
+	adsLog.Debugf("ADS:DELTA: REQ %s %s %s subscribe:%d unsubscribe:%d", con.PeerAddr, con.ConID, req.TypeUrl,
+		len(req.ResourceNamesSubscribe), len(req.ResourceNamesUnsubscribe))
+
+	push := s.globalPushContext()
+	switch req.TypeUrl {
+	case ClusterType:
+		con.CDSWatch = true
+		return s.pushCds(con, push, versionInfo())
+	case ListenerType:
+		con.LDSWatch = true
+		return s.pushLds(con, push, versionInfo())
+	case RouteType:
+		con.Routes = names
+		return s.pushRoute(con, push, versionInfo())
+	case EndpointType:
+		for _, cn := range con.Clusters {
+			s.removeEdsCon(cn, con.ConID)
+		}
+		for _, cn := range names {
+			s.addEdsCon(cn, con.ConID, con)
+		}
+		con.Clusters = names
+		return s.pushEds(push, con, versionInfo(), nil)
+	default:
+		adsLog.Warnf("ADS:DELTA: Unknown watched resources %s", req.String())
+	}
+	return nil
+}
+
+// resourceVersion computes a version for a marshaled resource, derived from its content. Two
+// resources with the same content have the same version, which is used to skip sending
+// resources the client already has.
+func resourceVersion(b []byte) string {
+	h := fnv.New64a()
+	_, _ = h.Write(b)
+	return strconv.FormatUint(h.Sum64(), 16)
+}
+
+// deltaResource wraps a generated resource for an incremental response.
+func deltaResource(name string, msg proto.Message) *xdsapi.Resource {
+	r, _ := types.MarshalAny(msg)
+	return &xdsapi.Resource{
+		Name:     name,
+		Version:  resourceVersion(r.Value),
+		Resource: r,
+	}
+}
+
+func clusterResources(clusters []*xdsapi.Cluster) []*xdsapi.Resource {
+	out := make([]*xdsapi.Resource, 0, len(clusters))
+	for _, c := range clusters {
+		out = append(out, deltaResource(c.Name, c))
+	}
+	return out
+}
+
+func listenerResources(listeners []*xdsapi.Listener) []*xdsapi.Resource {
+	out := make([]*xdsapi.Resource, 0, len(listeners))
+	for _, l := range listeners {
+		if l == nil {
+			continue
+		}
+		out = append(out, deltaResource(l.Name, l))
+	}
+	return out
+}
+
+func routeResources(routes []*xdsapi.RouteConfiguration) []*xdsapi.Resource {
+	out := make([]*xdsapi.Resource, 0, len(routes))
+	for _, r := range routes {
+		out = append(out, deltaResource(r.Name, r))
+	}
+	return out
+}
+
+func loadAssignmentResources(loadAssignments []*xdsapi.ClusterLoadAssignment) []*xdsapi.Resource {
+	out := make([]*xdsapi.Resource, 0, len(loadAssignments))
+	for _, l := range loadAssignments {
+		out = append(out, deltaResource(l.ClusterName, l))
+	}
+	return out
+}
+
+// sendDelta sends the resources that changed since the last response for the type. If complete
+// is set, resources is the full set the client should have, and previously sent resources
+// missing from it are reported as removed. Incremental EDS pushes only carry the updated
+// clusters and are not complete.
+func (conn *XdsConnection) sendDelta(typeURL string, resources []*xdsapi.Resource, version string, complete bool) error {
+	conn.mu.Lock()
+	w := conn.deltaWatches[typeURL]
+	if w == nil {
+		// Pushes for types the client never subscribed to are dropped.
+		conn.mu.Unlock()
+		return nil
+	}
+	changed := make([]*xdsapi.Resource, 0, len(resources))
+	present := make(map[string]struct{}, len(resources))
+	for _, r := range resources {
+		present[r.Name] = struct{}{}
+		if w.versions[r.Name] == r.Version {
+			continue
+		}
+		changed = append(changed, r)
+	}
+	var removed []string
+	if complete {
+		for name := range w.versions {
+			if _, f := present[name]; !f {
+				removed = append(removed, name)
+			}
+		}
+		sort.Strings(removed)
+	}
+	skip := w.sent && len(changed) == 0 && len(removed) == 0
+	conn.mu.Unlock()
+
+	if skip {
+		adsLog.Debugf("ADS:DELTA: no change for node:%s type:%s", conn.ConID, typeURL)
+		return nil
+	}
+
+	res := &xdsapi.DeltaDiscoveryResponse{
+		TypeUrl:           typeURL,
+		SystemVersionInfo: version,
+		Resources:         changed,
+		RemovedResources:  removed,
+		Nonce:             nonce(),
+	}
+	if err := conn.sendDeltaResponse(res); err != nil {
+		return err
+	}
+
+	conn.mu.Lock()
+	w.sent = true
+	for _, r := range changed {
+		w.versions[r.Name] = r.Version
+	}
+	for _, name := range removed {
+		delete(w.versions, name)
+	}
+	conn.mu.Unlock()
+
+	deltaResourcesSent.Record(float64(len(changed)))
+	deltaResourcesRemoved.Record(float64(len(removed)))
+	return nil
+}
+
+// Send an incremental response with timeout
+func (conn *XdsConnection) sendDeltaResponse(res *xdsapi.DeltaDiscoveryResponse) error {
+	done := make(chan error, 1)
+	t := time.NewTimer(SendTimeout)
+	go func() {
+		err := conn.deltaStream.Send(res)
+		done <- err
+		conn.mu.Lock()
+		switch res.TypeUrl {
+		case ClusterType:
+			conn.ClusterNonceSent = res.Nonce
+		case ListenerType:
+			conn.ListenerNonceSent = res.Nonce
+		case RouteType:
+			conn.RouteNonceSent = res.Nonce
+			conn.RouteVersionInfoSent = res.SystemVersionInfo
+		case EndpointType:
+			conn.EndpointNonceSent = res.Nonce
+		}
+		conn.mu.Unlock()
+	}()
+	select {
+	case <-t.C:
+		adsLog.Infof("Timeout writing %s", conn.ConID)
+		xdsResponseWriteTimeouts.Increment()
+		return errors.New("timeout sending")
+	case err := <-done:
+		t.Stop()
+		return err
+	}
+}
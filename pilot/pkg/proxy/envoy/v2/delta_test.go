@@ -0,0 +1,289 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	xdsapi "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	ads "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v2"
+	"google.golang.org/grpc"
+
+	rpc "istio.io/gogo-genproto/googleapis/google/rpc"
+
+	"istio.io/istio/pilot/pkg/model"
+	v2 "istio.io/istio/pilot/pkg/proxy/envoy/v2"
+	"istio.io/istio/pkg/config/host"
+	"istio.io/istio/tests/util"
+)
+
+// deltaClient is an incremental ADS client. Responses are read by a single goroutine, so a
+// receive that times out does not lose the next response.
+type deltaClient struct {
+	stream    ads.AggregatedDiscoveryService_DeltaAggregatedResourcesClient
+	responses chan *xdsapi.DeltaDiscoveryResponse
+}
+
+func connectDeltaADS(url string) (*deltaClient, util.TearDownFunc, error) {
+	conn, err := grpc.Dial(url, grpc.WithInsecure(), grpc.WithBlock())
+	if err != nil {
+		return nil, nil, fmt.Errorf("GRPC dial failed: %s", err)
+	}
+	xds := ads.NewAggregatedDiscoveryServiceClient(conn)
+	str, err := xds.DeltaAggregatedResources(context.Background())
+	if err != nil {
+		return nil, nil, fmt.Errorf("delta stream failed: %s", err)
+	}
+	c := &deltaClient{
+		stream:    str,
+		responses: make(chan *xdsapi.DeltaDiscoveryResponse, 10),
+	}
+	go func() {
+		defer close(c.responses)
+		for {
+			res, err := str.Recv()
+			if err != nil {
+				return
+			}
+			c.responses <- res
+		}
+	}()
+
+	return c, func() {
+		_ = str.CloseSend()
+		_ = conn.Close()
+	}, nil
+}
+
+// receive returns the next response, or nil if none was received within the timeout.
+func (c *deltaClient) receive(to time.Duration) *xdsapi.DeltaDiscoveryResponse {
+	select {
+	case res := <-c.responses:
+		return res
+	case <-time.After(to):
+		return nil
+	}
+}
+
+func (c *deltaClient) send(node, typeURL, nonce string, subscribe, unsubscribe []string) error {
+	return c.stream.Send(&xdsapi.DeltaDiscoveryRequest{
+		Node: &core.Node{
+			Id:       node,
+			Metadata: nodeMetadata,
+		},
+		TypeUrl:                  typeURL,
+		ResponseNonce:            nonce,
+		ResourceNamesSubscribe:   subscribe,
+		ResourceNamesUnsubscribe: unsubscribe,
+	})
+}
+
+func resourceNames(res *xdsapi.DeltaDiscoveryResponse) map[string]bool {
+	out := map[string]bool{}
+	for _, r := range res.Resources {
+		out[r.Name] = true
+	}
+	return out
+}
+
+func TestDeltaAdsClusters(t *testing.T) {
+	server, tearDown := initLocalPilotTestEnv(t)
+	defer tearDown()
+
+	c, cancel, err := connectDeltaADS(util.MockPilotGrpcAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cancel()
+
+	node := sidecarID("1.1.1.1", "app3")
+	if err := c.send(node, v2.ClusterType, "", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	res := c.receive(5 * time.Second)
+	if res == nil {
+		t.Fatal("No initial CDS response")
+	}
+	if len(res.Resources) == 0 {
+		t.Fatal("Expected clusters in initial CDS response")
+	}
+	if len(res.RemovedResources) != 0 {
+		t.Errorf("Unexpected removed resources %v", res.RemovedResources)
+	}
+	initial := len(res.Resources)
+	if err := c.send(node, v2.ClusterType, res.Nonce, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// A push without changes must not resend the clusters.
+	v2.AdsPushAll(server.EnvoyXdsServer)
+	if res := c.receive(time.Second); res != nil {
+		t.Fatalf("Unexpected CDS response without changes: %d resources", len(res.Resources))
+	}
+
+	server.EnvoyXdsServer.MemRegistry.AddService("delta.default.svc.cluster.local", &model.Service{
+		Hostname: "delta.default.svc.cluster.local",
+		Address:  "10.12.0.1",
+		Ports:    testPorts(0),
+	})
+	server.EnvoyXdsServer.ClearCache()
+	res = c.receive(5 * time.Second)
+	if res == nil {
+		t.Fatal("No CDS response after adding a service")
+	}
+	if len(res.Resources) == 0 || len(res.Resources) >= initial {
+		t.Fatalf("Expected only the new clusters, got %d of %d", len(res.Resources), initial)
+	}
+	for name := range resourceNames(res) {
+		_, _, hostname, _ := model.ParseSubsetKey(name)
+		if hostname != "delta.default.svc.cluster.local" {
+			t.Errorf("Unexpected cluster %s", name)
+		}
+	}
+}
+
+func TestDeltaAdsNackThenRemoval(t *testing.T) {
+	server, tearDown := initLocalPilotTestEnv(t)
+	defer tearDown()
+
+	c, cancel, err := connectDeltaADS(util.MockPilotGrpcAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cancel()
+
+	node := sidecarID("1.1.1.1", "app3")
+	if err := c.send(node, v2.ClusterType, "", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	res := c.receive(5 * time.Second)
+	if res == nil {
+		t.Fatal("No initial CDS response")
+	}
+	if err := c.send(node, v2.ClusterType, res.Nonce, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	hostname := host.Name("deltanack.default.svc.cluster.local")
+	server.EnvoyXdsServer.MemRegistry.AddService(hostname, &model.Service{
+		Hostname: hostname,
+		Address:  "10.12.0.3",
+		Ports:    testPorts(0),
+	})
+	server.EnvoyXdsServer.ClearCache()
+	res = c.receive(5 * time.Second)
+	if res == nil {
+		t.Fatal("No CDS response after adding a service")
+	}
+	added := resourceNames(res)
+
+	// The client rejects the response, and keeps the clusters it had.
+	if err := c.stream.Send(&xdsapi.DeltaDiscoveryRequest{
+		Node:          &core.Node{Id: node, Metadata: nodeMetadata},
+		TypeUrl:       v2.ClusterType,
+		ResponseNonce: res.Nonce,
+		ErrorDetail:   &rpc.Status{Message: "NOPE!"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(200 * time.Millisecond)
+
+	server.EnvoyXdsServer.MemRegistry.RemoveService(hostname)
+	server.EnvoyXdsServer.ClearCache()
+	res = c.receive(5 * time.Second)
+	if res == nil {
+		t.Fatal("No CDS response after removing the service")
+	}
+	removed := map[string]bool{}
+	for _, name := range res.RemovedResources {
+		removed[name] = true
+	}
+	for name := range added {
+		if !removed[name] {
+			t.Errorf("Cluster %s of the removed service is not reported as removed: %v", name, res.RemovedResources)
+		}
+	}
+	// The clusters the client still has are resent after the NACK.
+	if len(res.Resources) == 0 {
+		t.Error("Expected the clusters to be resent after the NACK")
+	}
+}
+
+func TestDeltaAdsEndpoints(t *testing.T) {
+	server, tearDown := initLocalPilotTestEnv(t)
+	defer tearDown()
+
+	server.EnvoyXdsServer.MemRegistry.AddService("deltaeds.default.svc.cluster.local", &model.Service{
+		Hostname: "deltaeds.default.svc.cluster.local",
+		Address:  "10.12.0.2",
+		Ports:    testPorts(0),
+	})
+	_ = server.EnvoyXdsServer.MemRegistry.AddEndpoint("deltaeds.default.svc.cluster.local",
+		"http-main", 2080, "10.2.0.1", 1080)
+	server.EnvoyXdsServer.ClearCache()
+	time.Sleep(time.Millisecond * 200)
+
+	c, cancel, err := connectDeltaADS(util.MockPilotGrpcAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cancel()
+
+	node := sidecarID("1.1.1.1", "app3")
+	cluster1 := "outbound|2080||deltaeds.default.svc.cluster.local"
+	cluster2 := "outbound|80||hello.default.svc.cluster.local"
+
+	if err := c.send(node, v2.EndpointType, "", []string{cluster1}, nil); err != nil {
+		t.Fatal(err)
+	}
+	res := c.receive(5 * time.Second)
+	if res == nil {
+		t.Fatal("No EDS response")
+	}
+	if names := resourceNames(res); len(names) != 1 || !names[cluster1] {
+		t.Fatalf("Expected %s, got %v", cluster1, names)
+	}
+
+	// Subscribing to one more cluster only sends the new one.
+	if err := c.send(node, v2.EndpointType, res.Nonce, []string{cluster2}, nil); err != nil {
+		t.Fatal(err)
+	}
+	res = c.receive(5 * time.Second)
+	if res == nil {
+		t.Fatal("No EDS response after subscribing")
+	}
+	if names := resourceNames(res); len(names) != 1 || !names[cluster2] {
+		t.Fatalf("Expected %s, got %v", cluster2, names)
+	}
+
+	// An endpoint update only sends the affected cluster.
+	if err := c.send(node, v2.EndpointType, res.Nonce, nil, []string{cluster2}); err != nil {
+		t.Fatal(err)
+	}
+	_ = server.EnvoyXdsServer.MemRegistry.AddEndpoint("deltaeds.default.svc.cluster.local",
+		"http-main", 2080, "10.2.0.2", 1080)
+	v2.AdsPushAll(server.EnvoyXdsServer)
+	res = c.receive(5 * time.Second)
+	if res == nil {
+		t.Fatal("No EDS response after endpoint update")
+	}
+	if names := resourceNames(res); len(names) != 1 || !names[cluster1] {
+		t.Fatalf("Expected %s, got %v", cluster1, names)
+	}
+}
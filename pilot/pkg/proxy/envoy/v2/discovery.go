@@ -196,7 +196,15 @@ func NewDiscoveryServer(
 	if configCache != nil {
 		// TODO: changes should not trigger a full recompute of LDS/RDS/CDS/EDS
 		// (especially mixerclient HTTP and quota)
-		configHandler := func(model.Config, model.Event) { out.clearCache() }
+		// The changed config is tracked, so that the push context only recomputes the indexes depending on it.
+		configHandler := func(c model.Config, e model.Event) {
+			out.ConfigUpdate(model.UpdateRequest{
+				Full: true,
+				ConfigsUpdated: map[model.ConfigKey]struct{}{
+					{Type: c.Type, Name: c.Name, Namespace: c.Namespace}: {},
+				},
+			})
+		}
 		for _, descriptor := range model.IstioConfigTypes {
 			configCache.RegisterEventHandler(descriptor.Type, configHandler)
 		}
@@ -288,7 +296,7 @@ func (s *DiscoveryServer) Push(req *model.UpdateRequest, edsUpdates map[string]s
 	// saved.
 	t0 := time.Now()
 	push := model.NewPushContext()
-	err := push.InitContext(s.Env)
+	err := push.InitContext(s.Env, pc, req)
 	if err != nil {
 		adsLog.Errorf("XDS: Failed to update services: %v", err)
 		// We can't push if we can't read the data - stick with previous version.
@@ -448,6 +456,14 @@ func doSendPushes(stopCh <-chan struct{}, semaphore chan struct{}, queue *PushQu
 
 			// Get the next proxy to push. This will block if there are no updates required.
 			client, info := queue.Dequeue()
+			done := doneFunc
+			if info.full {
+				// Full pushes are limited, to keep a part of the semaphore for EDS pushes.
+				done = func() {
+					queue.FullPushDone()
+					doneFunc()
+				}
+			}
 
 			proxiesQueueTime.Record(time.Since(info.start).Seconds())
 
@@ -464,12 +480,12 @@ func doSendPushes(stopCh <-chan struct{}, semaphore chan struct{}, queue *PushQu
 				case client.pushChannel <- &XdsEvent{
 					push:               info.push,
 					edsUpdatedServices: edsUpdates,
-					done:               doneFunc,
+					done:               done,
 					start:              info.start,
 				}:
 					return
-				case <-client.stream.Context().Done(): // grpc stream was closed
-					doneFunc()
+				case <-client.context().Done(): // grpc stream was closed
+					done()
 					adsLog.Infof("Client closed connection %v", client.ConID)
 				}
 			}()
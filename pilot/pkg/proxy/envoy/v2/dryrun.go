@@ -0,0 +1,442 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	xdsapi "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	http_conn "github.com/envoyproxy/go-control-plane/envoy/config/filter/network/http_connection_manager/v2"
+	xdsutil "github.com/envoyproxy/go-control-plane/pkg/util"
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/types"
+
+	"istio.io/istio/pilot/pkg/config/kube/crd"
+	"istio.io/istio/pilot/pkg/model"
+)
+
+var errReadOnlyStore = errors.New("the dry run config store is read only")
+
+// ConfigDryRun is the result of a dry run of candidate Istio configs for a proxy. It lists the xDS resources
+// that would be added, removed or modified if the configs were applied, and holds the current and the
+// proposed config dumps, in the form of the Envoy admin API config dump, for detailed comparisons.
+type ConfigDryRun struct {
+	ProxyID   string          `json:"proxy"`
+	Listeners ResourceDiff    `json:"listeners"`
+	Routes    ResourceDiff    `json:"routes"`
+	Clusters  ResourceDiff    `json:"clusters"`
+	Current   json.RawMessage `json:"current"`
+	Proposed  json.RawMessage `json:"proposed"`
+}
+
+// ResourceDiff holds the names of the xDS resources of one type that differ between two configurations.
+type ResourceDiff struct {
+	Added    []string `json:"added,omitempty"`
+	Removed  []string `json:"removed,omitempty"`
+	Modified []string `json:"modified,omitempty"`
+}
+
+// Empty returns true if the resources are the same in both configurations.
+func (d ResourceDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Modified) == 0
+}
+
+// xdsResources are the xDS resources generated for a proxy.
+type xdsResources struct {
+	listeners []*xdsapi.Listener
+	routes    []*xdsapi.RouteConfiguration
+	clusters  []*xdsapi.Cluster
+}
+
+// configDryRun overlays the candidate configs posted in the request body on the config store, regenerates the
+// xDS resources of a proxy with them and returns the differences with the resources the proxy currently has.
+// It is mapped to /debug/config_dryrun?proxyID=<id>.
+//
+// Only the config store is overlaid: the candidate ServiceEntries do not add services to the registry, and
+// endpoints are not compared.
+func (s *DiscoveryServer) configDryRun(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		_, _ = w.Write([]byte("The candidate configs must be posted"))
+		return
+	}
+	proxyID := req.URL.Query().Get("proxyID")
+	if proxyID == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte("You must provide a proxyID in the query string"))
+		return
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = fmt.Fprintf(w, "unable to read the candidate configs: %v", err)
+		return
+	}
+	candidates, _, err := crd.ParseInputs(string(body))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = fmt.Fprintf(w, "invalid candidate configs: %v", err)
+		return
+	}
+
+	con := connectionForProxy(proxyID)
+	if con == nil {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte("Proxy not connected to this Pilot instance"))
+		return
+	}
+
+	result, err := s.dryRun(con, candidates)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(err.Error()))
+		return
+	}
+	out, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(err.Error()))
+		return
+	}
+	w.Header().Add("Content-Type", "application/json")
+	_, _ = w.Write(out)
+}
+
+// connectionForProxy returns the most recent connection of the proxy, or nil if it is not connected.
+func connectionForProxy(proxyID string) *XdsConnection {
+	adsClientsMutex.RLock()
+	defer adsClientsMutex.RUnlock()
+	connections := adsSidecarIDConnectionsMap[proxyID]
+	mostRecent := ""
+	for key := range connections {
+		if mostRecent == "" || key > mostRecent {
+			mostRecent = key
+		}
+	}
+	if mostRecent == "" {
+		return nil
+	}
+	return connections[mostRecent]
+}
+
+func (s *DiscoveryServer) dryRun(con *XdsConnection, candidates []model.Config) (*ConfigDryRun, error) {
+	current, node, err := s.currentDryRunResources(con)
+	if err != nil {
+		return nil, fmt.Errorf("unable to generate the current config: %v", err)
+	}
+
+	store, err := newOverlayConfigStore(s.Env.IstioConfigStore, candidates, node)
+	if err != nil {
+		return nil, err
+	}
+	env := *s.Env
+	env.IstioConfigStore = model.MakeIstioStore(store)
+	push := model.NewPushContext()
+	if err := push.InitContext(&env, nil, nil); err != nil {
+		return nil, fmt.Errorf("unable to initialize the push context with the candidate configs: %v", err)
+	}
+	proposed, err := s.generateDryRunResources(&env, push, node, func(listeners []*xdsapi.Listener) []string {
+		return proposedRouteNames(current, listeners)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to generate the proposed config: %v", err)
+	}
+
+	result := &ConfigDryRun{
+		ProxyID:   node.ID,
+		Listeners: diffResources(listenersByName(current.listeners), listenersByName(proposed.listeners)),
+		Routes:    diffResources(routesByName(current.routes), routesByName(proposed.routes)),
+		Clusters:  diffResources(clustersByName(current.clusters), clustersByName(proposed.clusters)),
+	}
+	if result.Current, err = marshalConfigDump(current); err != nil {
+		return nil, err
+	}
+	if result.Proposed, err = marshalConfigDump(proposed); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// currentDryRunResources returns the xDS resources the proxy last received, along with a copy of the proxy.
+// The resources are the snapshots kept for /debug/adsz when they are enabled, otherwise they are regenerated
+// from the push context of the last push to the proxy.
+func (s *DiscoveryServer) currentDryRunResources(con *XdsConnection) (*xdsResources, *model.Proxy, error) {
+	con.mu.RLock()
+	if con.modelNode == nil {
+		con.mu.RUnlock()
+		return nil, nil, fmt.Errorf("proxy %s is not initialized", con.ConID)
+	}
+	node := *con.modelNode
+	push := con.lastPush
+	routeNames := append([]string(nil), con.Routes...)
+	var snapshot *xdsResources
+	if s.DebugConfigs && push != nil {
+		snapshot = &xdsResources{
+			listeners: append([]*xdsapi.Listener(nil), con.LDSListeners...),
+			clusters:  append([]*xdsapi.Cluster(nil), con.CDSClusters...),
+		}
+		for _, name := range routeNames {
+			if r, found := con.RouteConfigs[name]; found {
+				snapshot.routes = append(snapshot.routes, r)
+			}
+		}
+	}
+	con.mu.RUnlock()
+
+	if snapshot != nil {
+		return snapshot, &node, nil
+	}
+	if push == nil {
+		// nothing was pushed to the proxy yet
+		return &xdsResources{}, &node, nil
+	}
+	current, err := s.generateDryRunResources(s.Env, push, &node, func([]*xdsapi.Listener) []string {
+		return routeNames
+	})
+	return current, &node, err
+}
+
+// generateDryRunResources generates the xDS resources of the proxy for the push context. Unlike a push, the
+// proxy is copied so that its sidecar scope is left untouched, and invalid resources are reported as errors.
+// The routes generated are the ones named by routeNames for the generated listeners.
+func (s *DiscoveryServer) generateDryRunResources(env *model.Environment, push *model.PushContext,
+	proxy *model.Proxy, routeNames func([]*xdsapi.Listener) []string) (*xdsResources, error) {
+	node := *proxy
+	node.SetSidecarScope(push)
+
+	out := &xdsResources{
+		listeners: s.ConfigGenerator.BuildListeners(env, &node, push),
+		clusters:  s.ConfigGenerator.BuildClusters(env, &node, push),
+	}
+	for _, routeName := range routeNames(out.listeners) {
+		if r := s.ConfigGenerator.BuildHTTPRoutes(env, &node, push, routeName); r != nil {
+			out.routes = append(out.routes, r)
+		}
+	}
+
+	for _, l := range out.listeners {
+		if err := l.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid listener %s: %v", l.Name, err)
+		}
+	}
+	for _, r := range out.routes {
+		if err := r.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid route %s: %v", r.Name, err)
+		}
+	}
+	for _, c := range out.clusters {
+		if err := c.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid cluster %s: %v", c.Name, err)
+		}
+	}
+	return out, nil
+}
+
+// proposedRouteNames returns the names of the routes the proxy would watch with the proposed listeners: the
+// current routes, without the RDS routes that are no longer referenced and with the newly referenced ones.
+func proposedRouteNames(current *xdsResources, proposedListeners []*xdsapi.Listener) []string {
+	currentRDS := rdsRouteNames(current.listeners)
+	proposedRDS := rdsRouteNames(proposedListeners)
+
+	out := make([]string, 0, len(current.routes))
+	for _, r := range current.routes {
+		if _, found := currentRDS[r.Name]; found {
+			if _, found := proposedRDS[r.Name]; !found {
+				continue
+			}
+		}
+		out = append(out, r.Name)
+	}
+	for name := range proposedRDS {
+		if _, found := currentRDS[name]; !found {
+			out = append(out, name)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// rdsRouteNames returns the names of the RDS routes referenced by the HTTP connection managers of the listeners.
+func rdsRouteNames(listeners []*xdsapi.Listener) map[string]struct{} {
+	out := make(map[string]struct{})
+	for _, l := range listeners {
+		for _, fc := range l.FilterChains {
+			for _, filter := range fc.Filters {
+				if filter.Name != xdsutil.HTTPConnectionManager {
+					continue
+				}
+				hcm := &http_conn.HttpConnectionManager{}
+				var err error
+				if filter.GetTypedConfig() != nil {
+					err = types.UnmarshalAny(filter.GetTypedConfig(), hcm)
+				} else {
+					err = xdsutil.StructToMessage(filter.GetConfig(), hcm)
+				}
+				if err != nil {
+					continue
+				}
+				if rds := hcm.GetRds(); rds != nil {
+					out[rds.RouteConfigName] = struct{}{}
+				}
+			}
+		}
+	}
+	return out
+}
+
+func marshalConfigDump(resources *xdsResources) (json.RawMessage, error) {
+	dump, err := buildConfigDump(resources.clusters, resources.listeners, resources.routes)
+	if err != nil {
+		return nil, err
+	}
+	buffer := &bytes.Buffer{}
+	if err := (&jsonpb.Marshaler{}).Marshal(buffer, dump); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func listenersByName(listeners []*xdsapi.Listener) map[string]proto.Message {
+	out := make(map[string]proto.Message, len(listeners))
+	for _, l := range listeners {
+		out[l.Name] = l
+	}
+	return out
+}
+
+func routesByName(routes []*xdsapi.RouteConfiguration) map[string]proto.Message {
+	out := make(map[string]proto.Message, len(routes))
+	for _, r := range routes {
+		out[r.Name] = r
+	}
+	return out
+}
+
+func clustersByName(clusters []*xdsapi.Cluster) map[string]proto.Message {
+	out := make(map[string]proto.Message, len(clusters))
+	for _, c := range clusters {
+		out[c.Name] = c
+	}
+	return out
+}
+
+// diffResources compares the resources of one type by name.
+func diffResources(current, proposed map[string]proto.Message) ResourceDiff {
+	var diff ResourceDiff
+	for name, p := range proposed {
+		c, found := current[name]
+		switch {
+		case !found:
+			diff.Added = append(diff.Added, name)
+		case !proto.Equal(c, p):
+			diff.Modified = append(diff.Modified, name)
+		}
+	}
+	for name := range current {
+		if _, found := proposed[name]; !found {
+			diff.Removed = append(diff.Removed, name)
+		}
+	}
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Strings(diff.Modified)
+	return diff
+}
+
+// overlayConfigStore is a read-only view of a config store where the candidate configs of a dry run are added,
+// or replace the configs with the same type, namespace and name.
+type overlayConfigStore struct {
+	model.ConfigStore
+	overlay map[model.ConfigKey]model.Config
+}
+
+func newOverlayConfigStore(base model.ConfigStore, candidates []model.Config, node *model.Proxy) (*overlayConfigStore, error) {
+	// Short host names are resolved with the domain suffix of the mesh, which is the one of the proxy.
+	domain := strings.TrimPrefix(node.DNSDomain, node.ConfigNamespace+".svc.")
+
+	store := &overlayConfigStore{
+		ConfigStore: base,
+		overlay:     make(map[model.ConfigKey]model.Config, len(candidates)),
+	}
+	for _, config := range candidates {
+		schema, ok := base.ConfigDescriptor().GetByType(config.Type)
+		if !ok {
+			return nil, fmt.Errorf("config type %s of %s is not supported", config.Type, config.Name)
+		}
+		if config.Namespace == "" {
+			config.Namespace = node.ConfigNamespace
+		}
+		if err := schema.Validate(config.Name, config.Namespace, config.Spec); err != nil {
+			return nil, fmt.Errorf("invalid %s %s/%s: %v", config.Type, config.Namespace, config.Name, err)
+		}
+		if existing := base.Get(config.Type, config.Name, config.Namespace); existing != nil {
+			// Keep the place of the config in the creation order, which is used to merge configs.
+			config.CreationTimestamp = existing.CreationTimestamp
+			config.Domain = existing.Domain
+		} else {
+			config.CreationTimestamp = time.Now()
+			config.Domain = domain
+		}
+		store.overlay[model.ConfigKey{Type: config.Type, Name: config.Name, Namespace: config.Namespace}] = config
+	}
+	return store, nil
+}
+
+func (s *overlayConfigStore) Get(typ, name, namespace string) *model.Config {
+	if config, found := s.overlay[model.ConfigKey{Type: typ, Name: name, Namespace: namespace}]; found {
+		return &config
+	}
+	return s.ConfigStore.Get(typ, name, namespace)
+}
+
+func (s *overlayConfigStore) List(typ, namespace string) ([]model.Config, error) {
+	configs, err := s.ConfigStore.List(typ, namespace)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]model.Config, 0, len(configs))
+	for _, config := range configs {
+		if _, found := s.overlay[model.ConfigKey{Type: typ, Name: config.Name, Namespace: config.Namespace}]; !found {
+			out = append(out, config)
+		}
+	}
+	for key, config := range s.overlay {
+		if key.Type == typ && (namespace == model.NamespaceAll || key.Namespace == namespace) {
+			out = append(out, config)
+		}
+	}
+	return out, nil
+}
+
+func (s *overlayConfigStore) Create(model.Config) (string, error) {
+	return "", errReadOnlyStore
+}
+
+func (s *overlayConfigStore) Update(model.Config) (string, error) {
+	return "", errReadOnlyStore
+}
+
+func (s *overlayConfigStore) Delete(typ, name, namespace string) error {
+	return errReadOnlyStore
+}
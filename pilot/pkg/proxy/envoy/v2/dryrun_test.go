@@ -0,0 +1,224 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"istio.io/istio/istioctl/pkg/util/configdump"
+	v2 "istio.io/istio/pilot/pkg/proxy/envoy/v2"
+	"istio.io/istio/tests/util"
+)
+
+const dryRunConfigs = `
+apiVersion: networking.istio.io/v1alpha3
+kind: DestinationRule
+metadata:
+  name: hello
+spec:
+  host: hello.default.svc.cluster.local
+  subsets:
+  - name: v1
+    labels:
+      version: v1
+---
+apiVersion: networking.istio.io/v1alpha3
+kind: VirtualService
+metadata:
+  name: hello
+spec:
+  hosts:
+  - hello.default.svc.cluster.local
+  http:
+  - route:
+    - destination:
+        host: hello.default.svc.cluster.local
+        subset: v1
+`
+
+// A Sidecar exposing a new HTTP port adds a listener, and the RDS route it references.
+const dryRunNewPortConfigs = `
+apiVersion: networking.istio.io/v1alpha3
+kind: Sidecar
+metadata:
+  name: default
+spec:
+  egress:
+  - port:
+      number: 9999
+      protocol: HTTP
+      name: http-dryrun
+    hosts:
+    - "*/*"
+  - hosts:
+    - "*/*"
+`
+
+func TestConfigDryRun(t *testing.T) {
+	_, tearDown := initLocalPilotTestEnv(t)
+	defer tearDown()
+
+	envoy, cancel, err := connectADS(util.MockPilotGrpcAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cancel()
+	if err := sendCDSReq(sidecarID(app3Ip, "dryRunApp"), envoy); err != nil {
+		t.Fatal(err)
+	}
+	if err := sendLDSReq(sidecarID(app3Ip, "dryRunApp"), envoy); err != nil {
+		t.Fatal(err)
+	}
+	if err := sendRDSReq(sidecarID(app3Ip, "dryRunApp"), []string{"80"}, "", envoy); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := adsReceive(envoy, 5*time.Second); err != nil {
+			t.Fatal("Recv failed", err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		method   string
+		proxyID  string
+		body     string
+		wantCode int
+		want     *v2.ConfigDryRun
+	}{
+		{
+			name:     "diff with the candidate configs",
+			method:   "POST",
+			proxyID:  "dryRunApp-644fc65469-96dza.testns",
+			body:     dryRunConfigs,
+			wantCode: 200,
+			want: &v2.ConfigDryRun{
+				Routes: v2.ResourceDiff{Modified: []string{"80"}},
+				// The destination rule applies to all the ports of the hello service.
+				Clusters: v2.ResourceDiff{
+					Added:    helloClusters("v1"),
+					Modified: helloClusters(""),
+				},
+			},
+		},
+		{
+			name:     "new routes of the candidate configs",
+			method:   "POST",
+			proxyID:  "dryRunApp-644fc65469-96dza.testns",
+			body:     dryRunNewPortConfigs,
+			wantCode: 200,
+			want: &v2.ConfigDryRun{
+				Listeners: v2.ResourceDiff{Added: []string{"0.0.0.0_9999"}},
+				Routes:    v2.ResourceDiff{Added: []string{"9999"}},
+			},
+		},
+		{
+			name:     "no diff without candidate configs",
+			method:   "POST",
+			proxyID:  "dryRunApp-644fc65469-96dza.testns",
+			wantCode: 200,
+			want:     &v2.ConfigDryRun{},
+		},
+		{
+			name:     "returns 405 if not posted",
+			method:   "GET",
+			proxyID:  "dryRunApp-644fc65469-96dza.testns",
+			wantCode: 405,
+		},
+		{
+			name:     "returns 400 if no proxyID",
+			method:   "POST",
+			wantCode: 400,
+		},
+		{
+			name:     "returns 400 if the configs are invalid",
+			method:   "POST",
+			proxyID:  "dryRunApp-644fc65469-96dza.testns",
+			body:     strings.Replace(dryRunConfigs, "host: hello.default.svc.cluster.local", "host: ''", 1),
+			wantCode: 400,
+		},
+		{
+			name:     "returns 404 if proxy not found",
+			method:   "POST",
+			proxyID:  "not-found",
+			body:     dryRunConfigs,
+			wantCode: 404,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			url := fmt.Sprintf("http://localhost:%d/debug/config_dryrun", util.MockPilotHTTPPort)
+			if tt.proxyID != "" {
+				url += "?proxyID=" + tt.proxyID
+			}
+			req, err := http.NewRequest(tt.method, url, strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			body, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tt.wantCode {
+				t.Fatalf("wanted response code %v, got %v: %s", tt.wantCode, resp.StatusCode, body)
+			}
+			if tt.want == nil {
+				return
+			}
+
+			got := &v2.ConfigDryRun{}
+			if err := json.Unmarshal(body, got); err != nil {
+				t.Fatal(err)
+			}
+			if got.ProxyID != tt.proxyID {
+				t.Errorf("got proxy %q, want %q", got.ProxyID, tt.proxyID)
+			}
+			for _, dump := range []json.RawMessage{got.Current, got.Proposed} {
+				if err := json.Unmarshal(dump, &configdump.Wrapper{}); err != nil {
+					t.Errorf("invalid config dump: %v", err)
+				}
+			}
+			if !reflect.DeepEqual(got.Listeners, tt.want.Listeners) {
+				t.Errorf("got listeners diff %+v, want %+v", got.Listeners, tt.want.Listeners)
+			}
+			if !reflect.DeepEqual(got.Routes, tt.want.Routes) {
+				t.Errorf("got routes diff %+v, want %+v", got.Routes, tt.want.Routes)
+			}
+			if !reflect.DeepEqual(got.Clusters, tt.want.Clusters) {
+				t.Errorf("got clusters diff %+v, want %+v", got.Clusters, tt.want.Clusters)
+			}
+		})
+	}
+}
+
+func helloClusters(subset string) []string {
+	var out []string
+	for _, port := range []string{"100", "110", "120", "66", "80", "81", "90"} {
+		out = append(out, fmt.Sprintf("outbound|%s|%s|hello.default.svc.cluster.local", port, subset))
+	}
+	return out
+}
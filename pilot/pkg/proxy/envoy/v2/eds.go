@@ -26,6 +26,7 @@ import (
 	"github.com/envoyproxy/go-control-plane/envoy/api/v2/endpoint"
 	"github.com/gogo/protobuf/types"
 
+	meshconfig "istio.io/api/mesh/v1alpha1"
 	networkingapi "istio.io/api/networking/v1alpha3"
 
 	"istio.io/istio/pilot/pkg/model"
@@ -728,15 +729,16 @@ func (s *DiscoveryServer) pushEds(push *model.PushContext, con *XdsConnection, v
 		}
 
 		// If locality aware routing is enabled, prioritize endpoints or set their lb weight.
-		if s.Env.Mesh.LocalityLbSetting != nil {
+		if localityLbSetting := getLocalityLbSetting(push, con.modelNode, clusterName); localityLbSetting != nil {
 			// Make a shallow copy of the cla as we are mutating the endpoints with priorities/weights relative to the calling proxy
 			clonedCLA := util.CloneClusterLoadAssignment(l)
 			l = &clonedCLA
 
 			// Failover should only be enabled when there is an outlier detection, otherwise Envoy
-			// will never detect the hosts are unhealthy and redirect traffic.
-			enableFailover := hasOutlierDetection(push, con.modelNode, clusterName)
-			loadbalancer.ApplyLocalityLBSetting(con.modelNode.Locality, l, s.Env.Mesh.LocalityLbSetting, enableFailover)
+			// will never detect the hosts are unhealthy and redirect traffic. CDS adds a default
+			// outlier detection to the clusters that fail over without one.
+			enableFailover := loadbalancer.FailoverEnabled(localityLbSetting) || hasOutlierDetection(push, con.modelNode, clusterName)
+			loadbalancer.ApplyLocalityLBSetting(con.modelNode.Locality, l, localityLbSetting, enableFailover)
 		}
 
 		endpoints += len(l.Endpoints)
@@ -746,8 +748,13 @@ func (s *DiscoveryServer) pushEds(push *model.PushContext, con *XdsConnection, v
 		loadAssignments = append(loadAssignments, l)
 	}
 
-	response := endpointDiscoveryResponse(loadAssignments, version)
-	err := con.send(response)
+	var err error
+	if con.isDelta() {
+		// Incremental pushes only carry the updated services, the other clusters are unchanged.
+		err = con.sendDelta(EndpointType, loadAssignmentResources(loadAssignments), version, edsUpdatedServices == nil)
+	} else {
+		err = con.send(endpointDiscoveryResponse(loadAssignments, version))
+	}
 	if err != nil {
 		adsLog.Warnf("EDS: Send failure %s: %v", con.ConID, err)
 		recordSendError(edsSendErrPushes, err)
@@ -784,6 +791,36 @@ func getDestinationRule(push *model.PushContext, proxy *model.Proxy, hostname ho
 	return nil, nil
 }
 
+// getLocalityLbSetting returns the locality load balancer setting of the cluster: the one of the destination rule
+// of its service, or the mesh wide one.
+func getLocalityLbSetting(push *model.PushContext, proxy *model.Proxy, clusterName string) *meshconfig.LocalityLoadBalancerSetting {
+	if !push.HasLocalityLbSettings() {
+		return nil
+	}
+	_, _, hostname, _ := model.ParseSubsetKey(clusterName)
+	if service := serviceForHostname(push, proxy, hostname); service != nil {
+		return push.LocalityLbSetting(push.DestinationRule(proxy, service))
+	}
+	return push.LocalityLbSetting(nil)
+}
+
+// serviceForHostname returns the service with the hostname, preferring the one in the namespace of the proxy when
+// the hostname is defined in several namespaces.
+func serviceForHostname(push *model.PushContext, proxy *model.Proxy, hostname host.Name) *model.Service {
+	services := push.ServiceByHostnameAndNamespace[hostname]
+	if service, found := services[proxy.ConfigNamespace]; found {
+		return service
+	}
+	var out *model.Service
+	for namespace, service := range services {
+		// pick the same service on every push
+		if out == nil || namespace < out.Attributes.Namespace {
+			out = service
+		}
+	}
+	return out
+}
+
 func hasOutlierDetection(push *model.PushContext, proxy *model.Proxy, clusterName string) bool {
 	_, subsetName, hostname, portNumber := model.ParseSubsetKey(clusterName)
 
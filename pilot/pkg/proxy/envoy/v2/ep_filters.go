@@ -42,17 +42,14 @@ func EndpointsByNetworkFilter(endpoints []*endpoint.LocalityLbEndpoints, conn *X
 		network = ""
 	}
 
+	// The gateways of each network, configured or discovered by the registries.
+	gatewaysByNetwork := getNetworkGateways(env)
+
 	// calculate the multiples of weight.
 	// It is needed to normalize the LB Weight across different networks.
 	multiples := 1
-	for _, network := range env.MeshNetworks.Networks {
-		num := 0
-		registryName := getNetworkRegistry(network)
-		for _, gw := range network.Gateways {
-			addrs := getGatewayAddresses(gw, registryName, env)
-			num += len(addrs)
-		}
-		if num > 1 {
+	for _, gateways := range gatewaysByNetwork {
+		if num := len(gateways); num > 1 {
 			multiples *= num
 		}
 	}
@@ -89,43 +86,31 @@ func EndpointsByNetworkFilter(endpoints []*endpoint.LocalityLbEndpoints, conn *X
 		// for each one of those add a new endpoint that points to the network's
 		// gateway with the relevant weight
 		for network, w := range remoteEps {
-			networkConf, found := env.MeshNetworks.Networks[network]
-			if !found {
+			if _, found := env.MeshNetworks.Networks[network]; !found {
 				adsLog.Debugf("the endpoints within network %s will be ignored for no network configured", network)
 				continue
 			}
-			gws := networkConf.Gateways
+			gws := gatewaysByNetwork[network]
 			if len(gws) == 0 {
-				adsLog.Debugf("the endpoints within network %s will be ignored for no gateways configured", network)
+				adsLog.Debugf("the endpoints within network %s will be ignored for no gateways configured or discovered", network)
 				continue
 			}
 
-			registryName := getNetworkRegistry(networkConf)
-			gwEps := make([]*endpoint.LbEndpoint, 0)
 			// There may be multiples gateways for the network. Add an LbEndpoint for
 			// each one of them
+			gwEps := make([]*endpoint.LbEndpoint, 0, len(gws))
 			for _, gw := range gws {
-				gwAddresses := getGatewayAddresses(gw, registryName, env)
-				// If gateway addresses are found, create an endpoint for each one of them
-				if len(gwAddresses) > 0 {
-					for _, gwAddr := range gwAddresses {
-						epAddr := util.BuildAddress(gwAddr, gw.Port)
-						gwEp := &endpoint.LbEndpoint{
-							HostIdentifier: &endpoint.LbEndpoint_Endpoint{
-								Endpoint: &endpoint.Endpoint{
-									Address: epAddr,
-								},
-							},
-							LoadBalancingWeight: &types.UInt32Value{
-								Value: w,
-							},
-						}
-						gwEps = append(gwEps, gwEp)
-					}
+				gwEp := &endpoint.LbEndpoint{
+					HostIdentifier: &endpoint.LbEndpoint_Endpoint{
+						Endpoint: &endpoint.Endpoint{
+							Address: util.BuildAddress(gw.Addr, gw.Port),
+						},
+					},
+					LoadBalancingWeight: &types.UInt32Value{
+						Value: w,
+					},
 				}
-			}
-			if len(gwEps) == 0 {
-				continue
+				gwEps = append(gwEps, gwEp)
 			}
 			weight := w * uint32(multiples/len(gwEps))
 			for _, gwEp := range gwEps {
@@ -196,6 +181,32 @@ func getNetworkRegistry(network *v1alpha1.Network) string {
 	return registryName
 }
 
+// getNetworkGateways returns the gateways of the networks of the MeshNetworks configuration, the configured ones
+// followed by the ones discovered by the service registries.
+func getNetworkGateways(env *model.Environment) map[string][]*model.NetworkGateway {
+	var discovered map[string][]*model.NetworkGateway
+	if gd, ok := env.ServiceDiscovery.(model.NetworkGatewayDiscovery); ok {
+		discovered = gd.NetworkGateways()
+	}
+
+	out := make(map[string][]*model.NetworkGateway)
+	for name, network := range env.MeshNetworks.Networks {
+		registryName := getNetworkRegistry(network)
+		for _, gw := range network.Gateways {
+			for _, addr := range getGatewayAddresses(gw, registryName, env) {
+				out[name] = append(out[name], &model.NetworkGateway{
+					Network:   name,
+					ClusterID: registryName,
+					Addr:      addr,
+					Port:      gw.Port,
+				})
+			}
+		}
+		out[name] = append(out[name], discovered[name]...)
+	}
+	return out
+}
+
 func getGatewayAddresses(gw *v1alpha1.Network_IstioNetworkGateway, registryName string, env *model.Environment) []string {
 	// First, if a gateway address is provided in the configuration use it. If the gateway address
 	// in the config was a hostname it got already resolved and replaced with an IP address
@@ -15,6 +15,7 @@
 package v2
 
 import (
+	"reflect"
 	"sort"
 	"testing"
 
@@ -349,6 +350,63 @@ func TestEndpointsByNetworkFilter_RegistryServiceName(t *testing.T) {
 	}
 }
 
+// gatewayDiscovery is a service discovery that also discovers network gateways
+type gatewayDiscovery struct {
+	*MemServiceDiscovery
+	gateways map[string][]*model.NetworkGateway
+}
+
+func (d *gatewayDiscovery) NetworkGateways() map[string][]*model.NetworkGateway {
+	return d.gateways
+}
+
+func TestEndpointsByNetworkFilter_DiscoveredGateways(t *testing.T) {
+	//  - 1 gateway for network1
+	//  - 2 gateways for network2
+	//  - 1 gateway for network3
+	//  - 1 discovered gateway for network4
+	//  - 1 discovered gateway for network5, which is not a network of the MeshNetworks configuration
+	env := environment()
+	env.ServiceDiscovery = &gatewayDiscovery{
+		MemServiceDiscovery: NewMemServiceDiscovery(nil, 0),
+		gateways: map[string][]*model.NetworkGateway{
+			"network4": {{Network: "network4", ClusterID: "cluster4", Addr: "4.4.4.4", Port: 15443}},
+			"network5": {{Network: "network5", ClusterID: "cluster5", Addr: "5.5.5.5", Port: 15443}},
+		},
+	}
+
+	filtered := EndpointsByNetworkFilter(testEndpoints(), xdsConnection("network1"), env)
+	if len(filtered) != 1 {
+		t.Fatalf("Unexpected number of filtered endpoints: got %v, want 1", len(filtered))
+	}
+
+	// The weights are multiplied by 2, the number of gateways of network2.
+	want := map[string]uint32{
+		// 2 local endpoints
+		"10.0.0.1": 2,
+		"10.0.0.2": 2,
+		// 2 endpoints to the gateways of network2 for its endpoint
+		"2.2.2.2":  1,
+		"2.2.2.20": 1,
+		// 1 endpoint to the discovered gateway of network4 for its endpoint
+		"4.4.4.4": 2,
+	}
+	got := map[string]uint32{}
+	for _, lbEp := range filtered[0].LbEndpoints {
+		socketAddress := lbEp.GetEndpoint().Address.GetSocketAddress()
+		got[socketAddress.Address] = lbEp.LoadBalancingWeight.GetValue()
+		if socketAddress.Address == "4.4.4.4" && socketAddress.GetPortValue() != 15443 {
+			t.Errorf("Unexpected port for the discovered gateway: %v", socketAddress.GetPortValue())
+		}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Unexpected endpoints: got %v, want %v", got, want)
+	}
+	if weight := filtered[0].LoadBalancingWeight.GetValue(); weight != 8 {
+		t.Errorf("Unexpected weight: got %v, want 8", weight)
+	}
+}
+
 func xdsConnection(network string) *XdsConnection {
 	var metadata map[string]string
 	if network != "" {
@@ -28,11 +28,18 @@ func (s *DiscoveryServer) pushLds(con *XdsConnection, push *model.PushContext, v
 
 	rawListeners := s.generateRawListeners(con, push)
 
+	con.mu.Lock()
+	con.lastPush = push
 	if s.DebugConfigs {
 		con.LDSListeners = rawListeners
 	}
-	response := ldsDiscoveryResponse(rawListeners, version)
-	err := con.send(response)
+	con.mu.Unlock()
+	var err error
+	if con.isDelta() {
+		err = con.sendDelta(ListenerType, listenerResources(rawListeners), version, true)
+	} else {
+		err = con.send(ldsDiscoveryResponse(rawListeners, version))
+	}
 	if err != nil {
 		adsLog.Warnf("LDS: Send failure %s: %v", con.ConID, err)
 		recordSendError(ldsSendErrPushes, err)
@@ -75,10 +75,11 @@ func TestLDSIsolated(t *testing.T) {
 			t.Fatal(err)
 		}
 
-		// 7071 (inbound), 2001 (service - also as http proxy), 15002 (http-proxy)
+		// 7071 (inbound), 18080 (sidecar ingress, no service instance), 2001 (service - also as http proxy),
+		// 15002 (http-proxy)
 		// We dont get mixer on 9091 or 15004 because there are no services defined in istio-system namespace
 		// in the none.yaml setup
-		if len(ldsr.HTTPListeners) != 3 {
+		if len(ldsr.HTTPListeners) != 4 {
 			// TODO: we are still debating if for HTTP services we have any use case to create a 127.0.0.1:port outbound
 			// for the service (the http proxy is already covering this)
 			t.Error("HTTP listeners, expecting 5 got ", len(ldsr.HTTPListeners), ldsr.HTTPListeners)
@@ -430,9 +431,15 @@ func TestLDSWithSidecarForWorkloadWithoutService(t *testing.T) {
 		return
 	}
 
-	// Expect 1 HTTP listeners for 8081
-	if len(adsResponse.HTTPListeners) != 1 {
-		t.Fatalf("Expected 1 http listeners, got %d", len(adsResponse.HTTPListeners))
+	// Expect 3 HTTP listeners: 8081 outbound, 9080 inbound from the sidecar ingress listener and the
+	// virtual inbound listener, which now has a filter chain for 9080
+	if len(adsResponse.HTTPListeners) != 3 {
+		t.Fatalf("Expected 3 http listeners, got %d", len(adsResponse.HTTPListeners))
+	}
+
+	// The workload has no service, the inbound listener is built from the sidecar ingress listener
+	if adsResponse.HTTPListeners["98.1.1.1_9080"] == nil {
+		t.Fatal("Expected listener for 98.1.1.1_9080")
 	}
 
 	// TODO: This is flimsy. The ADSC code treats any listener with http connection manager as a HTTP listener
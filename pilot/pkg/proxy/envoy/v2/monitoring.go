@@ -25,6 +25,8 @@ var (
 	clusterTag = monitoring.MustCreateTag("cluster")
 	nodeTag    = monitoring.MustCreateTag("node")
 	typeTag    = monitoring.MustCreateTag("type")
+	laneTag    = monitoring.MustCreateTag("lane")
+	eventTag   = monitoring.MustCreateTag("event")
 
 	cdsReject = monitoring.NewGauge(
 		"pilot_xds_cds_reject",
@@ -102,6 +104,15 @@ var (
 	rdsSendErrPushes  = pushes.With(typeTag.Value("rds_senderr"))
 	rdsBuildErrPushes = pushes.With(typeTag.Value("rds_builderr"))
 
+	deltaResources = monitoring.NewSum(
+		"pilot_xds_delta_resources",
+		"Resources sent or removed on incremental xDS connections.",
+		eventTag,
+	)
+
+	deltaResourcesSent    = deltaResources.With(eventTag.Value("sent"))
+	deltaResourcesRemoved = deltaResources.With(eventTag.Value("removed"))
+
 	// only supported dimension is millis, unfortunately. default to unitdimensionless.
 	proxiesQueueTime = monitoring.NewDistribution(
 		"pilot_proxy_queue_time",
@@ -109,6 +120,14 @@ var (
 		[]float64{.1, 1, 3, 5, 10, 20, 30},
 	)
 
+	// only supported dimension is millis, unfortunately. default to unitdimensionless.
+	proxiesLaneQueueTime = monitoring.NewDistribution(
+		"pilot_proxy_lane_queue_time",
+		"Time a proxy waits in a lane of the push queue, from the first enqueue until being dequeued.",
+		[]float64{.1, 1, 3, 5, 10, 20, 30},
+		laneTag,
+	)
+
 	// only supported dimension is millis, unfortunately. default to unitdimensionless.
 	proxiesConvergeDelay = monitoring.NewDistribution(
 		"pilot_proxy_convergence_time",
@@ -169,8 +188,10 @@ func init() {
 		xdsClients,
 		xdsResponseWriteTimeouts,
 		pushes,
+		deltaResources,
 		proxiesConvergeDelay,
 		proxiesQueueTime,
+		proxiesLaneQueueTime,
 		proxiesConvergeDelayCdsErrors,
 		proxiesConvergeDelayEdsErrors,
 		proxiesConvergeDelayRdsErrors,
@@ -18,6 +18,7 @@ import (
 	"sync"
 	"time"
 
+	"istio.io/istio/pilot/pkg/features"
 	"istio.io/istio/pilot/pkg/model"
 )
 
@@ -34,33 +35,107 @@ type PushEvent struct {
 	full bool
 }
 
+// pushLane is a lane of the PushQueue. Proxies pending an incremental EDS push and proxies pending a
+// full push are queued separately, so that endpoint updates are not stuck behind full pushes.
+type pushLane int
+
+const (
+	edsLane pushLane = iota
+	fullLane
+	laneCount
+)
+
+func (l pushLane) String() string {
+	if l == fullLane {
+		return "full"
+	}
+	return "eds"
+}
+
+// queuedPush is a pending push for a proxy.
+type queuedPush struct {
+	con   *XdsConnection
+	event *PushEvent
+	lane  pushLane
+
+	// enqueued is the time the proxy was first queued for this push.
+	enqueued time.Time
+}
+
 type PushQueue struct {
-	mu          *sync.RWMutex
-	cond        *sync.Cond
-	eventsMap   map[*XdsConnection]*PushEvent
-	connections []*XdsConnection
+	mu   *sync.RWMutex
+	cond *sync.Cond
+
+	// pending holds the single, coalesced push pending for each connection.
+	pending map[*XdsConnection]*queuedPush
+
+	// lanes keep the pending pushes in FIFO order. A push that moved to another lane, or that was dequeued, is
+	// left behind as a stale entry and skipped on dequeue.
+	lanes      [laneCount][]*queuedPush
+	laneCounts [laneCount]int
+
+	// edsWeight is the number of proxies dequeued from the EDS lane for each proxy dequeued from the full lane,
+	// when both lanes have pending proxies.
+	edsWeight int
+	// edsDequeued counts the consecutive dequeues from the EDS lane while the full lane had pending proxies.
+	edsDequeued int
+
+	// fullLimit is the maximum number of full pushes in flight, so that the rest of the push throttle is
+	// reserved for EDS pushes. There is no limit if it is 0.
+	fullLimit int
+	// fullInFlight is the number of full pushes dequeued and not done yet.
+	fullInFlight int
 }
 
 func NewPushQueue() *PushQueue {
+	return newPushQueue(features.PushQueueEDSWeight, fullPushLimit(features.PushThrottle, features.PushThrottleEDSReserved))
+}
+
+func newPushQueue(edsWeight, fullLimit int) *PushQueue {
+	if edsWeight < 1 {
+		edsWeight = 1
+	}
 	mu := &sync.RWMutex{}
 	return &PushQueue{
 		mu:        mu,
-		eventsMap: make(map[*XdsConnection]*PushEvent),
+		pending:   make(map[*XdsConnection]*queuedPush),
 		cond:      sync.NewCond(mu),
+		edsWeight: edsWeight,
+		fullLimit: fullLimit,
+	}
+}
+
+// fullPushLimit returns the number of concurrent full pushes allowed by the push throttle, once the pushes
+// reserved for EDS are taken out. At least one full push is allowed.
+func fullPushLimit(throttle, edsReserved int) int {
+	if edsReserved <= 0 {
+		return 0
 	}
+	if limit := throttle - edsReserved; limit > 0 {
+		return limit
+	}
+	return 1
 }
 
 // Add will mark a proxy as pending a push. If it is already pending, pushInfo will be merged.
-// edsUpdatedServices will be added together, and full will be set if either were full
+// edsUpdatedServices will be added together, and full will be set if either were full. A proxy pending an
+// EDS push is moved to the full lane if the merged push is full.
 func (p *PushQueue) Enqueue(proxy *XdsConnection, pushInfo *PushEvent) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	event, exists := p.eventsMap[proxy]
+	qp, exists := p.pending[proxy]
 	if !exists {
-		p.eventsMap[proxy] = pushInfo
-		p.connections = append(p.connections, proxy)
+		qp = &queuedPush{
+			con:      proxy,
+			event:    pushInfo,
+			lane:     laneFor(pushInfo),
+			enqueued: time.Now(),
+		}
+		p.pending[proxy] = qp
+		p.push(qp)
 	} else {
+		event := qp.event
 		event.push = pushInfo.push
 		event.full = event.full || pushInfo.full
 
@@ -72,30 +147,100 @@ func (p *PushQueue) Enqueue(proxy *XdsConnection, pushInfo *PushEvent) {
 			edsUpdates[endpoint] = struct{}{}
 		}
 		event.edsUpdatedServices = edsUpdates
+
+		if lane := laneFor(event); lane != qp.lane {
+			p.laneCounts[qp.lane]--
+			qp.lane = lane
+			p.push(qp)
+		}
 	}
 	p.cond.Signal()
 }
 
-// Remove a proxy from the queue. If there are no proxies ready to be removed, this will block
+// Remove a proxy from the queue. If there are no proxies ready to be removed, this will block. A proxy
+// pending a full push is not ready while the full pushes in flight are at the limit; the full push must be
+// marked as done with FullPushDone.
 func (p *PushQueue) Dequeue() (*XdsConnection, *PushEvent) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	// Block until there is one to remove. Enqueue will signal when one is added.
-	for len(p.connections) == 0 {
+	// Block until there is one to remove. Enqueue and FullPushDone will signal when one is ready.
+	for p.laneCounts[edsLane] == 0 && (p.laneCounts[fullLane] == 0 || !p.canStartFull()) {
 		p.cond.Wait()
 	}
 
-	head := p.connections[0]
-	p.connections = p.connections[1:]
-	info := p.eventsMap[head]
-	delete(p.eventsMap, head)
-	return head, info
+	lane := p.nextLane()
+	qp := p.pop(lane)
+	delete(p.pending, qp.con)
+	p.laneCounts[lane]--
+	if lane == fullLane {
+		p.fullInFlight++
+	}
+
+	proxiesLaneQueueTime.With(laneTag.Value(lane.String())).Record(time.Since(qp.enqueued).Seconds())
+	return qp.con, qp.event
+}
+
+// FullPushDone marks a full push returned by Dequeue as done, allowing another full push to start.
+func (p *PushQueue) FullPushDone() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.fullInFlight--
+	p.cond.Signal()
 }
 
 // Get number of pending proxies
 func (p *PushQueue) Pending() int {
 	p.mu.Lock()
 	defer p.mu.Unlock()
-	return len(p.connections)
+	return len(p.pending)
+}
+
+func laneFor(event *PushEvent) pushLane {
+	if event.full {
+		return fullLane
+	}
+	return edsLane
+}
+
+// push appends the pending push to the tail of its lane. Must be called under lock.
+func (p *PushQueue) push(qp *queuedPush) {
+	p.lanes[qp.lane] = append(p.lanes[qp.lane], qp)
+	p.laneCounts[qp.lane]++
+}
+
+// nextLane selects the lane to dequeue from, favoring the EDS lane according to the weight. Must be called under
+// lock, with at least one pending proxy.
+func (p *PushQueue) nextLane() pushLane {
+	switch {
+	case p.laneCounts[fullLane] == 0 || !p.canStartFull():
+		return edsLane
+	case p.laneCounts[edsLane] == 0:
+		p.edsDequeued = 0
+		return fullLane
+	case p.edsDequeued < p.edsWeight:
+		p.edsDequeued++
+		return edsLane
+	default:
+		p.edsDequeued = 0
+		return fullLane
+	}
+}
+
+// canStartFull returns true if the full pushes in flight are below the limit. Must be called under lock.
+func (p *PushQueue) canStartFull() bool {
+	return p.fullLimit <= 0 || p.fullInFlight < p.fullLimit
+}
+
+// pop removes the oldest live push from the lane, dropping the stale entries in front of it. Must be called
+// under lock, with at least one pending proxy in the lane.
+func (p *PushQueue) pop(lane pushLane) *queuedPush {
+	for {
+		qp := p.lanes[lane][0]
+		p.lanes[lane][0] = nil
+		p.lanes[lane] = p.lanes[lane][1:]
+		if p.pending[qp.con] == qp && qp.lane == lane {
+			return qp
+		}
+	}
 }
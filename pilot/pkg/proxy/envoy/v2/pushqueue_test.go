@@ -152,6 +152,126 @@ func TestProxyQueue(t *testing.T) {
 		}
 	})
 
+	t.Run("eds pushes are not queued behind full pushes", func(t *testing.T) {
+		p := newPushQueue(1, 0)
+		p.Enqueue(proxies[0], &PushEvent{full: true})
+		p.Enqueue(proxies[1], &PushEvent{full: true})
+		p.Enqueue(proxies[2], &PushEvent{edsUpdatedServices: map[string]struct{}{"foo": {}}})
+		p.Enqueue(proxies[3], &PushEvent{edsUpdatedServices: map[string]struct{}{"foo": {}}})
+
+		ExpectDequeue(t, p, proxies[2])
+		ExpectDequeue(t, p, proxies[0])
+		ExpectDequeue(t, p, proxies[3])
+		ExpectDequeue(t, p, proxies[1])
+		ExpectTimeout(t, p)
+	})
+
+	t.Run("eds lane weight", func(t *testing.T) {
+		p := newPushQueue(2, 0)
+		p.Enqueue(proxies[0], &PushEvent{full: true})
+		p.Enqueue(proxies[1], &PushEvent{full: true})
+		for i := 2; i < 6; i++ {
+			p.Enqueue(proxies[i], &PushEvent{})
+		}
+
+		ExpectDequeue(t, p, proxies[2])
+		ExpectDequeue(t, p, proxies[3])
+		ExpectDequeue(t, p, proxies[0])
+		ExpectDequeue(t, p, proxies[4])
+		ExpectDequeue(t, p, proxies[5])
+		ExpectDequeue(t, p, proxies[1])
+		ExpectTimeout(t, p)
+	})
+
+	t.Run("full push moves proxy to the full lane", func(t *testing.T) {
+		p := newPushQueue(1, 0)
+		p.Enqueue(proxies[0], &PushEvent{full: true})
+		p.Enqueue(proxies[1], &PushEvent{edsUpdatedServices: map[string]struct{}{"foo": {}}})
+		p.Enqueue(proxies[2], &PushEvent{edsUpdatedServices: map[string]struct{}{"foo": {}}})
+		p.Enqueue(proxies[1], &PushEvent{full: true})
+		if p.Pending() != 3 {
+			t.Fatalf("Expected 3 pending proxies, got %v", p.Pending())
+		}
+
+		ExpectDequeue(t, p, proxies[2])
+		ExpectDequeue(t, p, proxies[0])
+		con, info := p.Dequeue()
+		if con != proxies[1] {
+			t.Fatalf("Expected proxy %v, got %v", proxies[1], con)
+		}
+		if !info.full {
+			t.Errorf("Expected full to be true, got false")
+		}
+		ExpectTimeout(t, p)
+	})
+
+	t.Run("eds push merges into pending full push", func(t *testing.T) {
+		p := newPushQueue(1, 0)
+		p.Enqueue(proxies[0], &PushEvent{full: true})
+		p.Enqueue(proxies[1], &PushEvent{full: true})
+		p.Enqueue(proxies[0], &PushEvent{edsUpdatedServices: map[string]struct{}{"foo": {}}})
+
+		ExpectDequeue(t, p, proxies[0])
+		ExpectDequeue(t, p, proxies[1])
+		ExpectTimeout(t, p)
+	})
+
+	t.Run("full pushes in flight are limited", func(t *testing.T) {
+		p := newPushQueue(1, 1)
+		p.Enqueue(proxies[0], &PushEvent{full: true})
+		p.Enqueue(proxies[1], &PushEvent{full: true})
+		p.Enqueue(proxies[2], &PushEvent{edsUpdatedServices: map[string]struct{}{"foo": {}}})
+
+		ExpectDequeue(t, p, proxies[2])
+		ExpectDequeue(t, p, proxies[0])
+
+		// The second full push waits for the first one to be done, while EDS pushes can still start.
+		result := make(chan *XdsConnection)
+		go func() {
+			con, _ := p.Dequeue()
+			result <- con
+		}()
+		time.Sleep(time.Millisecond * 100)
+		p.Enqueue(proxies[3], &PushEvent{edsUpdatedServices: map[string]struct{}{"foo": {}}})
+		if got := <-result; got != proxies[3] {
+			t.Fatalf("Expected proxy %v, got %v", proxies[3], got)
+		}
+
+		go func() {
+			con, _ := p.Dequeue()
+			result <- con
+		}()
+		select {
+		case got := <-result:
+			t.Fatalf("Expected the full push to wait, got %v", got)
+		case <-time.After(time.Millisecond * 100):
+		}
+		p.FullPushDone()
+		select {
+		case got := <-result:
+			if got != proxies[1] {
+				t.Fatalf("Expected proxy %v, got %v", proxies[1], got)
+			}
+		case <-time.After(time.Millisecond * 500):
+			t.Fatalf("Timed out")
+		}
+	})
+
+	t.Run("full push limit", func(t *testing.T) {
+		for _, tc := range []struct {
+			throttle, reserved, expected int
+		}{
+			{100, 10, 90},
+			{100, 0, 0},
+			{10, 10, 1},
+			{10, 20, 1},
+		} {
+			if got := fullPushLimit(tc.throttle, tc.reserved); got != tc.expected {
+				t.Errorf("fullPushLimit(%d, %d) => want %d, got %d", tc.throttle, tc.reserved, tc.expected, got)
+			}
+		}
+	})
+
 	t.Run("two removes, one should block one should return", func(t *testing.T) {
 		p := NewPushQueue()
 		wg := &sync.WaitGroup{}
@@ -28,6 +28,8 @@ import (
 
 func (s *DiscoveryServer) pushRoute(con *XdsConnection, push *model.PushContext, version string) error {
 	rawRoutes := s.generateRawRoutes(con, push)
+	con.mu.Lock()
+	con.lastPush = push
 	if s.DebugConfigs {
 		for _, r := range rawRoutes {
 			con.RouteConfigs[r.Name] = r
@@ -37,9 +39,14 @@ func (s *DiscoveryServer) pushRoute(con *XdsConnection, push *model.PushContext,
 			}
 		}
 	}
+	con.mu.Unlock()
 
-	response := routeDiscoveryResponse(rawRoutes, version)
-	err := con.send(response)
+	var err error
+	if con.isDelta() {
+		err = con.sendDelta(RouteType, routeResources(rawRoutes), version, true)
+	} else {
+		err = con.send(routeDiscoveryResponse(rawRoutes, version))
+	}
 	if err != nil {
 		adsLog.Warnf("RDS: Send failure for node:%v: %v", con.modelNode.ID, err)
 		recordSendError(rdsSendErrPushes, err)
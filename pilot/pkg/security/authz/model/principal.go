@@ -199,6 +199,9 @@ func principalForKeyValue(key, value string, forTCPFilter bool) *envoy_rbac.Prin
 		if value == allUsers || value == "*" {
 			return principalAny(true)
 		}
+		// The principal of a peer is its SPIFFE ID without the scheme, e.g. "partner.org/ns/foo/sa/bar". The
+		// SPIFFE ID is accepted as well, so that the identities of the federated trust domains can be used as is.
+		value = strings.TrimPrefix(value, spiffe.URIPrefix)
 		// We don't allow users to use "*" in names or not_names. However, we will use "*" internally to
 		// refer to authenticated users, since existing code using regex to map "*" to all authenticated
 		// users.
@@ -400,6 +400,40 @@ func TestPrincipal_Generate(t *testing.T) {
                     regex: .*
               - any: true`,
 		},
+		{
+			name: "principal with user of federated trust domain",
+			principal: &Principal{
+				User: "spiffe://partner.org/ns/foo/sa/bar",
+			},
+			wantYAML: `
+        andIds:
+          ids:
+          - metadata:
+              filter: istio_authn
+              path:
+              - key: source.principal
+              value:
+                stringMatch:
+                  exact: partner.org/ns/foo/sa/bar`,
+		},
+		{
+			name: "principal with names of federated trust domain for TCP filter",
+			principal: &Principal{
+				Names: []string{"spiffe://partner.org/ns/foo/sa/bar", "cluster.local/ns/foo/sa/bar"},
+			},
+			forTCPFilter: true,
+			wantYAML: `
+        andIds:
+          ids:
+          - orIds:
+              ids:
+              - authenticated:
+                  principalName:
+                    exact: spiffe://partner.org/ns/foo/sa/bar
+              - authenticated:
+                  principalName:
+                    exact: spiffe://cluster.local/ns/foo/sa/bar`,
+		},
 		{
 			name: "principal with property attrRequestPrincipal",
 			principal: &Principal{
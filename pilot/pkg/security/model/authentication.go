@@ -24,6 +24,7 @@ import (
 
 	"istio.io/istio/pilot/pkg/features"
 	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/spiffe"
 
 	authn "istio.io/api/authentication/v1alpha1"
 )
@@ -38,6 +39,10 @@ const (
 	// SDSRootResourceName is the sdsconfig name for root CA, used for fetching root cert.
 	SDSRootResourceName = "ROOTCA"
 
+	// SDSFederatedRootResourceName is the sdsconfig name for root CA along with the roots of the federated
+	// trust domains, used for fetching the roots of all the trust domains accepted by the mesh.
+	SDSFederatedRootResourceName = "FEDERATED_ROOTCA"
+
 	// K8sSATrustworthyJwtFileName is the token volume mount file name for k8s trustworthy jwt token.
 	K8sSATrustworthyJwtFileName = "/var/run/secrets/tokens/istio-token"
 
@@ -80,6 +85,34 @@ func GetConsolidateAuthenticationPolicy(store model.IstioConfigStore, serviceIns
 	return nil
 }
 
+// GetSdsRootResourceNameForInbound returns the sdsconfig name of the roots validating the peers of inbound
+// traffic: the roots of all the trust domains accepted by the mesh. The node agent name constrains the roots of
+// each federated trust domain to its own SPIFFE IDs, so that a foreign CA cannot issue the identities of the mesh.
+func GetSdsRootResourceNameForInbound() string {
+	if len(spiffe.GetFederatedTrustDomains()) > 0 {
+		return SDSFederatedRootResourceName
+	}
+	return SDSRootResourceName
+}
+
+// GetSdsRootResourceNameForOutbound returns the sdsconfig name of the roots validating an upstream with the
+// given subject alt names. When they all belong to the same federated trust domain, only the roots of this
+// trust domain are used, so that its CA cannot impersonate the services of another trust domain.
+func GetSdsRootResourceNameForOutbound(subjectAltNames []string) string {
+	trustDomain := ""
+	for _, san := range subjectAltNames {
+		td, err := spiffe.GetTrustDomainFromURI(san)
+		if err != nil || (trustDomain != "" && td != trustDomain) {
+			return SDSRootResourceName
+		}
+		trustDomain = td
+	}
+	if trustDomain == "" || !spiffe.IsFederatedTrustDomain(trustDomain) {
+		return SDSRootResourceName
+	}
+	return spiffe.URIPrefix + trustDomain
+}
+
 // ConstructSdsSecretConfig constructs SDS secret configuration for ingress gateway.
 func ConstructSdsSecretConfigForGatewayListener(name, sdsUdsPath string) *auth.SdsSecretConfig {
 	if name == "" || sdsUdsPath == "" {
@@ -172,7 +205,8 @@ func ConstructSdsSecretConfig(name, sdsUdsPath string, useK8sSATrustworthyJwt, u
 }
 
 // ConstructValidationContext constructs ValidationContext in CommonTlsContext.
-func ConstructValidationContext(rootCAFilePath string, subjectAltNames []string) *auth.CommonTlsContext_ValidationContext {
+func ConstructValidationContext(rootCAFilePath string, subjectAltNames []string,
+	metadata map[string]string) *auth.CommonTlsContext_ValidationContext {
 	ret := &auth.CommonTlsContext_ValidationContext{
 		ValidationContext: &auth.CertificateValidationContext{
 			TrustedCa: &core.DataSource{
@@ -180,6 +214,7 @@ func ConstructValidationContext(rootCAFilePath string, subjectAltNames []string)
 					Filename: rootCAFilePath,
 				},
 			},
+			Crl: ConstructCRL(metadata),
 		},
 	}
 
@@ -190,6 +225,20 @@ func ConstructValidationContext(rootCAFilePath string, subjectAltNames []string)
 	return ret
 }
 
+// ConstructCRL returns the certificate revocation list to check Istio mutual TLS peers against, or nil
+// if no CRL is configured for the proxy.
+func ConstructCRL(metadata map[string]string) *core.DataSource {
+	crlFile := model.GetOrDefaultFromMap(metadata, model.NodeMetadataTLSCRL, features.CRLFile.Get())
+	if crlFile == "" {
+		return nil
+	}
+	return &core.DataSource{
+		Specifier: &core.DataSource_Filename{
+			Filename: crlFile,
+		},
+	}
+}
+
 // this function is used to construct SDS config which is only available from 1.1
 func ConstructgRPCCallCredentials(tokenFileName, headerKey string) []*core.GrpcService_GoogleGrpc_CallCredentials {
 	// If k8s sa jwt token file exists, envoy only handles plugin credentials.
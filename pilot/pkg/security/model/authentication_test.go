@@ -24,6 +24,7 @@ import (
 	"github.com/gogo/protobuf/types"
 
 	"istio.io/istio/pilot/pkg/features"
+	"istio.io/istio/pkg/spiffe"
 )
 
 func TestConstructSdsSecretConfig(t *testing.T) {
@@ -222,3 +223,67 @@ func constructsdsconfighelper(tokenFileName, headerKey string, metaConfig *v2alp
 		},
 	}
 }
+
+func TestConstructValidationContextWithCRL(t *testing.T) {
+	ctx := ConstructValidationContext("/etc/certs/root-cert.pem", nil, map[string]string{})
+	if ctx.ValidationContext.Crl != nil {
+		t.Errorf("expected no CRL, got %v", ctx.ValidationContext.Crl)
+	}
+
+	ctx = ConstructValidationContext("/etc/certs/root-cert.pem", nil, map[string]string{"TLS_CRL": "/custom/crl.pem"})
+	if got := ctx.ValidationContext.Crl.GetFilename(); got != "/custom/crl.pem" {
+		t.Errorf("unexpected CRL file %q, expected /custom/crl.pem", got)
+	}
+	if got := ctx.ValidationContext.TrustedCa.GetFilename(); got != "/etc/certs/root-cert.pem" {
+		t.Errorf("unexpected root cert file %q", got)
+	}
+}
+
+func TestGetSdsRootResourceName(t *testing.T) {
+	if got := GetSdsRootResourceNameForInbound(); got != SDSRootResourceName {
+		t.Errorf("unexpected inbound root resource %q without federated trust domains", got)
+	}
+
+	spiffe.SetFederatedTrustDomains([]string{"partner.org"})
+	defer spiffe.SetFederatedTrustDomains(nil)
+
+	if got := GetSdsRootResourceNameForInbound(); got != SDSFederatedRootResourceName {
+		t.Errorf("unexpected inbound root resource %q with federated trust domains", got)
+	}
+
+	cases := []struct {
+		name            string
+		subjectAltNames []string
+		want            string
+	}{
+		{
+			name: "no subject alt names",
+			want: SDSRootResourceName,
+		},
+		{
+			name:            "local trust domain",
+			subjectAltNames: []string{"spiffe://cluster.local/ns/foo/sa/bar"},
+			want:            SDSRootResourceName,
+		},
+		{
+			name:            "federated trust domain",
+			subjectAltNames: []string{"spiffe://partner.org/ns/foo/sa/bar", "spiffe://partner.org/ns/foo/sa/baz"},
+			want:            "spiffe://partner.org",
+		},
+		{
+			name:            "mixed trust domains",
+			subjectAltNames: []string{"spiffe://partner.org/ns/foo/sa/bar", "spiffe://cluster.local/ns/foo/sa/bar"},
+			want:            SDSRootResourceName,
+		},
+		{
+			name:            "not a SPIFFE ID",
+			subjectAltNames: []string{"spiffe://partner.org/ns/foo/sa/bar", "partner.org"},
+			want:            SDSRootResourceName,
+		},
+	}
+	for _, c := range cases {
+		if got := GetSdsRootResourceNameForOutbound(c.subjectAltNames); got != c.want {
+			t.Errorf("%s: got root resource %q, want %q", c.name, got, c.want)
+		}
+	}
+}
@@ -324,3 +324,18 @@ func (c *Controller) GetIstioServiceAccounts(svc *model.Service, ports []int) []
 	}
 	return nil
 }
+
+// NetworkGateways implements model.NetworkGatewayDiscovery, merging the network gateways discovered by the registries
+func (c *Controller) NetworkGateways() map[string][]*model.NetworkGateway {
+	out := make(map[string][]*model.NetworkGateway)
+	for _, r := range c.GetRegistries() {
+		gd, ok := r.ServiceDiscovery.(model.NetworkGatewayDiscovery)
+		if !ok {
+			continue
+		}
+		for network, gateways := range gd.NetworkGateways() {
+			out[network] = append(out[network], gateways...)
+		}
+	}
+	return out
+}
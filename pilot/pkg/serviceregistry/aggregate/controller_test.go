@@ -534,3 +534,41 @@ func TestGetRegistries(t *testing.T) {
 		}
 	}
 }
+
+// gatewayDiscovery is a registry discovering network gateways
+type gatewayDiscovery struct {
+	*memory.ServiceDiscovery
+	gateways map[string][]*model.NetworkGateway
+}
+
+func (d *gatewayDiscovery) NetworkGateways() map[string][]*model.NetworkGateway {
+	return d.gateways
+}
+
+func TestNetworkGateways(t *testing.T) {
+	gw1 := &model.NetworkGateway{Network: "network1", ClusterID: "cluster-1", Addr: "1.1.1.1", Port: 15443}
+	gw2 := &model.NetworkGateway{Network: "network1", ClusterID: "cluster-2", Addr: "2.2.2.2", Port: 15443}
+	gw3 := &model.NetworkGateway{Network: "network2", ClusterID: "cluster-2", Addr: "3.3.3.3", Port: 31443}
+
+	ctls := buildMockController()
+	ctls.AddRegistry(Registry{
+		Name:             serviceregistry.KubernetesRegistry,
+		ClusterID:        "cluster-1",
+		ServiceDiscovery: &gatewayDiscovery{discovery1, map[string][]*model.NetworkGateway{"network1": {gw1}}},
+		Controller:       &MockController{},
+	})
+	ctls.AddRegistry(Registry{
+		Name:             serviceregistry.KubernetesRegistry,
+		ClusterID:        "cluster-2",
+		ServiceDiscovery: &gatewayDiscovery{discovery2, map[string][]*model.NetworkGateway{"network1": {gw2}, "network2": {gw3}}},
+		Controller:       &MockController{},
+	})
+
+	want := map[string][]*model.NetworkGateway{
+		"network1": {gw1, gw2},
+		"network2": {gw3},
+	}
+	if got := ctls.NetworkGateways(); !reflect.DeepEqual(got, want) {
+		t.Errorf("NetworkGateways() => got %v, want %v", got, want)
+	}
+}
@@ -40,13 +40,48 @@ type Controller struct {
 	initDone         bool
 }
 
+// ControllerOptions stores the configurable attributes of a Controller.
+type ControllerOptions struct {
+	// ServerURL is the address of the Consul agent. Use the https scheme to connect to the agent with TLS.
+	ServerURL string
+
+	// Interval is the delay before retrying a failed query. The blocking queries wait for a change up to a
+	// fixed 5 minutes.
+	Interval time.Duration
+
+	// Token is the ACL token used for the requests. If empty, the CONSUL_HTTP_TOKEN environment variable is used.
+	Token string
+
+	// TLS configures the client certificate and the CA used for the TLS connection to the agent. If empty, the
+	// CONSUL_CACERT, CONSUL_CLIENT_CERT and CONSUL_CLIENT_KEY environment variables are used.
+	TLS api.TLSConfig
+}
+
 // NewController creates a new Consul controller
-func NewController(addr string, interval time.Duration) (*Controller, error) {
+func NewController(options ControllerOptions) (*Controller, error) {
 	conf := api.DefaultConfig()
-	conf.Address = addr
+	conf.Address = options.ServerURL
+	if options.Token != "" {
+		conf.Token = options.Token
+	}
+	if options.TLS.CAFile != "" {
+		conf.TLSConfig.CAFile = options.TLS.CAFile
+	}
+	if options.TLS.CAPath != "" {
+		conf.TLSConfig.CAPath = options.TLS.CAPath
+	}
+	if options.TLS.CertFile != "" {
+		conf.TLSConfig.CertFile = options.TLS.CertFile
+	}
+	if options.TLS.KeyFile != "" {
+		conf.TLSConfig.KeyFile = options.TLS.KeyFile
+	}
+	if options.TLS.InsecureSkipVerify {
+		conf.TLSConfig.InsecureSkipVerify = true
+	}
 
 	client, err := api.NewClient(conf)
-	monitor := NewConsulMonitor(client, interval)
+	monitor := NewConsulMonitor(client, options.Interval)
 	controller := Controller{
 		monitor: monitor,
 		client:  client,
@@ -253,17 +288,25 @@ func (c *Controller) initCache() error {
 	}
 
 	for serviceName := range consulServices {
-		// get endpoints of a service from consul
-		endpoints, err := c.getCatalogService(serviceName, nil)
+		// get endpoints of a service from consul, with their health
+		entries, err := c.getHealthService(serviceName, nil)
 		if err != nil {
 			return err
 		}
-		c.services[serviceName] = convertService(endpoints)
 
-		instances := make([]*model.ServiceInstance, len(endpoints))
-		for i, endpoint := range endpoints {
-			instances[i] = convertInstance(endpoint)
+		endpoints := make([]*api.CatalogService, 0, len(entries))
+		instances := make([]*model.ServiceInstance, 0, len(entries))
+		for _, entry := range entries {
+			endpoint := convertServiceEntry(entry)
+			endpoints = append(endpoints, endpoint)
+			// The service is defined by all its endpoints, but only the healthy ones receive traffic.
+			if isHealthy(entry) {
+				instances = append(instances, convertInstance(endpoint))
+			} else {
+				log.Debugf("Ignoring unhealthy instance %s of service %s", endpoint.ServiceID, serviceName)
+			}
 		}
+		c.services[serviceName] = convertService(endpoints)
 		c.serviceInstances[serviceName] = instances
 	}
 
@@ -287,14 +330,14 @@ func (c *Controller) getServices() (map[string][]string, error) {
 }
 
 // nolint: unparam
-func (c *Controller) getCatalogService(name string, q *api.QueryOptions) ([]*api.CatalogService, error) {
-	endpoints, _, err := c.client.Catalog().Service(name, "", q)
+func (c *Controller) getHealthService(name string, q *api.QueryOptions) ([]*api.ServiceEntry, error) {
+	entries, _, err := c.client.Health().Service(name, "", false, q)
 	if err != nil {
-		log.Warnf("Could not retrieve service catalogue from consul: %v", err)
+		log.Warnf("Could not retrieve service health from consul: %v", err)
 		return nil, err
 	}
 
-	return endpoints, nil
+	return entries, nil
 }
 
 func (c *Controller) refreshCache() {
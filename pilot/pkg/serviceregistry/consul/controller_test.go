@@ -21,6 +21,8 @@ import (
 	"net/http/httptest"
 	"reflect"
 	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -96,13 +98,25 @@ var (
 	}
 )
 
+// mockServer is a fake Consul agent. It serves the catalog and health endpoints from its fields, and supports
+// blocking queries: the index is incremented whenever a change of the fields is observed.
 type mockServer struct {
 	Server      *httptest.Server
 	Services    map[string][]string
 	Productpage []*api.CatalogService
 	Reviews     []*api.CatalogService
 	Rating      []*api.CatalogService
-	Lock        sync.Mutex
+	// Health is the status of the health check of the instances, by service address. Instances without a
+	// status are passing.
+	Health map[string]string
+	// Token is the ACL token of the last request.
+	Token string
+	// Wait is the wait time of the last blocking query.
+	Wait time.Duration
+	Lock sync.Mutex
+
+	index     uint64
+	lastState string
 }
 
 func newServer() *mockServer {
@@ -111,6 +125,7 @@ func newServer() *mockServer {
 		Reviews:     make([]*api.CatalogService, len(reviews)),
 		Rating:      make([]*api.CatalogService, len(rating)),
 		Services:    make(map[string][]string),
+		Health:      make(map[string]string),
 	}
 
 	copy(m.Reviews, reviews)
@@ -120,46 +135,113 @@ func newServer() *mockServer {
 		m.Services[k] = v
 	}
 
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if r.URL.Path == "/v1/catalog/services" {
-			m.Lock.Lock()
-			data, _ := json.Marshal(&m.Services)
-			m.Lock.Unlock()
-			w.Header().Set("Content-Type", "application/json")
-			_, _ = fmt.Fprintln(w, string(data))
-		} else if r.URL.Path == "/v1/catalog/service/reviews" {
-			m.Lock.Lock()
-			data, _ := json.Marshal(&m.Reviews)
-			m.Lock.Unlock()
-			w.Header().Set("Content-Type", "application/json")
-			_, _ = fmt.Fprintln(w, string(data))
-		} else if r.URL.Path == "/v1/catalog/service/productpage" {
-			m.Lock.Lock()
-			data, _ := json.Marshal(&m.Productpage)
-			m.Lock.Unlock()
-			w.Header().Set("Content-Type", "application/json")
-			_, _ = fmt.Fprintln(w, string(data))
-		} else if r.URL.Path == "/v1/catalog/service/rating" {
-			m.Lock.Lock()
-			data, _ := json.Marshal(&m.Rating)
-			m.Lock.Unlock()
-			w.Header().Set("Content-Type", "application/json")
-			_, _ = fmt.Fprintln(w, string(data))
-		} else {
-			data, _ := json.Marshal(&[]*api.CatalogService{})
+	m.Server = httptest.NewServer(http.HandlerFunc(m.handle))
+	return &m
+}
+
+func (m *mockServer) handle(w http.ResponseWriter, r *http.Request) {
+	waitIndex, _ := strconv.ParseUint(r.URL.Query().Get("index"), 10, 64)
+	wait, _ := time.ParseDuration(r.URL.Query().Get("wait"))
+	deadline := time.Now().Add(wait)
+
+	m.Lock.Lock()
+	m.Token = r.Header.Get("X-Consul-Token")
+	if waitIndex != 0 {
+		m.Wait = wait
+	}
+	m.Lock.Unlock()
+
+	for {
+		m.Lock.Lock()
+		index := m.currentIndex()
+		data, _ := json.Marshal(m.response(r.URL.Path))
+		m.Lock.Unlock()
+
+		if waitIndex == 0 || index > waitIndex || time.Now().After(deadline) {
 			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("X-Consul-Index", strconv.FormatUint(index, 10))
 			_, _ = fmt.Fprintln(w, string(data))
+			return
 		}
-	}))
 
-	m.Server = server
-	return &m
+		select {
+		case <-r.Context().Done():
+			return
+		case <-time.After(time.Millisecond):
+		}
+	}
+}
+
+// currentIndex returns the index, after incrementing it if the state changed. Must be called under lock.
+func (m *mockServer) currentIndex() uint64 {
+	state, _ := json.Marshal([]interface{}{m.Services, m.Productpage, m.Reviews, m.Rating, m.Health})
+	if m.index == 0 || string(state) != m.lastState {
+		m.index++
+		m.lastState = string(state)
+	}
+	return m.index
+}
+
+// response returns the response for the endpoint. Must be called under lock.
+func (m *mockServer) response(path string) interface{} {
+	instances := map[string][]*api.CatalogService{
+		"productpage": m.Productpage,
+		"reviews":     m.Reviews,
+		"rating":      m.Rating,
+	}
+
+	switch {
+	case path == "/v1/catalog/services":
+		return m.Services
+	case strings.HasPrefix(path, "/v1/catalog/service/"):
+		if list, found := instances[strings.TrimPrefix(path, "/v1/catalog/service/")]; found {
+			return list
+		}
+		return []*api.CatalogService{}
+	case strings.HasPrefix(path, "/v1/health/service/"):
+		entries := make([]*api.ServiceEntry, 0)
+		for _, cs := range instances[strings.TrimPrefix(path, "/v1/health/service/")] {
+			status := m.Health[cs.ServiceAddress]
+			if status == "" {
+				status = api.HealthPassing
+			}
+			entries = append(entries, &api.ServiceEntry{
+				Node: &api.Node{
+					ID:         cs.ID,
+					Node:       cs.Node,
+					Address:    cs.Address,
+					Datacenter: cs.Datacenter,
+					Meta:       cs.NodeMeta,
+				},
+				Service: &api.AgentService{
+					ID:      cs.ServiceID,
+					Service: cs.ServiceName,
+					Tags:    cs.ServiceTags,
+					Meta:    cs.ServiceMeta,
+					Port:    cs.ServicePort,
+					Address: cs.ServiceAddress,
+				},
+				Checks: api.HealthChecks{
+					{
+						Node:        cs.Node,
+						CheckID:     "service:" + cs.ServiceID,
+						Status:      status,
+						ServiceID:   cs.ServiceID,
+						ServiceName: cs.ServiceName,
+					},
+				},
+			})
+		}
+		return entries
+	default:
+		return []*api.CatalogService{}
+	}
 }
 
 func TestInstances(t *testing.T) {
 	ts := newServer()
 	defer ts.Server.Close()
-	controller, err := NewController(ts.Server.URL, 3*time.Second)
+	controller, err := NewController(ControllerOptions{ServerURL: ts.Server.URL, Interval: 3 * time.Second})
 	if err != nil {
 		t.Errorf("could not create Consul Controller: %v", err)
 	}
@@ -229,7 +311,7 @@ func TestInstances(t *testing.T) {
 func TestInstancesBadHostname(t *testing.T) {
 	ts := newServer()
 	defer ts.Server.Close()
-	controller, err := NewController(ts.Server.URL, 3*time.Second)
+	controller, err := NewController(ControllerOptions{ServerURL: ts.Server.URL, Interval: 3 * time.Second})
 	if err != nil {
 		t.Errorf("could not create Consul Controller: %v", err)
 	}
@@ -251,7 +333,7 @@ func TestInstancesBadHostname(t *testing.T) {
 
 func TestInstancesError(t *testing.T) {
 	ts := newServer()
-	controller, err := NewController(ts.Server.URL, 3*time.Second)
+	controller, err := NewController(ControllerOptions{ServerURL: ts.Server.URL, Interval: 3 * time.Second})
 	if err != nil {
 		ts.Server.Close()
 		t.Errorf("could not create Consul Controller: %v", err)
@@ -277,7 +359,7 @@ func TestInstancesError(t *testing.T) {
 func TestGetService(t *testing.T) {
 	ts := newServer()
 	defer ts.Server.Close()
-	controller, err := NewController(ts.Server.URL, 3*time.Second)
+	controller, err := NewController(ControllerOptions{ServerURL: ts.Server.URL, Interval: 3 * time.Second})
 	if err != nil {
 		t.Errorf("could not create Consul Controller: %v", err)
 	}
@@ -298,7 +380,7 @@ func TestGetService(t *testing.T) {
 
 func TestGetServiceError(t *testing.T) {
 	ts := newServer()
-	controller, err := NewController(ts.Server.URL, 3*time.Second)
+	controller, err := NewController(ControllerOptions{ServerURL: ts.Server.URL, Interval: 3 * time.Second})
 	if err != nil {
 		ts.Server.Close()
 		t.Errorf("could not create Consul Controller: %v", err)
@@ -317,7 +399,7 @@ func TestGetServiceError(t *testing.T) {
 func TestGetServiceBadHostname(t *testing.T) {
 	ts := newServer()
 	defer ts.Server.Close()
-	controller, err := NewController(ts.Server.URL, 3*time.Second)
+	controller, err := NewController(ControllerOptions{ServerURL: ts.Server.URL, Interval: 3 * time.Second})
 	if err != nil {
 		t.Errorf("could not create Consul Controller: %v", err)
 	}
@@ -334,7 +416,7 @@ func TestGetServiceBadHostname(t *testing.T) {
 func TestGetServiceNoInstances(t *testing.T) {
 	ts := newServer()
 	defer ts.Server.Close()
-	controller, err := NewController(ts.Server.URL, 3*time.Second)
+	controller, err := NewController(ControllerOptions{ServerURL: ts.Server.URL, Interval: 3 * time.Second})
 	if err != nil {
 		t.Errorf("could not create Consul Controller: %v", err)
 	}
@@ -351,7 +433,7 @@ func TestGetServiceNoInstances(t *testing.T) {
 func TestServices(t *testing.T) {
 	ts := newServer()
 	defer ts.Server.Close()
-	controller, err := NewController(ts.Server.URL, 3*time.Second)
+	controller, err := NewController(ControllerOptions{ServerURL: ts.Server.URL, Interval: 3 * time.Second})
 	if err != nil {
 		t.Errorf("could not create Consul Controller: %v", err)
 	}
@@ -381,7 +463,7 @@ func TestServices(t *testing.T) {
 
 func TestServicesError(t *testing.T) {
 	ts := newServer()
-	controller, err := NewController(ts.Server.URL, 3*time.Second)
+	controller, err := NewController(ControllerOptions{ServerURL: ts.Server.URL, Interval: 3 * time.Second})
 	if err != nil {
 		ts.Server.Close()
 		t.Errorf("could not create Consul Controller: %v", err)
@@ -400,7 +482,7 @@ func TestServicesError(t *testing.T) {
 func TestGetProxyServiceInstances(t *testing.T) {
 	ts := newServer()
 	defer ts.Server.Close()
-	controller, err := NewController(ts.Server.URL, 3*time.Second)
+	controller, err := NewController(ControllerOptions{ServerURL: ts.Server.URL, Interval: 3 * time.Second})
 	if err != nil {
 		t.Errorf("could not create Consul Controller: %v", err)
 	}
@@ -421,7 +503,7 @@ func TestGetProxyServiceInstances(t *testing.T) {
 
 func TestGetProxyServiceInstancesError(t *testing.T) {
 	ts := newServer()
-	controller, err := NewController(ts.Server.URL, 3*time.Second)
+	controller, err := NewController(ControllerOptions{ServerURL: ts.Server.URL, Interval: 3 * time.Second})
 	if err != nil {
 		ts.Server.Close()
 		t.Errorf("could not create Consul Controller: %v", err)
@@ -440,7 +522,7 @@ func TestGetProxyServiceInstancesError(t *testing.T) {
 func TestGetProxyServiceInstancesWithMultiIPs(t *testing.T) {
 	ts := newServer()
 	defer ts.Server.Close()
-	controller, err := NewController(ts.Server.URL, 3*time.Second)
+	controller, err := NewController(ControllerOptions{ServerURL: ts.Server.URL, Interval: 3 * time.Second})
 	if err != nil {
 		t.Errorf("could not create Consul Controller: %v", err)
 	}
@@ -462,7 +544,7 @@ func TestGetProxyServiceInstancesWithMultiIPs(t *testing.T) {
 func TestGetProxyWorkloadLabels(t *testing.T) {
 	ts := newServer()
 	defer ts.Server.Close()
-	controller, err := NewController(ts.Server.URL, 3*time.Second)
+	controller, err := NewController(ControllerOptions{ServerURL: ts.Server.URL, Interval: 3 * time.Second})
 	if err != nil {
 		t.Errorf("could not create Consul Controller: %v", err)
 	}
@@ -521,7 +603,7 @@ func TestGetProxyWorkloadLabels(t *testing.T) {
 
 func TestGetServiceByCache(t *testing.T) {
 	ts := newServer()
-	controller, err := NewController(ts.Server.URL, 3*time.Second)
+	controller, err := NewController(ControllerOptions{ServerURL: ts.Server.URL, Interval: 3 * time.Second})
 	if err != nil {
 		t.Errorf("could not create Consul Controller: %v", err)
 	}
@@ -544,11 +626,14 @@ func TestGetServiceByCache(t *testing.T) {
 func TestGetInstanceByCacheAfterChanged(t *testing.T) {
 	ts := newServer()
 	defer ts.Server.Close()
-	controller, err := NewController(ts.Server.URL, 1*time.Second)
+	controller, err := NewController(ControllerOptions{ServerURL: ts.Server.URL, Interval: 1 * time.Second})
 	if err != nil {
 		t.Errorf("could not create Consul Controller: %v", err)
 	}
-	go controller.Run(make(chan struct{}))
+	// The blocking queries must be canceled before the server is closed.
+	stop := make(chan struct{})
+	defer close(stop)
+	go controller.Run(stop)
 
 	hostname := serviceHostname("reviews")
 	svc := &model.Service{
@@ -602,3 +687,64 @@ func TestGetInstanceByCacheAfterChanged(t *testing.T) {
 		}
 	}
 }
+
+func TestInstancesUnhealthy(t *testing.T) {
+	ts := newServer()
+	defer ts.Server.Close()
+	ts.Health["172.19.0.7"] = api.HealthCritical
+	controller, err := NewController(ControllerOptions{ServerURL: ts.Server.URL, Interval: 3 * time.Second})
+	if err != nil {
+		t.Errorf("could not create Consul Controller: %v", err)
+	}
+	svc := &model.Service{
+		Hostname: serviceHostname("reviews"),
+		Attributes: model.ServiceAttributes{
+			Name:      "reviews",
+			Namespace: model.IstioDefaultConfigNamespace,
+		},
+	}
+
+	instances, err := controller.InstancesByPort(svc, 0, labels.Collection{})
+	if err != nil {
+		t.Errorf("client encountered error during Instances(): %v", err)
+	}
+	if len(instances) != 2 {
+		t.Errorf("Instances() returned wrong # of service instances => %q, want 2", len(instances))
+	}
+	for _, inst := range instances {
+		if inst.Endpoint.Address == "172.19.0.7" {
+			t.Errorf("Instances() returned the unhealthy instance %v", inst.Endpoint.Address)
+		}
+	}
+
+	// The service is still defined by all its instances.
+	service, err := controller.GetService(serviceHostname("reviews"))
+	if err != nil {
+		t.Errorf("client encountered error during GetService(): %v", err)
+	}
+	if service == nil || len(service.Ports) != 2 {
+		t.Errorf("GetService() => %v, want a service with 2 ports", service)
+	}
+}
+
+func TestControllerToken(t *testing.T) {
+	ts := newServer()
+	defer ts.Server.Close()
+	controller, err := NewController(ControllerOptions{
+		ServerURL: ts.Server.URL,
+		Interval:  3 * time.Second,
+		Token:     "acl-token",
+	})
+	if err != nil {
+		t.Errorf("could not create Consul Controller: %v", err)
+	}
+
+	if _, err = controller.Services(); err != nil {
+		t.Errorf("client encountered error during Services(): %v", err)
+	}
+	ts.Lock.Lock()
+	defer ts.Lock.Unlock()
+	if ts.Token != "acl-token" {
+		t.Errorf("request ACL token => %q, want %q", ts.Token, "acl-token")
+	}
+}
@@ -31,14 +31,19 @@ import (
 const (
 	protocolTagName = "protocol"
 	externalTagName = "external"
+	localityTagName = "locality"
 )
 
+// convertLabels converts the tags of the form "key|value" or "key=value" to labels.
 func convertLabels(labelsStr []string) labels.Instance {
 	out := make(labels.Instance, len(labelsStr))
 	for _, tag := range labelsStr {
-		vals := strings.Split(tag, "|")
-		// Labels not of form "key|value" are ignored to avoid possible collisions
-		if len(vals) > 1 {
+		vals := strings.SplitN(tag, "|", 2)
+		if len(vals) < 2 {
+			vals = strings.SplitN(tag, "=", 2)
+		}
+		// Labels not of form "key|value" or "key=value" are ignored to avoid possible collisions
+		if len(vals) > 1 && vals[0] != "" {
 			out[vals[0]] = vals[1]
 		} else {
 			log.Debugf("Tag %v ignored since it is not of form key|value", tag)
@@ -47,6 +52,65 @@ func convertLabels(labelsStr []string) labels.Instance {
 	return out
 }
 
+// convertInstanceLabels returns the labels of a service instance, from its tags and its service metadata. The
+// metadata keys that have a special meaning for Istio are not converted.
+func convertInstanceLabels(instance *api.CatalogService) labels.Instance {
+	out := convertLabels(instance.ServiceTags)
+	for k, v := range instance.ServiceMeta {
+		switch k {
+		case protocolTagName, externalTagName, localityTagName:
+		default:
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// convertLocality returns the locality of a service instance. It can be set explicitly in the service or node
+// metadata, as "region/zone/subzone". It defaults to the Consul datacenter, as the region.
+func convertLocality(instance *api.CatalogService) string {
+	if l := instance.ServiceMeta[localityTagName]; l != "" {
+		return l
+	}
+	if l := instance.NodeMeta[localityTagName]; l != "" {
+		return l
+	}
+	return instance.Datacenter
+}
+
+// convertServiceEntry converts an entry of the health endpoint to the catalog representation of the instance.
+func convertServiceEntry(entry *api.ServiceEntry) *api.CatalogService {
+	out := &api.CatalogService{}
+	if entry.Node != nil {
+		out.ID = entry.Node.ID
+		out.Node = entry.Node.Node
+		out.Address = entry.Node.Address
+		out.Datacenter = entry.Node.Datacenter
+		out.TaggedAddresses = entry.Node.TaggedAddresses
+		out.NodeMeta = entry.Node.Meta
+	}
+	if entry.Service != nil {
+		out.ServiceID = entry.Service.ID
+		out.ServiceName = entry.Service.Service
+		out.ServiceAddress = entry.Service.Address
+		out.ServiceTags = entry.Service.Tags
+		out.ServiceMeta = entry.Service.Meta
+		out.ServicePort = entry.Service.Port
+	}
+	return out
+}
+
+// isHealthy returns false if a node or service health check of the instance is critical, or if the node or
+// the service is in maintenance mode.
+func isHealthy(entry *api.ServiceEntry) bool {
+	switch entry.Checks.AggregatedStatus() {
+	case api.HealthCritical, api.HealthMaint:
+		return false
+	default:
+		return true
+	}
+}
+
 func convertPort(port int, name string) *model.Port {
 	if name == "" {
 		name = "tcp"
@@ -108,7 +172,7 @@ func convertService(endpoints []*api.CatalogService) *model.Service {
 }
 
 func convertInstance(instance *api.CatalogService) *model.ServiceInstance {
-	svcLabels := convertLabels(instance.ServiceTags)
+	svcLabels := convertInstanceLabels(instance)
 	port := convertPort(instance.ServicePort, instance.ServiceMeta[protocolTagName])
 
 	addr := instance.ServiceAddress
@@ -130,7 +194,7 @@ func convertInstance(instance *api.CatalogService) *model.ServiceInstance {
 			Address:     addr,
 			Port:        instance.ServicePort,
 			ServicePort: port,
-			Locality:    instance.Datacenter,
+			Locality:    convertLocality(instance),
 		},
 		Service: &model.Service{
 			Hostname:     hostname,
@@ -47,6 +47,11 @@ var (
 		"badtag",
 		"goodtag|goodvalue",
 	}
+
+	equalLabels = []string{
+		"key1=val1",
+		"version=v1",
+	}
 )
 
 func TestConvertProtocol(t *testing.T) {
@@ -68,6 +73,63 @@ func TestConvertLabels(t *testing.T) {
 	if len(out) == len(badLabels) {
 		t.Errorf("convertLabels(%q) => length %v, want %v", badLabels, len(out), len(badLabels)-1)
 	}
+
+	out = convertLabels(equalLabels)
+	if len(out) != len(equalLabels) || out["version"] != "v1" {
+		t.Errorf("convertLabels(%q) => %v, want %v labels", equalLabels, out, len(equalLabels))
+	}
+}
+
+func TestConvertInstanceMetadata(t *testing.T) {
+	inst := &api.CatalogService{
+		ServiceName: "productpage",
+		ServiceTags: []string{"version|v1"},
+		ServicePort: 9080,
+		Datacenter:  "dc1",
+		ServiceMeta: map[string]string{protocolTagName: "http", "app": "productpage"},
+	}
+
+	out := convertInstance(inst)
+	if len(out.Labels) != 2 || out.Labels["app"] != "productpage" || out.Labels["version"] != "v1" {
+		t.Errorf("convertInstance() labels => %v, want the tag and the metadata", out.Labels)
+	}
+	if out.Endpoint.Locality != "dc1" {
+		t.Errorf("convertInstance() locality => %q, want %q", out.Endpoint.Locality, "dc1")
+	}
+
+	inst.NodeMeta = map[string]string{localityTagName: "us-east/zone-a"}
+	if out = convertInstance(inst); out.Endpoint.Locality != "us-east/zone-a" {
+		t.Errorf("convertInstance() locality => %q, want the node locality", out.Endpoint.Locality)
+	}
+
+	inst.ServiceMeta[localityTagName] = "us-east/zone-b/rack1"
+	if out = convertInstance(inst); out.Endpoint.Locality != "us-east/zone-b/rack1" {
+		t.Errorf("convertInstance() locality => %q, want the service locality", out.Endpoint.Locality)
+	}
+	if _, found := out.Labels[localityTagName]; found {
+		t.Errorf("convertInstance() labels => %v, the locality should not be a label", out.Labels)
+	}
+}
+
+func TestIsHealthy(t *testing.T) {
+	cases := []struct {
+		checks api.HealthChecks
+		want   bool
+	}{
+		{nil, true},
+		{api.HealthChecks{{CheckID: "serfHealth", Status: api.HealthPassing}}, true},
+		{api.HealthChecks{{CheckID: "service:a", Status: api.HealthWarning}}, true},
+		{api.HealthChecks{
+			{CheckID: "serfHealth", Status: api.HealthPassing},
+			{CheckID: "service:a", Status: api.HealthCritical},
+		}, false},
+		{api.HealthChecks{{CheckID: api.NodeMaint, Status: api.HealthCritical}}, false},
+	}
+	for _, c := range cases {
+		if got := isHealthy(&api.ServiceEntry{Checks: c.checks}); got != c.want {
+			t.Errorf("isHealthy(%v) => %v, want %v", c.checks, got, c.want)
+		}
+	}
 }
 
 func TestConvertInstance(t *testing.T) {
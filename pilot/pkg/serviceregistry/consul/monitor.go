@@ -15,8 +15,10 @@
 package consul
 
 import (
+	"context"
 	"reflect"
 	"sort"
+	"sync"
 	"time"
 
 	"github.com/hashicorp/consul/api"
@@ -25,8 +27,11 @@ import (
 	"istio.io/pkg/log"
 )
 
+// blockingQueryWaitTime is the longest time a blocking query waits for a change. Consul caps it at 10 minutes.
+const blockingQueryWaitTime = 5 * time.Minute
+
 type consulServices map[string][]string
-type consulServiceInstances []*api.CatalogService
+type consulServiceEntries []*api.ServiceEntry
 
 // Monitor handles service and instance changes
 type Monitor interface {
@@ -42,111 +47,196 @@ type InstanceHandler func(instance *api.CatalogService, event model.Event) error
 type ServiceHandler func(instances []*api.CatalogService, event model.Event) error
 
 type consulMonitor struct {
-	discovery            *api.Client
-	instanceCachedRecord consulServiceInstances
-	serviceCachedRecord  consulServices
-	instanceHandlers     []InstanceHandler
-	serviceHandlers      []ServiceHandler
-	period               time.Duration
+	discovery        *api.Client
+	instanceHandlers []InstanceHandler
+	serviceHandlers  []ServiceHandler
+	period           time.Duration
+	waitTime         time.Duration
+
+	mu sync.Mutex
+	// watchers holds the cancel functions of the instance watchers, by service name.
+	watchers map[string]context.CancelFunc
 }
 
-// NewConsulMonitor polls for changes in Consul Services and CatalogServices
+// NewConsulMonitor watches for changes in Consul Services and their instances, using blocking queries that
+// wait up to 5 minutes for a change. The period is the delay before retrying a failed query.
 func NewConsulMonitor(client *api.Client, period time.Duration) Monitor {
 	return &consulMonitor{
-		discovery:            client,
-		period:               period,
-		instanceCachedRecord: make(consulServiceInstances, 0),
-		serviceCachedRecord:  make(consulServices),
-		instanceHandlers:     make([]InstanceHandler, 0),
-		serviceHandlers:      make([]ServiceHandler, 0),
+		discovery:        client,
+		period:           period,
+		waitTime:         blockingQueryWaitTime,
+		instanceHandlers: make([]InstanceHandler, 0),
+		serviceHandlers:  make([]ServiceHandler, 0),
+		watchers:         make(map[string]context.CancelFunc),
 	}
 }
 
 func (m *consulMonitor) Start(stop <-chan struct{}) {
-	m.run(stop)
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-stop
+		cancel()
+	}()
+	m.run(ctx)
 }
 
-func (m *consulMonitor) run(stop <-chan struct{}) {
-	ticker := time.NewTicker(m.period)
+// run watches the service catalog until the context is canceled, and keeps an instance watcher running for
+// each service.
+func (m *consulMonitor) run(ctx context.Context) {
+	var index uint64
+	var record consulServices
 	for {
-		select {
-		case <-stop:
-			ticker.Stop()
+		q := &api.QueryOptions{WaitIndex: index, WaitTime: m.waitTime}
+		svcs, meta, err := m.discovery.Catalog().Services(q.WithContext(ctx))
+		if ctx.Err() != nil {
+			m.stopWatchers()
 			return
-		case <-ticker.C:
-			m.updateServiceRecord()
-			m.updateInstanceRecord()
 		}
+		if err != nil {
+			log.Warnf("Could not fetch services: %v", err)
+			if !m.sleep(ctx) {
+				m.stopWatchers()
+				return
+			}
+			continue
+		}
+		index = nextIndex(index, meta.LastIndex)
+
+		// The order of service tags may change even there is no service change
+		// Sort the service tags to avoid unnecessary pushes to envoy
+		for _, tags := range svcs {
+			sort.Strings(tags)
+		}
+		newRecord := consulServices(svcs)
+		if record != nil && reflect.DeepEqual(newRecord, record) {
+			continue
+		}
+
+		removed := m.reconcileWatchers(ctx, newRecord)
+		m.notifyServiceHandlers()
+		if removed {
+			// The instances of the removed services are gone as well.
+			m.notifyInstanceHandlers()
+		}
+		record = newRecord
 	}
 }
 
-func (m *consulMonitor) updateServiceRecord() {
-	svcs, _, err := m.discovery.Catalog().Services(nil)
-	if err != nil {
-		log.Warnf("Could not fetch services: %v", err)
-		return
-	}
+// reconcileWatchers starts an instance watcher for each new service, and stops the watchers of the removed
+// services. It returns true if any service was removed.
+func (m *consulMonitor) reconcileWatchers(ctx context.Context, svcs consulServices) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 
-	// The order of service tags may change even there is no service change
-	// Sort the service tags to avoid unnecessary pushes to envoy
-	for _, tags := range svcs {
-		sort.Strings(tags)
+	removed := false
+	for name, cancel := range m.watchers {
+		if _, found := svcs[name]; !found {
+			cancel()
+			delete(m.watchers, name)
+			removed = true
+		}
 	}
-	newRecord := consulServices(svcs)
-	if !reflect.DeepEqual(newRecord, m.serviceCachedRecord) {
-		// This is only a work-around solution currently
-		// Since Handler functions generally act as a refresher
-		// regardless of the input, thus passing in meaningless
-		// input should make functionalities work
-		//TODO
-		var obj []*api.CatalogService
-		var event model.Event
-		for _, f := range m.serviceHandlers {
-			go func(handler ServiceHandler) {
-				if err := handler(obj, event); err != nil {
-					log.Warnf("Error executing service handler function: %v", err)
-				}
-			}(f)
+	for name := range svcs {
+		if _, found := m.watchers[name]; !found {
+			watchCtx, cancel := context.WithCancel(ctx)
+			m.watchers[name] = cancel
+			go m.watchInstances(watchCtx, name)
 		}
-		m.serviceCachedRecord = newRecord
 	}
+	return removed
 }
 
-func (m *consulMonitor) updateInstanceRecord() {
-	svcs, _, err := m.discovery.Catalog().Services(nil)
-	if err != nil {
-		log.Warnf("Could not fetch instances: %v", err)
-		return
+func (m *consulMonitor) stopWatchers() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for name, cancel := range m.watchers {
+		cancel()
+		delete(m.watchers, name)
 	}
+}
 
-	instances := make([]*api.CatalogService, 0)
-	for name := range svcs {
-		endpoints, _, err := m.discovery.Catalog().Service(name, "", nil)
+// watchInstances watches the instances of a service and their health, until the context is canceled.
+func (m *consulMonitor) watchInstances(ctx context.Context, name string) {
+	var index uint64
+	var record consulServiceEntries
+	for {
+		q := &api.QueryOptions{WaitIndex: index, WaitTime: m.waitTime}
+		entries, meta, err := m.discovery.Health().Service(name, "", false, q.WithContext(ctx))
+		if ctx.Err() != nil {
+			return
+		}
 		if err != nil {
-			log.Warnf("Could not retrieve service catalogue from consul: %v", err)
+			log.Warnf("Could not retrieve instances of service %s from consul: %v", name, err)
+			if !m.sleep(ctx) {
+				return
+			}
 			continue
 		}
-		instances = append(instances, endpoints...)
-	}
+		index = nextIndex(index, meta.LastIndex)
 
-	newRecord := consulServiceInstances(instances)
-	sort.Sort(newRecord)
-	if !reflect.DeepEqual(newRecord, m.instanceCachedRecord) {
-		// This is only a work-around solution currently
-		// Since Handler functions generally act as a refresher
-		// regardless of the input, thus passing in meaningless
-		// input should make functionalities work
-		// TODO
-		obj := &api.CatalogService{}
-		var event model.Event
-		for _, f := range m.instanceHandlers {
-			go func(handler InstanceHandler) {
-				if err := handler(obj, event); err != nil {
-					log.Warnf("Error executing instance handler function: %v", err)
-				}
-			}(f)
+		newRecord := consulServiceEntries(entries)
+		sort.Sort(newRecord)
+		for _, e := range newRecord {
+			sort.Slice(e.Checks, func(i, j int) bool { return e.Checks[i].CheckID < e.Checks[j].CheckID })
 		}
-		m.instanceCachedRecord = newRecord
+		if record != nil && reflect.DeepEqual(newRecord, record) {
+			continue
+		}
+		m.notifyInstanceHandlers()
+		record = newRecord
+	}
+}
+
+// sleep waits for the retry period. It returns false if the context was canceled meanwhile.
+func (m *consulMonitor) sleep(ctx context.Context) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(m.period):
+		return true
+	}
+}
+
+// nextIndex returns the wait index of the next blocking query. The index is reset if it went backwards, as
+// recommended by the Consul documentation.
+func nextIndex(current, last uint64) uint64 {
+	if last < current {
+		return 0
+	}
+	return last
+}
+
+func (m *consulMonitor) notifyServiceHandlers() {
+	// This is only a work-around solution currently
+	// Since Handler functions generally act as a refresher
+	// regardless of the input, thus passing in meaningless
+	// input should make functionalities work
+	//TODO
+	var obj []*api.CatalogService
+	var event model.Event
+	for _, f := range m.serviceHandlers {
+		go func(handler ServiceHandler) {
+			if err := handler(obj, event); err != nil {
+				log.Warnf("Error executing service handler function: %v", err)
+			}
+		}(f)
+	}
+}
+
+func (m *consulMonitor) notifyInstanceHandlers() {
+	// This is only a work-around solution currently
+	// Since Handler functions generally act as a refresher
+	// regardless of the input, thus passing in meaningless
+	// input should make functionalities work
+	// TODO
+	obj := &api.CatalogService{}
+	var event model.Event
+	for _, f := range m.instanceHandlers {
+		go func(handler InstanceHandler) {
+			if err := handler(obj, event); err != nil {
+				log.Warnf("Error executing instance handler function: %v", err)
+			}
+		}(f)
 	}
 }
 
@@ -159,18 +249,23 @@ func (m *consulMonitor) AppendInstanceHandler(h InstanceHandler) {
 }
 
 // Len of the array
-func (a consulServiceInstances) Len() int {
+func (a consulServiceEntries) Len() int {
 	return len(a)
 }
 
 // Swap i and j
-func (a consulServiceInstances) Swap(i, j int) {
+func (a consulServiceEntries) Swap(i, j int) {
 	a[i], a[j] = a[j], a[i]
 }
 
 // Less i and j
-func (a consulServiceInstances) Less(i, j int) bool {
+func (a consulServiceEntries) Less(i, j int) bool {
 	// ID is the node ID
-	// ServiceID is a unique service instance identifier
-	return a[i].ID+a[i].ServiceID < a[j].ID+a[j].ServiceID
+	// Service.ID is a unique service instance identifier
+	ki := a[i].Node.ID + a[i].Service.ID
+	kj := a[j].Node.ID + a[j].Service.ID
+	if ki != kj {
+		return ki < kj
+	}
+	return a[i].Service.Address < a[j].Service.Address
 }
@@ -99,6 +99,12 @@ func TestController(t *testing.T) {
 	ts.Lock.Unlock()
 	expectNotify(t, 1)
 
+	// failing health check -> triggers instance update
+	ts.Lock.Lock()
+	ts.Health["172.19.0.11"] = api.HealthCritical
+	ts.Lock.Unlock()
+	expectNotify(t, 1)
+
 	// delete a service instance -> trigger instance update
 	ts.Lock.Lock()
 	ts.Reviews = reviews[0:1]
@@ -110,4 +116,12 @@ func TestController(t *testing.T) {
 	delete(ts.Services, "productpage")
 	ts.Lock.Unlock()
 	expectNotify(t, 2)
+
+	// The blocking queries wait for a change much longer than the retry period.
+	ts.Lock.Lock()
+	wait := ts.Wait
+	ts.Lock.Unlock()
+	if wait != blockingQueryWaitTime {
+		t.Errorf("unexpected wait time of the blocking queries %v, want %v", wait, blockingQueryWaitTime)
+	}
 }
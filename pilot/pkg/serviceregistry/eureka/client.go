@@ -0,0 +1,172 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eureka
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// appsPath is the path of the Eureka REST endpoint listing all the registered applications.
+	appsPath = "/apps"
+
+	requestTimeout = 10 * time.Second
+)
+
+// statusUp is the status of the instances ready to receive traffic. The other statuses are DOWN, STARTING,
+// OUT_OF_SERVICE and UNKNOWN.
+const statusUp = "UP"
+
+// client lists the applications registered in Eureka.
+type client interface {
+	// Applications returns all the registered applications and their instances.
+	Applications() ([]*application, error)
+}
+
+type getApplications struct {
+	Applications applications `json:"applications"`
+}
+
+type applications struct {
+	Applications []*application `json:"application"`
+}
+
+type application struct {
+	Name      string      `json:"name"`
+	Instances []*instance `json:"instance"`
+}
+
+type instance struct {
+	InstanceID string   `json:"instanceId,omitempty"`
+	Hostname   string   `json:"hostName"`
+	App        string   `json:"app"`
+	IPAddress  string   `json:"ipAddr"`
+	Status     string   `json:"status"`
+	Port       port     `json:"port,omitempty"`
+	SecurePort port     `json:"securePort,omitempty"`
+	VIPAddress string   `json:"vipAddress,omitempty"`
+	Metadata   metadata `json:"metadata,omitempty"`
+}
+
+type port struct {
+	Port    int
+	Enabled bool
+}
+
+// UnmarshalJSON accepts the port number as a JSON number or string, as the Eureka server encodes it
+// differently depending on its version.
+func (p *port) UnmarshalJSON(b []byte) error {
+	var raw struct {
+		Port    json.RawMessage `json:"$"`
+		Enabled json.RawMessage `json:"@enabled"`
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	n, err := unquote(raw.Port)
+	if err != nil {
+		return err
+	}
+	enabled, err := unquote(raw.Enabled)
+	if err != nil {
+		return err
+	}
+	p.Enabled = enabled == "true"
+	p.Port = 0
+	if n != "" {
+		if _, err := fmt.Sscanf(n, "%d", &p.Port); err != nil {
+			return fmt.Errorf("invalid port %q: %v", n, err)
+		}
+	}
+	return nil
+}
+
+// unquote returns the raw value of a JSON string, number or boolean.
+func unquote(raw json.RawMessage) (string, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return "", nil
+	}
+	if raw[0] == '"' {
+		var s string
+		err := json.Unmarshal(raw, &s)
+		return s, err
+	}
+	return string(raw), nil
+}
+
+type metadata map[string]string
+
+// UnmarshalJSON ignores the metadata entries that are not strings, such as the "@class" marker of the
+// empty metadata maps.
+func (m *metadata) UnmarshalJSON(b []byte) error {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	out := make(metadata, len(raw))
+	for k, v := range raw {
+		if s, ok := v.(string); ok && !strings.HasPrefix(k, "@") {
+			out[k] = s
+		}
+	}
+	*m = out
+	return nil
+}
+
+type httpClient struct {
+	client *http.Client
+	url    string
+}
+
+// newClient returns a client for the Eureka server at the given URL, e.g. "http://eureka:8761/eureka".
+func newClient(url string) client {
+	return &httpClient{
+		client: &http.Client{Timeout: requestTimeout},
+		url:    strings.TrimSuffix(url, "/"),
+	}
+}
+
+func (c *httpClient) Applications() ([]*application, error) {
+	req, err := http.NewRequest(http.MethodGet, c.url+appsPath, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close() // nolint: errcheck
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("eureka server %s returned %s: %s", c.url, resp.Status, strings.TrimSpace(string(data)))
+	}
+
+	var apps getApplications
+	if err := json.Unmarshal(data, &apps); err != nil {
+		return nil, fmt.Errorf("failed to parse the applications returned by %s: %v", c.url, err)
+	}
+	return apps.Applications.Applications, nil
+}
@@ -0,0 +1,196 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eureka
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// fakeServer is a fake Eureka server, serving the applications in the JSON format of the Eureka REST API.
+type fakeServer struct {
+	*httptest.Server
+
+	mutex sync.Mutex
+	apps  []*application
+	// fail makes the server return an internal error.
+	fail bool
+}
+
+func newFakeServer(apps []*application) *fakeServer {
+	s := &fakeServer{apps: apps}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+func (s *fakeServer) setApplications(apps []*application) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.apps = apps
+}
+
+func (s *fakeServer) setFail(fail bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.fail = fail
+}
+
+func (s *fakeServer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if r.URL.Path != "/eureka/apps" || r.Header.Get("Accept") != "application/json" {
+		http.NotFound(w, r)
+		return
+	}
+	if s.fail {
+		http.Error(w, "unavailable", http.StatusInternalServerError)
+		return
+	}
+
+	type jsonPort struct {
+		Port    int    `json:"$"`
+		Enabled string `json:"@enabled"`
+	}
+	toJSONPort := func(p port) jsonPort {
+		enabled := "false"
+		if p.Enabled {
+			enabled = "true"
+		}
+		return jsonPort{p.Port, enabled}
+	}
+	type jsonInstance struct {
+		InstanceID string                 `json:"instanceId"`
+		Hostname   string                 `json:"hostName"`
+		App        string                 `json:"app"`
+		IPAddress  string                 `json:"ipAddr"`
+		Status     string                 `json:"status"`
+		Port       jsonPort               `json:"port"`
+		SecurePort jsonPort               `json:"securePort"`
+		Metadata   map[string]interface{} `json:"metadata"`
+	}
+	type jsonApplication struct {
+		Name      string          `json:"name"`
+		Instances []*jsonInstance `json:"instance"`
+	}
+
+	out := make([]*jsonApplication, 0, len(s.apps))
+	for _, app := range s.apps {
+		a := &jsonApplication{Name: app.Name}
+		for _, inst := range app.Instances {
+			md := map[string]interface{}{"@class": "java.util.Collections$EmptyMap"}
+			for k, v := range inst.Metadata {
+				md[k] = v
+			}
+			a.Instances = append(a.Instances, &jsonInstance{
+				InstanceID: inst.InstanceID,
+				Hostname:   inst.Hostname,
+				App:        app.Name,
+				IPAddress:  inst.IPAddress,
+				Status:     inst.Status,
+				Port:       toJSONPort(inst.Port),
+				SecurePort: toJSONPort(inst.SecurePort),
+				Metadata:   md,
+			})
+		}
+		out = append(out, a)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"applications": map[string]interface{}{
+			"versions__delta": "1",
+			"application":     out,
+		},
+	})
+}
+
+func makeInstance(app, ip string, httpPort int, status string, md metadata) *instance {
+	return &instance{
+		InstanceID: ip + ":" + app,
+		Hostname:   ip,
+		App:        app,
+		IPAddress:  ip,
+		Status:     status,
+		Port:       port{Port: httpPort, Enabled: true},
+		SecurePort: port{Port: 443},
+		Metadata:   md,
+	}
+}
+
+func TestClientApplications(t *testing.T) {
+	apps := []*application{
+		{
+			Name: "A",
+			Instances: []*instance{
+				makeInstance("A", "10.0.0.1", 8080, statusUp, metadata{"version": "v1"}),
+				makeInstance("A", "10.0.0.2", 8080, "DOWN", metadata{}),
+			},
+		},
+	}
+	server := newFakeServer(apps)
+	defer server.Close()
+
+	got, err := newClient(server.URL + "/eureka/").Applications()
+	if err != nil {
+		t.Fatalf("Applications() => error %v", err)
+	}
+	if !reflect.DeepEqual(got, apps) {
+		gotJSON, _ := json.Marshal(got)
+		wantJSON, _ := json.Marshal(apps)
+		t.Errorf("Applications() => %s, want %s", gotJSON, wantJSON)
+	}
+}
+
+func TestClientApplicationsError(t *testing.T) {
+	server := newFakeServer(nil)
+	server.setFail(true)
+	defer server.Close()
+
+	if _, err := newClient(server.URL + "/eureka").Applications(); err == nil {
+		t.Error("Applications() => no error for a failed request")
+	}
+}
+
+func TestPortUnmarshal(t *testing.T) {
+	cases := []struct {
+		in   string
+		want port
+	}{
+		{`{"$": 8080, "@enabled": "true"}`, port{8080, true}},
+		{`{"$": "8080", "@enabled": "false"}`, port{8080, false}},
+		{`{"$": 443, "@enabled": true}`, port{443, true}},
+		{`{}`, port{}},
+	}
+	for _, c := range cases {
+		var got port
+		if err := json.Unmarshal([]byte(c.in), &got); err != nil {
+			t.Errorf("Unmarshal(%s) => error %v", c.in, err)
+			continue
+		}
+		if got != c.want {
+			t.Errorf("Unmarshal(%s) => %+v, want %+v", c.in, got, c.want)
+		}
+	}
+
+	var p port
+	if err := json.Unmarshal([]byte(`{"$": "http"}`), &p); err == nil {
+		t.Error("Unmarshal() => no error for an invalid port")
+	}
+}
@@ -0,0 +1,297 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eureka
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"istio.io/pkg/log"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/config/host"
+	"istio.io/istio/pkg/config/labels"
+	"istio.io/istio/pkg/spiffe"
+)
+
+// ControllerOptions stores the configurable attributes of a Controller.
+type ControllerOptions struct {
+	// ServerURL is the URL of the Eureka server REST API, e.g. "http://eureka:8761/eureka".
+	ServerURL string
+
+	// Interval is the delay between two polls of the registered applications.
+	Interval time.Duration
+}
+
+// Controller polls Eureka for the registered applications, and converts them to services. Only the instances
+// with the UP status are endpoints of the services.
+type Controller struct {
+	client   client
+	interval time.Duration
+
+	mutex            sync.RWMutex
+	services         map[host.Name]*model.Service
+	servicesList     []*model.Service
+	serviceInstances map[host.Name][]*model.ServiceInstance
+
+	serviceHandlers  []func(*model.Service, model.Event)
+	instanceHandlers []func(*model.ServiceInstance, model.Event)
+}
+
+var _ model.ServiceDiscovery = &Controller{}
+var _ model.Controller = &Controller{}
+
+// NewController creates a new Eureka controller
+func NewController(options ControllerOptions) (*Controller, error) {
+	if options.ServerURL == "" {
+		return nil, fmt.Errorf("missing the Eureka server URL")
+	}
+	if options.Interval <= 0 {
+		return nil, fmt.Errorf("invalid Eureka poll interval %v", options.Interval)
+	}
+	return &Controller{
+		client:           newClient(options.ServerURL),
+		interval:         options.Interval,
+		services:         make(map[host.Name]*model.Service),
+		serviceInstances: make(map[host.Name][]*model.ServiceInstance),
+	}, nil
+}
+
+// Services list declarations of all services in the system
+func (c *Controller) Services() ([]*model.Service, error) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.servicesList, nil
+}
+
+// GetService retrieves a service by host name if it exists
+func (c *Controller) GetService(hostname host.Name) (*model.Service, error) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.services[hostname], nil
+}
+
+// ManagementPorts retrieves set of health check ports by instance IP.
+// This does not apply to the Eureka service registry, as Eureka does not
+// manage the service instances.
+func (c *Controller) ManagementPorts(addr string) model.PortList {
+	return nil
+}
+
+// WorkloadHealthCheckInfo retrieves set of health check info by instance IP.
+// This does not apply to the Eureka service registry, as Eureka does not
+// manage the service instances.
+func (c *Controller) WorkloadHealthCheckInfo(addr string) model.ProbeList {
+	return nil
+}
+
+// InstancesByPort retrieves instances for a service that match
+// any of the supplied labels. All instances match an empty tag list.
+func (c *Controller) InstancesByPort(svc *model.Service, port int,
+	labels labels.Collection) ([]*model.ServiceInstance, error) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	var out []*model.ServiceInstance
+	for _, instance := range c.serviceInstances[svc.Hostname] {
+		if labels.HasSubsetOf(instance.Labels) && (port == 0 || port == instance.Endpoint.ServicePort.Port) {
+			out = append(out, instance)
+		}
+	}
+	return out, nil
+}
+
+// GetProxyServiceInstances lists service instances co-located with a given proxy
+func (c *Controller) GetProxyServiceInstances(node *model.Proxy) ([]*model.ServiceInstance, error) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	out := make([]*model.ServiceInstance, 0)
+	for _, instances := range c.serviceInstances {
+		for _, instance := range instances {
+			if proxyHasAddress(node, instance.Endpoint.Address) {
+				out = append(out, instance)
+			}
+		}
+	}
+	return out, nil
+}
+
+// GetProxyWorkloadLabels returns the labels of the instances co-located with a given proxy
+func (c *Controller) GetProxyWorkloadLabels(proxy *model.Proxy) (labels.Collection, error) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	out := make(labels.Collection, 0)
+	for _, instances := range c.serviceInstances {
+		for _, instance := range instances {
+			if proxyHasAddress(proxy, instance.Endpoint.Address) {
+				out = append(out, instance.Labels)
+			}
+		}
+	}
+	return out, nil
+}
+
+func proxyHasAddress(proxy *model.Proxy, addr string) bool {
+	for _, ipAddress := range proxy.IPAddresses {
+		if ipAddress == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// GetIstioServiceAccounts implements model.ServiceAccounts operation.
+// Eureka does not have service accounts or an equivalent concept: like for
+// Consul, all the services are assumed to run as the default service account.
+func (c *Controller) GetIstioServiceAccounts(svc *model.Service, ports []int) []string {
+	return []string{
+		spiffe.MustGenSpiffeURI("default", "default"),
+	}
+}
+
+// AppendServiceHandler implements a service catalog operation
+func (c *Controller) AppendServiceHandler(f func(*model.Service, model.Event)) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.serviceHandlers = append(c.serviceHandlers, f)
+	return nil
+}
+
+// AppendInstanceHandler implements a service catalog operation
+func (c *Controller) AppendInstanceHandler(f func(*model.ServiceInstance, model.Event)) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.instanceHandlers = append(c.instanceHandlers, f)
+	return nil
+}
+
+// Run polls Eureka until a signal is received
+func (c *Controller) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		c.refresh()
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// refresh fetches the applications from Eureka, updates the cache and notifies the handlers of the changes.
+// The cache is kept when Eureka cannot be reached.
+func (c *Controller) refresh() {
+	apps, err := c.client.Applications()
+	if err != nil {
+		log.Warnf("Could not retrieve the applications from Eureka: %v", err)
+		return
+	}
+	services := convertServices(apps)
+	serviceInstances := convertServiceInstances(services, apps)
+
+	servicesList := make([]*model.Service, 0, len(services))
+	for _, svc := range services {
+		servicesList = append(servicesList, svc)
+	}
+	sort.Slice(servicesList, func(i, j int) bool { return servicesList[i].Hostname < servicesList[j].Hostname })
+
+	c.mutex.Lock()
+	oldServices, oldInstances := c.services, c.serviceInstances
+	c.services, c.servicesList, c.serviceInstances = services, servicesList, serviceInstances
+	serviceHandlers, instanceHandlers := c.serviceHandlers, c.instanceHandlers
+	c.mutex.Unlock()
+
+	for _, change := range diffServices(oldServices, services) {
+		for _, f := range serviceHandlers {
+			f(change.service, change.event)
+		}
+	}
+	for _, change := range diffInstances(oldInstances, serviceInstances) {
+		for _, f := range instanceHandlers {
+			f(change.instance, change.event)
+		}
+	}
+}
+
+type serviceChange struct {
+	service *model.Service
+	event   model.Event
+}
+
+type instanceChange struct {
+	instance *model.ServiceInstance
+	event    model.Event
+}
+
+// diffServices returns the services added, updated and deleted between two polls.
+func diffServices(old, current map[host.Name]*model.Service) []serviceChange {
+	var out []serviceChange
+	for hostname, svc := range current {
+		prev, exists := old[hostname]
+		if !exists {
+			out = append(out, serviceChange{svc, model.EventAdd})
+		} else if !reflect.DeepEqual(prev, svc) {
+			out = append(out, serviceChange{svc, model.EventUpdate})
+		}
+	}
+	for hostname, svc := range old {
+		if _, exists := current[hostname]; !exists {
+			out = append(out, serviceChange{svc, model.EventDelete})
+		}
+	}
+	return out
+}
+
+// diffInstances returns the service instances added, updated and deleted between two polls. The instances of a
+// service are identified by their address and port.
+func diffInstances(old, current map[host.Name][]*model.ServiceInstance) []instanceChange {
+	type key struct {
+		hostname host.Name
+		address  string
+		port     int
+	}
+	index := func(instances map[host.Name][]*model.ServiceInstance) map[key]*model.ServiceInstance {
+		out := make(map[key]*model.ServiceInstance)
+		for hostname, list := range instances {
+			for _, instance := range list {
+				out[key{hostname, instance.Endpoint.Address, instance.Endpoint.Port}] = instance
+			}
+		}
+		return out
+	}
+	oldIndex, currentIndex := index(old), index(current)
+
+	var out []instanceChange
+	for k, instance := range currentIndex {
+		prev, exists := oldIndex[k]
+		if !exists {
+			out = append(out, instanceChange{instance, model.EventAdd})
+		} else if !reflect.DeepEqual(prev, instance) {
+			out = append(out, instanceChange{instance, model.EventUpdate})
+		}
+	}
+	for k, instance := range oldIndex {
+		if _, exists := currentIndex[k]; !exists {
+			out = append(out, instanceChange{instance, model.EventDelete})
+		}
+	}
+	return out
+}
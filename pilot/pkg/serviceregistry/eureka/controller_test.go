@@ -0,0 +1,238 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eureka
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/config/labels"
+)
+
+var testApps = []*application{
+	{
+		Name: "PRODUCTPAGE",
+		Instances: []*instance{
+			makeInstance("PRODUCTPAGE", "10.0.0.1", 9080, statusUp, metadata{"version": "v1"}),
+		},
+	},
+	{
+		Name: "REVIEWS",
+		Instances: []*instance{
+			makeInstance("REVIEWS", "10.0.0.2", 9080, statusUp, metadata{"version": "v1"}),
+			makeInstance("REVIEWS", "10.0.0.3", 9080, statusUp, metadata{"version": "v2"}),
+			makeInstance("REVIEWS", "10.0.0.4", 9080, "OUT_OF_SERVICE", metadata{"version": "v3"}),
+		},
+	},
+}
+
+func newTestController(t *testing.T, apps []*application) (*Controller, *fakeServer) {
+	t.Helper()
+	server := newFakeServer(apps)
+	controller, err := NewController(ControllerOptions{ServerURL: server.URL + "/eureka", Interval: 10 * time.Millisecond})
+	if err != nil {
+		server.Close()
+		t.Fatalf("NewController() => error %v", err)
+	}
+	controller.refresh()
+	return controller, server
+}
+
+func TestNewControllerInvalidOptions(t *testing.T) {
+	if _, err := NewController(ControllerOptions{Interval: time.Second}); err == nil {
+		t.Error("NewController() => no error without server URL")
+	}
+	if _, err := NewController(ControllerOptions{ServerURL: "http://eureka"}); err == nil {
+		t.Error("NewController() => no error without interval")
+	}
+}
+
+func TestServices(t *testing.T) {
+	controller, server := newTestController(t, testApps)
+	defer server.Close()
+
+	services, err := controller.Services()
+	if err != nil {
+		t.Fatalf("Services() => error %v", err)
+	}
+	var hostnames []string
+	for _, svc := range services {
+		hostnames = append(hostnames, string(svc.Hostname))
+	}
+	if len(hostnames) != 2 || hostnames[0] != "productpage.eureka" || hostnames[1] != "reviews.eureka" {
+		t.Errorf("Services() => %v, want [productpage.eureka reviews.eureka]", hostnames)
+	}
+
+	svc, err := controller.GetService("reviews.eureka")
+	if err != nil || svc == nil || svc.Hostname != "reviews.eureka" {
+		t.Errorf("GetService(reviews.eureka) => %v, %v", svc, err)
+	}
+	svc, err = controller.GetService("ratings.eureka")
+	if err != nil || svc != nil {
+		t.Errorf("GetService(ratings.eureka) => %v, %v, want no service", svc, err)
+	}
+}
+
+func TestInstancesByPort(t *testing.T) {
+	controller, server := newTestController(t, testApps)
+	defer server.Close()
+
+	svc, _ := controller.GetService("reviews.eureka")
+	cases := []struct {
+		name   string
+		port   int
+		labels labels.Collection
+		want   []string
+	}{
+		{"all", 0, nil, []string{"10.0.0.2", "10.0.0.3"}},
+		{"port", 9080, nil, []string{"10.0.0.2", "10.0.0.3"}},
+		{"other port", 8080, nil, nil},
+		{"labels", 9080, labels.Collection{{"version": "v2"}}, []string{"10.0.0.3"}},
+		{"unhealthy", 9080, labels.Collection{{"version": "v3"}}, nil},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			instances, err := controller.InstancesByPort(svc, c.port, c.labels)
+			if err != nil {
+				t.Fatalf("InstancesByPort() => error %v", err)
+			}
+			var got []string
+			for _, instance := range instances {
+				got = append(got, instance.Endpoint.Address)
+			}
+			sort.Strings(got)
+			if len(got) != len(c.want) {
+				t.Fatalf("InstancesByPort() => %v, want %v", got, c.want)
+			}
+			for i := range got {
+				if got[i] != c.want[i] {
+					t.Errorf("InstancesByPort() => %v, want %v", got, c.want)
+				}
+			}
+		})
+	}
+}
+
+func TestGetProxyServiceInstances(t *testing.T) {
+	controller, server := newTestController(t, testApps)
+	defer server.Close()
+
+	instances, err := controller.GetProxyServiceInstances(&model.Proxy{IPAddresses: []string{"172.0.0.1", "10.0.0.3"}})
+	if err != nil {
+		t.Fatalf("GetProxyServiceInstances() => error %v", err)
+	}
+	if len(instances) != 1 || instances[0].Service.Hostname != "reviews.eureka" {
+		t.Errorf("GetProxyServiceInstances() => %v, want the instance of reviews.eureka", instances)
+	}
+
+	workloadLabels, err := controller.GetProxyWorkloadLabels(&model.Proxy{IPAddresses: []string{"10.0.0.3"}})
+	if err != nil {
+		t.Fatalf("GetProxyWorkloadLabels() => error %v", err)
+	}
+	if len(workloadLabels) != 1 || workloadLabels[0]["version"] != "v2" {
+		t.Errorf("GetProxyWorkloadLabels() => %v, want [version=v2]", workloadLabels)
+	}
+
+	// The instances that are not UP are not co-located with the proxies.
+	instances, _ = controller.GetProxyServiceInstances(&model.Proxy{IPAddresses: []string{"10.0.0.4"}})
+	if len(instances) != 0 {
+		t.Errorf("GetProxyServiceInstances() => %v for an instance out of service", instances)
+	}
+}
+
+func TestRefreshError(t *testing.T) {
+	controller, server := newTestController(t, testApps)
+	defer server.Close()
+
+	// The cache is kept when Eureka cannot be reached.
+	server.setFail(true)
+	controller.refresh()
+	if services, _ := controller.Services(); len(services) != 2 {
+		t.Errorf("Services() => %d services after a failed poll, want 2", len(services))
+	}
+}
+
+func TestHandlers(t *testing.T) {
+	controller, server := newTestController(t, testApps)
+	defer server.Close()
+
+	type event struct {
+		name  string
+		event model.Event
+	}
+	serviceEvents := make(chan event, 10)
+	instanceEvents := make(chan event, 10)
+	_ = controller.AppendServiceHandler(func(svc *model.Service, e model.Event) {
+		serviceEvents <- event{string(svc.Hostname), e}
+	})
+	_ = controller.AppendInstanceHandler(func(instance *model.ServiceInstance, e model.Event) {
+		instanceEvents <- event{instance.Endpoint.Address, e}
+	})
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go controller.Run(stop)
+
+	// The instance v3 is back in service, the instance v1 goes down, and productpage is removed.
+	server.setApplications([]*application{
+		{
+			Name: "REVIEWS",
+			Instances: []*instance{
+				makeInstance("REVIEWS", "10.0.0.2", 9080, "DOWN", metadata{"version": "v1"}),
+				makeInstance("REVIEWS", "10.0.0.3", 9080, statusUp, metadata{"version": "v2"}),
+				makeInstance("REVIEWS", "10.0.0.4", 9080, statusUp, metadata{"version": "v3"}),
+			},
+		},
+	})
+
+	expect := func(events chan event, want map[event]bool) {
+		t.Helper()
+		for len(want) > 0 {
+			select {
+			case e := <-events:
+				if !want[e] {
+					t.Fatalf("unexpected event %v", e)
+				}
+				delete(want, e)
+			case <-time.After(5 * time.Second):
+				t.Fatalf("timed out waiting for the events %v", want)
+			}
+		}
+	}
+	expect(serviceEvents, map[event]bool{{"productpage.eureka", model.EventDelete}: true})
+	expect(instanceEvents, map[event]bool{
+		{"10.0.0.1", model.EventDelete}: true,
+		{"10.0.0.2", model.EventDelete}: true,
+		{"10.0.0.4", model.EventAdd}:    true,
+	})
+
+	svc, _ := controller.GetService("reviews.eureka")
+	instances, _ := controller.InstancesByPort(svc, 9080, nil)
+	if len(instances) != 2 {
+		t.Errorf("InstancesByPort() => %d instances, want 2", len(instances))
+	}
+
+	// Nothing is notified when nothing changed.
+	time.Sleep(50 * time.Millisecond)
+	select {
+	case e := <-serviceEvents:
+		t.Errorf("unexpected service event %v", e)
+	case e := <-instanceEvents:
+		t.Errorf("unexpected instance event %v", e)
+	default:
+	}
+}
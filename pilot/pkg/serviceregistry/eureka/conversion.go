@@ -0,0 +1,176 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eureka
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"istio.io/pkg/log"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/config/host"
+	"istio.io/istio/pkg/config/labels"
+	"istio.io/istio/pkg/config/protocol"
+)
+
+const (
+	// protocolMetadata is the instance metadata key overriding the protocol of its non secure port, which is
+	// HTTP by default.
+	protocolMetadata = "istio.protocol"
+
+	// domainSuffix is appended to the application names to form the service hostnames.
+	domainSuffix = "eureka"
+)
+
+// serviceHostname returns the hostname of the service of a Eureka application. Application names are case
+// insensitive, and reported in upper case by Eureka.
+func serviceHostname(app string) host.Name {
+	return host.Name(fmt.Sprintf("%s.%s", strings.ToLower(app), domainSuffix))
+}
+
+// isHealthy returns true if the instance can receive traffic.
+func isHealthy(inst *instance) bool {
+	return inst.Status == statusUp
+}
+
+// convertLabels converts the instance metadata to labels. The metadata keys that have a special meaning for
+// Istio are not converted.
+func convertLabels(md metadata) labels.Instance {
+	out := make(labels.Instance, len(md))
+	for k, v := range md {
+		if k == protocolMetadata {
+			continue
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// convertPorts returns the enabled ports of an instance.
+func convertPorts(inst *instance) model.PortList {
+	out := make(model.PortList, 0, 2)
+	if inst.Port.Enabled {
+		name := "http"
+		p := protocol.HTTP
+		if v := inst.Metadata[protocolMetadata]; v != "" {
+			name = strings.ToLower(v)
+			p = convertProtocol(v)
+		}
+		out = append(out, &model.Port{
+			Name:     name,
+			Port:     inst.Port.Port,
+			Protocol: p,
+		})
+	}
+	if inst.SecurePort.Enabled {
+		out = append(out, &model.Port{
+			Name:     "https",
+			Port:     inst.SecurePort.Port,
+			Protocol: protocol.HTTPS,
+		})
+	}
+	return out
+}
+
+func convertProtocol(name string) protocol.Instance {
+	p := protocol.Parse(name)
+	if p == protocol.Unsupported {
+		log.Warnf("unsupported protocol value: %s", name)
+		return protocol.TCP
+	}
+	return p
+}
+
+// convertServices returns the services of the applications, indexed by hostname. The ports of a service are
+// the ports of all its instances, whatever their status.
+func convertServices(apps []*application) map[host.Name]*model.Service {
+	out := make(map[host.Name]*model.Service)
+	for _, app := range apps {
+		hostname := serviceHostname(app.Name)
+
+		ports := make(map[int]*model.Port)
+		for _, inst := range app.Instances {
+			for _, port := range convertPorts(inst) {
+				if svcPort, exists := ports[port.Port]; exists && svcPort.Protocol != port.Protocol {
+					log.Warnf("Service %v has two instances on same port %v but different protocols (%v, %v)",
+						hostname, port.Port, svcPort.Protocol, port.Protocol)
+				} else {
+					ports[port.Port] = port
+				}
+			}
+		}
+
+		svcPorts := make(model.PortList, 0, len(ports))
+		for _, port := range ports {
+			svcPorts = append(svcPorts, port)
+		}
+		sort.Slice(svcPorts, func(i, j int) bool { return svcPorts[i].Port < svcPorts[j].Port })
+
+		out[hostname] = &model.Service{
+			Hostname:   hostname,
+			Address:    "0.0.0.0",
+			Ports:      svcPorts,
+			Resolution: model.ClientSideLB,
+			Attributes: model.ServiceAttributes{
+				Name:      string(hostname),
+				Namespace: model.IstioDefaultConfigNamespace,
+			},
+		}
+	}
+	return out
+}
+
+// convertServiceInstances returns the service instances of the healthy application instances, indexed by
+// hostname, with one service instance per port.
+func convertServiceInstances(services map[host.Name]*model.Service,
+	apps []*application) map[host.Name][]*model.ServiceInstance {
+	out := make(map[host.Name][]*model.ServiceInstance)
+	for _, app := range apps {
+		hostname := serviceHostname(app.Name)
+		svc := services[hostname]
+		if svc == nil {
+			continue
+		}
+		for _, inst := range app.Instances {
+			if !isHealthy(inst) {
+				log.Debugf("Ignoring instance %s of service %s with status %s", inst.InstanceID, hostname, inst.Status)
+				continue
+			}
+			if inst.IPAddress == "" {
+				log.Debugf("Ignoring instance %s of service %s without IP address", inst.InstanceID, hostname)
+				continue
+			}
+			instLabels := convertLabels(inst.Metadata)
+			for _, port := range convertPorts(inst) {
+				svcPort, exists := svc.Ports.GetByPort(port.Port)
+				if !exists {
+					continue
+				}
+				out[hostname] = append(out[hostname], &model.ServiceInstance{
+					Endpoint: model.NetworkEndpoint{
+						Address:     inst.IPAddress,
+						Port:        port.Port,
+						ServicePort: svcPort,
+					},
+					Service: svc,
+					Labels:  instLabels,
+				})
+			}
+		}
+	}
+	return out
+}
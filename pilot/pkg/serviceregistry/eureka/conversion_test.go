@@ -0,0 +1,150 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eureka
+
+import (
+	"reflect"
+	"testing"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/config/host"
+	"istio.io/istio/pkg/config/labels"
+	"istio.io/istio/pkg/config/protocol"
+)
+
+func TestServiceHostname(t *testing.T) {
+	if got, want := serviceHostname("USER-SERVICE"), host.Name("user-service.eureka"); got != want {
+		t.Errorf("serviceHostname() => %q, want %q", got, want)
+	}
+}
+
+func TestIsHealthy(t *testing.T) {
+	for status, want := range map[string]bool{
+		"UP":             true,
+		"DOWN":           false,
+		"STARTING":       false,
+		"OUT_OF_SERVICE": false,
+		"UNKNOWN":        false,
+	} {
+		if got := isHealthy(&instance{Status: status}); got != want {
+			t.Errorf("isHealthy(%s) => %v, want %v", status, got, want)
+		}
+	}
+}
+
+func TestConvertLabels(t *testing.T) {
+	got := convertLabels(metadata{"version": "v1", "zone": "a", protocolMetadata: "grpc"})
+	want := labels.Instance{"version": "v1", "zone": "a"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("convertLabels() => %v, want %v", got, want)
+	}
+}
+
+func TestConvertPorts(t *testing.T) {
+	cases := []struct {
+		name string
+		in   *instance
+		want model.PortList
+	}{
+		{
+			name: "http",
+			in:   &instance{Port: port{8080, true}, SecurePort: port{443, false}},
+			want: model.PortList{{Name: "http", Port: 8080, Protocol: protocol.HTTP}},
+		},
+		{
+			name: "http and https",
+			in:   &instance{Port: port{8080, true}, SecurePort: port{8443, true}},
+			want: model.PortList{
+				{Name: "http", Port: 8080, Protocol: protocol.HTTP},
+				{Name: "https", Port: 8443, Protocol: protocol.HTTPS},
+			},
+		},
+		{
+			name: "protocol override",
+			in:   &instance{Port: port{9090, true}, Metadata: metadata{protocolMetadata: "GRPC"}},
+			want: model.PortList{{Name: "grpc", Port: 9090, Protocol: protocol.GRPC}},
+		},
+		{
+			name: "unsupported protocol",
+			in:   &instance{Port: port{9090, true}, Metadata: metadata{protocolMetadata: "foo"}},
+			want: model.PortList{{Name: "foo", Port: 9090, Protocol: protocol.TCP}},
+		},
+		{
+			name: "no enabled port",
+			in:   &instance{Port: port{8080, false}},
+			want: model.PortList{},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := convertPorts(c.in); !reflect.DeepEqual(got, c.want) {
+				t.Errorf("convertPorts() => %v, want %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestConvertServices(t *testing.T) {
+	apps := []*application{
+		{
+			Name: "A",
+			Instances: []*instance{
+				makeInstance("A", "10.0.0.1", 8080, statusUp, metadata{"version": "v1"}),
+				makeInstance("A", "10.0.0.2", 9090, "DOWN", metadata{"version": "v2"}),
+				makeInstance("A", "", 8080, statusUp, nil),
+			},
+		},
+		{
+			Name:      "B",
+			Instances: []*instance{makeInstance("B", "10.0.0.3", 8080, "STARTING", nil)},
+		},
+	}
+	services := convertServices(apps)
+	if len(services) != 2 {
+		t.Fatalf("convertServices() => %d services, want 2", len(services))
+	}
+
+	a := services["a.eureka"]
+	if a == nil {
+		t.Fatalf("convertServices() => no service a.eureka in %v", services)
+	}
+	// The ports of the unhealthy instances are ports of the service.
+	wantPorts := model.PortList{
+		{Name: "http", Port: 8080, Protocol: protocol.HTTP},
+		{Name: "http", Port: 9090, Protocol: protocol.HTTP},
+	}
+	if !reflect.DeepEqual(a.Ports, wantPorts) {
+		t.Errorf("service ports => %v, want %v", a.Ports, wantPorts)
+	}
+	if a.Resolution != model.ClientSideLB || a.Attributes.Namespace != model.IstioDefaultConfigNamespace {
+		t.Errorf("unexpected service %+v", a)
+	}
+
+	instances := convertServiceInstances(services, apps)
+	if len(instances["b.eureka"]) != 0 {
+		t.Errorf("convertServiceInstances() => instances %v for a service without healthy instance", instances["b.eureka"])
+	}
+	// Only the healthy instance with an address is an endpoint.
+	if len(instances["a.eureka"]) != 1 {
+		t.Fatalf("convertServiceInstances() => %d instances, want 1", len(instances["a.eureka"]))
+	}
+	got := instances["a.eureka"][0]
+	if got.Endpoint.Address != "10.0.0.1" || got.Endpoint.Port != 8080 || got.Endpoint.ServicePort != a.Ports[0] {
+		t.Errorf("unexpected endpoint %+v", got.Endpoint)
+	}
+	if got.Service != a || !reflect.DeepEqual(got.Labels, labels.Instance{"version": "v1"}) {
+		t.Errorf("unexpected instance %+v", got)
+	}
+}
@@ -15,6 +15,7 @@
 package controller
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net"
@@ -138,6 +139,18 @@ type Controller struct {
 
 	// Network name for the registry as specified by the MeshNetworks configmap
 	networkForRegistry string
+
+	// networkGateways stores hostname ==> gateways of the mesh networks discovered from the service
+	networkGateways map[host.Name][]*model.NetworkGateway
+	// networkGatewayServices stores hostname ==> service for the services with the NetworkGatewayLabel
+	networkGatewayServices map[host.Name]*v1.Service
+	// networkGatewayHostnames stores load balancer ingress hostname ==> resolved addresses for the network
+	// gateway services. The hostnames are resolved in the background by resolveNetworkGateways.
+	networkGatewayHostnames map[string][]string
+	// resolveNetworkGatewaysCh signals resolveNetworkGateways that the hostnames have to be resolved
+	resolveNetworkGatewaysCh chan struct{}
+	// lookupHost resolves the load balancer ingress hostnames, it is replaced in tests
+	lookupHost func(ctx context.Context, host string) ([]string, error)
 }
 
 type cacheHandler struct {
@@ -160,18 +173,25 @@ func NewController(client kubernetes.Interface, options Options) *Controller {
 		XDSUpdater:                 options.XDSUpdater,
 		servicesMap:                make(map[host.Name]*model.Service),
 		externalNameSvcInstanceMap: make(map[host.Name][]*model.ServiceInstance),
+		networkGateways:            make(map[host.Name][]*model.NetworkGateway),
+		networkGatewayServices:     make(map[host.Name]*v1.Service),
+		networkGatewayHostnames:    make(map[string][]string),
+		resolveNetworkGatewaysCh:   make(chan struct{}, 1),
+		lookupHost:                 net.DefaultResolver.LookupHost,
 	}
 
 	sharedInformers := informers.NewSharedInformerFactoryWithOptions(client, options.ResyncPeriod, informers.WithNamespace(options.WatchedNamespace))
 
 	svcInformer := sharedInformers.Core().V1().Services().Informer()
 	out.services = out.createCacheHandler(svcInformer, "Services")
+	out.services.handler.Append(out.onNetworkGatewayServiceEvent)
 
 	epInformer := sharedInformers.Core().V1().Endpoints().Informer()
 	out.endpoints = out.createEDSCacheHandler(epInformer, "Endpoints")
 
 	nodeInformer := sharedInformers.Core().V1().Nodes().Informer()
 	out.nodes = out.createCacheHandler(nodeInformer, "Nodes")
+	out.nodes.handler.Append(out.onNetworkGatewayNodeEvent)
 
 	podInformer := sharedInformers.Core().V1().Pods().Informer()
 	out.pods = newPodCache(out.createCacheHandler(podInformer, "Pod"), out)
@@ -277,6 +297,7 @@ func (c *Controller) Run(stop <-chan struct{}) {
 	go c.services.informer.Run(stop)
 	go c.pods.informer.Run(stop)
 	go c.nodes.informer.Run(stop)
+	go c.resolveNetworkGateways(stop)
 
 	// To avoid endpoints without labels or ports, wait for sync.
 	cache.WaitForCacheSync(stop, c.nodes.informer.HasSynced, c.pods.informer.HasSynced,
@@ -0,0 +1,274 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/serviceregistry/kube"
+	"istio.io/istio/pkg/config/host"
+
+	"istio.io/pkg/log"
+)
+
+const (
+	// NetworkGatewayLabel marks a Service as a gateway of a mesh network. The value is the name of the network,
+	// or empty for the network of the cluster in the MeshNetworks configuration.
+	NetworkGatewayLabel = "topology.istio.io/network"
+
+	// NetworkGatewayPortAnnotation is the port of a network gateway Service that receives the traffic from
+	// the other networks.
+	NetworkGatewayPortAnnotation = "networking.istio.io/gatewayPort"
+
+	// DefaultNetworkGatewayPort is the network gateway port of the Services without NetworkGatewayPortAnnotation.
+	DefaultNetworkGatewayPort = 15443
+
+	// networkGatewayLookupTimeout bounds the resolution of a load balancer ingress hostname.
+	networkGatewayLookupTimeout = 5 * time.Second
+)
+
+// NetworkGateways implements model.NetworkGatewayDiscovery. The gateways are discovered from the Services with
+// the NetworkGatewayLabel: the load balancer ingress addresses of LoadBalancer Services, or the external
+// addresses of the nodes for NodePort Services and LoadBalancer Services without an ingress yet. The ingress
+// hostnames are resolved in the background, their gateways appear once resolved.
+func (c *Controller) NetworkGateways() map[string][]*model.NetworkGateway {
+	c.RLock()
+	defer c.RUnlock()
+
+	hostnames := make([]string, 0, len(c.networkGateways))
+	for hostname := range c.networkGateways {
+		hostnames = append(hostnames, string(hostname))
+	}
+	sort.Strings(hostnames)
+
+	out := make(map[string][]*model.NetworkGateway)
+	for _, hostname := range hostnames {
+		for _, gw := range c.networkGateways[host.Name(hostname)] {
+			network := gw.Network
+			if network == "" {
+				network = c.networkForRegistry
+			}
+			if network == "" {
+				continue
+			}
+			gw := *gw
+			gw.Network = network
+			out[network] = append(out[network], &gw)
+		}
+	}
+	return out
+}
+
+// onNetworkGatewayServiceEvent updates the network gateways discovered from the service.
+func (c *Controller) onNetworkGatewayServiceEvent(obj interface{}, event model.Event) error {
+	svc, ok := obj.(*v1.Service)
+	if !ok {
+		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			return nil
+		}
+		if svc, ok = tombstone.Obj.(*v1.Service); !ok {
+			return nil
+		}
+	}
+
+	hostname := kube.ServiceHostname(svc.Name, svc.Namespace, c.domainSuffix)
+	_, isGateway := svc.Labels[NetworkGatewayLabel]
+
+	if event == model.EventDelete || !isGateway {
+		c.Lock()
+		delete(c.networkGateways, hostname)
+		delete(c.networkGatewayServices, hostname)
+		c.Unlock()
+		return nil
+	}
+
+	gateways := c.extractNetworkGateways(svc)
+	c.Lock()
+	c.networkGatewayServices[hostname] = svc
+	c.networkGateways[hostname] = gateways
+	c.Unlock()
+
+	// Load balancer hostnames are resolved in the background, DNS lookups must not block the informer.
+	if len(kube.LoadBalancerHostnames(*svc)) > 0 {
+		select {
+		case c.resolveNetworkGatewaysCh <- struct{}{}:
+		default:
+			// a resolution is already pending
+		}
+	}
+	return nil
+}
+
+// onNetworkGatewayNodeEvent rediscovers the network gateways of the node port Services when the nodes change.
+func (c *Controller) onNetworkGatewayNodeEvent(obj interface{}, event model.Event) error {
+	c.refreshNetworkGateways()
+	return nil
+}
+
+// resolveNetworkGateways resolves the load balancer ingress hostnames of the network gateway Services when
+// signaled, out of the informer handlers, and pushes the gateways that changed.
+func (c *Controller) resolveNetworkGateways(stop <-chan struct{}) {
+	for {
+		select {
+		case <-stop:
+			return
+		case <-c.resolveNetworkGatewaysCh:
+		}
+
+		c.RLock()
+		var hostnames []string
+		for _, svc := range c.networkGatewayServices {
+			hostnames = append(hostnames, kube.LoadBalancerHostnames(*svc)...)
+		}
+		previous := c.networkGatewayHostnames
+		c.RUnlock()
+
+		resolved := make(map[string][]string, len(hostnames))
+		for _, hostname := range hostnames {
+			if _, found := resolved[hostname]; found {
+				continue
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), networkGatewayLookupTimeout)
+			addrs, err := c.lookupHost(ctx, hostname)
+			cancel()
+			if err != nil {
+				log.Warnf("unable to resolve the load balancer ingress %s of a network gateway: %v", hostname, err)
+				// keep the last known addresses
+				if addrs, found := previous[hostname]; found {
+					resolved[hostname] = addrs
+				}
+				continue
+			}
+			sort.Strings(addrs)
+			resolved[hostname] = addrs
+		}
+
+		c.Lock()
+		c.networkGatewayHostnames = resolved
+		c.Unlock()
+		c.refreshNetworkGateways()
+	}
+}
+
+// refreshNetworkGateways rediscovers the network gateways of all the gateway Services, and pushes the changes.
+func (c *Controller) refreshNetworkGateways() {
+	c.RLock()
+	services := make(map[host.Name]*v1.Service, len(c.networkGatewayServices))
+	for hostname, svc := range c.networkGatewayServices {
+		services[hostname] = svc
+	}
+	c.RUnlock()
+
+	changed := false
+	for hostname, svc := range services {
+		gateways := c.extractNetworkGateways(svc)
+		c.Lock()
+		// Skip the services deleted in the meantime.
+		if _, found := c.networkGatewayServices[hostname]; found && !reflect.DeepEqual(gateways, c.networkGateways[hostname]) {
+			c.networkGateways[hostname] = gateways
+			changed = true
+		}
+		c.Unlock()
+	}
+
+	if changed && c.XDSUpdater != nil {
+		c.XDSUpdater.ConfigUpdate(model.UpdateRequest{Full: true})
+	}
+}
+
+// extractNetworkGateways returns the network gateways of a Service with the NetworkGatewayLabel.
+func (c *Controller) extractNetworkGateways(svc *v1.Service) []*model.NetworkGateway {
+	gwPort := DefaultNetworkGatewayPort
+	if value, found := svc.Annotations[NetworkGatewayPortAnnotation]; found {
+		port, err := strconv.Atoi(value)
+		if err != nil {
+			log.Warnf("invalid %s annotation %q of network gateway service %s/%s", NetworkGatewayPortAnnotation,
+				value, svc.Namespace, svc.Name)
+			return nil
+		}
+		gwPort = port
+	}
+
+	network := svc.Labels[NetworkGatewayLabel]
+	newGateway := func(addr string, port uint32) *model.NetworkGateway {
+		return &model.NetworkGateway{
+			Network:   network,
+			ClusterID: c.ClusterID,
+			Addr:      addr,
+			Port:      port,
+		}
+	}
+
+	var gateways []*model.NetworkGateway
+	for _, addr := range kube.LoadBalancerAddresses(*svc) {
+		gateways = append(gateways, newGateway(addr, uint32(gwPort)))
+	}
+	c.RLock()
+	for _, hostname := range kube.LoadBalancerHostnames(*svc) {
+		for _, addr := range c.networkGatewayHostnames[hostname] {
+			gateways = append(gateways, newGateway(addr, uint32(gwPort)))
+		}
+	}
+	c.RUnlock()
+	if len(gateways) > 0 {
+		return gateways
+	}
+
+	// Fall back to the node ports, reachable on the external addresses of the nodes.
+	if svc.Spec.Type != v1.ServiceTypeNodePort && svc.Spec.Type != v1.ServiceTypeLoadBalancer {
+		return nil
+	}
+	var nodePort int32
+	for _, port := range svc.Spec.Ports {
+		if int(port.Port) == gwPort {
+			nodePort = port.NodePort
+			break
+		}
+	}
+	if nodePort == 0 {
+		return nil
+	}
+	for _, addr := range c.nodeExternalAddresses() {
+		gateways = append(gateways, newGateway(addr, uint32(nodePort)))
+	}
+	return gateways
+}
+
+// nodeExternalAddresses returns the sorted external IP addresses of the nodes.
+func (c *Controller) nodeExternalAddresses() []string {
+	var addrs []string
+	for _, obj := range c.nodes.informer.GetStore().List() {
+		node, ok := obj.(*v1.Node)
+		if !ok {
+			continue
+		}
+		for _, address := range node.Status.Addresses {
+			if address.Type == v1.NodeExternalIP && address.Address != "" {
+				addrs = append(addrs, address.Address)
+			}
+		}
+	}
+	sort.Strings(addrs)
+	return addrs
+}
@@ -0,0 +1,149 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	meshconfig "istio.io/api/mesh/v1alpha1"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/test"
+)
+
+func TestNetworkGateways(t *testing.T) {
+	controller, _ := newFakeController(t)
+	defer controller.Stop()
+
+	controller.ClusterID = "cluster1"
+	controller.InitNetworkLookup(&meshconfig.MeshNetworks{
+		Networks: map[string]*meshconfig.Network{
+			"network1": {
+				Endpoints: []*meshconfig.Network_NetworkEndpoints{
+					{Ne: &meshconfig.Network_NetworkEndpoints_FromRegistry{FromRegistry: "cluster1"}},
+				},
+			},
+		},
+	})
+
+	addNodes(t, controller, generateNodeWithAddresses("node1", "3.3.3.3", "10.0.0.1"))
+
+	// A load balancer gateway of network2.
+	createNetworkGatewayService(t, controller, "lb-gateway", "network2", nil, coreV1.ServiceSpec{
+		Type:  coreV1.ServiceTypeLoadBalancer,
+		Ports: []coreV1.ServicePort{{Name: "tls", Port: 15443, NodePort: 31443}},
+	}, coreV1.ServiceStatus{
+		LoadBalancer: coreV1.LoadBalancerStatus{Ingress: []coreV1.LoadBalancerIngress{{IP: "2.2.2.2"}}},
+	})
+	// A node port gateway of the network of the cluster, on another port.
+	createNetworkGatewayService(t, controller, "nodeport-gateway", "",
+		map[string]string{NetworkGatewayPortAnnotation: "443"}, coreV1.ServiceSpec{
+			Type:  coreV1.ServiceTypeNodePort,
+			Ports: []coreV1.ServicePort{{Name: "tls", Port: 443, NodePort: 30443}},
+		}, coreV1.ServiceStatus{})
+	// Not a gateway.
+	createService(controller, "not-a-gateway", "istio-system", nil, []int32{15443}, nil, t)
+
+	expectNetworkGateways(t, controller, map[string][]*model.NetworkGateway{
+		"network1": {{Network: "network1", ClusterID: "cluster1", Addr: "3.3.3.3", Port: 30443}},
+		"network2": {{Network: "network2", ClusterID: "cluster1", Addr: "2.2.2.2", Port: 15443}},
+	})
+
+	// The node port gateways follow the nodes.
+	addNodes(t, controller, generateNodeWithAddresses("node2", "4.4.4.4", "10.0.0.2"))
+	expectNetworkGateways(t, controller, map[string][]*model.NetworkGateway{
+		"network1": {
+			{Network: "network1", ClusterID: "cluster1", Addr: "3.3.3.3", Port: 30443},
+			{Network: "network1", ClusterID: "cluster1", Addr: "4.4.4.4", Port: 30443},
+		},
+		"network2": {{Network: "network2", ClusterID: "cluster1", Addr: "2.2.2.2", Port: 15443}},
+	})
+
+	if err := controller.client.CoreV1().Services("istio-system").Delete("lb-gateway", &metaV1.DeleteOptions{}); err != nil {
+		t.Fatalf("Cannot delete service: %v", err)
+	}
+	expectNetworkGateways(t, controller, map[string][]*model.NetworkGateway{
+		"network1": {
+			{Network: "network1", ClusterID: "cluster1", Addr: "3.3.3.3", Port: 30443},
+			{Network: "network1", ClusterID: "cluster1", Addr: "4.4.4.4", Port: 30443},
+		},
+	})
+}
+
+func TestNetworkGatewaysLoadBalancerHostname(t *testing.T) {
+	controller, _ := newFakeController(t)
+	defer controller.Stop()
+
+	resolved := make(chan string, 1)
+	controller.lookupHost = func(_ context.Context, host string) ([]string, error) {
+		resolved <- host
+		return []string{"6.6.6.6", "5.5.5.5"}, nil
+	}
+
+	createNetworkGatewayService(t, controller, "lb-gateway", "network2", nil, coreV1.ServiceSpec{
+		Type:  coreV1.ServiceTypeLoadBalancer,
+		Ports: []coreV1.ServicePort{{Name: "tls", Port: 15443, NodePort: 31443}},
+	}, coreV1.ServiceStatus{
+		LoadBalancer: coreV1.LoadBalancerStatus{Ingress: []coreV1.LoadBalancerIngress{{Hostname: "gateway.example.com"}}},
+	})
+
+	if host := <-resolved; host != "gateway.example.com" {
+		t.Fatalf("resolved %s, want gateway.example.com", host)
+	}
+	expectNetworkGateways(t, controller, map[string][]*model.NetworkGateway{
+		"network2": {
+			{Network: "network2", ClusterID: controller.ClusterID, Addr: "5.5.5.5", Port: 15443},
+			{Network: "network2", ClusterID: controller.ClusterID, Addr: "6.6.6.6", Port: 15443},
+		},
+	})
+}
+
+func expectNetworkGateways(t *testing.T, controller *Controller, want map[string][]*model.NetworkGateway) {
+	t.Helper()
+	test.Eventually(t, "network gateways", func() bool {
+		return reflect.DeepEqual(controller.NetworkGateways(), want)
+	})
+}
+
+func createNetworkGatewayService(t *testing.T, controller *Controller, name, network string, annotations map[string]string,
+	spec coreV1.ServiceSpec, status coreV1.ServiceStatus) {
+	service := &coreV1.Service{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:        name,
+			Namespace:   "istio-system",
+			Labels:      map[string]string{NetworkGatewayLabel: network},
+			Annotations: annotations,
+		},
+		Spec:   spec,
+		Status: status,
+	}
+	if _, err := controller.client.CoreV1().Services(service.Namespace).Create(service); err != nil {
+		t.Fatalf("Cannot create service %s (error: %v)", name, err)
+	}
+}
+
+func generateNodeWithAddresses(name, externalIP, internalIP string) *coreV1.Node {
+	node := generateNode(name, nil)
+	node.Status.Addresses = []coreV1.NodeAddress{
+		{Type: coreV1.NodeExternalIP, Address: externalIP},
+		{Type: coreV1.NodeInternalIP, Address: internalIP},
+	}
+	return node
+}
@@ -15,9 +15,7 @@
 package kube
 
 import (
-	"context"
 	"fmt"
-	"net"
 	"sort"
 	"strconv"
 	"strings"
@@ -28,6 +26,7 @@ import (
 
 	"istio.io/api/annotation"
 
+	"istio.io/istio/pilot/pkg/features"
 	"istio.io/istio/pilot/pkg/model"
 	"istio.io/istio/pkg/config/constants"
 	"istio.io/istio/pkg/config/host"
@@ -49,10 +48,19 @@ func convertPort(port coreV1.ServicePort) *model.Port {
 	return &model.Port{
 		Name:     port.Name,
 		Port:     int(port.Port),
-		Protocol: kube.ConvertProtocol(port.Name, port.Protocol),
+		Protocol: convertProtocol(port.Name, port.Protocol),
 	}
 }
 
+// convertProtocol keeps ports without a declared protocol as protocol.Unsupported when protocol sniffing
+// is enabled, so that the proxy detects their protocol instead of treating them as TCP.
+func convertProtocol(name string, proto coreV1.Protocol) protocol.Instance {
+	if features.EnableProtocolSniffingForOutbound.Get() || features.EnableProtocolSniffingForInbound.Get() {
+		return kube.ConvertDeclaredProtocol(name, proto)
+	}
+	return kube.ConvertProtocol(name, proto)
+}
+
 func ConvertService(svc coreV1.Service, domainSuffix string, clusterID string) *model.Service {
 	addr, external := constants.UnspecifiedIP, ""
 	if svc.Spec.ClusterIP != "" && svc.Spec.ClusterIP != coreV1.ClusterIPNone {
@@ -113,26 +121,43 @@ func ConvertService(svc coreV1.Service, domainSuffix string, clusterID string) *
 		},
 	}
 
-	if svc.Spec.Type == coreV1.ServiceTypeLoadBalancer && len(svc.Status.LoadBalancer.Ingress) > 0 {
-		var lbAddrs []string
-		for _, ingress := range svc.Status.LoadBalancer.Ingress {
-			if len(ingress.IP) > 0 {
-				lbAddrs = append(lbAddrs, ingress.IP)
-			} else if len(ingress.Hostname) > 0 {
-				addrs, err := net.DefaultResolver.LookupHost(context.TODO(), ingress.Hostname)
-				if err != nil {
-					lbAddrs = append(lbAddrs, addrs...)
-				}
-			}
-		}
-		if len(lbAddrs) > 0 {
-			istioService.Attributes.ClusterExternalAddresses = map[string][]string{clusterID: lbAddrs}
-		}
+	if lbAddrs := LoadBalancerAddresses(svc); len(lbAddrs) > 0 {
+		istioService.Attributes.ClusterExternalAddresses = map[string][]string{clusterID: lbAddrs}
 	}
 
 	return istioService
 }
 
+// LoadBalancerAddresses returns the IP addresses of the load balancer ingress of a LoadBalancer service.
+// Ingress hostnames are not resolved, see LoadBalancerHostnames.
+func LoadBalancerAddresses(svc coreV1.Service) []string {
+	if svc.Spec.Type != coreV1.ServiceTypeLoadBalancer {
+		return nil
+	}
+	var lbAddrs []string
+	for _, ingress := range svc.Status.LoadBalancer.Ingress {
+		if len(ingress.IP) > 0 {
+			lbAddrs = append(lbAddrs, ingress.IP)
+		}
+	}
+	return lbAddrs
+}
+
+// LoadBalancerHostnames returns the hostnames of the load balancer ingress of a LoadBalancer service that has
+// no IP address, as provided by some cloud load balancers.
+func LoadBalancerHostnames(svc coreV1.Service) []string {
+	if svc.Spec.Type != coreV1.ServiceTypeLoadBalancer {
+		return nil
+	}
+	var hostnames []string
+	for _, ingress := range svc.Status.LoadBalancer.Ingress {
+		if len(ingress.IP) == 0 && len(ingress.Hostname) > 0 {
+			hostnames = append(hostnames, ingress.Hostname)
+		}
+	}
+	return hostnames
+}
+
 func ExternalNameServiceInstances(k8sSvc coreV1.Service, svc *model.Service) []*model.ServiceInstance {
 	if k8sSvc.Spec.Type != coreV1.ServiceTypeExternalName || k8sSvc.Spec.ExternalName == "" {
 		return nil
@@ -91,6 +91,27 @@ func TestConvertProtocol(t *testing.T) {
 	}
 }
 
+func TestConvertDeclaredProtocol(t *testing.T) {
+	cases := []struct {
+		name  string
+		proto coreV1.Protocol
+		out   protocol.Instance
+	}{
+		{"", coreV1.ProtocolTCP, protocol.Unsupported},
+		{"httptest", coreV1.ProtocolTCP, protocol.Unsupported},
+		{"udp", coreV1.ProtocolTCP, protocol.Unsupported},
+		{"tcp", coreV1.ProtocolTCP, protocol.TCP},
+		{"http-test", coreV1.ProtocolTCP, protocol.HTTP},
+		{"grpc-web", coreV1.ProtocolTCP, protocol.GRPCWeb},
+		{"http", coreV1.ProtocolUDP, protocol.UDP},
+	}
+	for _, c := range cases {
+		if out := kube.ConvertDeclaredProtocol(c.name, c.proto); out != c.out {
+			t.Errorf("ConvertDeclaredProtocol(%q, %q) => %q, want %q", c.name, c.proto, out, c.out)
+		}
+	}
+}
+
 func BenchmarkConvertProtocol(b *testing.B) {
 	cases := []struct {
 		name  string
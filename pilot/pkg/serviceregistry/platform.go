@@ -24,6 +24,10 @@ const (
 	KubernetesRegistry ServiceRegistry = "Kubernetes"
 	// ConsulRegistry is a service registry backed by Consul
 	ConsulRegistry ServiceRegistry = "Consul"
+	// EurekaRegistry is a service registry backed by Netflix Eureka
+	EurekaRegistry ServiceRegistry = "Eureka"
+	// ZookeeperRegistry is a service registry backed by the Dubbo providers registered in ZooKeeper
+	ZookeeperRegistry ServiceRegistry = "Zookeeper"
 	// MCPRegistry is a service registry backed by MCP ServiceEntries
 	MCPRegistry ServiceRegistry = "MCP"
 )
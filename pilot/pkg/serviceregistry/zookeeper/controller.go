@@ -0,0 +1,434 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zookeeper
+
+import (
+	"fmt"
+	"path"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/samuel/go-zookeeper/zk"
+
+	"istio.io/pkg/log"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/config/host"
+	"istio.io/istio/pkg/config/labels"
+	"istio.io/istio/pkg/spiffe"
+)
+
+const (
+	// DefaultRoot is the default root znode of the Dubbo registry.
+	DefaultRoot = "/dubbo"
+
+	providersNode = "providers"
+
+	defaultSessionTimeout = 10 * time.Second
+	defaultRetryInterval  = 5 * time.Second
+)
+
+// client is the subset of the ZooKeeper client used by the controller.
+type client interface {
+	// ChildrenW returns the children of a znode, and a channel receiving an event when they change.
+	ChildrenW(path string) ([]string, *zk.Stat, <-chan zk.Event, error)
+}
+
+// ControllerOptions stores the configurable attributes of a Controller.
+type ControllerOptions struct {
+	// Servers are the addresses of the ZooKeeper servers.
+	Servers []string
+
+	// Root is the root znode of the Dubbo registry. Defaults to DefaultRoot.
+	Root string
+
+	// SessionTimeout is the timeout of the ZooKeeper session. Defaults to 10s.
+	SessionTimeout time.Duration
+
+	// RetryInterval is the delay before watching a znode again after an error. Defaults to 5s.
+	RetryInterval time.Duration
+}
+
+// Controller watches the Dubbo providers registered in ZooKeeper, and converts each Dubbo interface to a
+// service.
+type Controller struct {
+	client        client
+	close         func()
+	root          string
+	retryInterval time.Duration
+
+	mutex sync.RWMutex
+	// services and instances are indexed by interface.
+	services  map[string]*model.Service
+	instances map[string][]*model.ServiceInstance
+	// watchers stops the watch of the providers of each interface.
+	watchers map[string]chan struct{}
+
+	serviceHandlers  []func(*model.Service, model.Event)
+	instanceHandlers []func(*model.ServiceInstance, model.Event)
+}
+
+var _ model.ServiceDiscovery = &Controller{}
+var _ model.Controller = &Controller{}
+
+// zkLogger forwards the logs of the ZooKeeper client.
+type zkLogger struct{}
+
+func (zkLogger) Printf(format string, args ...interface{}) {
+	log.Debugf("zookeeper: "+format, args...)
+}
+
+// NewController creates a new ZooKeeper controller
+func NewController(options ControllerOptions) (*Controller, error) {
+	if len(options.Servers) == 0 {
+		return nil, fmt.Errorf("missing the ZooKeeper servers")
+	}
+	sessionTimeout := options.SessionTimeout
+	if sessionTimeout <= 0 {
+		sessionTimeout = defaultSessionTimeout
+	}
+	conn, _, err := zk.Connect(options.Servers, sessionTimeout, zk.WithLogger(zkLogger{}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to ZooKeeper %v: %v", options.Servers, err)
+	}
+	return newController(conn, conn.Close, options), nil
+}
+
+func newController(c client, close func(), options ControllerOptions) *Controller {
+	root := options.Root
+	if root == "" {
+		root = DefaultRoot
+	}
+	retryInterval := options.RetryInterval
+	if retryInterval <= 0 {
+		retryInterval = defaultRetryInterval
+	}
+	return &Controller{
+		client:        c,
+		close:         close,
+		root:          root,
+		retryInterval: retryInterval,
+		services:      make(map[string]*model.Service),
+		instances:     make(map[string][]*model.ServiceInstance),
+		watchers:      make(map[string]chan struct{}),
+	}
+}
+
+// Services list declarations of all services in the system
+func (c *Controller) Services() ([]*model.Service, error) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	out := make([]*model.Service, 0, len(c.services))
+	for _, svc := range c.services {
+		out = append(out, svc)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Hostname < out[j].Hostname })
+	return out, nil
+}
+
+// GetService retrieves a service by host name if it exists
+func (c *Controller) GetService(hostname host.Name) (*model.Service, error) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	for _, svc := range c.services {
+		if svc.Hostname == hostname {
+			return svc, nil
+		}
+	}
+	return nil, nil
+}
+
+// ManagementPorts retrieves set of health check ports by instance IP.
+// This does not apply to the ZooKeeper service registry, as ZooKeeper does not
+// manage the service instances.
+func (c *Controller) ManagementPorts(addr string) model.PortList {
+	return nil
+}
+
+// WorkloadHealthCheckInfo retrieves set of health check info by instance IP.
+// This does not apply to the ZooKeeper service registry, as ZooKeeper does not
+// manage the service instances.
+func (c *Controller) WorkloadHealthCheckInfo(addr string) model.ProbeList {
+	return nil
+}
+
+// InstancesByPort retrieves instances for a service that match
+// any of the supplied labels. All instances match an empty tag list.
+func (c *Controller) InstancesByPort(svc *model.Service, port int,
+	labels labels.Collection) ([]*model.ServiceInstance, error) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	var out []*model.ServiceInstance
+	for _, instances := range c.instances {
+		for _, instance := range instances {
+			if instance.Service.Hostname == svc.Hostname && labels.HasSubsetOf(instance.Labels) &&
+				(port == 0 || port == instance.Endpoint.ServicePort.Port) {
+				out = append(out, instance)
+			}
+		}
+	}
+	return out, nil
+}
+
+// GetProxyServiceInstances lists service instances co-located with a given proxy
+func (c *Controller) GetProxyServiceInstances(node *model.Proxy) ([]*model.ServiceInstance, error) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	out := make([]*model.ServiceInstance, 0)
+	for _, instances := range c.instances {
+		for _, instance := range instances {
+			if proxyHasAddress(node, instance.Endpoint.Address) {
+				out = append(out, instance)
+			}
+		}
+	}
+	return out, nil
+}
+
+// GetProxyWorkloadLabels returns the labels of the instances co-located with a given proxy
+func (c *Controller) GetProxyWorkloadLabels(proxy *model.Proxy) (labels.Collection, error) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	out := make(labels.Collection, 0)
+	for _, instances := range c.instances {
+		for _, instance := range instances {
+			if proxyHasAddress(proxy, instance.Endpoint.Address) {
+				out = append(out, instance.Labels)
+			}
+		}
+	}
+	return out, nil
+}
+
+func proxyHasAddress(proxy *model.Proxy, addr string) bool {
+	for _, ipAddress := range proxy.IPAddresses {
+		if ipAddress == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// GetIstioServiceAccounts implements model.ServiceAccounts operation.
+// Dubbo providers do not have service accounts or an equivalent concept: like
+// for Consul, all the services are assumed to run as the default service account.
+func (c *Controller) GetIstioServiceAccounts(svc *model.Service, ports []int) []string {
+	return []string{
+		spiffe.MustGenSpiffeURI("default", "default"),
+	}
+}
+
+// AppendServiceHandler implements a service catalog operation
+func (c *Controller) AppendServiceHandler(f func(*model.Service, model.Event)) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.serviceHandlers = append(c.serviceHandlers, f)
+	return nil
+}
+
+// AppendInstanceHandler implements a service catalog operation
+func (c *Controller) AppendInstanceHandler(f func(*model.ServiceInstance, model.Event)) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.instanceHandlers = append(c.instanceHandlers, f)
+	return nil
+}
+
+// Run watches the Dubbo interfaces until a signal is received
+func (c *Controller) Run(stop <-chan struct{}) {
+	defer func() {
+		c.mutex.Lock()
+		for iface, watcherStop := range c.watchers {
+			close(watcherStop)
+			delete(c.watchers, iface)
+		}
+		c.mutex.Unlock()
+		if c.close != nil {
+			c.close()
+		}
+	}()
+
+	for {
+		ifaces, _, events, err := c.client.ChildrenW(c.root)
+		if err != nil {
+			log.Warnf("Could not watch the Dubbo interfaces under %s: %v", c.root, err)
+			select {
+			case <-stop:
+				return
+			case <-time.After(c.retryInterval):
+				continue
+			}
+		}
+		c.reconcileWatchers(ifaces)
+
+		select {
+		case <-stop:
+			return
+		case <-events:
+		}
+	}
+}
+
+// reconcileWatchers starts watching the providers of the new interfaces, and stops watching the providers
+// of the removed interfaces.
+func (c *Controller) reconcileWatchers(ifaces []string) {
+	current := make(map[string]bool, len(ifaces))
+	for _, iface := range ifaces {
+		current[iface] = true
+	}
+
+	c.mutex.Lock()
+	for iface := range current {
+		if _, exists := c.watchers[iface]; !exists {
+			watcherStop := make(chan struct{})
+			c.watchers[iface] = watcherStop
+			go c.watchProviders(iface, watcherStop)
+		}
+	}
+	var removed []string
+	for iface, watcherStop := range c.watchers {
+		if !current[iface] {
+			close(watcherStop)
+			delete(c.watchers, iface)
+			removed = append(removed, iface)
+		}
+	}
+	c.mutex.Unlock()
+
+	for _, iface := range removed {
+		c.updateProviders(iface, nil, nil)
+	}
+}
+
+// watchProviders watches the providers of an interface until stopped.
+func (c *Controller) watchProviders(iface string, stop chan struct{}) {
+	providersPath := path.Join(c.root, iface, providersNode)
+	for {
+		nodes, _, events, err := c.client.ChildrenW(providersPath)
+		if err != nil {
+			if err != zk.ErrNoNode {
+				log.Warnf("Could not watch the Dubbo providers under %s: %v", providersPath, err)
+			}
+			// The interface has no provider yet, or the providers are unknown: they are watched again later.
+			select {
+			case <-stop:
+				return
+			case <-time.After(c.retryInterval):
+				continue
+			}
+		}
+
+		c.updateProviders(iface, parseProviders(iface, nodes), stop)
+
+		select {
+		case <-stop:
+			return
+		case <-events:
+		}
+	}
+}
+
+// updateProviders updates the service and instances of an interface, and notifies the handlers of the changes.
+// The updates of a watcher are ignored once the interface is no longer watched by it.
+func (c *Controller) updateProviders(iface string, providers []*provider, watcher chan struct{}) {
+	var svc *model.Service
+	var instances []*model.ServiceInstance
+	if len(providers) > 0 {
+		svc = convertService(iface, providers)
+		instances = convertInstances(svc, providers)
+	}
+
+	c.mutex.Lock()
+	if watcher != nil && c.watchers[iface] != watcher {
+		c.mutex.Unlock()
+		return
+	}
+	oldService, oldInstances := c.services[iface], c.instances[iface]
+	if svc == nil {
+		delete(c.services, iface)
+		delete(c.instances, iface)
+	} else {
+		c.services[iface] = svc
+		c.instances[iface] = instances
+	}
+	serviceHandlers, instanceHandlers := c.serviceHandlers, c.instanceHandlers
+	c.mutex.Unlock()
+
+	serviceChanged, serviceEvent := true, model.EventUpdate
+	switch {
+	case oldService == nil && svc == nil:
+		serviceChanged = false
+	case oldService == nil:
+		serviceEvent = model.EventAdd
+	case svc == nil:
+		serviceEvent, svc = model.EventDelete, oldService
+	case reflect.DeepEqual(oldService, svc):
+		serviceChanged = false
+	}
+	if serviceChanged {
+		for _, f := range serviceHandlers {
+			f(svc, serviceEvent)
+		}
+	}
+
+	for _, change := range diffInstances(oldInstances, instances) {
+		for _, f := range instanceHandlers {
+			f(change.instance, change.event)
+		}
+	}
+}
+
+type instanceChange struct {
+	instance *model.ServiceInstance
+	event    model.Event
+}
+
+// diffInstances returns the service instances added, updated and deleted. The instances are identified by
+// their address and port.
+func diffInstances(old, current []*model.ServiceInstance) []instanceChange {
+	type key struct {
+		address string
+		port    int
+	}
+	oldIndex := make(map[key]*model.ServiceInstance, len(old))
+	for _, instance := range old {
+		oldIndex[key{instance.Endpoint.Address, instance.Endpoint.Port}] = instance
+	}
+
+	var out []instanceChange
+	for _, instance := range current {
+		k := key{instance.Endpoint.Address, instance.Endpoint.Port}
+		prev, exists := oldIndex[k]
+		if !exists {
+			out = append(out, instanceChange{instance, model.EventAdd})
+		} else if !reflect.DeepEqual(prev, instance) {
+			out = append(out, instanceChange{instance, model.EventUpdate})
+		}
+		delete(oldIndex, k)
+	}
+	for _, instance := range old {
+		if _, removed := oldIndex[key{instance.Endpoint.Address, instance.Endpoint.Port}]; removed {
+			out = append(out, instanceChange{instance, model.EventDelete})
+		}
+	}
+	return out
+}
@@ -0,0 +1,234 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zookeeper
+
+import (
+	"errors"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/samuel/go-zookeeper/zk"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/config/labels"
+)
+
+// fakeZooKeeper is an in-process ZooKeeper tree, supporting the watches of the children of the znodes.
+type fakeZooKeeper struct {
+	mutex   sync.Mutex
+	nodes   map[string]bool
+	watches map[string][]chan zk.Event
+	// fail makes the watches fail.
+	fail bool
+}
+
+func newFakeZooKeeper() *fakeZooKeeper {
+	return &fakeZooKeeper{
+		nodes:   map[string]bool{"/": true},
+		watches: make(map[string][]chan zk.Event),
+	}
+}
+
+func (z *fakeZooKeeper) ChildrenW(p string) ([]string, *zk.Stat, <-chan zk.Event, error) {
+	z.mutex.Lock()
+	defer z.mutex.Unlock()
+
+	if z.fail {
+		return nil, nil, nil, errors.New("connection lost")
+	}
+	if !z.nodes[p] {
+		return nil, nil, nil, zk.ErrNoNode
+	}
+	var children []string
+	for node := range z.nodes {
+		if node != "/" && path.Dir(node) == p {
+			children = append(children, path.Base(node))
+		}
+	}
+	sort.Strings(children)
+
+	events := make(chan zk.Event, 1)
+	z.watches[p] = append(z.watches[p], events)
+	return children, &zk.Stat{NumChildren: int32(len(children))}, events, nil
+}
+
+func (z *fakeZooKeeper) setFail(fail bool) {
+	z.mutex.Lock()
+	defer z.mutex.Unlock()
+	z.fail = fail
+}
+
+// create creates a znode and its missing parents.
+func (z *fakeZooKeeper) create(p string) {
+	z.mutex.Lock()
+	defer z.mutex.Unlock()
+
+	for node := p; !z.nodes[node]; node = path.Dir(node) {
+		z.nodes[node] = true
+		z.trigger(path.Dir(node), zk.EventNodeChildrenChanged)
+	}
+}
+
+// delete deletes a znode and its children.
+func (z *fakeZooKeeper) delete(p string) {
+	z.mutex.Lock()
+	defer z.mutex.Unlock()
+
+	for node := range z.nodes {
+		if node == p || strings.HasPrefix(node, p+"/") {
+			delete(z.nodes, node)
+			z.trigger(node, zk.EventNodeDeleted)
+		}
+	}
+	z.trigger(path.Dir(p), zk.EventNodeChildrenChanged)
+}
+
+// trigger fires the watches of a znode, which are one-time triggers.
+func (z *fakeZooKeeper) trigger(p string, t zk.EventType) {
+	for _, events := range z.watches[p] {
+		events <- zk.Event{Type: t, Path: p}
+	}
+	delete(z.watches, p)
+}
+
+func providerPath(iface, u string) string {
+	return path.Join(DefaultRoot, iface, providersNode, providerNode(u))
+}
+
+type event struct {
+	name  string
+	event model.Event
+}
+
+func startController(t *testing.T, z *fakeZooKeeper) (*Controller, chan event, chan event, func()) {
+	t.Helper()
+	controller := newController(z, nil, ControllerOptions{RetryInterval: 10 * time.Millisecond})
+	serviceEvents := make(chan event, 100)
+	instanceEvents := make(chan event, 100)
+	_ = controller.AppendServiceHandler(func(svc *model.Service, e model.Event) {
+		serviceEvents <- event{string(svc.Hostname), e}
+	})
+	_ = controller.AppendInstanceHandler(func(instance *model.ServiceInstance, e model.Event) {
+		instanceEvents <- event{instance.Endpoint.Address, e}
+	})
+	stop := make(chan struct{})
+	go controller.Run(stop)
+	return controller, serviceEvents, instanceEvents, func() { close(stop) }
+}
+
+func expectEvents(t *testing.T, events chan event, want ...event) {
+	t.Helper()
+	expected := make(map[event]bool)
+	for _, e := range want {
+		expected[e] = true
+	}
+	for len(expected) > 0 {
+		select {
+		case e := <-events:
+			if !expected[e] {
+				t.Fatalf("unexpected event %v, want %v", e, expected)
+			}
+			delete(expected, e)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for the events %v", expected)
+		}
+	}
+}
+
+func TestController(t *testing.T) {
+	z := newFakeZooKeeper()
+	z.create(providerPath(demoInterface, "dubbo://10.0.0.1:20880/"+demoInterface+"?version=1.0.0"))
+	controller, serviceEvents, instanceEvents, stop := startController(t, z)
+	defer stop()
+
+	hostname := string(serviceHostname(demoInterface))
+	expectEvents(t, serviceEvents, event{hostname, model.EventAdd})
+	expectEvents(t, instanceEvents, event{"10.0.0.1", model.EventAdd})
+
+	services, err := controller.Services()
+	if err != nil || len(services) != 1 || string(services[0].Hostname) != hostname {
+		t.Fatalf("Services() => %v, %v, want [%s]", services, err, hostname)
+	}
+	svc, err := controller.GetService(services[0].Hostname)
+	if err != nil || svc != services[0] {
+		t.Errorf("GetService() => %v, %v", svc, err)
+	}
+
+	// A new version is deployed.
+	z.create(providerPath(demoInterface, "dubbo://10.0.0.2:20880/"+demoInterface+"?version=2.0.0"))
+	expectEvents(t, instanceEvents, event{"10.0.0.2", model.EventAdd})
+
+	instances, err := controller.InstancesByPort(svc, 20880, labels.Collection{{"version": "2.0.0"}})
+	if err != nil || len(instances) != 1 || instances[0].Endpoint.Address != "10.0.0.2" {
+		t.Errorf("InstancesByPort() => %v, %v, want the instance 10.0.0.2", instances, err)
+	}
+	instances, _ = controller.InstancesByPort(svc, 0, nil)
+	if len(instances) != 2 {
+		t.Errorf("InstancesByPort() => %d instances, want 2", len(instances))
+	}
+
+	proxyInstances, err := controller.GetProxyServiceInstances(&model.Proxy{IPAddresses: []string{"10.0.0.2"}})
+	if err != nil || len(proxyInstances) != 1 || proxyInstances[0].Labels["version"] != "2.0.0" {
+		t.Errorf("GetProxyServiceInstances() => %v, %v", proxyInstances, err)
+	}
+	workloadLabels, err := controller.GetProxyWorkloadLabels(&model.Proxy{IPAddresses: []string{"10.0.0.1"}})
+	if err != nil || len(workloadLabels) != 1 || workloadLabels[0]["version"] != "1.0.0" {
+		t.Errorf("GetProxyWorkloadLabels() => %v, %v", workloadLabels, err)
+	}
+
+	// The old version is stopped: its ephemeral znode is removed.
+	z.delete(providerPath(demoInterface, "dubbo://10.0.0.1:20880/"+demoInterface+"?version=1.0.0"))
+	expectEvents(t, instanceEvents, event{"10.0.0.1", model.EventDelete})
+
+	// The interface is removed.
+	z.delete(path.Join(DefaultRoot, demoInterface))
+	expectEvents(t, serviceEvents, event{hostname, model.EventDelete})
+	expectEvents(t, instanceEvents, event{"10.0.0.2", model.EventDelete})
+	if services, _ := controller.Services(); len(services) != 0 {
+		t.Errorf("Services() => %v after the interface was removed", services)
+	}
+}
+
+func TestControllerProvidersCreatedLater(t *testing.T) {
+	z := newFakeZooKeeper()
+	// Dubbo creates the interface znode for the consumers before any provider registers.
+	z.create(path.Join(DefaultRoot, demoInterface, "consumers"))
+	controller, serviceEvents, _, stop := startController(t, z)
+	defer stop()
+
+	z.create(providerPath(demoInterface, "rest://10.0.0.1:8080/"+demoInterface))
+	expectEvents(t, serviceEvents, event{string(serviceHostname(demoInterface)), model.EventAdd})
+
+	services, _ := controller.Services()
+	if len(services) != 1 || len(services[0].Ports) != 1 || services[0].Ports[0].Port != 8080 {
+		t.Errorf("Services() => %v, want the service with the port 8080", services)
+	}
+}
+
+func TestControllerRetry(t *testing.T) {
+	z := newFakeZooKeeper()
+	z.setFail(true)
+	z.create(providerPath(demoInterface, "dubbo://10.0.0.1:20880/"+demoInterface))
+	_, serviceEvents, _, stop := startController(t, z)
+	defer stop()
+
+	time.Sleep(50 * time.Millisecond)
+	z.setFail(false)
+	expectEvents(t, serviceEvents, event{string(serviceHostname(demoInterface)), model.EventAdd})
+}
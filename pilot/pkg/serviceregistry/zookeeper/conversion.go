@@ -0,0 +1,221 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zookeeper
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"istio.io/pkg/log"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/config/host"
+	"istio.io/istio/pkg/config/labels"
+	"istio.io/istio/pkg/config/protocol"
+)
+
+const (
+	// domainSuffix is appended to the Dubbo interface names to form the service hostnames.
+	domainSuffix = "dubbo"
+
+	// weightParam is the URL parameter holding the load balancing weight of a provider.
+	weightParam = "weight"
+)
+
+// ignoredParams are the provider URL parameters that are not converted to labels: they either describe
+// the interface rather than the provider, or change whenever the provider restarts.
+var ignoredParams = map[string]bool{
+	"anyhost":   true,
+	"interface": true,
+	"methods":   true,
+	"pid":       true,
+	"side":      true,
+	"timestamp": true,
+	weightParam: true,
+}
+
+// provider is a Dubbo provider, registered as a znode named after its URL under
+// <root>/<interface>/providers, e.g.
+// dubbo://10.0.0.1:20880/org.apache.dubbo.demo.DemoService?application=demo&version=1.0.0
+type provider struct {
+	Interface string
+	Protocol  string
+	Address   string
+	Port      int
+	Params    map[string]string
+}
+
+// parseProvider decodes the name of a provider znode.
+func parseProvider(iface, node string) (*provider, error) {
+	raw, err := url.QueryUnescape(node)
+	if err != nil {
+		return nil, fmt.Errorf("invalid provider %q: %v", node, err)
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid provider URL %q: %v", raw, err)
+	}
+	if u.Scheme == "" {
+		return nil, fmt.Errorf("missing protocol in provider URL %q", raw)
+	}
+	addr, portStr, err := net.SplitHostPort(u.Host)
+	if err != nil {
+		return nil, fmt.Errorf("invalid address in provider URL %q: %v", raw, err)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil || port <= 0 || port > 65535 {
+		return nil, fmt.Errorf("invalid port in provider URL %q", raw)
+	}
+
+	params := make(map[string]string)
+	for k, v := range u.Query() {
+		if len(v) > 0 {
+			params[k] = v[0]
+		}
+	}
+	if i := params["interface"]; i != "" && i != iface {
+		return nil, fmt.Errorf("provider URL %q is registered for interface %s", raw, iface)
+	}
+
+	return &provider{
+		Interface: iface,
+		Protocol:  u.Scheme,
+		Address:   addr,
+		Port:      port,
+		Params:    params,
+	}, nil
+}
+
+// parseProviders decodes the provider znodes of an interface. The invalid providers are ignored.
+func parseProviders(iface string, nodes []string) []*provider {
+	out := make([]*provider, 0, len(nodes))
+	for _, node := range nodes {
+		p, err := parseProvider(iface, node)
+		if err != nil {
+			log.Warnf("Ignoring Dubbo provider of %s: %v", iface, err)
+			continue
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+// serviceHostname returns the hostname of the service of a Dubbo interface.
+func serviceHostname(iface string) host.Name {
+	return host.Name(fmt.Sprintf("%s.%s", strings.ToLower(iface), domainSuffix))
+}
+
+// convertProtocol returns the protocol of a Dubbo protocol name.
+func convertProtocol(name string) protocol.Instance {
+	switch strings.ToLower(name) {
+	case "dubbo", "hessian", "rmi":
+		return protocol.TCP
+	case "rest", "webservice":
+		return protocol.HTTP
+	}
+	p := protocol.Parse(name)
+	if p == protocol.Unsupported {
+		log.Warnf("unsupported protocol value: %s", name)
+		return protocol.TCP
+	}
+	return p
+}
+
+func convertPort(p *provider) *model.Port {
+	return &model.Port{
+		Name:     strings.ToLower(p.Protocol),
+		Port:     p.Port,
+		Protocol: convertProtocol(p.Protocol),
+	}
+}
+
+// convertLabels returns the labels of a provider, from its URL parameters.
+func convertLabels(p *provider) labels.Instance {
+	out := make(labels.Instance, len(p.Params))
+	for k, v := range p.Params {
+		if !ignoredParams[k] {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// convertService returns the service of the providers of an interface. Its ports are the ports of all the
+// providers.
+func convertService(iface string, providers []*provider) *model.Service {
+	hostname := serviceHostname(iface)
+
+	ports := make(map[int]*model.Port)
+	for _, p := range providers {
+		port := convertPort(p)
+		if svcPort, exists := ports[port.Port]; exists && svcPort.Protocol != port.Protocol {
+			log.Warnf("Service %v has two instances on same port %v but different protocols (%v, %v)",
+				hostname, port.Port, svcPort.Protocol, port.Protocol)
+		} else {
+			ports[port.Port] = port
+		}
+	}
+
+	svcPorts := make(model.PortList, 0, len(ports))
+	for _, port := range ports {
+		svcPorts = append(svcPorts, port)
+	}
+	sort.Slice(svcPorts, func(i, j int) bool { return svcPorts[i].Port < svcPorts[j].Port })
+
+	return &model.Service{
+		Hostname:   hostname,
+		Address:    "0.0.0.0",
+		Ports:      svcPorts,
+		Resolution: model.ClientSideLB,
+		Attributes: model.ServiceAttributes{
+			Name:      string(hostname),
+			Namespace: model.IstioDefaultConfigNamespace,
+		},
+	}
+}
+
+// convertInstances returns the service instances of the providers of a service.
+func convertInstances(svc *model.Service, providers []*provider) []*model.ServiceInstance {
+	out := make([]*model.ServiceInstance, 0, len(providers))
+	for _, p := range providers {
+		svcPort, exists := svc.Ports.GetByPort(p.Port)
+		if !exists {
+			continue
+		}
+		var weight uint32
+		if w := p.Params[weightParam]; w != "" {
+			if n, err := strconv.ParseUint(w, 10, 32); err == nil {
+				weight = uint32(n)
+			} else {
+				log.Debugf("Ignoring invalid weight %q of provider %s:%d of %s", w, p.Address, p.Port, p.Interface)
+			}
+		}
+		out = append(out, &model.ServiceInstance{
+			Endpoint: model.NetworkEndpoint{
+				Address:     p.Address,
+				Port:        p.Port,
+				ServicePort: svcPort,
+				LbWeight:    weight,
+			},
+			Service: svc,
+			Labels:  convertLabels(p),
+		})
+	}
+	return out
+}
@@ -0,0 +1,144 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zookeeper
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/config/host"
+	"istio.io/istio/pkg/config/labels"
+	"istio.io/istio/pkg/config/protocol"
+)
+
+const demoInterface = "org.apache.dubbo.demo.DemoService"
+
+// providerNode returns the name of the znode of a provider URL, as registered by Dubbo.
+func providerNode(u string) string {
+	return url.QueryEscape(u)
+}
+
+func TestParseProvider(t *testing.T) {
+	got, err := parseProvider(demoInterface, providerNode(
+		"dubbo://10.0.0.1:20880/org.apache.dubbo.demo.DemoService?anyhost=true&application=demo"+
+			"&interface=org.apache.dubbo.demo.DemoService&methods=sayHello,sayBye&version=1.0.0"))
+	if err != nil {
+		t.Fatalf("parseProvider() => error %v", err)
+	}
+	want := &provider{
+		Interface: demoInterface,
+		Protocol:  "dubbo",
+		Address:   "10.0.0.1",
+		Port:      20880,
+		Params: map[string]string{
+			"anyhost":     "true",
+			"application": "demo",
+			"interface":   demoInterface,
+			"methods":     "sayHello,sayBye",
+			"version":     "1.0.0",
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseProvider() => %+v, want %+v", got, want)
+	}
+}
+
+func TestParseProviderInvalid(t *testing.T) {
+	for _, node := range []string{
+		"%zz",
+		providerNode("10.0.0.1:20880/org.apache.dubbo.demo.DemoService"),
+		providerNode("dubbo://10.0.0.1/org.apache.dubbo.demo.DemoService"),
+		providerNode("dubbo://10.0.0.1:http/org.apache.dubbo.demo.DemoService"),
+		providerNode("dubbo://10.0.0.1:70000/org.apache.dubbo.demo.DemoService"),
+		providerNode("dubbo://10.0.0.1:20880/org.apache.dubbo.demo.OtherService?interface=org.apache.dubbo.demo.OtherService"),
+	} {
+		if p, err := parseProvider(demoInterface, node); err == nil {
+			t.Errorf("parseProvider(%s) => %+v, want error", node, p)
+		}
+	}
+
+	providers := parseProviders(demoInterface, []string{"%zz", providerNode("dubbo://10.0.0.1:20880/" + demoInterface)})
+	if len(providers) != 1 || providers[0].Address != "10.0.0.1" {
+		t.Errorf("parseProviders() => %+v, want the valid provider only", providers)
+	}
+}
+
+func TestServiceHostname(t *testing.T) {
+	if got, want := serviceHostname(demoInterface), host.Name("org.apache.dubbo.demo.demoservice.dubbo"); got != want {
+		t.Errorf("serviceHostname() => %q, want %q", got, want)
+	}
+}
+
+func TestConvertProtocol(t *testing.T) {
+	for name, want := range map[string]protocol.Instance{
+		"dubbo":  protocol.TCP,
+		"rest":   protocol.HTTP,
+		"http":   protocol.HTTP,
+		"grpc":   protocol.GRPC,
+		"thrift": protocol.Thrift,
+		"foo":    protocol.TCP,
+	} {
+		if got := convertProtocol(name); got != want {
+			t.Errorf("convertProtocol(%s) => %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestConvertServiceAndInstances(t *testing.T) {
+	providers := parseProviders(demoInterface, []string{
+		providerNode("dubbo://10.0.0.1:20880/" + demoInterface + "?application=demo&version=1.0.0&timestamp=1&pid=12&weight=200"),
+		providerNode("dubbo://10.0.0.2:20880/" + demoInterface + "?application=demo&version=2.0.0&group=canary"),
+		providerNode("rest://10.0.0.2:8080/" + demoInterface + "?application=demo&version=2.0.0&weight=x"),
+	})
+
+	svc := convertService(demoInterface, providers)
+	wantPorts := model.PortList{
+		{Name: "rest", Port: 8080, Protocol: protocol.HTTP},
+		{Name: "dubbo", Port: 20880, Protocol: protocol.TCP},
+	}
+	if svc.Hostname != serviceHostname(demoInterface) || !reflect.DeepEqual(svc.Ports, wantPorts) {
+		t.Errorf("convertService() => %+v, want ports %v", svc, wantPorts)
+	}
+	if svc.Resolution != model.ClientSideLB || svc.Attributes.Namespace != model.IstioDefaultConfigNamespace {
+		t.Errorf("unexpected service %+v", svc)
+	}
+
+	instances := convertInstances(svc, providers)
+	if len(instances) != 3 {
+		t.Fatalf("convertInstances() => %d instances, want 3", len(instances))
+	}
+	want := []struct {
+		address string
+		port    int
+		weight  uint32
+		labels  labels.Instance
+	}{
+		{"10.0.0.1", 20880, 200, labels.Instance{"application": "demo", "version": "1.0.0"}},
+		{"10.0.0.2", 20880, 0, labels.Instance{"application": "demo", "version": "2.0.0", "group": "canary"}},
+		{"10.0.0.2", 8080, 0, labels.Instance{"application": "demo", "version": "2.0.0"}},
+	}
+	for i, w := range want {
+		got := instances[i]
+		if got.Endpoint.Address != w.address || got.Endpoint.Port != w.port || got.Endpoint.LbWeight != w.weight ||
+			got.Endpoint.ServicePort.Port != w.port || got.Service != svc {
+			t.Errorf("instance %d => %+v, want %s:%d with weight %d", i, got.Endpoint, w.address, w.port, w.weight)
+		}
+		if !reflect.DeepEqual(got.Labels, w.labels) {
+			t.Errorf("instance %d labels => %v, want %v", i, got.Labels, w.labels)
+		}
+	}
+}
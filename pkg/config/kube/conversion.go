@@ -45,6 +45,15 @@ var grpcWebLen = len(grpcWeb)
 
 // ConvertProtocol from k8s protocol and port name
 func ConvertProtocol(name string, proto coreV1.Protocol) protocol.Instance {
+	if p := ConvertDeclaredProtocol(name, proto); p != protocol.Unsupported {
+		return p
+	}
+	return protocol.TCP
+}
+
+// ConvertDeclaredProtocol is like ConvertProtocol, but returns protocol.Unsupported for TCP ports
+// whose name does not declare a protocol, so that the protocol can be detected by the proxy.
+func ConvertDeclaredProtocol(name string, proto coreV1.Protocol) protocol.Instance {
 	out := protocol.TCP
 	switch proto {
 	case coreV1.ProtocolUDP:
@@ -58,9 +67,9 @@ func ConvertProtocol(name string, proto coreV1.Protocol) protocol.Instance {
 		if i >= 0 {
 			name = name[:i]
 		}
-		p := protocol.Parse(name)
-		if p != protocol.UDP && p != protocol.Unsupported {
-			out = p
+		out = protocol.Parse(name)
+		if out == protocol.UDP {
+			out = protocol.Unsupported
 		}
 	}
 	return out
@@ -54,6 +54,10 @@ const (
 	Redis Instance = "Redis"
 	// MySQL declares that the port carries MySQL traffic.
 	MySQL Instance = "MySQL"
+	// Kafka declares that the port carries Kafka traffic.
+	Kafka Instance = "Kafka"
+	// Thrift declares that the port carries Thrift traffic.
+	Thrift Instance = "Thrift"
 	// Unsupported - value to signify that the protocol is unsupported.
 	Unsupported Instance = "UnsupportedProtocol"
 )
@@ -83,6 +87,10 @@ func Parse(s string) Instance {
 		return Redis
 	case "mysql":
 		return MySQL
+	case "kafka":
+		return Kafka
+	case "thrift":
+		return Thrift
 	}
 
 	return Unsupported
@@ -111,7 +119,7 @@ func (i Instance) IsHTTP() bool {
 // IsTCP is true for protocols that use TCP as transport protocol
 func (i Instance) IsTCP() bool {
 	switch i {
-	case TCP, HTTPS, TLS, Mongo, Redis, MySQL:
+	case TCP, HTTPS, TLS, Mongo, Redis, MySQL, Kafka, Thrift:
 		return true
 	default:
 		return false
@@ -137,3 +145,9 @@ func (i Instance) IsGRPC() bool {
 		return false
 	}
 }
+
+// IsUnsupported is true for protocols that are not supported by the proxy, including ports that do not
+// declare a protocol.
+func (i Instance) IsUnsupported() bool {
+	return i == Unsupported
+}
@@ -63,6 +63,12 @@ func TestParse(t *testing.T) {
 		{"mysql", protocol.MySQL},
 		{"MYSQL", protocol.MySQL},
 		{"MySQL", protocol.MySQL},
+		{"Kafka", protocol.Kafka},
+		{"kafka", protocol.Kafka},
+		{"KAFKA", protocol.Kafka},
+		{"Thrift", protocol.Thrift},
+		{"thrift", protocol.Thrift},
+		{"THRIFT", protocol.Thrift},
 		{"", protocol.Unsupported},
 		{"SMTP", protocol.Unsupported},
 	}
@@ -1109,7 +1109,7 @@ func ValidateMeshConfig(mesh *meshconfig.MeshConfig) (errs error) {
 		errs = multierror.Append(errs, err)
 	}
 
-	if err := validateLocalityLbSetting(mesh.LocalityLbSetting); err != nil {
+	if err := ValidateLocalityLbSetting(mesh.LocalityLbSetting); err != nil {
 		errs = multierror.Append(errs, err)
 	}
 
@@ -2484,8 +2484,8 @@ func appendErrors(err error, errs ...error) error {
 	return err
 }
 
-// validateLocalityLbSetting checks the LocalityLbSetting of MeshConfig
-func validateLocalityLbSetting(lb *meshconfig.LocalityLoadBalancerSetting) error {
+// ValidateLocalityLbSetting checks a locality load balancer setting, of MeshConfig or of a DestinationRule
+func ValidateLocalityLbSetting(lb *meshconfig.LocalityLoadBalancerSetting) error {
 	if lb == nil {
 		return nil
 	}
@@ -2501,8 +2501,8 @@ func validateLocalityLbSetting(lb *meshconfig.LocalityLoadBalancerSetting) error
 		destLocalities := make([]string, 0)
 		for loc, weight := range locality.To {
 			destLocalities = append(destLocalities, loc)
-			if weight == 0 {
-				return fmt.Errorf("locality weight must not be in range [1, 100]")
+			if weight == 0 || weight > 100 {
+				return fmt.Errorf("locality weight %v for %s must be in range [1, 100]", weight, loc)
 			}
 			totalWeight += weight
 		}
@@ -2518,6 +2518,7 @@ func validateLocalityLbSetting(lb *meshconfig.LocalityLoadBalancerSetting) error
 		return err
 	}
 
+	failovers := make(map[string]bool)
 	for _, failover := range lb.GetFailover() {
 		if failover.From == failover.To {
 			return fmt.Errorf("locality lb failover settings must specify different regions")
@@ -2525,6 +2526,14 @@ func validateLocalityLbSetting(lb *meshconfig.LocalityLoadBalancerSetting) error
 		if strings.Contains(failover.To, "*") {
 			return fmt.Errorf("locality lb failover region should not contain '*' wildcard")
 		}
+		if strings.Contains(failover.From, "/") || strings.Contains(failover.To, "/") {
+			return fmt.Errorf("locality lb failover from %q to %q must specify regions, not zones", failover.From, failover.To)
+		}
+		key := failover.From + "->" + failover.To
+		if failovers[key] {
+			return fmt.Errorf("locality lb failover from %q to %q is specified more than once", failover.From, failover.To)
+		}
+		failovers[key] = true
 	}
 
 	return nil
@@ -1285,7 +1285,7 @@ func TestValidateServerPort(t *testing.T) {
 			""},
 		{"invalid protocol",
 			&networking.Port{
-				Protocol: "kafka",
+				Protocol: "fancy",
 				Number:   1,
 				Name:     "Henry",
 			},
@@ -4750,10 +4750,69 @@ func TestValidateLocalityLbSetting(t *testing.T) {
 			},
 			valid: false,
 		},
+		{
+			name: "invalid LocalityLoadBalancerSetting_Distribute weight overflowing the total",
+			in: &meshconfig.LocalityLoadBalancerSetting{
+				Distribute: []*meshconfig.LocalityLoadBalancerSetting_Distribute{
+					{
+						From: "a/b/c",
+						To: map[string]uint32{
+							"a/b/c": 4294967295,
+							"a/b1":  101,
+						},
+					},
+				},
+			},
+			valid: false,
+		},
+		{
+			name: "valid multi level failover",
+			in: &meshconfig.LocalityLoadBalancerSetting{
+				Failover: []*meshconfig.LocalityLoadBalancerSetting_Failover{
+					{
+						From: "region1",
+						To:   "region2",
+					},
+					{
+						From: "region1",
+						To:   "region3",
+					},
+				},
+			},
+			valid: true,
+		},
+		{
+			name: "invalid failover to a zone",
+			in: &meshconfig.LocalityLoadBalancerSetting{
+				Failover: []*meshconfig.LocalityLoadBalancerSetting_Failover{
+					{
+						From: "region1",
+						To:   "region2/zone1",
+					},
+				},
+			},
+			valid: false,
+		},
+		{
+			name: "invalid duplicated failover",
+			in: &meshconfig.LocalityLoadBalancerSetting{
+				Failover: []*meshconfig.LocalityLoadBalancerSetting_Failover{
+					{
+						From: "region1",
+						To:   "region2",
+					},
+					{
+						From: "region1",
+						To:   "region2",
+					},
+				},
+			},
+			valid: false,
+		},
 	}
 
 	for _, c := range cases {
-		if got := validateLocalityLbSetting(c.in); (got == nil) != c.valid {
+		if got := ValidateLocalityLbSetting(c.in); (got == nil) != c.valid {
 			t.Errorf("ValidateLocalityLbSetting failed on %v: got valid=%v but wanted valid=%v: %v",
 				c.name, got == nil, c.valid, got)
 		}
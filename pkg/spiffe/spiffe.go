@@ -34,6 +34,9 @@ const (
 var (
 	trustDomain      = defaultTrustDomain
 	trustDomainMutex sync.RWMutex
+
+	// federatedTrustDomains are the foreign trust domains whose identities are accepted by the mesh.
+	federatedTrustDomains []string
 )
 
 func SetTrustDomain(value string) {
@@ -50,6 +53,51 @@ func GetTrustDomain() string {
 	return trustDomain
 }
 
+// SetFederatedTrustDomains sets the foreign trust domains whose identities are accepted by the mesh, along with
+// the identities of the local trust domain.
+func SetFederatedTrustDomains(values []string) {
+	var domains []string
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			domains = append(domains, strings.Replace(v, "@", ".", -1))
+		}
+	}
+	trustDomainMutex.Lock()
+	federatedTrustDomains = domains
+	trustDomainMutex.Unlock()
+}
+
+// GetFederatedTrustDomains returns the foreign trust domains whose identities are accepted by the mesh.
+func GetFederatedTrustDomains() []string {
+	trustDomainMutex.RLock()
+	defer trustDomainMutex.RUnlock()
+	return federatedTrustDomains
+}
+
+// IsFederatedTrustDomain returns true if the trust domain is a foreign trust domain whose identities are
+// accepted by the mesh.
+func IsFederatedTrustDomain(value string) bool {
+	for _, domain := range GetFederatedTrustDomains() {
+		if domain == value {
+			return true
+		}
+	}
+	return false
+}
+
+// GetTrustDomainFromURI returns the trust domain of a SPIFFE ID, e.g. "partner.org" for
+// "spiffe://partner.org/ns/foo/sa/bar".
+func GetTrustDomainFromURI(uri string) (string, error) {
+	if !strings.HasPrefix(uri, URIPrefix) {
+		return "", fmt.Errorf("the SPIFFE ID %q does not have the %q prefix", uri, URIPrefix)
+	}
+	domain := strings.SplitN(strings.TrimPrefix(uri, URIPrefix), "/", 2)[0]
+	if domain == "" {
+		return "", fmt.Errorf("the SPIFFE ID %q does not have a trust domain", uri)
+	}
+	return domain, nil
+}
+
 func DetermineTrustDomain(commandLineTrustDomain string, isKubernetes bool) string {
 	if len(commandLineTrustDomain) != 0 {
 		return commandLineTrustDomain
@@ -15,6 +15,7 @@
 package spiffe
 
 import (
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -106,6 +107,56 @@ func TestGetSetTrustDomain(t *testing.T) {
 	}
 }
 
+func TestGetSetFederatedTrustDomains(t *testing.T) {
+	defer SetFederatedTrustDomains(nil)
+
+	SetFederatedTrustDomains([]string{"partner.org", " other@mesh ", ""})
+	if got := GetFederatedTrustDomains(); !reflect.DeepEqual(got, []string{"partner.org", "other.mesh"}) {
+		t.Errorf("unexpected federated trust domains: %v", got)
+	}
+	if !IsFederatedTrustDomain("other.mesh") {
+		t.Errorf("expected other.mesh to be a federated trust domain")
+	}
+	if IsFederatedTrustDomain("cluster.local") {
+		t.Errorf("expected cluster.local not to be a federated trust domain")
+	}
+}
+
+func TestGetTrustDomainFromURI(t *testing.T) {
+	cases := []struct {
+		uri         string
+		trustDomain string
+		expectErr   bool
+	}{
+		{
+			uri:         "spiffe://partner.org/ns/foo/sa/bar",
+			trustDomain: "partner.org",
+		},
+		{
+			uri:         "spiffe://partner.org",
+			trustDomain: "partner.org",
+		},
+		{
+			uri:       "partner.org/ns/foo/sa/bar",
+			expectErr: true,
+		},
+		{
+			uri:       "spiffe:///ns/foo/sa/bar",
+			expectErr: true,
+		},
+	}
+	for _, c := range cases {
+		got, err := GetTrustDomainFromURI(c.uri)
+		if c.expectErr {
+			if err == nil {
+				t.Errorf("%s: expected an error", c.uri)
+			}
+		} else if got != c.trustDomain {
+			t.Errorf("%s: expected trust domain %s, actual %s (error: %v)", c.uri, c.trustDomain, got, err)
+		}
+	}
+}
+
 func TestMustGenSpiffeURI(t *testing.T) {
 	if nonsense := MustGenSpiffeURI("", ""); nonsense != "spiffe://cluster.local/ns//sa/" {
 		t.Errorf("Unexpected spiffe URI for empty namespace and service account: %s", nonsense)
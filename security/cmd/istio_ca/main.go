@@ -17,6 +17,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"strings"
 	"time"
@@ -30,10 +31,13 @@ import (
 	pkgcmd "istio.io/istio/pkg/cmd"
 	kubelib "istio.io/istio/pkg/kube"
 	"istio.io/istio/pkg/spiffe"
+	"istio.io/istio/security/pkg/adapter/vault"
 	"istio.io/istio/security/pkg/caclient"
 	"istio.io/istio/security/pkg/cmd"
+	"istio.io/istio/security/pkg/k8s/configmap"
 	"istio.io/istio/security/pkg/k8s/controller"
 	"istio.io/istio/security/pkg/pki/ca"
+	"istio.io/istio/security/pkg/pki/util"
 	probecontroller "istio.io/istio/security/pkg/probe"
 	"istio.io/istio/security/pkg/registry"
 	"istio.io/istio/security/pkg/registry/kube"
@@ -62,6 +66,17 @@ type cliOptions struct { // nolint: maligned
 
 	selfSignedCA        bool
 	selfSignedCACertTTL time.Duration
+	// The EC signature algorithm of the self-signed CA key. If empty, an RSA key is used.
+	selfSignedCAECSigAlg string
+
+	// Configuration if the CA certificate is signed by Vault.
+	vaultAddress              string
+	vaultTLSRootCertFile      string
+	vaultTokenFile            string
+	vaultKubernetesAuthPath   string
+	vaultKubernetesAuthRole   string
+	vaultJWTPath              string
+	vaultSignIntermediatePath string
 
 	workloadCertTTL    time.Duration
 	maxWorkloadCertTTL time.Duration
@@ -77,6 +92,12 @@ type cliOptions struct { // nolint: maligned
 	grpcPort   int
 	serverOnly bool
 
+	// Comma separated string containing the identities allowed to revoke certificates.
+	revocationAdmins string
+
+	// The configuration file of the authentication of the VM workloads by their cloud instance identity.
+	instanceIdentityConfig string
+
 	// Whether the CA signs certificates for other CAs.
 	signCACerts bool
 	// Whether to generate PKCS#8 private keys.
@@ -191,6 +212,30 @@ func initCLI() {
 			"When set to true, the '--signing-cert' and '--signing-key' options are ignored.")
 	flags.DurationVar(&opts.selfSignedCACertTTL, "self-signed-ca-cert-ttl", cmd.DefaultSelfSignedCACertTTL,
 		"The TTL of self-signed CA root certificate.")
+	flags.StringVar(&opts.selfSignedCAECSigAlg, "self-signed-ca-ecc-signature-algorithm", "",
+		"The EC signature algorithm of the self-signed CA key. Only 'ECDSA' (P-256) is supported. "+
+			"If unspecified, an RSA key is generated.")
+
+	// Configuration if Citadel's CA certificate is signed by Vault.
+	flags.StringVar(&opts.vaultAddress, "vault-address", "",
+		"The address of the Vault server, e.g. 'https://vault:8200'. When set, Citadel generates its CA key and "+
+			"gets its CA certificate signed by the Vault PKI secrets engine, and the '--signing-cert' and "+
+			"'--signing-key' options are ignored. The CA certificate is requested with the TTL "+
+			"'--requested-ca-cert-ttl', and renewed after half of its lifetime.")
+	flags.StringVar(&opts.vaultTLSRootCertFile, "vault-tls-root-cert", "",
+		"Path to the root certificate file of the Vault server. If unspecified, the system roots are used.")
+	flags.StringVar(&opts.vaultTokenFile, "vault-token-file", "",
+		"Path to the file of the Vault token, read at each signing so that it can be renewed. If unspecified, "+
+			"Citadel logs into the Vault Kubernetes auth method.")
+	flags.StringVar(&opts.vaultKubernetesAuthPath, "vault-kubernetes-auth-path", "auth/kubernetes/login",
+		"The login path of the Vault Kubernetes auth method.")
+	flags.StringVar(&opts.vaultKubernetesAuthRole, "vault-kubernetes-auth-role", "",
+		"The role Citadel logs into the Vault Kubernetes auth method with.")
+	flags.StringVar(&opts.vaultJWTPath, "vault-jwt-path", "/var/run/secrets/kubernetes.io/serviceaccount/token",
+		"Path to the service account token Citadel logs into the Vault Kubernetes auth method with.")
+	flags.StringVar(&opts.vaultSignIntermediatePath, "vault-sign-intermediate-path", "pki/root/sign-intermediate",
+		"The Vault path signing the intermediate CA certificates.")
+
 	flags.StringVar(&opts.trustDomain, "trust-domain", "",
 		"The domain serves to identify the system with SPIFFE.")
 	// Upstream CA configuration if Citadel interacts with upstream CA.
@@ -215,6 +260,11 @@ func initCLI() {
 	// gRPC server for signing CSRs.
 	flags.StringVar(&opts.grpcHosts, "grpc-host-identities", "istio-ca,istio-citadel",
 		"The list of hostnames for istio ca server, separated by comma.")
+	flags.StringVar(&opts.revocationAdmins, "revocation-admin-identities", "",
+		"The list of identities allowed to revoke certificates through the Citadel GRPC server, separated by comma.")
+	flags.StringVar(&opts.instanceIdentityConfig, "instance-identity-config", "",
+		"The configuration file mapping the AWS and GCP instance identity documents of the VM workloads to "+
+			"service accounts. If unspecified, the VM workloads are not authenticated by their instance identity.")
 	flags.IntVar(&opts.grpcPort, "grpc-port", 8060, "The port number for Citadel GRPC server. "+
 		"If unspecified, Citadel will not serve GRPC requests.")
 	flags.BoolVar(&opts.serverOnly, "server-only", false, "When set, Citadel only serves as a server without writing "+
@@ -257,6 +307,8 @@ func initCLI() {
 
 	rootCmd.AddCommand(cmd.NewProbeCmd())
 
+	rootCmd.AddCommand(newRootRotationCmd())
+
 	opts.loggingOptions.AttachCobraFlags(rootCmd)
 	opts.ctrlzOptions.AttachCobraFlags(rootCmd)
 
@@ -316,6 +368,16 @@ func runCA() {
 	ca := createCA(cs.CoreV1())
 
 	stopCh := make(chan struct{})
+	// Keep the published CRL from expiring.
+	go ca.RunCRLRefresher(stopCh)
+	if opts.selfSignedCA {
+		// Follow the root rotation driven by the root-rotation command.
+		go ca.RunRootRotationWatcher(cs.CoreV1(), opts.istioCaStorageNamespace, opts.rootCertFile, stopCh)
+	}
+	if opts.vaultAddress != "" {
+		// Renew the CA certificate signed by Vault.
+		go ca.RunIntermediateRenewal(cs.CoreV1(), opts.istioCaStorageNamespace, stopCh)
+	}
 	if !opts.serverOnly {
 		log.Infof("Creating Kubernetes controller to write issued keys and certs into secret ...")
 		// For workloads in K8s, we apply the configured workload cert TTL.
@@ -359,7 +421,8 @@ func runCA() {
 		// The CA API uses cert with the max workload cert TTL.
 		hostnames := append(strings.Split(opts.grpcHosts, ","), fqdn())
 		caServer, startErr := caserver.New(ca, opts.maxWorkloadCertTTL, opts.signCACerts, hostnames,
-			opts.grpcPort, spiffe.GetTrustDomain(), opts.sdsEnabled)
+			opts.grpcPort, spiffe.GetTrustDomain(), opts.sdsEnabled, revocationAdmins(), opts.instanceIdentityConfig,
+			configmap.NewController(opts.istioCaStorageNamespace, cs.CoreV1()))
 		if startErr != nil {
 			fatalf("Failed to create istio ca server: %v", startErr)
 		}
@@ -423,7 +486,20 @@ func createCA(client corev1.CoreV1Interface) *ca.IstioCA {
 	var caOpts *ca.IstioCAOptions
 	var err error
 
-	if opts.selfSignedCA {
+	if opts.vaultAddress != "" {
+		log.Infof("Use certificate signed by Vault at %s as the CA certificate", opts.vaultAddress)
+		spiffe.SetTrustDomain(spiffe.DetermineTrustDomain(opts.trustDomain, true))
+		vaultCA, err := vault.NewCA(vaultOptions())
+		if err != nil {
+			fatalf("Failed to create the Vault client (error: %v)", err)
+		}
+		caOpts, err = ca.NewUpstreamIstioCAOptions(vaultCA, opts.cAClientConfig.RequestedCertTTL, opts.workloadCertTTL,
+			opts.maxWorkloadCertTTL, spiffe.GetTrustDomain(), opts.cAClientConfig.RSAKeySize,
+			opts.istioCaStorageNamespace, client, opts.rootCertFile)
+		if err != nil {
+			fatalf("Failed to create a Citadel signed by Vault (error: %v)", err)
+		}
+	} else if opts.selfSignedCA {
 		log.Info("Use self-signed certificate as the CA certificate")
 		spiffe.SetTrustDomain(spiffe.DetermineTrustDomain(opts.trustDomain, true))
 		// Abort after 20 minutes.
@@ -437,7 +513,8 @@ func createCA(client corev1.CoreV1Interface) *ca.IstioCA {
 		}
 		caOpts, err = ca.NewSelfSignedIstioCAOptions(ctx, opts.selfSignedCACertTTL, opts.workloadCertTTL,
 			opts.maxWorkloadCertTTL, spiffe.GetTrustDomain(), opts.dualUse,
-			opts.istioCaStorageNamespace, checkInterval, client, opts.rootCertFile)
+			opts.istioCaStorageNamespace, checkInterval, client, opts.rootCertFile,
+			util.SupportedECSignatureAlgorithms(opts.selfSignedCAECSigAlg))
 		if err != nil {
 			fatalf("Failed to create a self-signed Citadel (error: %v)", err)
 		}
@@ -472,7 +549,50 @@ func createCA(client corev1.CoreV1Interface) *ca.IstioCA {
 	return istioCA
 }
 
+func vaultOptions() vault.Options {
+	options := vault.Options{
+		Address:              opts.vaultAddress,
+		SignIntermediatePath: opts.vaultSignIntermediatePath,
+	}
+	if opts.vaultTLSRootCertFile != "" {
+		rootCert, err := ioutil.ReadFile(opts.vaultTLSRootCertFile)
+		if err != nil {
+			fatalf("Failed to read the Vault TLS root certificate (error: %v)", err)
+		}
+		options.TLSRootCert = rootCert
+	}
+	if opts.vaultTokenFile != "" {
+		options.TokenFile = opts.vaultTokenFile
+	} else {
+		options.KubernetesAuthPath = opts.vaultKubernetesAuthPath
+		options.KubernetesAuthRole = opts.vaultKubernetesAuthRole
+		options.JWTPath = opts.vaultJWTPath
+	}
+	return options
+}
+
+func revocationAdmins() []string {
+	var admins []string
+	for _, id := range strings.Split(opts.revocationAdmins, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			admins = append(admins, id)
+		}
+	}
+	return admins
+}
+
 func verifyCommandLineOptions() {
+	if opts.vaultAddress != "" {
+		if opts.selfSignedCA {
+			fatalf("'-vault-address' and '-self-signed-ca' cannot be both specified")
+		}
+		if opts.vaultTokenFile == "" && opts.vaultKubernetesAuthRole == "" {
+			fatalf("No Vault authentication has been specified. Either specify a token file via " +
+				"'-vault-token-file' option or a role via '-vault-kubernetes-auth-role'")
+		}
+		return
+	}
+
 	if opts.selfSignedCA {
 		return
 	}
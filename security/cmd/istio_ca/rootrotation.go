@@ -0,0 +1,189 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	kubelib "istio.io/istio/pkg/kube"
+	"istio.io/istio/pkg/spiffe"
+	"istio.io/istio/security/pkg/cmd"
+	"istio.io/istio/security/pkg/pki/ca"
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+type rootRotationOptions struct {
+	kubeConfigFile          string
+	istioCaStorageNamespace string
+
+	newRootCertFile string
+	newRootKeyFile  string
+	rootCertTTL     time.Duration
+	trustDomain     string
+	ecSigAlg        string
+}
+
+// newRootRotationCmd returns the command driving the rotation of the root of a self-signed Citadel. Each step
+// updates the CA secret, which the running Citadel instances pick up.
+func newRootRotationCmd() *cobra.Command {
+	rrOpts := &rootRotationOptions{}
+	rootRotationCmd := &cobra.Command{
+		Use:   "root-rotation",
+		Short: "Rotate the root certificate of a self-signed Citadel",
+		Long: "Rotate the root certificate of a self-signed Citadel without breaking mTLS between workloads. " +
+			"'start' makes workloads trust a new root along with the current one, 'activate' signs the workload " +
+			"certificates with the new root, and 'retire' removes the old root once all the workload certificates " +
+			"signed by it have been rotated.",
+	}
+	rootRotationCmd.PersistentFlags().StringVar(&rrOpts.kubeConfigFile, "kube-config", "",
+		"Specifies path to kubeconfig file. This must be specified when not running inside a Kubernetes pod.")
+	rootRotationCmd.PersistentFlags().StringVar(&rrOpts.istioCaStorageNamespace, "citadel-storage-namespace",
+		"istio-system", "Namespace where the Citadel pod is running.")
+
+	startCmd := &cobra.Command{
+		Use:   "start",
+		Short: "Add a new root, trusted along with the current root",
+		Args:  cobra.ExactArgs(0),
+		RunE: func(c *cobra.Command, args []string) error {
+			client, err := rrOpts.client()
+			if err != nil {
+				return err
+			}
+			var newCert, newKey []byte
+			if rrOpts.newRootCertFile != "" || rrOpts.newRootKeyFile != "" {
+				if newCert, err = ioutil.ReadFile(rrOpts.newRootCertFile); err != nil {
+					return fmt.Errorf("failed to read the new root cert (%v)", err)
+				}
+				if newKey, err = ioutil.ReadFile(rrOpts.newRootKeyFile); err != nil {
+					return fmt.Errorf("failed to read the new root key (%v)", err)
+				}
+			}
+			options := util.CertOptions{
+				TTL:          rrOpts.rootCertTTL,
+				Org:          spiffe.DetermineTrustDomain(rrOpts.trustDomain, true),
+				IsCA:         true,
+				IsSelfSigned: true,
+				RSAKeySize:   2048,
+				ECSigAlg:     util.SupportedECSignatureAlgorithms(rrOpts.ecSigAlg),
+			}
+			if err := ca.StartRootRotation(client, rrOpts.istioCaStorageNamespace, newCert, newKey, options); err != nil {
+				return err
+			}
+			return printRootRotationStatus(c, client, rrOpts.istioCaStorageNamespace)
+		},
+	}
+	startCmd.Flags().StringVar(&rrOpts.newRootCertFile, "new-root-cert", "",
+		"Path to the new root certificate file. A new root is generated when not set.")
+	startCmd.Flags().StringVar(&rrOpts.newRootKeyFile, "new-root-key", "", "Path to the new root key file.")
+	startCmd.Flags().DurationVar(&rrOpts.rootCertTTL, "root-cert-ttl", cmd.DefaultSelfSignedCACertTTL,
+		"The TTL of the generated root certificate.")
+	startCmd.Flags().StringVar(&rrOpts.trustDomain, "trust-domain", "",
+		"The domain serves to identify the system with spiffe. It is the organization of the generated root certificate.")
+	startCmd.Flags().StringVar(&rrOpts.ecSigAlg, "ecc-signature-algorithm", "",
+		"The type of ECC signature algorithm of the generated root certificate. Currently only ECDSA is supported. "+
+			"A RSA key is generated when not set.")
+
+	activateCmd := &cobra.Command{
+		Use:   "activate",
+		Short: "Sign the workload certificates with the new root",
+		Args:  cobra.ExactArgs(0),
+		RunE: func(c *cobra.Command, args []string) error {
+			client, err := rrOpts.client()
+			if err != nil {
+				return err
+			}
+			if err := ca.ActivateNewRoot(client, rrOpts.istioCaStorageNamespace); err != nil {
+				return err
+			}
+			return printRootRotationStatus(c, client, rrOpts.istioCaStorageNamespace)
+		},
+	}
+
+	retireCmd := &cobra.Command{
+		Use:   "retire",
+		Short: "Stop trusting the old root, which completes the rotation",
+		Args:  cobra.ExactArgs(0),
+		RunE: func(c *cobra.Command, args []string) error {
+			client, err := rrOpts.client()
+			if err != nil {
+				return err
+			}
+			if err := ca.RetireOldRoot(client, rrOpts.istioCaStorageNamespace); err != nil {
+				return err
+			}
+			return printRootRotationStatus(c, client, rrOpts.istioCaStorageNamespace)
+		},
+	}
+
+	statusCmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show the phase of the root rotation and the roots",
+		Args:  cobra.ExactArgs(0),
+		RunE: func(c *cobra.Command, args []string) error {
+			client, err := rrOpts.client()
+			if err != nil {
+				return err
+			}
+			return printRootRotationStatus(c, client, rrOpts.istioCaStorageNamespace)
+		},
+	}
+
+	rootRotationCmd.AddCommand(startCmd, activateCmd, retireCmd, statusCmd)
+	return rootRotationCmd
+}
+
+func (o *rootRotationOptions) client() (corev1.CoreV1Interface, error) {
+	cs, err := kubelib.CreateClientset(o.kubeConfigFile, "")
+	if err != nil {
+		return nil, fmt.Errorf("could not create k8s clientset: %v", err)
+	}
+	return cs.CoreV1(), nil
+}
+
+func printRootRotationStatus(c *cobra.Command, client corev1.CoreV1Interface, namespace string) error {
+	status, err := ca.GetRootRotationStatus(client, namespace)
+	if err != nil {
+		return err
+	}
+
+	out := c.OutOrStdout()
+	fmt.Fprintf(out, "Phase: %s\n", status.Phase)
+	if status.NewRoot == nil {
+		fmt.Fprintf(out, "Root: %s\n", describeRoot(status.OldRoot))
+	} else {
+		fmt.Fprintf(out, "Old root: %s\n", describeRoot(status.OldRoot))
+		fmt.Fprintf(out, "New root: %s\n", describeRoot(status.NewRoot))
+	}
+
+	switch status.Phase {
+	case ca.RootRotationTrustNewRoot:
+		fmt.Fprintln(out, "Next step: once all the workloads trust both roots, run 'root-rotation activate'.")
+	case ca.RootRotationSignWithNewRoot:
+		fmt.Fprintln(out, "Next step: once all the workload certificates are signed by the new root, "+
+			"run 'root-rotation retire'.")
+	}
+	return nil
+}
+
+func describeRoot(root *x509.Certificate) string {
+	return fmt.Sprintf("subject %q, serial %s, expires %s", root.Subject.String(), root.SerialNumber.String(),
+		root.NotAfter.Format(time.RFC3339))
+}
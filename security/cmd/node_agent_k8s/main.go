@@ -16,6 +16,7 @@ package main
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
 	"strings"
 	"time"
@@ -28,8 +29,11 @@ import (
 
 	"istio.io/istio/pkg/cmd"
 	"istio.io/istio/security/pkg/nodeagent/cache"
+	"istio.io/istio/security/pkg/nodeagent/model"
 	"istio.io/istio/security/pkg/nodeagent/sds"
 	"istio.io/istio/security/pkg/nodeagent/secretfetcher"
+	"istio.io/istio/security/pkg/nodeagent/workloadapi"
+	"istio.io/istio/security/pkg/pki/util"
 	"istio.io/istio/security/pkg/server/monitoring"
 	"istio.io/pkg/collateral"
 	"istio.io/pkg/env"
@@ -55,6 +59,11 @@ const (
 	trustDomain     = "Trust_Domain"
 	trustDomainFlag = "trustDomain"
 
+	// The foreign trust domains whose identities are accepted, with the files of their root certificates.
+	// example value format like "partner.org=/etc/certs/partner/root-cert.pem,other.org=/etc/certs/other/root-cert.pem"
+	federatedTrustBundles     = "FEDERATED_TRUST_BUNDLES"
+	federatedTrustBundlesFlag = "federatedTrustBundles"
+
 	// The workload SDS mode allows node agent to provision credentials to workload proxy by sending
 	// CSR to CA.
 	enableWorkloadSDS     = "ENABLE_WORKLOAD_SDS"
@@ -65,6 +74,11 @@ const (
 	enableIngressGatewaySDS     = "ENABLE_INGRESS_GATEWAY_SDS"
 	enableIngressGatewaySDSFlag = "enableIngressGatewaySDS"
 
+	// The SPIFFE Workload API mode allows node agent to provision X.509-SVIDs and JWT-SVIDs to the workloads
+	// calling it, with the identity attested from their peer credentials.
+	enableWorkloadAPI     = "ENABLE_WORKLOAD_API"
+	enableWorkloadAPIFlag = "enableWorkloadAPI"
+
 	// The environmental variable name for Vault CA address.
 	vaultAddress     = "VAULT_ADDR"
 	vaultAddressFlag = "vaultAddress"
@@ -94,6 +108,11 @@ const (
 	// validate the certificate's format which is returned by CA.
 	skipValidateCertFlag = "SKIP_CERT_VALIDATION"
 
+	// The environmental variable name for the EC signature algorithm of workload keys.
+	// Only "ECDSA" is supported. If empty, RSA keys are generated.
+	eccSigAlg     = "ECC_SIGNATURE_ALGORITHM"
+	eccSigAlgFlag = "eccSigAlg"
+
 	// The environmental variable name for secret TTL, node agent decides whether a secret
 	// is expired if time.now - secret.createtime >= secretTTL.
 	// example value format like "90m"
@@ -129,6 +148,9 @@ var (
 	workloadSdsCacheOptions cache.Options
 	gatewaySdsCacheOptions  cache.Options
 	serverOptions           sds.Options
+	workloadAPIOptions      workloadapi.Options
+	enableWorkloadAPIOption bool
+	workloadAPIJWTBundles   string
 	gatewaySecretChan       chan struct{}
 	loggingOptions          = log.DefaultOptions()
 	ctrlzOptions            = ctrlz.DefaultOptions()
@@ -166,6 +188,21 @@ var (
 				return fmt.Errorf("failed to create sds service")
 			}
 
+			if enableWorkloadAPIOption {
+				workloadAPIOptions.TrustDomain = serverOptions.TrustDomain
+				for trustDomain := range workloadSdsCacheOptions.FederatedTrustBundles {
+					workloadAPIOptions.FederatedTrustDomains = append(workloadAPIOptions.FederatedTrustDomains, trustDomain)
+				}
+				// validated by validateOptions
+				workloadAPIOptions.JWTBundleFiles, _ = parseTrustDomainFiles(workloadAPIJWTBundles, "JWT bundle")
+				workloadAPIServer, err := workloadapi.NewServer(workloadAPIOptions, workloadSecretCache)
+				if err != nil {
+					log.Errorf("failed to create workload API service: %v", err)
+					return fmt.Errorf("failed to create workload API service")
+				}
+				defer workloadAPIServer.Stop()
+			}
+
 			monitorErrCh := make(chan error)
 			// Start the monitoring server.
 			if monitoringPortEnv > 0 {
@@ -208,8 +245,13 @@ func newSecretCache(serverOptions sds.Options) (workloadSecretCache, gatewaySecr
 			os.Exit(1)
 		}
 		workloadSdsCacheOptions.TrustDomain = serverOptions.TrustDomain
+		workloadSdsCacheOptions.FederatedTrustBundles, err = loadFederatedTrustBundles(serverOptions.FederatedTrustBundles)
+		if err != nil {
+			log.Errorf("failed to load the federated trust bundles: %v", err)
+			os.Exit(1)
+		}
 		workloadSdsCacheOptions.Plugins = sds.NewPlugins(serverOptions.PluginNames)
-		workloadSecretCache = cache.NewSecretCache(wSecretFetcher, sds.NotifyProxy, workloadSdsCacheOptions)
+		workloadSecretCache = cache.NewSecretCache(wSecretFetcher, notifyWorkloadSecret, workloadSdsCacheOptions)
 	} else {
 		workloadSecretCache = nil
 	}
@@ -229,15 +271,26 @@ func newSecretCache(serverOptions sds.Options) (workloadSecretCache, gatewaySecr
 	return workloadSecretCache, gatewaySecretCache
 }
 
+// notifyWorkloadSecret sends the rotated workload secrets to the proxies, or to the Workload API callers.
+func notifyWorkloadSecret(connKey cache.ConnKey, secret *model.SecretItem) error {
+	if workloadapi.IsWorkloadAPIConnection(connKey.ConnectionID) {
+		return workloadapi.NotifyWorkload(connKey, secret)
+	}
+	return sds.NotifyProxy(connKey, secret)
+}
+
 var (
 	pluginNamesEnv                     = env.RegisterStringVar(pluginNames, "", "").Get()
 	enableWorkloadSDSEnv               = env.RegisterBoolVar(enableWorkloadSDS, true, "").Get()
 	enableIngressGatewaySDSEnv         = env.RegisterBoolVar(enableIngressGatewaySDS, false, "").Get()
+	enableWorkloadAPIEnv               = env.RegisterBoolVar(enableWorkloadAPI, false, "").Get()
 	alwaysValidTokenFlagEnv            = env.RegisterBoolVar(alwaysValidTokenFlag, false, "").Get()
 	skipValidateCertFlagEnv            = env.RegisterBoolVar(skipValidateCertFlag, false, "").Get()
+	eccSigAlgEnv                       = env.RegisterStringVar(eccSigAlg, "", "").Get()
 	caProviderEnv                      = env.RegisterStringVar(caProvider, "", "").Get()
 	caEndpointEnv                      = env.RegisterStringVar(caEndpoint, "", "").Get()
 	trustDomainEnv                     = env.RegisterStringVar(trustDomain, "", "").Get()
+	federatedTrustBundlesEnv           = env.RegisterStringVar(federatedTrustBundles, "", "").Get()
 	vaultAddressEnv                    = env.RegisterStringVar(vaultAddress, "", "").Get()
 	vaultRoleEnv                       = env.RegisterStringVar(vaultRole, "", "").Get()
 	vaultAuthPathEnv                   = env.RegisterStringVar(vaultAuthPath, "", "").Get()
@@ -269,6 +322,10 @@ func applyEnvVars(cmd *cobra.Command) {
 		serverOptions.EnableIngressGatewaySDS = enableIngressGatewaySDSEnv
 	}
 
+	if !cmd.Flag(enableWorkloadAPIFlag).Changed {
+		enableWorkloadAPIOption = enableWorkloadAPIEnv
+	}
+
 	if !cmd.Flag(alwaysValidTokenFlagFlag).Changed {
 		serverOptions.AlwaysValidTokenFlag = alwaysValidTokenFlagEnv
 	}
@@ -285,6 +342,10 @@ func applyEnvVars(cmd *cobra.Command) {
 		serverOptions.TrustDomain = trustDomainEnv
 	}
 
+	if !cmd.Flag(federatedTrustBundlesFlag).Changed {
+		serverOptions.FederatedTrustBundles = federatedTrustBundlesEnv
+	}
+
 	if !cmd.Flag(vaultAddressFlag).Changed {
 		serverOptions.VaultAddress = vaultAddressEnv
 	}
@@ -321,6 +382,10 @@ func applyEnvVars(cmd *cobra.Command) {
 		workloadSdsCacheOptions.SkipValidateCert = skipValidateCertFlagEnv
 	}
 
+	if !cmd.Flag(eccSigAlgFlag).Changed {
+		workloadSdsCacheOptions.ECCSigAlg = eccSigAlgEnv
+	}
+
 	serverOptions.RecycleInterval = staledConnectionRecycleIntervalEnv
 
 	if !cmd.Flag(InitialBackoffFlag).Changed {
@@ -343,6 +408,26 @@ func validateOptions() error {
 		return fmt.Errorf("UDS paths for ingress gateway and workload cannot be the same: %s", serverOptions.IngressGatewayUDSPath)
 	}
 
+	if alg := workloadSdsCacheOptions.ECCSigAlg; alg != "" && alg != string(util.EcdsaSigAlg) {
+		return fmt.Errorf("unsupported EC signature algorithm: %s", alg)
+	}
+
+	if _, err := parseTrustDomainFiles(serverOptions.FederatedTrustBundles, "federated trust bundle"); err != nil {
+		return err
+	}
+
+	if enableWorkloadAPIOption {
+		if !serverOptions.EnableWorkloadSDS {
+			return fmt.Errorf("workload SDS must be enabled when the workload API is enabled")
+		}
+		if workloadAPIOptions.UDSPath == serverOptions.WorkloadUDSPath {
+			return fmt.Errorf("UDS paths for workload API and workload SDS cannot be the same: %s", workloadAPIOptions.UDSPath)
+		}
+		if _, err := parseTrustDomainFiles(workloadAPIJWTBundles, "JWT bundle"); err != nil {
+			return err
+		}
+	}
+
 	if serverOptions.EnableWorkloadSDS {
 		if serverOptions.CAProviderName == "" {
 			return fmt.Errorf("CA provider cannot be empty when workload SDS is enabled")
@@ -354,6 +439,46 @@ func validateOptions() error {
 	return nil
 }
 
+// parseTrustDomainFiles returns the files of the trust domains, e.g. the files of their root certificates,
+// keyed by trust domain, from a comma separated list of <trust domain>=<file>.
+func parseTrustDomainFiles(value, kind string) (map[string]string, error) {
+	files := make(map[string]string)
+	for _, entry := range strings.Split(value, ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid %s %q, expecting <trust domain>=<file>", kind, entry)
+		}
+		if _, found := files[parts[0]]; found {
+			return nil, fmt.Errorf("duplicated %s for trust domain %q", kind, parts[0])
+		}
+		files[parts[0]] = parts[1]
+	}
+	return files, nil
+}
+
+// loadFederatedTrustBundles reads the root certificates of the foreign trust domains, keyed by trust domain.
+func loadFederatedTrustBundles(value string) (map[string][]byte, error) {
+	files, err := parseTrustDomainFiles(value, "federated trust bundle")
+	if err != nil {
+		return nil, err
+	}
+	bundles := make(map[string][]byte, len(files))
+	for trustDomain, file := range files {
+		bundle, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the trust bundle of trust domain %q: %v", trustDomain, err)
+		}
+		if _, err := util.ParsePemEncodedCertificate(bundle); err != nil {
+			return nil, fmt.Errorf("invalid trust bundle of trust domain %q: %v", trustDomain, err)
+		}
+		bundles[trustDomain] = bundle
+	}
+	return bundles, nil
+}
+
 func main() {
 	rootCmd.PersistentFlags().BoolVar(&serverOptions.EnableWorkloadSDS, enableWorkloadSDSFlag,
 		true,
@@ -367,11 +492,31 @@ func main() {
 	rootCmd.PersistentFlags().StringVar(&serverOptions.IngressGatewayUDSPath, "gatewayUdsPath",
 		"/var/run/ingress_gateway/sds", "Unix domain socket through which SDS server communicates with ingress gateway proxies.")
 
+	rootCmd.PersistentFlags().BoolVar(&enableWorkloadAPIOption, enableWorkloadAPIFlag,
+		false,
+		"If true, node agent serves the SPIFFE Workload API and provisions X.509-SVIDs and JWT-SVIDs to workloads.")
+	rootCmd.PersistentFlags().StringVar(&workloadAPIOptions.UDSPath, "workloadAPIUDSPath",
+		"/var/run/spiffe/workload.sock", "Unix domain socket through which the SPIFFE Workload API is served")
+	rootCmd.PersistentFlags().StringVar(&workloadAPIOptions.TokenPath, "workloadAPITokenPath",
+		"/var/run/secrets/kubernetes.io/serviceaccount/token",
+		"Path of the credential token in the file system of the workloads calling the SPIFFE Workload API")
+	rootCmd.PersistentFlags().DurationVar(&workloadAPIOptions.JWTSVIDTTL, "workloadAPIJWTSVIDTTL",
+		5*time.Minute, "TTL of the JWT-SVIDs issued by the SPIFFE Workload API")
+	rootCmd.PersistentFlags().StringVar(&workloadAPIOptions.JWTAuthorityKeyFile, "workloadAPIJWTAuthorityKeyFile", "",
+		"File of the private key signing the JWT-SVIDs of the trust domain, shared by its node agents. "+
+			"The SPIFFE Workload API does not issue JWT-SVIDs without it")
+	rootCmd.PersistentFlags().StringVar(&workloadAPIJWTBundles, "workloadAPIJWTBundles", "",
+		"The comma separated list of the trust domains with the files of the JWK sets of their JWT authorities, "+
+			"e.g. partner.org=/etc/jwt/partner/bundle.json")
+
 	rootCmd.PersistentFlags().StringVar(&serverOptions.CAProviderName, caProviderFlag, "", "CA provider")
 	rootCmd.PersistentFlags().StringVar(&serverOptions.CAEndpoint, caEndpointFlag, "", "CA endpoint")
 
 	rootCmd.PersistentFlags().StringVar(&serverOptions.TrustDomain, trustDomainFlag,
 		"", "The trust domain this node agent run in")
+	rootCmd.PersistentFlags().StringVar(&serverOptions.FederatedTrustBundles, federatedTrustBundlesFlag, "",
+		"The comma separated list of the foreign trust domains whose identities are accepted, with the files of "+
+			"their root certificates, e.g. partner.org=/etc/certs/partner/root-cert.pem")
 	rootCmd.PersistentFlags().StringArrayVar(&serverOptions.PluginNames, pluginNamesFlag,
 		[]string{}, "authentication provider specific plugin names")
 
@@ -399,6 +544,9 @@ func main() {
 		false,
 		"If true, node agent skip validating format of certificate returned from CA.")
 
+	rootCmd.PersistentFlags().StringVar(&workloadSdsCacheOptions.ECCSigAlg, eccSigAlgFlag, "",
+		"The EC signature algorithm of workload keys. Only 'ECDSA' is supported. If empty, RSA keys are generated.")
+
 	rootCmd.PersistentFlags().StringVar(&serverOptions.VaultAddress, vaultAddressFlag, "",
 		"Vault address")
 	rootCmd.PersistentFlags().StringVar(&serverOptions.VaultRole, vaultRoleFlag, "",
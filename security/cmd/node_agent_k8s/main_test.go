@@ -67,11 +67,54 @@ func TestValidateOptions(t *testing.T) {
 			},
 			errorMsg: "CA endpoint cannot be empty when workload SDS is enabled",
 		},
+		{
+			name: "ECDSA signature algorithm",
+			setExtraOptions: func() {
+				workloadSdsCacheOptions.ECCSigAlg = "ECDSA"
+			},
+		},
+		{
+			name: "unsupported EC signature algorithm",
+			setExtraOptions: func() {
+				workloadSdsCacheOptions.ECCSigAlg = "ED25519"
+			},
+			errorMsg: "unsupported EC signature algorithm",
+		},
+		{
+			name: "federated trust bundles",
+			setExtraOptions: func() {
+				serverOptions.FederatedTrustBundles = "partner.org=/etc/certs/partner/root-cert.pem, other.org=/other.pem"
+			},
+		},
+		{
+			name: "invalid federated trust bundle",
+			setExtraOptions: func() {
+				serverOptions.FederatedTrustBundles = "partner.org"
+			},
+			errorMsg: "invalid federated trust bundle",
+		},
+		{
+			name: "duplicated federated trust bundle",
+			setExtraOptions: func() {
+				serverOptions.FederatedTrustBundles = "partner.org=/a.pem,partner.org=/b.pem"
+			},
+			errorMsg: "duplicated federated trust bundle",
+		},
+		{
+			name: "invalid JWT bundle",
+			setExtraOptions: func() {
+				enableWorkloadAPIOption = true
+				workloadAPIJWTBundles = "partner.org=/a.json,/b.json"
+			},
+			errorMsg: "invalid JWT bundle",
+		},
 	}
 
 	for _, c := range cases {
 
 		// Set the valid options as the base for the testing.
+		enableWorkloadAPIOption = false
+		workloadAPIJWTBundles = ""
 		workloadSdsCacheOptions = cache.Options{
 			InitialBackoff: 10,
 		}
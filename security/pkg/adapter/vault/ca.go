@@ -16,191 +16,212 @@
 package vault
 
 import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
 	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
-	"istio.io/istio/security/pkg/pki/util"
-)
-
-/*
-// Config for prototyping purpose
-const (
-	istioCaMountPoint   = "istio_ca"
-	istioCaDescription  = "Istio CA"
-	configCaKeyCertPath = "istio_ca/config/ca"
-	workloadRolePath    = "istio_ca/roles/workload_role"
-	signCsrPath         = "istio_ca/sign-verbatim"
-)
+	"github.com/hashicorp/vault/api"
 
-// Config for prototyping purpose
-// TODO (lei-tang): move the these config to ca_test.go
-const (
-	vaultAddrForTesting = "http://127.0.0.1:8200"
-	tokenForTesting     = "myroot"
-	testCAKeyCertFile   = "testdata/istio_ca.pem"
-	testCsrFile         = "testdata/workload-1.csr"
+	"istio.io/pkg/log"
 )
-*/
-
-// CA connects to Vault to sign certificates.
-type CA struct {
-}
 
-// Sign takes a PEM-encoded CSR and returns a signed certificate. If the CA is a multicluster CA,
-// the signed certificate is a CA certificate (CA:TRUE in X509v3 Basic Constraints), otherwise, it is a workload
-// certificate.
-func (v *CA) Sign(csrPEM []byte, ttl time.Duration) ([]byte, error) {
-	return nil, fmt.Errorf("not implemented")
+// defaultCommonName is the common name of the intermediate CA certificates requested without one.
+const defaultCommonName = "Istio CA"
+
+var vaultLog = log.RegisterScope("vault", "Vault upstream CA debugging", 0)
+
+// Options are the configuration of the connection of Citadel to the Vault PKI secrets engine.
+type Options struct {
+	// Address is the address of the Vault server, e.g. "https://vault.vault.svc:8200".
+	Address string
+	// TLSRootCert is the PEM encoded root certificate of the Vault server. The system roots are used if empty.
+	TLSRootCert []byte
+
+	// TokenFile is the file of the token authenticating Citadel to Vault. It is read at each signing, so that
+	// the token can be renewed or rotated, e.g. by the Vault agent. If it is empty, Citadel logs into the
+	// Kubernetes auth method.
+	TokenFile string
+	// KubernetesAuthPath is the login path of the Kubernetes auth method, e.g. "auth/kubernetes/login".
+	KubernetesAuthPath string
+	// KubernetesAuthRole is the role Citadel logs in with.
+	KubernetesAuthRole string
+	// JWTPath is the file of the service account token Citadel logs in with. It is read at each login, so
+	// that the token can be rotated.
+	JWTPath string
+
+	// SignIntermediatePath is the path signing the intermediate CA certificates, e.g. "pki/root/sign-intermediate".
+	SignIntermediatePath string
 }
 
-// SignCAServerCert signs the certificate for the Istio CA server (to serve the CSR, etc).
-func (v *CA) SignCAServerCert(csrPEM []byte, ttl time.Duration) ([]byte, error) {
-	return nil, fmt.Errorf("not implemented")
+// CA is an upstream authority of Citadel, which signs its intermediate CA certificate with the Vault PKI
+// secrets engine.
+type CA struct {
+	options Options
+	client  *api.Client
 }
 
-// GetKeyCertBundle returns the KeyCertBundle for the CA.
-func (v *CA) GetKeyCertBundle() util.KeyCertBundle {
-	return nil
-}
+// NewCA creates a CA connected to Vault.
+func NewCA(options Options) (*CA, error) {
+	if options.Address == "" {
+		return nil, errors.New("the Vault address must be specified")
+	}
+	if options.SignIntermediatePath == "" {
+		return nil, errors.New("the Vault path signing intermediate certificates must be specified")
+	}
+	if options.TokenFile == "" && (options.KubernetesAuthPath == "" || options.KubernetesAuthRole == "" ||
+		options.JWTPath == "") {
+		return nil, errors.New("either a Vault token or the Kubernetes auth path, role and JWT must be specified")
+	}
 
-/*
-// Get the connection to a Vault server and set the token for the connection.
-// vaultAddr: the address of the Vault server (e.g., "http://127.0.0.1:8200").
-// token: used for authentication.
-func getVaultConnection(vaultAddr string, token string) (*api.Client, error) {
 	config := api.DefaultConfig()
-	config.Address = vaultAddr
-
+	config.Address = options.Address
+	if len(options.TLSRootCert) > 0 {
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(options.TLSRootCert) {
+			return nil, errors.New("failed to append the Vault TLS root certificate to the certificate pool")
+		}
+		config.HttpClient = &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}
+	}
 	client, err := api.NewClient(config)
 	if err != nil {
-		log.Errorf("NewClient() failed (error %v)", err)
-		return nil, err
+		return nil, fmt.Errorf("failed to create a Vault client: %v", err)
 	}
-
-	//Simply sets the token for future requests without actual authentication
-	client.SetToken(token)
-	return client, nil
+	// The token from the environment is not used, only the configured authentication.
+	client.ClearToken()
+	vaultLog.Infof("Created Vault upstream CA for %s", options.Address)
+
+	return &CA{
+		options: options,
+		client:  client,
+	}, nil
 }
 
-// Mount the Vault PKI.
-// caMountPoint: the mount point for CA (e.g., "istio_ca")
-// caDescription: a description for CA (e.g., "Istio CA")
-func mountVaultPki(client *api.Client, caMountPoint string, caDescription string) error {
-	var mountInput api.MountInput
-	mountInput.Description = caDescription
-	mountInput.Type = "pki"
-	err := client.Sys().Mount(caMountPoint, &mountInput)
-	if err != nil {
-		log.Errorf("Mount() failed (error %v)", err)
-		return err
+// SignIntermediate signs the PEM encoded CSR of an intermediate CA certificate. It returns the certificate
+// chain, starting with the signed certificate and excluding the root, and the root certificate.
+func (v *CA) SignIntermediate(csrPEM []byte, ttl time.Duration) (certChain, rootCert []byte, err error) {
+	if err := v.authenticate(); err != nil {
+		return nil, nil, err
 	}
-	return nil
-}
 
-// Set the workload role that issues certs with the given max-TTL and number of key bits.
-// rolePath: the path to the workload role (e.g., "istio_ca/roles/workload_role")
-// maxTtl:  the max life time of a workload cert (e.g., "1h")
-// keyBits:  the number of bits for the key of a workload cert (e.g., 2048)
-func setWorkloadRole(client *api.Client, rolePath string, maxTTL string, keyBits int) error {
-	m := map[string]interface{}{
-		"max_ttl":           maxTTL,
-		"key_bits":          keyBits,
-		"enforce_hostnames": false,
-		"allow_any_name":    true,
+	block, _ := pem.Decode(csrPEM)
+	if block == nil {
+		return nil, nil, errors.New("invalid PEM encoded CSR")
 	}
-
-	_, err := client.Logical().Write(rolePath, m)
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
 	if err != nil {
-		log.Errorf("Write() failed (error %v)", err)
-		return err
+		return nil, nil, fmt.Errorf("failed to parse the CSR: %v", err)
 	}
-	return nil
-}
-
-// Set the certificate and the private key of the CA.
-// caConfigPath: the path for configuring the CA (e.g., "istio_ca/config/ca")
-// keyCert: the private key and the public certificate of the CA
-func setCaKeyCert(client *api.Client, caConfigPath string, keyCert string) (*api.Secret, error) {
-	m := map[string]interface{}{
-		"pem_bundle": keyCert,
+	// Vault requires a common name for the intermediate CA certificates.
+	commonName := csr.Subject.CommonName
+	if commonName == "" {
+		commonName = defaultCommonName
 	}
 
-	res, err := client.Logical().Write(caConfigPath, m)
+	resp, err := v.client.Logical().Write(v.options.SignIntermediatePath, map[string]interface{}{
+		"csr":          string(csrPEM),
+		"format":       "pem",
+		"ttl":          strconv.FormatInt(int64(ttl.Seconds()), 10) + "s",
+		"common_name":  commonName,
+		"organization": strings.Join(csr.Subject.Organization, ","),
+	})
 	if err != nil {
-		log.Errorf("Write() failed (error %v)", err)
-		return nil, err
+		return nil, nil, fmt.Errorf("failed to post to %s: %v", v.options.SignIntermediatePath, err)
 	}
-	return res, nil
-}
-
-// Sign a CSR and return the signed certificate.
-// csrPath: the path for signing a CSR (e.g., "istio_ca/sign-verbatim")
-// csr: the CSR to be signed
-func signCsr(client *api.Client, csrPath string, csr string) (*api.Secret, error) {
-	m := map[string]interface{}{
-		"name":                "workload_role",
-		"format":              "pem",
-		"use_csr_common_name": true,
-		"csr":                 csr,
+	if resp == nil || resp.Data == nil {
+		return nil, nil, errors.New("the sign response has no data")
 	}
 
-	res, err := client.Logical().Write(csrPath, m)
-	if err != nil {
-		log.Errorf("Write() failed (error %v)", err)
-		return nil, err
+	cert, ok := resp.Data["certificate"].(string)
+	if !ok || cert == "" {
+		return nil, nil, errors.New("no certificate in the sign response")
+	}
+	certs := []string{cert}
+	// ca_chain holds the issuing CA and its parents, up to the root. Older Vault versions only return issuing_ca.
+	if chain, ok := resp.Data["ca_chain"].([]interface{}); ok && len(chain) > 0 {
+		for i, c := range chain {
+			s, ok := c.(string)
+			if !ok {
+				return nil, nil, fmt.Errorf("the certificate %d of the CA chain is not a string", i)
+			}
+			certs = append(certs, s)
+		}
+	} else if issuingCA, ok := resp.Data["issuing_ca"].(string); ok && issuingCA != "" {
+		certs = append(certs, issuingCA)
+	} else {
+		return nil, nil, errors.New("no CA chain in the sign response")
 	}
-	return res, nil
+	return splitChain(certs)
 }
 
-//RunProtoTypeSignCsrFlow runs a prototyping signCsr flow, includes:
-//- Create a connection to Vault
-//- Mount Vault PKI
-//- Set CA signing key and cert
-//- Set workload role for issuing certificates
-//- Sign CSR and print the certificate signed
-func RunProtoTypeSignCsrFlow() error {
-	client, err := getVaultConnection(vaultAddrForTesting, tokenForTesting)
-	if err != nil {
-		log.Errorf("getVaultConnection() failed (error %v)", err)
-		return err
+// authenticate sets the token of the client, read from the token file or got by logging into the Kubernetes
+// auth method.
+func (v *CA) authenticate() error {
+	if v.options.TokenFile == "" {
+		return v.login()
 	}
-
-	err = mountVaultPki(client, istioCaMountPoint, istioCaDescription)
+	token, err := ioutil.ReadFile(v.options.TokenFile)
 	if err != nil {
-		log.Errorf("mountVaultPki() failed (error %v)", err)
-		return err
+		return fmt.Errorf("failed to read the Vault token: %v", err)
+	}
+	if len(bytes.TrimSpace(token)) == 0 {
+		return fmt.Errorf("the Vault token file %s is empty", v.options.TokenFile)
 	}
+	v.client.SetToken(string(bytes.TrimSpace(token)))
+	return nil
+}
 
-	keyCert, err := ioutil.ReadFile(testCAKeyCertFile)
+// login logs into the Kubernetes auth method, and sets the token of the client.
+func (v *CA) login() error {
+	jwt, err := ioutil.ReadFile(v.options.JWTPath)
 	if err != nil {
-		log.Errorf("ReadFile() failed (error %v)", err)
-		return err
+		return fmt.Errorf("failed to read the service account token: %v", err)
 	}
-	_, err = setCaKeyCert(client, configCaKeyCertPath, string(keyCert))
+	resp, err := v.client.Logical().Write(v.options.KubernetesAuthPath, map[string]interface{}{
+		"jwt":  strings.TrimSpace(string(jwt)),
+		"role": v.options.KubernetesAuthRole,
+	})
 	if err != nil {
-		log.Errorf("setCaKeyCert() failed (error %v)", err)
-		return err
+		return fmt.Errorf("failed to login Vault at %s: %v", v.options.Address, err)
 	}
-
-	err = setWorkloadRole(client, workloadRolePath, "1h", 2048)
-	if err != nil {
-		log.Errorf("setWorkloadRole() failed (error %v)", err)
-		return err
+	if resp == nil || resp.Auth == nil || resp.Auth.ClientToken == "" {
+		return errors.New("no client token in the login response")
 	}
+	v.client.SetToken(resp.Auth.ClientToken)
+	return nil
+}
 
-	testCsr, err := ioutil.ReadFile(testCsrFile)
-	if err != nil {
-		log.Errorf("ReadFile() failed (error %v)", err)
-		return err
+// splitChain returns the PEM encoded certificates of the chain but the last one, and the last one, which is
+// the root.
+func splitChain(certs []string) (certChain, rootCert []byte, err error) {
+	var chain [][]byte
+	for _, c := range certs {
+		block, rest := pem.Decode([]byte(c))
+		if block == nil || block.Type != "CERTIFICATE" || len(bytes.TrimSpace(rest)) != 0 {
+			return nil, nil, fmt.Errorf("invalid PEM encoded certificate in the sign response")
+		}
+		if _, err := x509.ParseCertificate(block.Bytes); err != nil {
+			return nil, nil, fmt.Errorf("invalid certificate in the sign response: %v", err)
+		}
+		pemCert := pem.EncodeToMemory(block)
+		// The issuing CA may be repeated at the end of the chain.
+		if len(chain) > 0 && bytes.Equal(chain[len(chain)-1], pemCert) {
+			continue
+		}
+		chain = append(chain, pemCert)
 	}
-	res, err := signCsr(client, signCsrPath, string(testCsr))
-	if err != nil {
-		log.Errorf("signCsr() failed (error %v)", err)
-		return err
+	if len(chain) < 2 {
+		return nil, nil, errors.New("the certificate chain in the sign response has no root")
 	}
-	log.Info("The certificate generated from CSR is :")
-	//Print the certificate
-	log.Infof("%v", res.Data["certificate"])
-	return nil
+	return bytes.Join(chain[:len(chain)-1], nil), chain[len(chain)-1], nil
 }
-*/
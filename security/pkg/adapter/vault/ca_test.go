@@ -0,0 +1,315 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vault
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+const (
+	testToken      = "test-token"
+	testLoginToken = "test-login-token"
+	testJWT        = "test-jwt"
+	testRole       = "istio-ca"
+	loginPath      = "auth/kubernetes/login"
+	signPath       = "pki/root/sign-intermediate"
+)
+
+// fakeVault is a local Vault server, with the Kubernetes auth method and the PKI secrets engine.
+type fakeVault struct {
+	server   *httptest.Server
+	rootCert *x509.Certificate
+	rootKey  crypto.PrivateKey
+	rootPem  []byte
+	// noCAChain makes the sign response only have issuing_ca, as in older Vault versions.
+	noCAChain bool
+	// lastRequest is the last sign request.
+	lastRequest map[string]interface{}
+}
+
+func newFakeVault(t *testing.T) *fakeVault {
+	rootPem, rootKeyPem, err := util.GenCertKeyFromOptions(util.CertOptions{
+		TTL:          24 * time.Hour,
+		Org:          "vault.org",
+		IsCA:         true,
+		IsSelfSigned: true,
+		RSAKeySize:   2048,
+	})
+	if err != nil {
+		t.Fatalf("Failed to generate the Vault root: %v", err)
+	}
+	v := &fakeVault{rootPem: rootPem}
+	if v.rootCert, err = util.ParsePemEncodedCertificate(rootPem); err != nil {
+		t.Fatal(err)
+	}
+	if v.rootKey, err = util.ParsePemEncodedKey(rootKeyPem); err != nil {
+		t.Fatal(err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/"+loginPath, v.login)
+	mux.HandleFunc("/v1/"+signPath, v.sign)
+	v.server = httptest.NewServer(mux)
+	return v
+}
+
+func (v *fakeVault) login(w http.ResponseWriter, r *http.Request) {
+	var req map[string]string
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req["jwt"] != testJWT || req["role"] != testRole {
+		writeError(w, http.StatusForbidden, "permission denied")
+		return
+	}
+	writeJSON(w, map[string]interface{}{
+		"auth": map[string]interface{}{"client_token": testLoginToken},
+	})
+}
+
+func (v *fakeVault) sign(w http.ResponseWriter, r *http.Request) {
+	if token := r.Header.Get("X-Vault-Token"); token != testToken && token != testLoginToken {
+		writeError(w, http.StatusForbidden, "permission denied")
+		return
+	}
+	var req map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	v.lastRequest = req
+	csrPEM, _ := req["csr"].(string)
+	csr, err := util.ParsePemEncodedCSR([]byte(csrPEM))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	ttl, err := time.ParseDuration(req["ttl"].(string))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	der, err := util.GenCertFromCSR(csr, v.rootCert, csr.PublicKey, v.rootKey, nil, ttl, true)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	data := map[string]interface{}{
+		"certificate": string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		"issuing_ca":  string(v.rootPem),
+	}
+	if !v.noCAChain {
+		data["ca_chain"] = []string{string(v.rootPem)}
+	}
+	writeJSON(w, map[string]interface{}{"data": data})
+}
+
+func writeJSON(w http.ResponseWriter, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, code int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(map[string][]string{"errors": {msg}})
+}
+
+func TestSignIntermediate(t *testing.T) {
+	v := newFakeVault(t)
+	defer v.server.Close()
+
+	dir, err := ioutil.TempDir("", "vault-ca-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	jwtPath := filepath.Join(dir, "token")
+	if err := ioutil.WriteFile(jwtPath, []byte(testJWT+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	tokenFile := filepath.Join(dir, "vault-token")
+	if err := ioutil.WriteFile(tokenFile, []byte(testToken+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	wrongTokenFile := filepath.Join(dir, "wrong-vault-token")
+	if err := ioutil.WriteFile(wrongTokenFile, []byte("wrong-token"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := map[string]struct {
+		options   Options
+		noCAChain bool
+		expectErr string
+	}{
+		"token": {
+			options: Options{Address: v.server.URL, TokenFile: tokenFile, SignIntermediatePath: signPath},
+		},
+		"kubernetes auth": {
+			options: Options{Address: v.server.URL, KubernetesAuthPath: loginPath, KubernetesAuthRole: testRole,
+				JWTPath: jwtPath, SignIntermediatePath: signPath},
+		},
+		"issuing CA only": {
+			options:   Options{Address: v.server.URL, TokenFile: tokenFile, SignIntermediatePath: signPath},
+			noCAChain: true,
+		},
+		"wrong token": {
+			options:   Options{Address: v.server.URL, TokenFile: wrongTokenFile, SignIntermediatePath: signPath},
+			expectErr: "permission denied",
+		},
+		"missing token": {
+			options: Options{Address: v.server.URL, TokenFile: filepath.Join(dir, "missing"),
+				SignIntermediatePath: signPath},
+			expectErr: "failed to read the Vault token",
+		},
+		"wrong role": {
+			options: Options{Address: v.server.URL, KubernetesAuthPath: loginPath, KubernetesAuthRole: "wrong-role",
+				JWTPath: jwtPath, SignIntermediatePath: signPath},
+			expectErr: "failed to login Vault",
+		},
+		"missing JWT": {
+			options: Options{Address: v.server.URL, KubernetesAuthPath: loginPath, KubernetesAuthRole: testRole,
+				JWTPath: filepath.Join(dir, "missing"), SignIntermediatePath: signPath},
+			expectErr: "failed to read the service account token",
+		},
+		"wrong path": {
+			options:   Options{Address: v.server.URL, TokenFile: tokenFile, SignIntermediatePath: "pki/wrong"},
+			expectErr: "failed to post to pki/wrong",
+		},
+	}
+
+	for id, tc := range testCases {
+		t.Run(id, func(t *testing.T) {
+			v.noCAChain = tc.noCAChain
+			ca, err := NewCA(tc.options)
+			if err != nil {
+				t.Fatalf("Failed to create the Vault CA: %v", err)
+			}
+			csrPEM, keyPEM, err := util.GenCSR(util.CertOptions{Org: "test.org", IsCA: true, RSAKeySize: 2048})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			certChain, rootCert, err := ca.SignIntermediate(csrPEM, 24*time.Hour)
+			if tc.expectErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectErr) {
+					t.Fatalf("Expecting error %q, got %v", tc.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Failed to sign the intermediate CA certificate: %v", err)
+			}
+
+			if string(rootCert) != string(v.rootPem) {
+				t.Errorf("Unexpected root certificate: %s", rootCert)
+			}
+			if err := util.VerifyCertificate(keyPEM, certChain, rootCert, &util.VerifyFields{
+				IsCA:     true,
+				KeyUsage: x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+			}); err != nil {
+				t.Errorf("Failed to verify the intermediate CA certificate: %v", err)
+			}
+			if v.lastRequest["ttl"] != "86400s" || v.lastRequest["common_name"] != defaultCommonName ||
+				v.lastRequest["organization"] != "test.org" {
+				t.Errorf("Unexpected sign request: %v", v.lastRequest)
+			}
+		})
+	}
+}
+
+func TestSignIntermediateRenewedToken(t *testing.T) {
+	v := newFakeVault(t)
+	defer v.server.Close()
+
+	dir, err := ioutil.TempDir("", "vault-ca-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	tokenFile := filepath.Join(dir, "vault-token")
+	if err := ioutil.WriteFile(tokenFile, []byte("expired-token"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	ca, err := NewCA(Options{Address: v.server.URL, TokenFile: tokenFile, SignIntermediatePath: signPath})
+	if err != nil {
+		t.Fatalf("Failed to create the Vault CA: %v", err)
+	}
+	csrPEM, _, err := util.GenCSR(util.CertOptions{Org: "test.org", IsCA: true, RSAKeySize: 2048})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := ca.SignIntermediate(csrPEM, time.Hour); err == nil {
+		t.Fatal("Expecting an error with the expired token")
+	}
+
+	// The renewed token is read from the file at the next signing.
+	if err := ioutil.WriteFile(tokenFile, []byte(testToken), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := ca.SignIntermediate(csrPEM, time.Hour); err != nil {
+		t.Errorf("Failed to sign with the renewed token: %v", err)
+	}
+}
+
+func TestNewCA(t *testing.T) {
+	testCases := map[string]struct {
+		options   Options
+		expectErr string
+	}{
+		"no address": {
+			options:   Options{TokenFile: "token", SignIntermediatePath: signPath},
+			expectErr: "the Vault address must be specified",
+		},
+		"no sign path": {
+			options:   Options{Address: "http://127.0.0.1:8200", TokenFile: "token"},
+			expectErr: "the Vault path signing intermediate certificates must be specified",
+		},
+		"no authentication": {
+			options:   Options{Address: "http://127.0.0.1:8200", KubernetesAuthPath: loginPath, SignIntermediatePath: signPath},
+			expectErr: "either a Vault token or the Kubernetes auth path, role and JWT must be specified",
+		},
+		"invalid TLS root cert": {
+			options: Options{Address: "https://127.0.0.1:8200", TokenFile: "token", SignIntermediatePath: signPath,
+				TLSRootCert: []byte("invalid")},
+			expectErr: "failed to append the Vault TLS root certificate",
+		},
+	}
+
+	for id, tc := range testCases {
+		t.Run(id, func(t *testing.T) {
+			_, err := NewCA(tc.options)
+			if err == nil || !strings.Contains(err.Error(), tc.expectErr) {
+				t.Errorf("Expecting error %q, got %v", tc.expectErr, err)
+			}
+		})
+	}
+}
@@ -33,7 +33,8 @@ type GcpTokenFetcher struct {
 
 func (fetcher *GcpTokenFetcher) getTokenURI() string {
 	// The GCE metadata service URI to get identity token of current (i.e., default) service account.
-	return "instance/service-accounts/default/identity?audience=" + fetcher.Aud
+	// The full format includes the project and the instance, which Citadel maps to a service account.
+	return "instance/service-accounts/default/identity?format=full&audience=" + fetcher.Aud
 }
 
 // FetchToken fetches the GCE VM identity jwt token from its metadata server.
@@ -26,9 +26,17 @@ import (
 const (
 	istioSecurityConfigMapName = "istio-security"
 	caTLSRootCertName          = "caTLSRootCert"
+	// The CRL is stored PEM-encoded rather than base64-encoded, so that the key can be
+	// projected into a volume and consumed by Envoy as-is.
+	caCRLName = "crl.pem"
+	// The instance bindings are kept apart from the CA TLS root cert, since the latter is read by every workload.
+	// A binding takes about 80 bytes and is never removed by Citadel, so the ConfigMap reaches the 1 MiB limit of
+	// the ConfigMaps after about 12000 instances. The bindings of the terminated instances can be deleted by the
+	// operator, as the instance IDs are not reused.
+	instanceBindingsConfigMapName = "istio-instance-bindings"
 )
 
-// Controller manages the CA TLS root cert in ConfigMap.
+// Controller manages the CA TLS root cert, the CRL and the instance bindings in ConfigMap.
 type Controller struct {
 	core      corev1.CoreV1Interface
 	namespace string
@@ -44,34 +52,84 @@ func NewController(namespace string, core corev1.CoreV1Interface) *Controller {
 
 // InsertCATLSRootCert updates the CA TLS root certificate in the configmap.
 func (c *Controller) InsertCATLSRootCert(value string) error {
-	configmap, err := c.core.ConfigMaps(c.namespace).Get(istioSecurityConfigMapName, metav1.GetOptions{})
+	if err := c.insertData(istioSecurityConfigMapName, caTLSRootCertName, value); err != nil {
+		return fmt.Errorf("failed to insert CA TLS root cert: %v", err)
+	}
+	return nil
+}
+
+// InsertCRL updates the PEM-encoded certificate revocation list in the configmap.
+func (c *Controller) InsertCRL(value string) error {
+	if err := c.insertData(istioSecurityConfigMapName, caCRLName, value); err != nil {
+		return fmt.Errorf("failed to insert CRL: %v", err)
+	}
+	return nil
+}
+
+// BindInstance binds the VM instance to the hash of the nonce presented on its first authentication, unless it
+// is already bound, and returns the binding of the instance. The binding is only inserted if the configmap has
+// not changed since it was read, so that concurrent Citadel replicas can not bind the same instance twice: the
+// losing request fails on the conflict instead.
+func (c *Controller) BindInstance(instance, binding string) (string, error) {
+	configmap, err := c.core.ConfigMaps(c.namespace).Get(instanceBindingsConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return "", fmt.Errorf("failed to get the binding of instance %s: %v", instance, err)
+		}
+		configmap = &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      instanceBindingsConfigMapName,
+				Namespace: c.namespace,
+			},
+			Data: map[string]string{instance: binding},
+		}
+		if _, err = c.core.ConfigMaps(c.namespace).Create(configmap); err != nil {
+			return "", fmt.Errorf("failed to insert the binding of instance %s: %v", instance, err)
+		}
+		return binding, nil
+	}
+	if bound, ok := configmap.Data[instance]; ok {
+		return bound, nil
+	}
+	if configmap.Data == nil {
+		configmap.Data = map[string]string{}
+	}
+	configmap.Data[instance] = binding
+	// The configmap carries the resource version it was read at, so the update fails if it changed meanwhile.
+	if _, err = c.core.ConfigMaps(c.namespace).Update(configmap); err != nil {
+		return "", fmt.Errorf("failed to insert the binding of instance %s: %v", instance, err)
+	}
+	return binding, nil
+}
+
+func (c *Controller) insertData(name, key, value string) error {
+	configmap, err := c.core.ConfigMaps(c.namespace).Get(name, metav1.GetOptions{})
 	exists := true
 	if err != nil {
 		if errors.IsNotFound(err) {
 			// Create a new ConfigMap.
 			configmap = &v1.ConfigMap{
 				ObjectMeta: metav1.ObjectMeta{
-					Name:      istioSecurityConfigMapName,
+					Name:      name,
 					Namespace: c.namespace,
 				},
 				Data: map[string]string{},
 			}
 			exists = false
 		} else {
-			return fmt.Errorf("failed to insert CA TLS root cert: %v", err)
+			return err
 		}
 	}
-	configmap.Data[caTLSRootCertName] = value
+	if configmap.Data == nil {
+		configmap.Data = map[string]string{}
+	}
+	configmap.Data[key] = value
 	if exists {
-		if _, err = c.core.ConfigMaps(c.namespace).Update(configmap); err != nil {
-			return fmt.Errorf("failed to insert CA TLS root cert: %v", err)
-		}
+		_, err = c.core.ConfigMaps(c.namespace).Update(configmap)
 	} else {
-		if _, err = c.core.ConfigMaps(c.namespace).Create(configmap); err != nil {
-			return fmt.Errorf("failed to insert CA TLS root cert: %v", err)
-		}
+		_, err = c.core.ConfigMaps(c.namespace).Create(configmap)
 	}
-	return nil
+	return err
 }
 
 // GetCATLSRootCert gets the CA TLS root certificate from the configmap.
@@ -88,3 +146,16 @@ func (c *Controller) GetCATLSRootCert() (string, error) {
 
 	return rootCert, nil
 }
+
+// GetCRL gets the PEM-encoded certificate revocation list from the configmap. An empty string is
+// returned if no CRL has been published yet.
+func (c *Controller) GetCRL() (string, error) {
+	configmap, err := c.core.ConfigMaps(c.namespace).Get(istioSecurityConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to get CRL: %v", err)
+	}
+	return configmap.Data[caCRLName], nil
+}
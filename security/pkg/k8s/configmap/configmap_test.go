@@ -19,7 +19,9 @@ import (
 	"testing"
 
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/kubernetes/fake"
 	ktesting "k8s.io/client-go/testing"
@@ -178,6 +180,77 @@ func TestGetCATLSRootCert(t *testing.T) {
 	}
 }
 
+func TestInsertAndGetCRL(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	controller := NewController("test-ns", client.CoreV1())
+
+	crl, err := controller.GetCRL()
+	if err != nil || crl != "" {
+		t.Fatalf("expected no CRL before publishing, got %q (error %v)", crl, err)
+	}
+
+	if err := controller.InsertCATLSRootCert("ROOT_CERT"); err != nil {
+		t.Fatalf("failed to insert root cert: %v", err)
+	}
+	if err := controller.InsertCRL("TEST_CRL"); err != nil {
+		t.Fatalf("failed to insert CRL: %v", err)
+	}
+
+	if crl, err = controller.GetCRL(); err != nil || crl != "TEST_CRL" {
+		t.Errorf("unexpected CRL %q (error %v), expected TEST_CRL", crl, err)
+	}
+	// The CRL lives beside the root cert in the same configmap.
+	if cert, err := controller.GetCATLSRootCert(); err != nil || cert != "ROOT_CERT" {
+		t.Errorf("unexpected root cert %q (error %v), expected ROOT_CERT", cert, err)
+	}
+}
+
+func TestBindInstance(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	controller := NewController("test-ns", client.CoreV1())
+
+	binding, err := controller.BindInstance("aws.i-1234567890abcdef0", "NONCE_HASH")
+	if err != nil || binding != "NONCE_HASH" {
+		t.Fatalf("unexpected binding %q (error %v) on the first use, expected NONCE_HASH", binding, err)
+	}
+	if err := controller.InsertCATLSRootCert("ROOT_CERT"); err != nil {
+		t.Fatalf("failed to insert root cert: %v", err)
+	}
+	// The first binding is kept.
+	binding, err = controller.BindInstance("aws.i-1234567890abcdef0", "OTHER_HASH")
+	if err != nil || binding != "NONCE_HASH" {
+		t.Errorf("unexpected binding %q (error %v), expected NONCE_HASH", binding, err)
+	}
+	binding, err = controller.BindInstance("aws.i-0000000000000000", "OTHER_HASH")
+	if err != nil || binding != "OTHER_HASH" {
+		t.Errorf("unexpected binding %q (error %v) of a new instance, expected OTHER_HASH", binding, err)
+	}
+	// The bindings are not published with the root cert.
+	configmap, err := client.CoreV1().ConfigMaps("test-ns").Get(istioSecurityConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := configmap.Data["aws.i-1234567890abcdef0"]; ok {
+		t.Errorf("the instance binding is stored in the %s configmap", istioSecurityConfigMapName)
+	}
+}
+
+func TestBindInstanceConflict(t *testing.T) {
+	gr := schema.GroupResource{Resource: "configmaps"}
+	bindings := createConfigMap("test-ns", map[string]string{})
+	bindings.Name = instanceBindingsConfigMapName
+	client := fake.NewSimpleClientset(bindings)
+	controller := NewController("test-ns", client.CoreV1())
+	// Another replica updated the configmap since it was read.
+	client.PrependReactor("update", "configmaps", func(ktesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.NewConflict(gr, instanceBindingsConfigMapName, fmt.Errorf("modified"))
+	})
+
+	if binding, err := controller.BindInstance("aws.i-1234567890abcdef0", "NONCE_HASH"); err == nil {
+		t.Errorf("expected the conflicting binding to fail, got %q", binding)
+	}
+}
+
 func createConfigMap(namespace string, data map[string]string) *v1.ConfigMap {
 	return &v1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
@@ -0,0 +1,148 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+)
+
+// federatedAnchorOrganization is the organization of the generated trust anchor of the federated trust domains.
+const federatedAnchorOrganization = "Istio federated trust domains"
+
+// constrainFederatedTrustBundles returns the PEM encoded roots accepting the identities of the federated trust
+// domains, and only them. Envoy cannot tell which root verified a peer, so the roots of a foreign CA would let it
+// issue certificates for the identities of any trust domain. Instead, each root of a trust domain is cross-signed
+// by a generated trust anchor, with a name constraint only permitting the SPIFFE IDs of the trust domain: the peer
+// certificates chain to the anchor through the cross certificate, and the TLS stack rejects the other SPIFFE IDs.
+// The anchor key is only used here and is not kept.
+func constrainFederatedTrustBundles(bundles map[string][]byte) ([]byte, error) {
+	if len(bundles) == 0 {
+		return nil, nil
+	}
+
+	trustDomains := make([]string, 0, len(bundles))
+	for trustDomain := range bundles {
+		trustDomains = append(trustDomains, trustDomain)
+	}
+	sort.Strings(trustDomains)
+
+	roots := make(map[string][]*x509.Certificate, len(bundles))
+	var notBefore, notAfter time.Time
+	for _, trustDomain := range trustDomains {
+		certs, err := parsePemCerts(bundles[trustDomain])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the trust bundle of trust domain %q: %v", trustDomain, err)
+		}
+		for _, cert := range certs {
+			if notBefore.IsZero() || cert.NotBefore.Before(notBefore) {
+				notBefore = cert.NotBefore
+			}
+			if cert.NotAfter.After(notAfter) {
+				notAfter = cert.NotAfter
+			}
+		}
+		roots[trustDomain] = certs
+	}
+
+	anchorKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate the federated trust anchor key: %v", err)
+	}
+	serial, err := randomSerialNumber()
+	if err != nil {
+		return nil, err
+	}
+	anchorTemplate := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{Organization: []string{federatedAnchorOrganization}},
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	anchorDER, err := x509.CreateCertificate(rand.Reader, anchorTemplate, anchorTemplate, &anchorKey.PublicKey, anchorKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the federated trust anchor: %v", err)
+	}
+	anchor, err := x509.ParseCertificate(anchorDER)
+	if err != nil {
+		return nil, err
+	}
+
+	out := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: anchorDER})
+	for _, trustDomain := range trustDomains {
+		for _, root := range roots[trustDomain] {
+			if serial, err = randomSerialNumber(); err != nil {
+				return nil, err
+			}
+			crossTemplate := &x509.Certificate{
+				SerialNumber: serial,
+				// The subject and key of the root, so that the certificates it signed chain to the cross certificate.
+				RawSubject:                  root.RawSubject,
+				SubjectKeyId:                root.SubjectKeyId,
+				NotBefore:                   root.NotBefore,
+				NotAfter:                    root.NotAfter,
+				KeyUsage:                    x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+				BasicConstraintsValid:       true,
+				IsCA:                        true,
+				PermittedURIDomains:         []string{trustDomain},
+				PermittedDNSDomainsCritical: true,
+			}
+			crossDER, err := x509.CreateCertificate(rand.Reader, crossTemplate, anchor, root.PublicKey, anchorKey)
+			if err != nil {
+				return nil, fmt.Errorf("failed to cross-sign a root of trust domain %q: %v", trustDomain, err)
+			}
+			out = append(out, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: crossDER})...)
+		}
+	}
+	return out, nil
+}
+
+// parsePemCerts parses the PEM encoded certificates, it fails if there is none.
+func parsePemCerts(certs []byte) ([]*x509.Certificate, error) {
+	var out []*x509.Certificate
+	for block, rest := pem.Decode(certs); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, cert)
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("no certificate found")
+	}
+	return out, nil
+}
+
+func randomSerialNumber() (*big.Int, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate a serial number: %v", err)
+	}
+	return serial, nil
+}
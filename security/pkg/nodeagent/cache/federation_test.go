@@ -0,0 +1,98 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"crypto/x509"
+	"strings"
+	"testing"
+	"time"
+
+	pkiutil "istio.io/istio/security/pkg/pki/util"
+)
+
+func TestConstrainFederatedTrustBundles(t *testing.T) {
+	partnerRootPem, partnerKeyPem, err := pkiutil.GenCertKeyFromOptions(pkiutil.CertOptions{
+		Host:         "partner.org",
+		TTL:          time.Hour,
+		Org:          "Partner",
+		IsCA:         true,
+		IsSelfSigned: true,
+		RSAKeySize:   2048,
+	})
+	if err != nil {
+		t.Fatalf("failed to generate the partner root: %v", err)
+	}
+	partnerRoot, err := pkiutil.ParsePemEncodedCertificate(partnerRootPem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	partnerKey, err := pkiutil.ParsePemEncodedKey(partnerKeyPem)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	roots, err := constrainFederatedTrustBundles(map[string][]byte{"partner.org": partnerRootPem})
+	if err != nil {
+		t.Fatalf("failed to constrain the trust bundles: %v", err)
+	}
+	// Envoy trusts all the certificates of the bundle.
+	certs, err := parsePemCerts(roots)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := x509.NewCertPool()
+	for _, cert := range certs {
+		if cert.Equal(partnerRoot) {
+			t.Fatal("the partner root should not be trusted as is")
+		}
+		pool.AddCert(cert)
+	}
+
+	cases := []struct {
+		name  string
+		id    string
+		valid bool
+	}{
+		{"partner identity", "spiffe://partner.org/ns/foo/sa/bar", true},
+		{"local identity signed by the partner", "spiffe://cluster.local/ns/foo/sa/bar", false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			leafPem, _, err := pkiutil.GenCertKeyFromOptions(pkiutil.CertOptions{
+				Host:       c.id,
+				TTL:        time.Hour,
+				SignerCert: partnerRoot,
+				SignerPriv: partnerKey,
+				IsClient:   true,
+				RSAKeySize: 2048,
+			})
+			if err != nil {
+				t.Fatalf("failed to generate the leaf: %v", err)
+			}
+			leaf, err := pkiutil.ParsePemEncodedCertificate(leafPem)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = leaf.Verify(x509.VerifyOptions{Roots: pool, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny}})
+			if c.valid && err != nil {
+				t.Errorf("expected %s to be accepted: %v", c.id, err)
+			}
+			if !c.valid && (err == nil || !strings.Contains(err.Error(), "constraint")) {
+				t.Errorf("expected %s to be rejected by the name constraint, got %v", c.id, err)
+			}
+		})
+	}
+}
@@ -21,6 +21,8 @@ import (
 	"strings"
 
 	"google.golang.org/grpc/codes"
+
+	"istio.io/istio/pkg/spiffe"
 )
 
 func constructCSRHostName(trustDomain, token string) (string, error) {
@@ -52,7 +54,7 @@ func constructCSRHostName(trustDomain, token string) (string, error) {
 	ns := ss[2] //namespace
 	sa := ss[3] //service account
 
-	domain := "cluster.local"
+	domain := defaultTrustDomain
 	if trustDomain != "" {
 		domain = trustDomain
 	}
@@ -60,6 +62,22 @@ func constructCSRHostName(trustDomain, token string) (string, error) {
 	return fmt.Sprintf(identityTemplate, domain, ns, sa), nil
 }
 
+// isRootCertResource returns true if the SDS resource is root certs rather than a key/cert pair: the root cert,
+// the root cert along with the federated roots, or the roots of a trust domain ("spiffe://<trust domain>").
+func isRootCertResource(resourceName string) bool {
+	return resourceName == RootCertReqResourceName || resourceName == FederatedRootCertReqResourceName ||
+		strings.HasPrefix(resourceName, spiffe.URIPrefix)
+}
+
+// appendPem appends PEM encoded certificates, making sure that they are separated by a new line.
+func appendPem(certs []byte, more []byte) []byte {
+	result := append([]byte{}, certs...)
+	if len(result) > 0 && result[len(result)-1] != '\n' {
+		result = append(result, '\n')
+	}
+	return append(result, more...)
+}
+
 // isRetryableErr checks if a failed request should be retry based on gRPC resp code or http status code.
 func isRetryableErr(c codes.Code, httpRespCode int, isGrpc bool) bool {
 	if isGrpc {
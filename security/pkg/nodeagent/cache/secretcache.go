@@ -27,6 +27,7 @@ import (
 	"time"
 
 	"istio.io/istio/pkg/mcp/status"
+	"istio.io/istio/pkg/spiffe"
 	"istio.io/istio/security/pkg/nodeagent/model"
 	"istio.io/istio/security/pkg/nodeagent/plugin"
 	"istio.io/istio/security/pkg/nodeagent/secretfetcher"
@@ -52,6 +53,10 @@ const (
 	// RootCertReqResourceName is resource name of discovery request for root certificate.
 	RootCertReqResourceName = "ROOTCA"
 
+	// FederatedRootCertReqResourceName is resource name of discovery request for the root certificate along
+	// with the root certificates of the federated trust domains.
+	FederatedRootCertReqResourceName = "FEDERATED_ROOTCA"
+
 	// WorkloadKeyCertResourceName is the resource name of the discovery request for workload
 	// identity.
 	// TODO: change all the pilot one reference definition here instead.
@@ -60,6 +65,9 @@ const (
 	// identityTemplate is the format template of identity in the CSR request.
 	identityTemplate = "spiffe://%s/ns/%s/sa/%s"
 
+	// defaultTrustDomain is the trust domain of the identities when none is configured.
+	defaultTrustDomain = "cluster.local"
+
 	// For REST APIs between envoy->nodeagent, default value of 1s is used.
 	envoyDefaultTimeoutInMilliSec = 1000
 
@@ -105,6 +113,15 @@ type Options struct {
 
 	// set this flag to true if skip validate format for certificate chain returned from CA.
 	SkipValidateCert bool
+
+	// The EC signature algorithm of the generated workload keys, e.g. "ECDSA".
+	// If empty, RSA keys are generated.
+	ECCSigAlg string
+
+	// FederatedTrustBundles are the PEM encoded root certificates of the foreign trust domains, keyed by
+	// trust domain. They are served for the "spiffe://<trust domain>" resource names, and along with the
+	// root certificate for the FEDERATED_ROOTCA resource name.
+	FederatedTrustBundles map[string][]byte
 }
 
 // SecretManager defines secrets management interface which is used by SDS.
@@ -164,6 +181,11 @@ type SecretCache struct {
 	rootCertMutex      *sync.Mutex
 	rootCert           []byte
 	rootCertExpireTime time.Time
+
+	// The name constrained roots of the federated trust domains, generated once.
+	federatedRootsOnce sync.Once
+	federatedRoots     []byte
+	federatedRootsErr  error
 }
 
 // NewSecretCache creates a new secret cache.
@@ -198,7 +220,7 @@ func (sc *SecretCache) GenerateSecret(ctx context.Context, connectionID, resourc
 	}
 
 	conIDresourceNamePrefix := cacheLogPrefix(connectionID, resourceName)
-	if resourceName != RootCertReqResourceName {
+	if !isRootCertResource(resourceName) {
 		// If working as Citadel agent, send request for normal key/cert pair.
 		// If working as ingress gateway agent, fetch key/cert or root cert from SecretFetcher. Resource name for
 		// root cert ends with "-cacert".
@@ -215,7 +237,8 @@ func (sc *SecretCache) GenerateSecret(ctx context.Context, connectionID, resourc
 
 	// If request is for root certificate,
 	// retry since rootCert may be empty until there is CSR response returned from CA.
-	if sc.rootCert == nil {
+	usesRootCert := sc.usesRootCert(resourceName)
+	if sc.rootCert == nil && usesRootCert {
 		wait := retryWaitDuration
 		retryNum := 0
 		for ; retryNum < maxRetryNum; retryNum++ {
@@ -228,17 +251,23 @@ func (sc *SecretCache) GenerateSecret(ctx context.Context, connectionID, resourc
 		}
 	}
 
-	if sc.rootCert == nil {
+	if sc.rootCert == nil && usesRootCert {
 		cacheLog.Errorf("%s failed to get root cert for proxy", conIDresourceNamePrefix)
 		return nil, errors.New("failed to get root cert")
 
 	}
 
+	rootCert, expireTime, err := sc.rootCertForResource(resourceName)
+	if err != nil {
+		cacheLog.Errorf("%s failed to get root cert for proxy: %v", conIDresourceNamePrefix, err)
+		return nil, err
+	}
+
 	t := time.Now()
 	ns = &model.SecretItem{
 		ResourceName: resourceName,
-		RootCert:     sc.rootCert,
-		ExpireTime:   sc.rootCertExpireTime,
+		RootCert:     rootCert,
+		ExpireTime:   expireTime,
 		Token:        token,
 		CreatedTime:  t,
 		Version:      t.String(),
@@ -452,7 +481,12 @@ func (sc *SecretCache) rotate(updateRootFlag bool) {
 
 		// only refresh root cert if updateRootFlag is set to true.
 		if updateRootFlag {
-			if connKey.ResourceName != RootCertReqResourceName {
+			if !isRootCertResource(connKey.ResourceName) || !sc.usesRootCert(connKey.ResourceName) {
+				return true
+			}
+			rootCert, expireTime, err := sc.rootCertForResource(connKey.ResourceName)
+			if err != nil {
+				cacheLog.Errorf("%s failed to rotate root cert: %v", conIDresourceNamePrefix, err)
 				return true
 			}
 
@@ -460,8 +494,8 @@ func (sc *SecretCache) rotate(updateRootFlag bool) {
 			t := time.Now()
 			ns := &model.SecretItem{
 				ResourceName: connKey.ResourceName,
-				RootCert:     sc.rootCert,
-				ExpireTime:   sc.rootCertExpireTime,
+				RootCert:     rootCert,
+				ExpireTime:   expireTime,
 				Token:        e.Token,
 				CreatedTime:  t,
 				Version:      t.String(),
@@ -474,7 +508,7 @@ func (sc *SecretCache) rotate(updateRootFlag bool) {
 		}
 
 		// If updateRootFlag isn't set, return directly if cached item is root cert.
-		if connKey.ResourceName == RootCertReqResourceName {
+		if isRootCertResource(connKey.ResourceName) {
 			return true
 		}
 
@@ -589,6 +623,7 @@ func (sc *SecretCache) generateSecret(ctx context.Context, token string, connKey
 	options := util.CertOptions{
 		Host:       csrHostName,
 		RSAKeySize: keySize,
+		ECSigAlg:   util.SupportedECSignatureAlgorithms(sc.configOptions.ECCSigAlg),
 	}
 
 	// Generate the cert/key, send CSR to CA.
@@ -660,6 +695,55 @@ func (sc *SecretCache) generateSecret(ctx context.Context, token string, connKey
 	}, nil
 }
 
+// usesRootCert returns true if the root cert resource includes the root cert returned by the CA, i.e. unless it is
+// the resource of a federated trust domain.
+func (sc *SecretCache) usesRootCert(resourceName string) bool {
+	if !strings.HasPrefix(resourceName, spiffe.URIPrefix) {
+		return true
+	}
+	return strings.TrimPrefix(resourceName, spiffe.URIPrefix) == sc.trustDomain()
+}
+
+// rootCertForResource returns the root certs served for the root cert resource, and their expire time.
+func (sc *SecretCache) rootCertForResource(resourceName string) ([]byte, time.Time, error) {
+	switch {
+	case resourceName == RootCertReqResourceName:
+		return sc.rootCert, sc.rootCertExpireTime, nil
+	case resourceName == FederatedRootCertReqResourceName:
+		// The root cert first, then the roots of the federated trust domains, constrained to their own
+		// identities since the peers of all the trust domains are validated together.
+		sc.federatedRootsOnce.Do(func() {
+			sc.federatedRoots, sc.federatedRootsErr = constrainFederatedTrustBundles(sc.configOptions.FederatedTrustBundles)
+		})
+		if sc.federatedRootsErr != nil {
+			return nil, time.Time{}, sc.federatedRootsErr
+		}
+		return appendPem(sc.rootCert, sc.federatedRoots), sc.rootCertExpireTime, nil
+	}
+
+	trustDomain := strings.TrimPrefix(resourceName, spiffe.URIPrefix)
+	if trustDomain == sc.trustDomain() {
+		return sc.rootCert, sc.rootCertExpireTime, nil
+	}
+	bundle, found := sc.configOptions.FederatedTrustBundles[trustDomain]
+	if !found {
+		return nil, time.Time{}, fmt.Errorf("no trust bundle for trust domain %q", trustDomain)
+	}
+	expireTime, err := nodeagentutil.ParseCertAndGetExpiryTimestamp(bundle)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to parse the trust bundle of trust domain %q: %v", trustDomain, err)
+	}
+	return bundle, expireTime, nil
+}
+
+// trustDomain returns the trust domain of the certificates signed by the CA.
+func (sc *SecretCache) trustDomain() string {
+	if sc.configOptions.TrustDomain != "" {
+		return sc.configOptions.TrustDomain
+	}
+	return defaultTrustDomain
+}
+
 func (sc *SecretCache) shouldRefresh(s *model.SecretItem) bool {
 	// secret should be refreshed before it expired, SecretRefreshGraceDuration is the grace period;
 	return time.Now().After(s.ExpireTime.Add(-sc.configOptions.SecretRefreshGraceDuration))
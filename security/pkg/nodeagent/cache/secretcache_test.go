@@ -295,6 +295,71 @@ func testWorkloadAgentGenerateSecret(t *testing.T, isUsingPluginProvider bool) {
 	}
 }
 
+func TestWorkloadAgentGenerateFederatedRootCert(t *testing.T) {
+	fakeCACli := mock.NewMockCAClient(mockCertChain1st, mockCertChainRemain)
+	opt := Options{
+		SecretTTL:        time.Minute,
+		RotationInterval: 300 * time.Microsecond,
+		EvictionDuration: 2 * time.Second,
+		InitialBackoff:   10,
+		SkipValidateCert: true,
+		TrustDomain:      "cluster.local",
+		FederatedTrustBundles: map[string][]byte{
+			"partner.org": k8sCaCert,
+		},
+	}
+	fetcher := &secretfetcher.SecretFetcher{
+		UseCaClient: true,
+		CaClient:    fakeCACli,
+	}
+	sc := NewSecretCache(fetcher, notifyCb, opt)
+	defer sc.Close()
+
+	conID := "proxy1-id"
+	ctx := context.Background()
+
+	// The roots of a federated trust domain do not depend on the CA.
+	gotSecret, err := sc.GenerateSecret(ctx, conID, "spiffe://partner.org", "jwtToken1")
+	if err != nil {
+		t.Fatalf("Failed to get secrets: %v", err)
+	}
+	if got, want := gotSecret.RootCert, k8sCaCert; !bytes.Equal(got, want) {
+		t.Errorf("RootCert: got: %s, want: %s", got, want)
+	}
+	if got, want := gotSecret.ExpireTime, k8sCaCertExpireTime; !got.Equal(want) {
+		t.Errorf("ExpireTime: got: %v, want: %v", got, want)
+	}
+
+	if _, err := sc.GenerateSecret(ctx, conID, "spiffe://unknown.org", "jwtToken1"); err == nil {
+		t.Error("Expected an error for a trust domain without trust bundle")
+	}
+
+	if _, err := sc.GenerateSecret(ctx, conID, testResourceName, "jwtToken1"); err != nil {
+		t.Fatalf("Failed to get secrets: %v", err)
+	}
+
+	gotSecret, err = sc.GenerateSecret(ctx, conID, "spiffe://cluster.local", "jwtToken1")
+	if err != nil {
+		t.Fatalf("Failed to get secrets: %v", err)
+	}
+	if got, want := gotSecret.RootCert, []byte("rootcert"); !bytes.Equal(got, want) {
+		t.Errorf("RootCert: got: %s, want: %s", got, want)
+	}
+
+	gotSecret, err = sc.GenerateSecret(ctx, conID, FederatedRootCertReqResourceName, "jwtToken1")
+	if err != nil {
+		t.Fatalf("Failed to get secrets: %v", err)
+	}
+	// The federated roots are constrained to their trust domain instead of being trusted as is.
+	if got := gotSecret.RootCert; !bytes.HasPrefix(got, []byte("rootcert\n")) || bytes.Contains(got, k8sCaCert) {
+		t.Errorf("RootCert: got: %s, want the root cert and the constrained federated roots", got)
+	}
+	if certs, err := parsePemCerts(gotSecret.RootCert); err != nil || len(certs) != 2 {
+		t.Errorf("RootCert: got %d certs (error %v), want the federated trust anchor and a cross certificate", len(certs), err)
+	}
+	checkBool(t, "SecretExist", sc.SecretExist(conID, FederatedRootCertReqResourceName, "jwtToken1", gotSecret.Version), true)
+}
+
 func TestWorkloadAgentRefreshSecret(t *testing.T) {
 	fakeCACli := mock.NewMockCAClient(mockCertChain1st, mockCertChainRemain)
 	opt := Options{
@@ -63,6 +63,10 @@ type Options struct {
 	// https://github.com/spiffe/spiffe/blob/master/standards/SPIFFE-ID.md#21-trust-domain
 	TrustDomain string
 
+	// FederatedTrustBundles is the comma separated list of the foreign trust domains with the files of
+	// their root certificates, e.g. "partner.org=/etc/certs/partner/root-cert.pem".
+	FederatedTrustBundles string
+
 	// PluginNames is plugins' name for certain authentication provider.
 	PluginNames []string
 
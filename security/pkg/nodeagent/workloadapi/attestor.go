@@ -0,0 +1,100 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workloadapi
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Attestor returns the credential token of the workload running the process with the given credentials. The
+// SecretCache sends the token to the CA, which issues the certificates of the identity the token belongs to.
+type Attestor interface {
+	Attest(creds PeerCredentials) (string, error)
+}
+
+// procTokenAttestor reads the token from the file system of the caller, through /proc/<pid>/root. The PID is
+// provided by the kernel, so that a process can only get the identity of the workload it runs in. As the caller
+// may exit and its PID be reused by another process meanwhile, the /proc/<pid> directory is opened before
+// reading the token, and the attestation is refused if the start time of the process changed.
+type procTokenAttestor struct {
+	procRoot  string
+	tokenPath string
+}
+
+// NewProcTokenAttestor returns an Attestor reading the token at tokenPath in the file system of the caller,
+// e.g. the Kubernetes service account token of its pod. The node agent must share the PID namespace of the
+// callers, e.g. with hostPID.
+func NewProcTokenAttestor(tokenPath string) Attestor {
+	return &procTokenAttestor{
+		procRoot:  "/proc",
+		tokenPath: tokenPath,
+	}
+}
+
+func (a *procTokenAttestor) Attest(creds PeerCredentials) (string, error) {
+	if creds.PID <= 0 {
+		return "", fmt.Errorf("invalid PID %d", creds.PID)
+	}
+	dir, err := os.Open(filepath.Join(a.procRoot, strconv.Itoa(int(creds.PID))))
+	if err != nil {
+		return "", fmt.Errorf("failed to open the process %d: %v", creds.PID, err)
+	}
+	defer dir.Close()
+	// The files are resolved through the descriptor of the directory, which keeps referring to the same
+	// process whatever happens to its PID.
+	pinned := filepath.Join("/proc/self/fd", strconv.Itoa(int(dir.Fd())))
+
+	startTime, err := processStartTime(pinned)
+	if err != nil {
+		return "", fmt.Errorf("failed to get the start time of process %d: %v", creds.PID, err)
+	}
+	token, err := ioutil.ReadFile(filepath.Join(pinned, "root", a.tokenPath))
+	if err != nil {
+		return "", fmt.Errorf("failed to read the token of process %d: %v", creds.PID, err)
+	}
+	if t, err := processStartTime(pinned); err != nil || t != startTime {
+		return "", fmt.Errorf("process %d was replaced while reading its token", creds.PID)
+	}
+	if t := strings.TrimSpace(string(token)); t != "" {
+		return t, nil
+	}
+	return "", fmt.Errorf("empty token for process %d", creds.PID)
+}
+
+// processStartTime returns the start time of the process, in clock ticks after the boot, from the stat file of
+// its /proc directory.
+func processStartTime(dir string) (uint64, error) {
+	stat, err := ioutil.ReadFile(filepath.Join(dir, "stat"))
+	if err != nil {
+		return 0, err
+	}
+	// The command name, in the second field, may contain spaces and parentheses: the fields are counted
+	// after its closing parenthesis, which is followed by the third field.
+	end := strings.LastIndexByte(string(stat), ')')
+	if end < 0 {
+		return 0, fmt.Errorf("malformed stat %q", stat)
+	}
+	fields := strings.Fields(string(stat[end+1:]))
+	// The start time is the 22nd field.
+	if len(fields) < 22-2 {
+		return 0, fmt.Errorf("malformed stat %q", stat)
+	}
+	return strconv.ParseUint(fields[22-3], 10, 64)
+}
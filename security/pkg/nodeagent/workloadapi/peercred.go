@@ -0,0 +1,81 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workloadapi
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+// PeerCredentials are the credentials of the process at the other end of a Unix domain socket connection,
+// as provided by the kernel.
+type PeerCredentials struct {
+	PID int32
+	UID uint32
+	GID uint32
+}
+
+// AuthType implements credentials.AuthInfo.
+func (PeerCredentials) AuthType() string {
+	return "peercred"
+}
+
+// peerCredentialsTransport is a gRPC transport credentials which attaches the PeerCredentials of the caller to
+// the connections accepted on a Unix domain socket.
+type peerCredentialsTransport struct{}
+
+var _ credentials.TransportCredentials = peerCredentialsTransport{}
+
+func (peerCredentialsTransport) ClientHandshake(context.Context, string, net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	return nil, nil, errors.New("peer credentials are not supported by clients")
+}
+
+func (peerCredentialsTransport) ServerHandshake(conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	creds, err := getPeerCredentials(conn)
+	if err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	return conn, creds, nil
+}
+
+func (peerCredentialsTransport) Info() credentials.ProtocolInfo {
+	return credentials.ProtocolInfo{SecurityProtocol: "peercred"}
+}
+
+func (peerCredentialsTransport) Clone() credentials.TransportCredentials {
+	return peerCredentialsTransport{}
+}
+
+func (peerCredentialsTransport) OverrideServerName(string) error {
+	return nil
+}
+
+// peerCredentialsFromContext returns the PeerCredentials of the caller of a gRPC method.
+func peerCredentialsFromContext(ctx context.Context) (PeerCredentials, error) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return PeerCredentials{}, errors.New("no peer in the context")
+	}
+	creds, ok := p.AuthInfo.(PeerCredentials)
+	if !ok {
+		return PeerCredentials{}, errors.New("no peer credentials in the context")
+	}
+	return creds, nil
+}
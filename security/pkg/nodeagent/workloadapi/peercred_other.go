@@ -0,0 +1,27 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !linux
+
+package workloadapi
+
+import (
+	"errors"
+	"net"
+)
+
+// getPeerCredentials returns the credentials of the process at the other end of a Unix domain socket connection.
+func getPeerCredentials(net.Conn) (PeerCredentials, error) {
+	return PeerCredentials{}, errors.New("peer credentials are only supported on Linux")
+}
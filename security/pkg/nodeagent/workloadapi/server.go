@@ -0,0 +1,478 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workloadapi
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	jose "gopkg.in/square/go-jose.v2"
+
+	"istio.io/istio/pkg/spiffe"
+	"istio.io/istio/security/pkg/nodeagent/cache"
+	"istio.io/istio/security/pkg/nodeagent/model"
+	workloadpb "istio.io/istio/security/proto/spiffe"
+	"istio.io/pkg/log"
+)
+
+const (
+	// connectionIDPrefix is the prefix of the SecretCache connection IDs of the Workload API callers.
+	connectionIDPrefix = "workloadapi-"
+
+	// securityHeader is the gRPC metadata which must be set by the Workload API clients, so that the API can't
+	// be called through a server-side request forgery.
+	securityHeader = "workload.spiffe.io"
+
+	// defaultJWTSVIDTTL is the default lifetime of the JWT-SVIDs.
+	defaultJWTSVIDTTL = 5 * time.Minute
+)
+
+var (
+	workloadAPILog = log.RegisterScope("workloadapi", "SPIFFE Workload API debugging", 0)
+
+	connectionNumber = int64(0)
+
+	// streams are the open streams of the Workload API, by SecretCache connection ID.
+	streams      = map[string]*workloadStream{}
+	streamsMutex sync.Mutex
+)
+
+// Options provides all of the configuration parameters for the Workload API server.
+type Options struct {
+	// UDSPath is the Unix domain socket the Workload API listens on.
+	UDSPath string
+
+	// TokenPath is the path of the credential token in the file system of the callers, e.g. the Kubernetes
+	// service account token.
+	TokenPath string
+
+	// TrustDomain is the trust domain of the workloads. It defaults to the trust domain of the mesh.
+	TrustDomain string
+
+	// FederatedTrustDomains are the foreign trust domains trusted by the workloads. Their roots are returned
+	// with the X.509-SVIDs.
+	FederatedTrustDomains []string
+
+	// JWTAuthorityKeyFile is the PEM encoded private key signing the JWT-SVIDs of the trust domain. It is shared
+	// by the node agents of the trust domain, so that they validate the JWT-SVIDs of each other. JWT-SVIDs are
+	// not issued without it.
+	JWTAuthorityKeyFile string
+
+	// JWTBundleFiles are the files of the JWK sets of the JWT authorities, keyed by trust domain: the federated
+	// trust domains whose JWT-SVIDs are validated, and the previous keys of the trust domain during a rotation.
+	JWTBundleFiles map[string]string
+
+	// JWTSVIDTTL is the lifetime of the JWT-SVIDs. It defaults to 5 minutes.
+	JWTSVIDTTL time.Duration
+}
+
+// Server is the SPIFFE Workload API server. It issues the X.509-SVIDs and JWT-SVIDs of the workloads calling
+// it on a Unix domain socket, with the identity attested from their peer credentials.
+type Server struct {
+	options       Options
+	secretManager cache.SecretManager
+	attestor      Attestor
+	grpcServer    *grpc.Server
+	listener      net.Listener
+
+	// jwtAuthority signs the JWT-SVIDs, it is nil if they are not issued.
+	jwtAuthority *jwtAuthority
+	// jwtBundles are the JWT authorities keyed by trust domain.
+	jwtBundles map[string]*jose.JSONWebKeySet
+}
+
+// workloadStream is an open stream of the Workload API, which is updated when its secrets are rotated.
+type workloadStream struct {
+	mutex       sync.Mutex
+	secrets     map[string]*model.SecretItem
+	closed      bool
+	pushChannel chan struct{}
+}
+
+// NewServer creates and starts the Workload API server, with the secrets of the workloads issued by the
+// secretManager.
+func NewServer(options Options, secretManager cache.SecretManager) (*Server, error) {
+	return newServer(options, secretManager, NewProcTokenAttestor(options.TokenPath))
+}
+
+func newServer(options Options, secretManager cache.SecretManager, attestor Attestor) (*Server, error) {
+	if options.JWTSVIDTTL == 0 {
+		options.JWTSVIDTTL = defaultJWTSVIDTTL
+	}
+	if options.TrustDomain == "" {
+		options.TrustDomain = spiffe.GetTrustDomain()
+	}
+
+	var authority *jwtAuthority
+	bundles := map[string]*jose.JSONWebKeySet{}
+	if options.JWTAuthorityKeyFile != "" {
+		var err error
+		if authority, err = loadJWTAuthority(options.JWTAuthorityKeyFile); err != nil {
+			return nil, err
+		}
+		bundles[options.TrustDomain] = &jose.JSONWebKeySet{Keys: []jose.JSONWebKey{authority.publicKey()}}
+	}
+	for trustDomain, file := range options.JWTBundleFiles {
+		bundle, err := loadJWTBundle(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the JWT bundle of trust domain %q: %v", trustDomain, err)
+		}
+		if bundles[trustDomain] == nil {
+			bundles[trustDomain] = &jose.JSONWebKeySet{}
+		}
+		bundles[trustDomain].Keys = append(bundles[trustDomain].Keys, bundle.Keys...)
+	}
+
+	listener, err := setUpUds(options.UDSPath)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		options:       options,
+		secretManager: secretManager,
+		attestor:      attestor,
+		grpcServer:    grpc.NewServer(grpc.Creds(peerCredentialsTransport{})),
+		listener:      listener,
+		jwtAuthority:  authority,
+		jwtBundles:    bundles,
+	}
+	workloadpb.RegisterSpiffeWorkloadAPIServer(s.grpcServer, s)
+
+	go func() {
+		workloadAPILog.Infof("Start the Workload API server on unix://%s", options.UDSPath)
+		if err := s.grpcServer.Serve(listener); err != nil {
+			workloadAPILog.Errorf("Workload API server stopped: %v", err)
+		}
+	}()
+	return s, nil
+}
+
+// Stop stops the Workload API server.
+func (s *Server) Stop() {
+	if s == nil {
+		return
+	}
+	s.grpcServer.Stop()
+	_ = os.Remove(s.options.UDSPath)
+}
+
+func setUpUds(udsPath string) (net.Listener, error) {
+	// Remove unix socket before use.
+	if err := os.Remove(udsPath); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to remove unix://%s: %v", udsPath, err)
+	}
+
+	listener, err := net.Listen("unix", udsPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on unix socket %q: %v", udsPath, err)
+	}
+
+	// The workloads run as any user.
+	if err := os.Chmod(udsPath, 0666); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to update %q permission: %v", udsPath, err)
+	}
+	return listener, nil
+}
+
+// FetchX509SVID implements SpiffeWorkloadAPIServer. It sends the X.509-SVID of the caller with the roots of
+// its trust domain and of the federated trust domains, and sends them again when they are rotated.
+func (s *Server) FetchX509SVID(_ *workloadpb.X509SVIDRequest, stream workloadpb.SpiffeWorkloadAPI_FetchX509SVIDServer) error {
+	resourceNames := []string{cache.WorkloadKeyCertResourceName, cache.RootCertReqResourceName}
+	for _, td := range s.options.FederatedTrustDomains {
+		resourceNames = append(resourceNames, spiffe.URIPrefix+td)
+	}
+	return s.stream(stream.Context(), resourceNames, func(secrets map[string]*model.SecretItem) error {
+		resp, err := s.x509SVIDResponse(secrets)
+		if err != nil {
+			return status.Errorf(codes.Internal, "failed to build the X.509-SVID response: %v", err)
+		}
+		return stream.Send(resp)
+	})
+}
+
+// FetchJWTBundles implements SpiffeWorkloadAPIServer. It sends the JWT authorities of the local and federated
+// trust domains. They are loaded when the server starts, so they are sent once.
+func (s *Server) FetchJWTBundles(_ *workloadpb.JWTBundlesRequest, stream workloadpb.SpiffeWorkloadAPI_FetchJWTBundlesServer) error {
+	if _, err := s.authenticate(stream.Context()); err != nil {
+		return err
+	}
+	resp, err := s.jwtBundlesResponse()
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to build the JWT bundles response: %v", err)
+	}
+	if err := stream.Send(resp); err != nil {
+		return err
+	}
+	<-stream.Context().Done()
+	return nil
+}
+
+// FetchJWTSVID implements SpiffeWorkloadAPIServer. The JWT-SVID has the SPIFFE ID of a new X.509-SVID of the
+// caller, and is signed by the JWT authority of the trust domain.
+func (s *Server) FetchJWTSVID(ctx context.Context, req *workloadpb.JWTSVIDRequest) (*workloadpb.JWTSVIDResponse, error) {
+	if len(req.Audience) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "audience must be specified")
+	}
+	token, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if s.jwtAuthority == nil {
+		return nil, status.Error(codes.Unavailable, "no JWT authority is configured, JWT-SVIDs are not issued")
+	}
+
+	conID := constructConnectionID()
+	defer s.secretManager.DeleteSecret(conID, cache.WorkloadKeyCertResourceName)
+	secret, err := s.secretManager.GenerateSecret(ctx, conID, cache.WorkloadKeyCertResourceName, token)
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "failed to generate the X.509-SVID: %v", err)
+	}
+	svid, err := parseX509SVID(secret.CertificateChain, secret.PrivateKey)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to parse the X.509-SVID: %v", err)
+	}
+	if req.SpiffeId != "" && req.SpiffeId != svid.spiffeID {
+		return nil, status.Errorf(codes.PermissionDenied, "the workload is not entitled to %q", req.SpiffeId)
+	}
+
+	jwt, err := signJWTSVID(s.jwtAuthority, svid, req.Audience, s.options.JWTSVIDTTL, time.Now())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to sign the JWT-SVID: %v", err)
+	}
+	return &workloadpb.JWTSVIDResponse{
+		Svids: []*workloadpb.JWTSVID{{
+			SpiffeId: svid.spiffeID,
+			Svid:     jwt,
+		}},
+	}, nil
+}
+
+// ValidateJWTSVID implements SpiffeWorkloadAPIServer. The JWT-SVID must be signed by a JWT authority of the
+// trust domain of its SPIFFE ID, the local trust domain or a federated trust domain.
+func (s *Server) ValidateJWTSVID(ctx context.Context, req *workloadpb.ValidateJWTSVIDRequest) (*workloadpb.ValidateJWTSVIDResponse, error) {
+	if req.Audience == "" {
+		return nil, status.Error(codes.InvalidArgument, "audience must be specified")
+	}
+	if req.Svid == "" {
+		return nil, status.Error(codes.InvalidArgument, "svid must be specified")
+	}
+	if _, err := s.authenticate(ctx); err != nil {
+		return nil, err
+	}
+
+	spiffeID, claims, err := validateJWTSVID(req.Svid, req.Audience, s.jwtBundles, time.Now())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid JWT-SVID: %v", err)
+	}
+	return &workloadpb.ValidateJWTSVIDResponse{
+		SpiffeId: spiffeID,
+		Claims:   claims,
+	}, nil
+}
+
+// authenticate returns the credential token of the caller.
+func (s *Server) authenticate(ctx context.Context) (string, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok || len(md.Get(securityHeader)) != 1 || md.Get(securityHeader)[0] != "true" {
+		return "", status.Errorf(codes.InvalidArgument, "security header %q is missing", securityHeader)
+	}
+	creds, err := peerCredentialsFromContext(ctx)
+	if err != nil {
+		return "", status.Errorf(codes.Unauthenticated, "failed to get the peer credentials: %v", err)
+	}
+	token, err := s.attestor.Attest(creds)
+	if err != nil {
+		workloadAPILog.Warnf("Failed to attest process %d: %v", creds.PID, err)
+		return "", status.Errorf(codes.PermissionDenied, "failed to attest the caller: %v", err)
+	}
+	return token, nil
+}
+
+// stream generates the secrets of the caller for the resources, and sends them until the stream is closed.
+// The secrets are sent again each time one of them is rotated by the SecretCache.
+func (s *Server) stream(ctx context.Context, resourceNames []string, send func(map[string]*model.SecretItem) error) error {
+	token, err := s.authenticate(ctx)
+	if err != nil {
+		return err
+	}
+
+	conID := constructConnectionID()
+	ws := &workloadStream{
+		secrets:     map[string]*model.SecretItem{},
+		pushChannel: make(chan struct{}, 1),
+	}
+	streamsMutex.Lock()
+	streams[conID] = ws
+	streamsMutex.Unlock()
+	defer func() {
+		streamsMutex.Lock()
+		delete(streams, conID)
+		streamsMutex.Unlock()
+		for _, resourceName := range resourceNames {
+			s.secretManager.DeleteSecret(conID, resourceName)
+		}
+	}()
+
+	for _, resourceName := range resourceNames {
+		secret, err := s.secretManager.GenerateSecret(ctx, conID, resourceName, token)
+		if err != nil {
+			workloadAPILog.Errorf("%s failed to generate secret %q: %v", conID, resourceName, err)
+			return status.Errorf(codes.Unavailable, "failed to generate secret %q: %v", resourceName, err)
+		}
+		ws.mutex.Lock()
+		// Don't overwrite a secret already rotated.
+		if ws.secrets[resourceName] == nil {
+			ws.secrets[resourceName] = secret
+		}
+		ws.mutex.Unlock()
+	}
+
+	for {
+		ws.mutex.Lock()
+		if ws.closed {
+			ws.mutex.Unlock()
+			return status.Error(codes.Unavailable, "the secrets of the workload are no longer available")
+		}
+		secrets := make(map[string]*model.SecretItem, len(ws.secrets))
+		for name, secret := range ws.secrets {
+			secrets[name] = secret
+		}
+		ws.mutex.Unlock()
+
+		if err := send(secrets); err != nil {
+			workloadAPILog.Errorf("%s failed to send the secrets: %v", conID, err)
+			return err
+		}
+		workloadAPILog.Debugf("%s pushed the secrets", conID)
+
+		select {
+		case <-ws.pushChannel:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// x509SVIDResponse converts the PEM encoded secrets of the SecretCache to an X.509-SVID response.
+func (s *Server) x509SVIDResponse(secrets map[string]*model.SecretItem) (*workloadpb.X509SVIDResponse, error) {
+	keyCert := secrets[cache.WorkloadKeyCertResourceName]
+	root := secrets[cache.RootCertReqResourceName]
+	svid, err := parseX509SVID(keyCert.CertificateChain, keyCert.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.MarshalPKCS8PrivateKey(svid.key)
+	if err != nil {
+		return nil, err
+	}
+	var chain []byte
+	for _, cert := range svid.certs {
+		chain = append(chain, cert.Raw...)
+	}
+	bundle, err := certificatesToDER(root.RootCert)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &workloadpb.X509SVIDResponse{
+		Svids: []*workloadpb.X509SVID{{
+			SpiffeId:    svid.spiffeID,
+			X509Svid:    chain,
+			X509SvidKey: key,
+			Bundle:      bundle,
+		}},
+	}
+	for _, td := range s.options.FederatedTrustDomains {
+		resourceName := spiffe.URIPrefix + td
+		if secrets[resourceName] == nil {
+			continue
+		}
+		federated, err := certificatesToDER(secrets[resourceName].RootCert)
+		if err != nil {
+			return nil, err
+		}
+		if resp.FederatedBundles == nil {
+			resp.FederatedBundles = map[string][]byte{}
+		}
+		resp.FederatedBundles[resourceName] = federated
+	}
+	return resp, nil
+}
+
+// jwtBundlesResponse converts the JWT authorities to a JWT bundles response, keyed by the SPIFFE ID of the
+// trust domains.
+func (s *Server) jwtBundlesResponse() (*workloadpb.JWTBundlesResponse, error) {
+	resp := &workloadpb.JWTBundlesResponse{
+		Bundles: map[string][]byte{},
+	}
+	for trustDomain, bundle := range s.jwtBundles {
+		jwks, err := json.Marshal(bundle)
+		if err != nil {
+			return nil, err
+		}
+		resp.Bundles[spiffe.URIPrefix+trustDomain] = jwks
+	}
+	return resp, nil
+}
+
+func constructConnectionID() string {
+	id := atomic.AddInt64(&connectionNumber, 1)
+	return connectionIDPrefix + strconv.FormatInt(id, 10)
+}
+
+// IsWorkloadAPIConnection returns true if the SecretCache connection ID belongs to a Workload API caller.
+func IsWorkloadAPIConnection(connectionID string) bool {
+	return strings.HasPrefix(connectionID, connectionIDPrefix)
+}
+
+// NotifyWorkload sends the rotated secret to the Workload API caller. The stream is closed if the secret is nil.
+func NotifyWorkload(connKey cache.ConnKey, secret *model.SecretItem) error {
+	streamsMutex.Lock()
+	ws := streams[connKey.ConnectionID]
+	streamsMutex.Unlock()
+	if ws == nil {
+		return fmt.Errorf("no Workload API stream with id %q can be found", connKey.ConnectionID)
+	}
+
+	ws.mutex.Lock()
+	if secret == nil {
+		ws.closed = true
+	} else {
+		ws.secrets[connKey.ResourceName] = secret
+	}
+	ws.mutex.Unlock()
+
+	select {
+	case ws.pushChannel <- struct{}{}:
+	default:
+		// A push is already pending, it sends the latest secrets.
+	}
+	return nil
+}
@@ -0,0 +1,662 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workloadapi
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	jose "gopkg.in/square/go-jose.v2"
+
+	"istio.io/istio/security/pkg/nodeagent/cache"
+	"istio.io/istio/security/pkg/nodeagent/model"
+	"istio.io/istio/security/pkg/pki/util"
+	workloadpb "istio.io/istio/security/proto/spiffe"
+)
+
+const (
+	testToken       = "test-token"
+	testSpiffeID    = "spiffe://cluster.local/ns/default/sa/test"
+	testTrustDomain = "partner.org"
+)
+
+type fakeSecretManager struct {
+	mutex     sync.Mutex
+	certChain []byte
+	key       []byte
+	root      []byte
+	federated []byte
+	tokens    map[cache.ConnKey]string
+}
+
+func (m *fakeSecretManager) GenerateSecret(_ context.Context, connectionID, resourceName, token string) (*model.SecretItem, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.tokens[cache.ConnKey{ConnectionID: connectionID, ResourceName: resourceName}] = token
+	secret := &model.SecretItem{ResourceName: resourceName, Token: token}
+	switch resourceName {
+	case cache.WorkloadKeyCertResourceName:
+		secret.CertificateChain = m.certChain
+		secret.PrivateKey = m.key
+	case cache.RootCertReqResourceName:
+		secret.RootCert = m.root
+	case cache.FederatedRootCertReqResourceName:
+		secret.RootCert = append(append([]byte{}, m.root...), m.federated...)
+	case "spiffe://" + testTrustDomain:
+		secret.RootCert = m.federated
+	}
+	return secret, nil
+}
+
+func (m *fakeSecretManager) ShouldWaitForIngressGatewaySecret(string, string, string) bool {
+	return false
+}
+
+func (m *fakeSecretManager) SecretExist(string, string, string, string) bool {
+	return false
+}
+
+func (m *fakeSecretManager) DeleteSecret(connectionID, resourceName string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	delete(m.tokens, cache.ConnKey{ConnectionID: connectionID, ResourceName: resourceName})
+}
+
+func (m *fakeSecretManager) connectionIDs() map[string]bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	ids := map[string]bool{}
+	for key := range m.tokens {
+		ids[key.ConnectionID] = true
+	}
+	return ids
+}
+
+func genRoot(t *testing.T, org string) ([]byte, *x509.Certificate, interface{}) {
+	t.Helper()
+	certPEM, keyPEM, err := util.GenCertKeyFromOptions(util.CertOptions{
+		Org:          org,
+		TTL:          time.Hour,
+		IsCA:         true,
+		IsSelfSigned: true,
+		ECSigAlg:     util.EcdsaSigAlg,
+	})
+	if err != nil {
+		t.Fatalf("failed to generate the root: %v", err)
+	}
+	cert, err := util.ParsePemEncodedCertificate(certPEM)
+	if err != nil {
+		t.Fatalf("failed to parse the root: %v", err)
+	}
+	key, err := util.ParsePemEncodedKey(keyPEM)
+	if err != nil {
+		t.Fatalf("failed to parse the root key: %v", err)
+	}
+	return certPEM, cert, key
+}
+
+func genLeaf(t *testing.T, rootCert *x509.Certificate, rootKey interface{}, host string, ecc bool) ([]byte, []byte) {
+	t.Helper()
+	options := util.CertOptions{
+		Host:       host,
+		TTL:        time.Hour,
+		SignerCert: rootCert,
+		SignerPriv: rootKey,
+		IsServer:   true,
+		IsClient:   true,
+		RSAKeySize: 2048,
+	}
+	if ecc {
+		options.ECSigAlg = util.EcdsaSigAlg
+	}
+	certPEM, keyPEM, err := util.GenCertKeyFromOptions(options)
+	if err != nil {
+		t.Fatalf("failed to generate the leaf: %v", err)
+	}
+	return certPEM, keyPEM
+}
+
+// genJWTAuthority returns a JWT authority with a new key, and the PEM encoding of the key.
+func genJWTAuthority(t *testing.T, curve elliptic.Curve) (*jwtAuthority, []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(curve, rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate the JWT authority key: %v", err)
+	}
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	authority, err := newJWTAuthority(key)
+	if err != nil {
+		t.Fatalf("failed to create the JWT authority: %v", err)
+	}
+	return authority, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+}
+
+type testEnv struct {
+	dir              string
+	manager          *fakeSecretManager
+	server           *Server
+	client           workloadpb.SpiffeWorkloadAPIClient
+	conn             *grpc.ClientConn
+	rootCert         *x509.Certificate
+	rootKey          interface{}
+	federated        *x509.Certificate
+	jwtAuthority     *jwtAuthority
+	federatedJWTAuth *jwtAuthority
+}
+
+func (e *testEnv) close() {
+	e.conn.Close()
+	e.server.Stop()
+	os.RemoveAll(e.dir)
+}
+
+// setUp starts a Workload API server attesting the test process with testToken.
+func setUp(t *testing.T, token string) *testEnv {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "workloadapi")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	procRoot := filepath.Join(dir, "proc")
+	tokenDir := filepath.Join(procRoot, strconv.Itoa(os.Getpid()), "root", "var", "run")
+	if err := os.MkdirAll(tokenDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeStat(filepath.Join(procRoot, strconv.Itoa(os.Getpid())), 1000); err != nil {
+		t.Fatal(err)
+	}
+	if token != "" {
+		if err := ioutil.WriteFile(filepath.Join(tokenDir, "token"), []byte(token+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rootPEM, rootCert, rootKey := genRoot(t, "cluster.local")
+	federatedPEM, federated, _ := genRoot(t, testTrustDomain)
+	certPEM, keyPEM := genLeaf(t, rootCert, rootKey, testSpiffeID, false)
+	manager := &fakeSecretManager{
+		certChain: certPEM,
+		key:       keyPEM,
+		root:      rootPEM,
+		federated: federatedPEM,
+		tokens:    map[cache.ConnKey]string{},
+	}
+
+	authority, authorityKeyPEM := genJWTAuthority(t, elliptic.P256())
+	authorityKeyFile := filepath.Join(dir, "jwt-key.pem")
+	if err := ioutil.WriteFile(authorityKeyFile, authorityKeyPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+	federatedAuthority, _ := genJWTAuthority(t, elliptic.P384())
+	federatedBundle, err := json.Marshal(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{federatedAuthority.publicKey()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	federatedBundleFile := filepath.Join(dir, "partner-jwks.json")
+	if err := ioutil.WriteFile(federatedBundleFile, federatedBundle, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	socket := filepath.Join(dir, "workload.sock")
+	server, err := newServer(Options{
+		UDSPath:               socket,
+		TrustDomain:           "cluster.local",
+		FederatedTrustDomains: []string{testTrustDomain},
+		JWTAuthorityKeyFile:   authorityKeyFile,
+		JWTBundleFiles:        map[string]string{testTrustDomain: federatedBundleFile},
+	}, manager, &procTokenAttestor{procRoot: procRoot, tokenPath: "/var/run/token"})
+	if err != nil {
+		t.Fatalf("failed to start the server: %v", err)
+	}
+
+	conn, err := grpc.Dial(socket, grpc.WithInsecure(), grpc.WithContextDialer(
+		func(ctx context.Context, addr string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", socket)
+		}))
+	if err != nil {
+		server.Stop()
+		t.Fatalf("failed to dial the server: %v", err)
+	}
+	return &testEnv{
+		dir:              dir,
+		manager:          manager,
+		server:           server,
+		client:           workloadpb.NewSpiffeWorkloadAPIClient(conn),
+		conn:             conn,
+		rootCert:         rootCert,
+		rootKey:          rootKey,
+		federated:        federated,
+		jwtAuthority:     authority,
+		federatedJWTAuth: federatedAuthority,
+	}
+}
+
+// writeStat writes the stat file of a process started at startTime in its /proc directory.
+func writeStat(dir string, startTime int) error {
+	fields := make([]string, 52)
+	for i := range fields {
+		fields[i] = "0"
+	}
+	fields[0], fields[1], fields[2] = "42", "(work load)", "S"
+	fields[21] = strconv.Itoa(startTime)
+	return ioutil.WriteFile(filepath.Join(dir, "stat"), []byte(strings.Join(fields, " ")+"\n"), 0644)
+}
+
+func withSecurityHeader(ctx context.Context) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, securityHeader, "true")
+}
+
+func derOf(t *testing.T, certPEM []byte) []byte {
+	t.Helper()
+	block, _ := pem.Decode(certPEM)
+	if block == nil {
+		t.Fatal("invalid PEM certificate")
+	}
+	return block.Bytes
+}
+
+func TestFetchX509SVID(t *testing.T) {
+	env := setUp(t, testToken)
+	defer env.close()
+
+	ctx, cancel := context.WithTimeout(withSecurityHeader(context.Background()), 10*time.Second)
+	defer cancel()
+	stream, err := env.client.FetchX509SVID(ctx, &workloadpb.X509SVIDRequest{})
+	if err != nil {
+		t.Fatalf("FetchX509SVID failed: %v", err)
+	}
+	resp, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("failed to receive the X.509-SVID: %v", err)
+	}
+
+	if len(resp.Svids) != 1 {
+		t.Fatalf("expecting 1 SVID, got %d", len(resp.Svids))
+	}
+	svid := resp.Svids[0]
+	if svid.SpiffeId != testSpiffeID {
+		t.Errorf("SPIFFE ID: got %q, want %q", svid.SpiffeId, testSpiffeID)
+	}
+	certs, err := x509.ParseCertificates(svid.X509Svid)
+	if err != nil || len(certs) != 1 {
+		t.Fatalf("invalid X.509-SVID: %v", err)
+	}
+	if !reflect.DeepEqual(certs[0].Raw, derOf(t, env.manager.certChain)) {
+		t.Errorf("unexpected X.509-SVID")
+	}
+	if _, err := x509.ParsePKCS8PrivateKey(svid.X509SvidKey); err != nil {
+		t.Errorf("the key is not PKCS#8 encoded: %v", err)
+	}
+	if !reflect.DeepEqual(svid.Bundle, env.rootCert.Raw) {
+		t.Errorf("unexpected bundle")
+	}
+	wantFederated := map[string][]byte{"spiffe://" + testTrustDomain: env.federated.Raw}
+	if !reflect.DeepEqual(resp.FederatedBundles, wantFederated) {
+		t.Errorf("unexpected federated bundles: %v", resp.FederatedBundles)
+	}
+
+	var conID string
+	for id := range env.manager.connectionIDs() {
+		conID = id
+	}
+	if !IsWorkloadAPIConnection(conID) {
+		t.Fatalf("unexpected connection ID %q", conID)
+	}
+	env.manager.mutex.Lock()
+	token := env.manager.tokens[cache.ConnKey{ConnectionID: conID, ResourceName: cache.WorkloadKeyCertResourceName}]
+	env.manager.mutex.Unlock()
+	if token != testToken {
+		t.Errorf("token: got %q, want %q", token, testToken)
+	}
+
+	// The rotated X.509-SVID is sent to the workload.
+	certPEM, keyPEM := genLeaf(t, env.rootCert, env.rootKey, testSpiffeID, true)
+	if err := NotifyWorkload(cache.ConnKey{ConnectionID: conID, ResourceName: cache.WorkloadKeyCertResourceName},
+		&model.SecretItem{CertificateChain: certPEM, PrivateKey: keyPEM}); err != nil {
+		t.Fatalf("NotifyWorkload failed: %v", err)
+	}
+	resp, err = stream.Recv()
+	if err != nil {
+		t.Fatalf("failed to receive the rotated X.509-SVID: %v", err)
+	}
+	if !reflect.DeepEqual(resp.Svids[0].X509Svid, derOf(t, certPEM)) {
+		t.Errorf("the X.509-SVID was not rotated")
+	}
+
+	// The stream is closed when the secret is removed from the cache.
+	if err := NotifyWorkload(cache.ConnKey{ConnectionID: conID, ResourceName: cache.WorkloadKeyCertResourceName}, nil); err != nil {
+		t.Fatalf("NotifyWorkload failed: %v", err)
+	}
+	if _, err := stream.Recv(); status.Code(err) != codes.Unavailable {
+		t.Errorf("expecting an Unavailable error, got %v", err)
+	}
+	for i := 0; len(env.manager.connectionIDs()) != 0; i++ {
+		if i == 50 {
+			t.Fatalf("the secrets of the stream were not deleted: %v", env.manager.connectionIDs())
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if err := NotifyWorkload(cache.ConnKey{ConnectionID: conID, ResourceName: cache.WorkloadKeyCertResourceName}, nil); err == nil {
+		t.Errorf("expecting an error for a closed stream")
+	}
+}
+
+func TestFetchAndValidateJWTSVID(t *testing.T) {
+	env := setUp(t, testToken)
+	defer env.close()
+	ctx, cancel := context.WithTimeout(withSecurityHeader(context.Background()), 10*time.Second)
+	defer cancel()
+
+	if _, err := env.client.FetchJWTSVID(ctx, &workloadpb.JWTSVIDRequest{}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expecting an InvalidArgument error without audience, got %v", err)
+	}
+	if _, err := env.client.FetchJWTSVID(ctx, &workloadpb.JWTSVIDRequest{
+		Audience: []string{"foo"},
+		SpiffeId: "spiffe://cluster.local/ns/default/sa/other",
+	}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("expecting a PermissionDenied error for another SPIFFE ID, got %v", err)
+	}
+
+	resp, err := env.client.FetchJWTSVID(ctx, &workloadpb.JWTSVIDRequest{Audience: []string{"foo", "bar"}})
+	if err != nil {
+		t.Fatalf("FetchJWTSVID failed: %v", err)
+	}
+	if len(resp.Svids) != 1 || resp.Svids[0].SpiffeId != testSpiffeID {
+		t.Fatalf("unexpected JWT-SVIDs: %v", resp.Svids)
+	}
+	jws, err := jose.ParseSigned(resp.Svids[0].Svid)
+	if err != nil {
+		t.Fatalf("invalid JWT-SVID: %v", err)
+	}
+	if header := jws.Signatures[0].Header; header.KeyID != env.jwtAuthority.keyID || header.Algorithm != string(jose.ES256) {
+		t.Errorf("the JWT-SVID should be signed by the JWT authority, got header %+v", header)
+	}
+	if ids := env.manager.connectionIDs(); len(ids) != 0 {
+		t.Errorf("the secrets of the request were not deleted: %v", ids)
+	}
+
+	validated, err := env.client.ValidateJWTSVID(ctx, &workloadpb.ValidateJWTSVIDRequest{
+		Audience: "bar",
+		Svid:     resp.Svids[0].Svid,
+	})
+	if err != nil {
+		t.Fatalf("ValidateJWTSVID failed: %v", err)
+	}
+	if validated.SpiffeId != testSpiffeID {
+		t.Errorf("SPIFFE ID: got %q, want %q", validated.SpiffeId, testSpiffeID)
+	}
+	if sub := validated.Claims.Fields["sub"].GetStringValue(); sub != testSpiffeID {
+		t.Errorf("sub claim: got %q, want %q", sub, testSpiffeID)
+	}
+
+	if _, err := env.client.ValidateJWTSVID(ctx, &workloadpb.ValidateJWTSVIDRequest{
+		Audience: "baz",
+		Svid:     resp.Svids[0].Svid,
+	}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expecting an InvalidArgument error for another audience, got %v", err)
+	}
+}
+
+func TestValidateJWTSVID(t *testing.T) {
+	rootPEM, rootCert, rootKey := genRoot(t, "cluster.local")
+	partnerRootPEM, partnerRoot, partnerKey := genRoot(t, testTrustDomain)
+	authority, _ := genJWTAuthority(t, elliptic.P256())
+	partnerAuthority, _ := genJWTAuthority(t, elliptic.P521())
+	unknownAuthority, _ := genJWTAuthority(t, elliptic.P256())
+	bundles := map[string]*jose.JSONWebKeySet{
+		"cluster.local": {Keys: []jose.JSONWebKey{authority.publicKey()}},
+		testTrustDomain: {Keys: []jose.JSONWebKey{partnerAuthority.publicKey()}},
+	}
+	partnerSpiffeID := "spiffe://" + testTrustDomain + "/ns/default/sa/test"
+	now := time.Now()
+
+	sign := func(authority *jwtAuthority, spiffeID string, ttl time.Duration) string {
+		certPEM, keyPEM := genLeaf(t, rootCert, rootKey, spiffeID, true)
+		chainPEM := append(certPEM, rootPEM...)
+		if spiffeID == partnerSpiffeID {
+			certPEM, keyPEM = genLeaf(t, partnerRoot, partnerKey, spiffeID, true)
+			chainPEM = append(certPEM, partnerRootPEM...)
+		}
+		svid, err := parseX509SVID(chainPEM, keyPEM)
+		if err != nil {
+			t.Fatalf("failed to parse the X.509-SVID: %v", err)
+		}
+		token, err := signJWTSVID(authority, svid, []string{"foo"}, ttl, now)
+		if err != nil {
+			t.Fatalf("failed to sign the JWT-SVID: %v", err)
+		}
+		return token
+	}
+
+	testCases := []struct {
+		name    string
+		token   string
+		aud     string
+		wantID  string
+		wantErr bool
+	}{
+		{
+			name:   "valid",
+			token:  sign(authority, testSpiffeID, time.Minute),
+			aud:    "foo",
+			wantID: testSpiffeID,
+		},
+		{
+			name:   "valid of federated trust domain",
+			token:  sign(partnerAuthority, partnerSpiffeID, time.Minute),
+			aud:    "foo",
+			wantID: partnerSpiffeID,
+		},
+		{
+			name:    "local identity signed by the federated trust domain",
+			token:   sign(partnerAuthority, testSpiffeID, time.Minute),
+			aud:     "foo",
+			wantErr: true,
+		},
+		{
+			name:    "federated identity signed by the local trust domain",
+			token:   sign(authority, partnerSpiffeID, time.Minute),
+			aud:     "foo",
+			wantErr: true,
+		},
+		{
+			name:    "wrong audience",
+			token:   sign(authority, testSpiffeID, time.Minute),
+			aud:     "bar",
+			wantErr: true,
+		},
+		{
+			name:    "expired",
+			token:   sign(authority, testSpiffeID, -time.Minute),
+			aud:     "foo",
+			wantErr: true,
+		},
+		{
+			name:    "unknown authority",
+			token:   sign(unknownAuthority, testSpiffeID, time.Minute),
+			aud:     "foo",
+			wantErr: true,
+		},
+		{
+			name:    "malformed",
+			token:   "not-a-jwt",
+			aud:     "foo",
+			wantErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			id, claims, err := validateJWTSVID(tc.token, tc.aud, bundles, now)
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("expecting an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if id != tc.wantID {
+				t.Errorf("SPIFFE ID: got %q, want %q", id, tc.wantID)
+			}
+			if aud := claims.Fields["aud"].GetListValue().GetValues(); len(aud) != 1 || aud[0].GetStringValue() != "foo" {
+				t.Errorf("unexpected aud claim: %v", aud)
+			}
+		})
+	}
+}
+
+func TestJWTAlgorithm(t *testing.T) {
+	for curve, want := range map[elliptic.Curve]jose.SignatureAlgorithm{
+		elliptic.P256(): jose.ES256,
+		elliptic.P384(): jose.ES384,
+		elliptic.P521(): jose.ES512,
+	} {
+		authority, _ := genJWTAuthority(t, curve)
+		if authority.alg != want {
+			t.Errorf("%s: got algorithm %s, want %s", curve.Params().Name, authority.alg, want)
+		}
+	}
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if alg, err := jwtAlgorithm(rsaKey.Public()); err != nil || alg != jose.RS256 {
+		t.Errorf("RSA: got algorithm %s (error %v), want %s", alg, err, jose.RS256)
+	}
+}
+
+func TestFetchJWTBundles(t *testing.T) {
+	env := setUp(t, testToken)
+	defer env.close()
+	ctx, cancel := context.WithTimeout(withSecurityHeader(context.Background()), 10*time.Second)
+	defer cancel()
+
+	stream, err := env.client.FetchJWTBundles(ctx, &workloadpb.JWTBundlesRequest{})
+	if err != nil {
+		t.Fatalf("FetchJWTBundles failed: %v", err)
+	}
+	resp, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("failed to receive the JWT bundles: %v", err)
+	}
+	for trustDomain, authority := range map[string]*jwtAuthority{
+		"spiffe://cluster.local":      env.jwtAuthority,
+		"spiffe://" + testTrustDomain: env.federatedJWTAuth,
+	} {
+		var jwks jose.JSONWebKeySet
+		if err := json.Unmarshal(resp.Bundles[trustDomain], &jwks); err != nil {
+			t.Fatalf("invalid JWT bundle of %s: %v", trustDomain, err)
+		}
+		if len(jwks.Keys) != 1 || jwks.Keys[0].KeyID != authority.keyID ||
+			!reflect.DeepEqual(jwks.Keys[0].Key, authority.key.Public()) {
+			t.Errorf("unexpected JWT bundle of %s: %s", trustDomain, resp.Bundles[trustDomain])
+		}
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	env := setUp(t, "")
+	defer env.close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	req := &workloadpb.JWTSVIDRequest{Audience: []string{"foo"}}
+	if _, err := env.client.FetchJWTSVID(ctx, req); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expecting an InvalidArgument error without the security header, got %v", err)
+	}
+	if _, err := env.client.FetchJWTSVID(withSecurityHeader(ctx), req); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("expecting a PermissionDenied error without token, got %v", err)
+	}
+}
+
+func TestAttestReplacedProcess(t *testing.T) {
+	dir, err := ioutil.TempDir("", "workloadapi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	procDir := filepath.Join(dir, "42")
+	if err := os.MkdirAll(filepath.Join(procDir, "root"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeStat(procDir, 1000); err != nil {
+		t.Fatal(err)
+	}
+	attestor := &procTokenAttestor{procRoot: dir, tokenPath: "/token"}
+
+	if err := ioutil.WriteFile(filepath.Join(procDir, "root", "token"), []byte(testToken), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if token, err := attestor.Attest(PeerCredentials{PID: 42}); err != nil || token != testToken {
+		t.Fatalf("unexpected token %q (error %v), expected %q", token, err, testToken)
+	}
+
+	// The token is read from a FIFO, which is written after the PID was reused by another process.
+	tokenFile := filepath.Join(procDir, "root", "token")
+	if err := os.Remove(tokenFile); err != nil {
+		t.Fatal(err)
+	}
+	if err := syscall.Mkfifo(tokenFile, 0644); err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		f, err := os.OpenFile(tokenFile, os.O_WRONLY, 0)
+		if err != nil {
+			return
+		}
+		defer f.Close()
+		if writeStat(procDir, 2000) == nil {
+			_, _ = f.WriteString(testToken)
+		}
+	}()
+	if token, err := attestor.Attest(PeerCredentials{PID: 42}); err == nil {
+		t.Errorf("expecting an error for a replaced process, got token %q", token)
+	}
+
+	if err := os.Remove(filepath.Join(procDir, "stat")); err != nil {
+		t.Fatal(err)
+	}
+	if token, err := attestor.Attest(PeerCredentials{PID: 42}); err == nil {
+		t.Errorf("expecting an error without the start time of the process, got token %q", token)
+	}
+}
@@ -0,0 +1,340 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workloadapi
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/gogo/protobuf/types"
+	jose "gopkg.in/square/go-jose.v2"
+
+	"istio.io/istio/pkg/spiffe"
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+// x509SVID is the X.509-SVID of a workload, as returned by the SecretCache.
+type x509SVID struct {
+	spiffeID string
+	certs    []*x509.Certificate
+	key      crypto.PrivateKey
+}
+
+// parseX509SVID parses the PEM encoded certificate chain and private key of a workload.
+func parseX509SVID(certChainPEM, keyPEM []byte) (*x509SVID, error) {
+	certs, err := parseCertificates(certChainPEM)
+	if err != nil {
+		return nil, err
+	}
+	key, err := util.ParsePemEncodedKey(keyPEM)
+	if err != nil {
+		return nil, err
+	}
+	spiffeID, err := getSpiffeID(certs[0])
+	if err != nil {
+		return nil, err
+	}
+	return &x509SVID{
+		spiffeID: spiffeID,
+		certs:    certs,
+		key:      key,
+	}, nil
+}
+
+// parseCertificates parses PEM encoded certificates.
+func parseCertificates(certsPEM []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for rest := certsPEM; ; {
+		var block *pem.Block
+		if block, rest = pem.Decode(rest); block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse certificate: %v", err)
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, errors.New("no certificate found")
+	}
+	return certs, nil
+}
+
+// certificatesToDER returns the concatenated ASN.1 DER encoding of PEM encoded certificates.
+func certificatesToDER(certsPEM []byte) ([]byte, error) {
+	certs, err := parseCertificates(certsPEM)
+	if err != nil {
+		return nil, err
+	}
+	var der bytes.Buffer
+	for _, cert := range certs {
+		der.Write(cert.Raw)
+	}
+	return der.Bytes(), nil
+}
+
+// getSpiffeID returns the SPIFFE ID of a certificate.
+func getSpiffeID(cert *x509.Certificate) (string, error) {
+	ids, err := util.ExtractIDs(cert.Extensions)
+	if err != nil {
+		return "", err
+	}
+	for _, id := range ids {
+		if strings.HasPrefix(id, spiffe.URIPrefix) {
+			return id, nil
+		}
+	}
+	return "", errors.New("no SPIFFE ID in the certificate")
+}
+
+// jwtSVIDUse is the "use" of the keys of the JWT bundles.
+const jwtSVIDUse = "jwt-svid"
+
+// jwtClaims are the claims of a JWT-SVID.
+type jwtClaims struct {
+	Subject   string   `json:"sub"`
+	Audience  []string `json:"aud"`
+	ExpiresAt int64    `json:"exp"`
+	IssuedAt  int64    `json:"iat"`
+}
+
+// jwtAuthority is the key signing the JWT-SVIDs of the local trust domain. Its public key is published in the
+// JWT bundle of the trust domain, identified by its key ID.
+type jwtAuthority struct {
+	key   crypto.Signer
+	keyID string
+	alg   jose.SignatureAlgorithm
+}
+
+// loadJWTAuthority reads the PEM encoded private key of the JWT authority.
+func loadJWTAuthority(file string) (*jwtAuthority, error) {
+	keyPEM, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the JWT authority key: %v", err)
+	}
+	key, err := util.ParsePemEncodedKey(keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the JWT authority key: %v", err)
+	}
+	return newJWTAuthority(key)
+}
+
+func newJWTAuthority(key crypto.PrivateKey) (*jwtAuthority, error) {
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+	alg, err := jwtAlgorithm(signer.Public())
+	if err != nil {
+		return nil, err
+	}
+	keyID, err := jwtKeyID(signer.Public())
+	if err != nil {
+		return nil, err
+	}
+	return &jwtAuthority{
+		key:   signer,
+		keyID: keyID,
+		alg:   alg,
+	}, nil
+}
+
+// publicKey returns the JWK of the authority published in the JWT bundle.
+func (a *jwtAuthority) publicKey() jose.JSONWebKey {
+	return jose.JSONWebKey{
+		Key:       a.key.Public(),
+		KeyID:     a.keyID,
+		Algorithm: string(a.alg),
+		Use:       jwtSVIDUse,
+	}
+}
+
+// jwtAlgorithm returns the signature algorithm of a key: RS256 for RSA keys, and ES256, ES384 or ES512 for
+// EC keys on the P-256, P-384 and P-521 curves.
+func jwtAlgorithm(key crypto.PublicKey) (jose.SignatureAlgorithm, error) {
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		return jose.RS256, nil
+	case *ecdsa.PublicKey:
+		switch k.Curve {
+		case elliptic.P256():
+			return jose.ES256, nil
+		case elliptic.P384():
+			return jose.ES384, nil
+		case elliptic.P521():
+			return jose.ES512, nil
+		}
+		return "", fmt.Errorf("unsupported EC curve %s", k.Curve.Params().Name)
+	}
+	return "", fmt.Errorf("unsupported public key type %T", key)
+}
+
+// jwtKeyID returns the key ID of a public key, its RFC 7638 thumbprint.
+func jwtKeyID(key crypto.PublicKey) (string, error) {
+	thumbprint, err := (&jose.JSONWebKey{Key: key}).Thumbprint(crypto.SHA256)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(thumbprint), nil
+}
+
+// loadJWTBundle reads a JWK set of JWT authorities, whose keys must all have a key ID.
+func loadJWTBundle(file string) (*jose.JSONWebKeySet, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	bundle := &jose.JSONWebKeySet{}
+	if err := json.Unmarshal(data, bundle); err != nil {
+		return nil, err
+	}
+	for _, key := range bundle.Keys {
+		if key.KeyID == "" {
+			return nil, errors.New("a key has no key ID")
+		}
+		if _, err := jwtAlgorithm(key.Key); err != nil {
+			return nil, fmt.Errorf("key %q: %v", key.KeyID, err)
+		}
+	}
+	return bundle, nil
+}
+
+// signJWTSVID returns a JWT-SVID of the X.509-SVID for the audience, signed by the JWT authority with its key
+// ID in the "kid" header. It expires with the X.509-SVID, if not before.
+func signJWTSVID(authority *jwtAuthority, svid *x509SVID, audience []string, ttl time.Duration, now time.Time) (string, error) {
+	signer, err := jose.NewSigner(jose.SigningKey{
+		Algorithm: authority.alg,
+		Key:       jose.JSONWebKey{Key: authority.key, KeyID: authority.keyID},
+	}, (&jose.SignerOptions{}).WithType("JWT"))
+	if err != nil {
+		return "", err
+	}
+
+	expiresAt := now.Add(ttl)
+	if notAfter := svid.certs[0].NotAfter; notAfter.Before(expiresAt) {
+		expiresAt = notAfter
+	}
+	payload, err := json.Marshal(jwtClaims{
+		Subject:   svid.spiffeID,
+		Audience:  audience,
+		ExpiresAt: expiresAt.Unix(),
+		IssuedAt:  now.Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+	jws, err := signer.Sign(payload)
+	if err != nil {
+		return "", err
+	}
+	return jws.CompactSerialize()
+}
+
+// validateJWTSVID validates a JWT-SVID for the audience against the JWT bundles keyed by trust domain, and returns
+// its SPIFFE ID and claims. The JWT-SVID must be signed by a JWT authority of the trust domain of its SPIFFE ID.
+func validateJWTSVID(token, audience string, bundles map[string]*jose.JSONWebKeySet, now time.Time) (string, *types.Struct, error) {
+	jws, err := jose.ParseSigned(token)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to parse the JWT-SVID: %v", err)
+	}
+	if len(jws.Signatures) != 1 {
+		return "", nil, fmt.Errorf("expecting one signature, found %d", len(jws.Signatures))
+	}
+	header := jws.Signatures[0].Header
+	if header.KeyID == "" {
+		return "", nil, errors.New("the JWT-SVID has no key ID")
+	}
+
+	// The trust domains whose authorities signed the JWT-SVID.
+	var payload []byte
+	signers := map[string]bool{}
+	for trustDomain, bundle := range bundles {
+		for _, key := range bundle.Key(header.KeyID) {
+			if alg, err := jwtAlgorithm(key.Key); err != nil || string(alg) != header.Algorithm {
+				continue
+			}
+			if verified, err := jws.Verify(key.Key); err == nil {
+				payload = verified
+				signers[trustDomain] = true
+			}
+		}
+	}
+	if payload == nil {
+		return "", nil, fmt.Errorf("the JWT-SVID is not signed by a known JWT authority (key ID %q)", header.KeyID)
+	}
+
+	var claims struct {
+		Subject   string          `json:"sub"`
+		Audience  json.RawMessage `json:"aud"`
+		ExpiresAt int64           `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return "", nil, fmt.Errorf("failed to parse the claims of the JWT-SVID: %v", err)
+	}
+	trustDomain, err := spiffe.GetTrustDomainFromURI(claims.Subject)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid subject: %v", err)
+	}
+	if !signers[trustDomain] {
+		return "", nil, fmt.Errorf("the JWT-SVID of %q is not signed by a JWT authority of its trust domain", claims.Subject)
+	}
+	if claims.ExpiresAt == 0 || !now.Before(time.Unix(claims.ExpiresAt, 0)) {
+		return "", nil, errors.New("the JWT-SVID has expired")
+	}
+	if !hasAudience(claims.Audience, audience) {
+		return "", nil, fmt.Errorf("the JWT-SVID is not for the audience %q", audience)
+	}
+
+	s := &types.Struct{}
+	if err := jsonpb.Unmarshal(bytes.NewReader(payload), s); err != nil {
+		return "", nil, fmt.Errorf("failed to convert the claims of the JWT-SVID: %v", err)
+	}
+	return claims.Subject, s, nil
+}
+
+// hasAudience returns true if the "aud" claim, either a string or an array of strings, contains the audience.
+func hasAudience(claim json.RawMessage, audience string) bool {
+	var audiences []string
+	if err := json.Unmarshal(claim, &audiences); err != nil {
+		var single string
+		if err := json.Unmarshal(claim, &single); err != nil {
+			return false
+		}
+		audiences = []string{single}
+	}
+	for _, a := range audiences {
+		if a == audience {
+			return true
+		}
+	}
+	return false
+}
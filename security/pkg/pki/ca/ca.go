@@ -67,6 +67,8 @@ const (
 	selfSignedCA caTypes = iota
 	// pluggedCertCA means the Istio CA uses a operator-specified key/cert.
 	pluggedCertCA
+	// upstreamCA means the Istio CA uses a key/cert signed by an upstream authority, e.g. Vault.
+	upstreamCA
 )
 
 // CertificateAuthority contains methods to be supported by a CA.
@@ -90,6 +92,12 @@ type IstioCAOptions struct {
 
 	LivenessProbeOptions *probe.Options
 	ProbeCheckInterval   time.Duration
+
+	// crlStore is where the CRL is published. The CRL is only kept in memory if it is nil.
+	crlStore crlStore
+
+	// upstream renews the key/cert of an upstreamCA.
+	upstream *upstreamSigner
 }
 
 // IstioCA generates keys and certificates for Istio identities.
@@ -99,7 +107,11 @@ type IstioCA struct {
 
 	keyCertBundle util.KeyCertBundle
 
+	revocations *revocationList
+
 	livenessProbe *probe.Probe
+
+	upstream *upstreamSigner
 }
 
 // Append root certificates in rootCertFile to the input certificate.
@@ -127,8 +139,10 @@ func appendRootCerts(pemCert []byte, rootCertFile string) ([]byte, error) {
 }
 
 // NewSelfSignedIstioCAOptions returns a new IstioCAOptions instance using self-signed certificate.
+// The self-signed key is an RSA key, unless ecSigAlg is set.
 func NewSelfSignedIstioCAOptions(ctx context.Context, caCertTTL, certTTL, maxCertTTL time.Duration, org string, dualUse bool,
-	namespace string, readCertRetryInterval time.Duration, client corev1.CoreV1Interface, rootCertFile string) (caOpts *IstioCAOptions, err error) {
+	namespace string, readCertRetryInterval time.Duration, client corev1.CoreV1Interface, rootCertFile string,
+	ecSigAlg util.SupportedECSignatureAlgorithms) (caOpts *IstioCAOptions, err error) {
 	// For the first time the CA is up, if readSigningCertOnly is unset,
 	// it generates a self-signed key/cert pair and write it to CASecret.
 	// For subsequent restart, CA will reads key/cert from CASecret.
@@ -154,6 +168,7 @@ func NewSelfSignedIstioCAOptions(ctx context.Context, caCertTTL, certTTL, maxCer
 		CAType:     selfSignedCA,
 		CertTTL:    certTTL,
 		MaxCertTTL: maxCertTTL,
+		crlStore:   configmap.NewController(namespace, client),
 	}
 	if scrtErr != nil {
 		log.Infof("Failed to get secret (error: %s), will create one", scrtErr)
@@ -164,6 +179,7 @@ func NewSelfSignedIstioCAOptions(ctx context.Context, caCertTTL, certTTL, maxCer
 			IsCA:         true,
 			IsSelfSigned: true,
 			RSAKeySize:   caKeySize,
+			ECSigAlg:     ecSigAlg,
 			IsDualUse:    dualUse,
 		}
 		pemCert, pemKey, ckErr := util.GenCertKeyFromOptions(options)
@@ -189,12 +205,16 @@ func NewSelfSignedIstioCAOptions(ctx context.Context, caCertTTL, certTTL, maxCer
 		log.Infof("Using self-generated public key: %v", string(rootCerts))
 	} else {
 		log.Infof("Load signing key and cert from existing secret %s:%s", caSecret.Namespace, caSecret.Name)
-		rootCerts, err := appendRootCerts(caSecret.Data[caCertID], rootCertFile)
+		signingCert, signingKey, rootCerts, err := keyCertFromCASecret(caSecret)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the CA secret (%v)", err)
+		}
+		rootCerts, err = appendRootCerts(rootCerts, rootCertFile)
 		if err != nil {
 			return nil, fmt.Errorf("failed to append root certificates (%v)", err)
 		}
-		if caOpts.KeyCertBundle, err = util.NewVerifiedKeyCertBundleFromPem(caSecret.Data[caCertID],
-			caSecret.Data[caPrivateKeyID], nil, rootCerts); err != nil {
+		if caOpts.KeyCertBundle, err = util.NewVerifiedKeyCertBundleFromPem(signingCert,
+			signingKey, nil, rootCerts); err != nil {
 			return nil, fmt.Errorf("failed to create CA KeyCertBundle (%v)", err)
 		}
 		log.Infof("Using existing public key: %v", string(rootCerts))
@@ -213,6 +233,7 @@ func NewPluggedCertIstioCAOptions(certChainFile, signingCertFile, signingKeyFile
 		CAType:     pluggedCertCA,
 		CertTTL:    certTTL,
 		MaxCertTTL: maxCertTTL,
+		crlStore:   configmap.NewController(namespace, client),
 	}
 	if caOpts.KeyCertBundle, err = util.NewVerifiedKeyCertBundleFromFile(
 		signingCertFile, signingKeyFile, certChainFile, rootCertFile); err != nil {
@@ -254,7 +275,20 @@ func NewIstioCA(opts *IstioCAOptions) (*IstioCA, error) {
 		certTTL:       opts.CertTTL,
 		maxCertTTL:    opts.MaxCertTTL,
 		keyCertBundle: opts.KeyCertBundle,
+		revocations:   newRevocationList(opts.crlStore),
 		livenessProbe: probe.NewProbe(),
+		upstream:      opts.upstream,
+	}
+
+	if opts.crlStore != nil {
+		// Restore the revoked certificates and publish a fresh CRL, so that it is available to the
+		// proxies before the first revocation.
+		if err := ca.loadCRL(); err != nil {
+			log.Errorf("Failed to load the published CRL (%v)", err)
+		}
+		if _, err := ca.GetCRL(); err != nil {
+			log.Errorf("Failed to publish the CRL (%v)", err)
+		}
 	}
 
 	return ca, nil
@@ -289,6 +323,7 @@ func (ca *IstioCA) Sign(csrPEM []byte, subjectIDs []string, requestedLifetime ti
 	if err != nil {
 		return nil, NewError(CertGenError, err)
 	}
+	ca.trackIssued(certBytes)
 
 	block := &pem.Block{
 		Type:  "CERTIFICATE",
@@ -95,7 +95,7 @@ func TestCreateSelfSignedIstioCAWithoutSecret(t *testing.T) {
 	rootCertFile := ""
 
 	caopts, err := NewSelfSignedIstioCAOptions(context.Background(), caCertTTL, defaultCertTTL, maxCertTTL,
-		org, false, caNamespace, -1, client.CoreV1(), rootCertFile)
+		org, false, caNamespace, -1, client.CoreV1(), rootCertFile, "")
 	if err != nil {
 		t.Fatalf("Failed to create a self-signed CA Options: %v", err)
 	}
@@ -182,7 +182,7 @@ func TestCreateSelfSignedIstioCAWithSecret(t *testing.T) {
 	const rootCertFile = ""
 
 	caopts, err := NewSelfSignedIstioCAOptions(context.Background(), caCertTTL, certTTL, maxCertTTL,
-		org, false, caNamespace, -1, client.CoreV1(), rootCertFile)
+		org, false, caNamespace, -1, client.CoreV1(), rootCertFile, "")
 	if err != nil {
 		t.Fatalf("Failed to create a self-signed CA Options: %v", err)
 	}
@@ -250,7 +250,7 @@ func TestCreateSelfSignedIstioCAReadSigningCertOnly(t *testing.T) {
 	ctx0, cancel0 := context.WithTimeout(context.Background(), time.Millisecond*50)
 	defer cancel0()
 	_, err := NewSelfSignedIstioCAOptions(ctx0, caCertTTL, certTTL, maxCertTTL,
-		org, false, caNamespace, time.Millisecond*10, client.CoreV1(), rootCertFile)
+		org, false, caNamespace, time.Millisecond*10, client.CoreV1(), rootCertFile, "")
 	if err == nil {
 		t.Errorf("Expected error, but succeeded.")
 	} else if err.Error() != expectedErr {
@@ -268,7 +268,7 @@ func TestCreateSelfSignedIstioCAReadSigningCertOnly(t *testing.T) {
 	ctx1, cancel1 := context.WithCancel(context.Background())
 	defer cancel1()
 	caopts, err := NewSelfSignedIstioCAOptions(ctx1, caCertTTL, certTTL, maxCertTTL,
-		org, false, caNamespace, time.Millisecond*10, client.CoreV1(), rootCertFile)
+		org, false, caNamespace, time.Millisecond*10, client.CoreV1(), rootCertFile, "")
 	if err != nil {
 		t.Errorf("Unexpected error: %v", err)
 	}
@@ -415,6 +415,61 @@ func TestSignCSRForWorkload(t *testing.T) {
 	}
 }
 
+func TestSignCSRWithMixedKeyTypes(t *testing.T) {
+	subjectID := "spiffe://example.com/ns/foo/sa/bar"
+	cases := map[string]struct {
+		caSigAlg  util.SupportedECSignatureAlgorithms
+		csrSigAlg util.SupportedECSignatureAlgorithms
+	}{
+		"RSA CA signs ECDSA CSR": {
+			caSigAlg:  "",
+			csrSigAlg: util.EcdsaSigAlg,
+		},
+		"ECDSA CA signs RSA CSR": {
+			caSigAlg:  util.EcdsaSigAlg,
+			csrSigAlg: "",
+		},
+		"ECDSA CA signs ECDSA CSR": {
+			caSigAlg:  util.EcdsaSigAlg,
+			csrSigAlg: util.EcdsaSigAlg,
+		},
+	}
+
+	for id, tc := range cases {
+		opts := util.CertOptions{
+			Host:       subjectID,
+			RSAKeySize: 2048,
+			ECSigAlg:   tc.csrSigAlg,
+		}
+		csrPEM, keyPEM, err := util.GenCSR(opts)
+		if err != nil {
+			t.Fatalf("%s: %v", id, err)
+		}
+
+		ca, err := createCAWithKeyType(time.Hour, tc.caSigAlg)
+		if err != nil {
+			t.Fatalf("%s: %v", id, err)
+		}
+
+		certPEM, err := ca.Sign(csrPEM, []string{subjectID}, 30*time.Minute, false)
+		if err != nil {
+			t.Fatalf("%s: %v", id, err)
+		}
+
+		fields := &util.VerifyFields{
+			ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+			KeyUsage:    x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+			IsCA:        false,
+			Host:        subjectID,
+		}
+		_, _, certChainBytes, rootCertBytes := ca.GetCAKeyCertBundle().GetAll()
+		if err = util.VerifyCertificate(
+			keyPEM, append(certPEM, certChainBytes...), rootCertBytes, fields); err != nil {
+			t.Errorf("%s: %v", id, err)
+		}
+	}
+}
+
 func TestSignCSRForCA(t *testing.T) {
 	subjectID := "spiffe://example.com/ns/foo/sa/baz"
 	opts := util.CertOptions{
@@ -438,7 +493,7 @@ func TestSignCSRForCA(t *testing.T) {
 	}
 
 	fields := &util.VerifyFields{
-		KeyUsage: x509.KeyUsageCertSign,
+		KeyUsage: x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
 		IsCA:     true,
 		Host:     subjectID,
 	}
@@ -526,6 +581,12 @@ root-cert-3`
 }
 
 func createCA(maxTTL time.Duration) (*IstioCA, error) {
+	return createCAWithKeyType(maxTTL, "")
+}
+
+// createCAWithKeyType creates a CA whose root and intermediate keys are generated with the given EC
+// signature algorithm, or RSA if it is empty.
+func createCAWithKeyType(maxTTL time.Duration, ecSigAlg util.SupportedECSignatureAlgorithms) (*IstioCA, error) {
 	// Generate root CA key and cert.
 	rootCAOpts := util.CertOptions{
 		IsCA:         true,
@@ -533,6 +594,7 @@ func createCA(maxTTL time.Duration) (*IstioCA, error) {
 		TTL:          time.Hour,
 		Org:          "Root CA",
 		RSAKeySize:   2048,
+		ECSigAlg:     ecSigAlg,
 	}
 	rootCertBytes, rootKeyBytes, err := util.GenCertKeyFromOptions(rootCAOpts)
 	if err != nil {
@@ -555,6 +617,7 @@ func createCA(maxTTL time.Duration) (*IstioCA, error) {
 		TTL:          time.Hour,
 		Org:          "Intermediate CA",
 		RSAKeySize:   2048,
+		ECSigAlg:     ecSigAlg,
 		SignerCert:   rootCert,
 		SignerPriv:   rootKey,
 	}
@@ -0,0 +1,275 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ca
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"istio.io/pkg/log"
+)
+
+const (
+	// crlTTL is the validity period of a published CRL. The CRL is re-signed at half of this period.
+	crlTTL = 24 * time.Hour
+
+	blockTypeCRL = "X509 CRL"
+)
+
+// CertificateRevoker contains methods to be supported by a CA that can revoke the certificates it issued.
+type CertificateRevoker interface {
+	// Revoke adds the certificates with the given serial numbers to the CRL, publishes the CRL and
+	// returns it PEM-encoded.
+	Revoke(serialNumbers []*big.Int) ([]byte, error)
+	// GetCRL returns the PEM-encoded CRL currently published by the CA.
+	GetCRL() ([]byte, error)
+}
+
+// crlStore persists the PEM-encoded CRL, e.g. in the ConfigMap beside the CA root cert.
+type crlStore interface {
+	InsertCRL(value string) error
+	GetCRL() (string, error)
+}
+
+// revokedCert is an entry of the CRL.
+type revokedCert struct {
+	entry pkix.RevokedCertificate
+	// The entry is dropped from the CRL once the revoked certificate has expired on its own.
+	expiry time.Time
+}
+
+// revocationList tracks the certificates issued and revoked by the CA.
+type revocationList struct {
+	mutex sync.Mutex
+	// issued maps the serial numbers of the certificates issued by the CA to their expiry.
+	issued map[string]time.Time
+	// revoked contains the CRL entries, keyed by serial number.
+	revoked map[string]revokedCert
+
+	crlPEM        []byte
+	crlThisUpdate time.Time
+
+	store crlStore
+}
+
+func newRevocationList(store crlStore) *revocationList {
+	return &revocationList{
+		issued:  map[string]time.Time{},
+		revoked: map[string]revokedCert{},
+		store:   store,
+	}
+}
+
+// trackIssued records the serial number of a certificate signed by the CA.
+func (ca *IstioCA) trackIssued(certBytes []byte) {
+	cert, err := x509.ParseCertificate(certBytes)
+	if err != nil {
+		log.Warnf("Failed to parse the issued certificate, it can only be revoked by serial number: %v", err)
+		return
+	}
+	ca.revocations.mutex.Lock()
+	ca.revocations.issued[cert.SerialNumber.Text(16)] = cert.NotAfter
+	ca.revocations.mutex.Unlock()
+}
+
+// Revoke adds the certificates with the given serial numbers to the CRL, publishes the CRL and
+// returns it PEM-encoded. Serial numbers that are not known to this CA instance, e.g. the ones issued
+// before a restart, are kept in the CRL for the longest lifetime a certificate issued by the CA can have.
+func (ca *IstioCA) Revoke(serialNumbers []*big.Int) ([]byte, error) {
+	rl := ca.revocations
+	rl.mutex.Lock()
+	defer rl.mutex.Unlock()
+
+	now := time.Now()
+	for _, sn := range serialNumbers {
+		key := sn.Text(16)
+		if _, ok := rl.revoked[key]; ok {
+			continue
+		}
+		expiry, ok := rl.issued[key]
+		if !ok {
+			log.Warnf("Revoking certificate with serial number %s that is not tracked by Citadel", key)
+			expiry = now.Add(ca.maxCertLifetime())
+		}
+		delete(rl.issued, key)
+		rl.revoked[key] = revokedCert{
+			entry: pkix.RevokedCertificate{
+				SerialNumber:   sn,
+				RevocationTime: now,
+			},
+			expiry: expiry,
+		}
+		log.Infof("Certificate with serial number %s is revoked", key)
+	}
+	return ca.publishCRLLocked(now)
+}
+
+// GetCRL returns the PEM-encoded CRL, re-signing it first if it is due for an update.
+func (ca *IstioCA) GetCRL() ([]byte, error) {
+	rl := ca.revocations
+	rl.mutex.Lock()
+	defer rl.mutex.Unlock()
+
+	now := time.Now()
+	if rl.crlPEM != nil && now.Before(rl.crlThisUpdate.Add(crlTTL/2)) {
+		return rl.crlPEM, nil
+	}
+	return ca.publishCRLLocked(now)
+}
+
+// RunCRLRefresher periodically re-signs and publishes the CRL, so that it does not expire while
+// there are no revocations. It blocks until stopCh is closed.
+func (ca *IstioCA) RunCRLRefresher(stopCh <-chan struct{}) {
+	ticker := time.NewTicker(crlTTL / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if _, err := ca.GetCRL(); err != nil {
+				log.Errorf("Failed to refresh the CRL: %v", err)
+			}
+		case <-stopCh:
+			return
+		}
+	}
+}
+
+// loadCRL restores the revoked certificates from the CRL previously published in the store. A CRL that
+// is not signed by the current signing cert, e.g. after the CA key rotated, is ignored.
+func (ca *IstioCA) loadCRL() error {
+	signingCert, _, _, _ := ca.keyCertBundle.GetAll()
+	if signingCert == nil {
+		return fmt.Errorf("istio CA is not ready")
+	}
+
+	rl := ca.revocations
+	rl.mutex.Lock()
+	defer rl.mutex.Unlock()
+	if err := ca.mergeStoredCRLLocked(signingCert); err != nil {
+		return err
+	}
+	log.Infof("Loaded %d revoked certificates from the published CRL", len(rl.revoked))
+	return nil
+}
+
+// mergeStoredCRLLocked adds the entries of the CRL published in the store to the revocation list, so that
+// the revocations made by the other Citadel replicas are not dropped by the next publication. A CRL that
+// is not signed by the given cert is ignored. The caller must hold the revocation list mutex.
+func (ca *IstioCA) mergeStoredCRLLocked(signingCert *x509.Certificate) error {
+	rl := ca.revocations
+	if rl.store == nil {
+		return nil
+	}
+	crlPEM, err := rl.store.GetCRL()
+	if err != nil || crlPEM == "" {
+		return err
+	}
+	block, _ := pem.Decode([]byte(crlPEM))
+	if block == nil || block.Type != blockTypeCRL {
+		log.Warnf("Ignoring the published CRL, which is not PEM-encoded")
+		return nil
+	}
+	crl, err := x509.ParseCRL(block.Bytes)
+	if err != nil {
+		log.Warnf("Ignoring the published CRL, which cannot be parsed: %v", err)
+		return nil
+	}
+	if err := signingCert.CheckCRLSignature(crl); err != nil {
+		log.Warnf("Ignoring the published CRL, which is not signed by the CA signing cert: %v", err)
+		return nil
+	}
+
+	for _, entry := range crl.TBSCertList.RevokedCertificates {
+		key := entry.SerialNumber.Text(16)
+		if _, ok := rl.revoked[key]; ok {
+			continue
+		}
+		delete(rl.issued, key)
+		rl.revoked[key] = revokedCert{
+			entry: pkix.RevokedCertificate{
+				SerialNumber:   entry.SerialNumber,
+				RevocationTime: entry.RevocationTime,
+			},
+			expiry: entry.RevocationTime.Add(ca.maxCertLifetime()),
+		}
+	}
+	return nil
+}
+
+// publishCRLLocked merges the entries of the CRL in the store, drops the expired entries, signs a new CRL
+// and writes it to the store. Each replica keeps its own revocations in memory, so a revocation lost by
+// concurrent publications is restored by the next refresh of the replica that made it.
+// The caller must hold the revocation list mutex.
+func (ca *IstioCA) publishCRLLocked(now time.Time) ([]byte, error) {
+	rl := ca.revocations
+	signingCert, signingKey, _, _ := ca.keyCertBundle.GetAll()
+	if signingCert == nil || signingKey == nil {
+		return nil, NewError(CANotReady, fmt.Errorf("Istio CA is not ready")) // nolint
+	}
+	signer, ok := (*signingKey).(crypto.Signer)
+	if !ok {
+		return nil, NewError(CRLGenError, fmt.Errorf("the CA signing key cannot sign a CRL"))
+	}
+	if err := ca.mergeStoredCRLLocked(signingCert); err != nil {
+		return nil, NewError(CRLGenError, fmt.Errorf("failed to read the published CRL (%v)", err))
+	}
+
+	for sn, expiry := range rl.issued {
+		if expiry.Before(now) {
+			delete(rl.issued, sn)
+		}
+	}
+	entries := make([]pkix.RevokedCertificate, 0, len(rl.revoked))
+	for sn, rc := range rl.revoked {
+		if rc.expiry.Before(now) {
+			delete(rl.revoked, sn)
+			continue
+		}
+		entries = append(entries, rc.entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].SerialNumber.Cmp(entries[j].SerialNumber) < 0
+	})
+
+	crlBytes, err := signingCert.CreateCRL(rand.Reader, signer, entries, now, now.Add(crlTTL))
+	if err != nil {
+		return nil, NewError(CRLGenError, fmt.Errorf("failed to sign the CRL (%v)", err))
+	}
+	crlPEM := pem.EncodeToMemory(&pem.Block{Type: blockTypeCRL, Bytes: crlBytes})
+	if rl.store != nil {
+		if err := rl.store.InsertCRL(string(crlPEM)); err != nil {
+			return nil, NewError(CRLGenError, fmt.Errorf("failed to publish the CRL (%v)", err))
+		}
+	}
+	rl.crlPEM = crlPEM
+	rl.crlThisUpdate = now
+	return crlPEM, nil
+}
+
+// maxCertLifetime returns an upper bound of the lifetime of the certificates signed by the CA.
+func (ca *IstioCA) maxCertLifetime() time.Duration {
+	if ca.certTTL > ca.maxCertTTL {
+		return ca.certTTL
+	}
+	return ca.maxCertTTL
+}
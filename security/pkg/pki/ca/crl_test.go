@@ -0,0 +1,225 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ca
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/kubernetes/fake"
+
+	"istio.io/istio/security/pkg/k8s/configmap"
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+func signWorkloadCert(t *testing.T, ca *IstioCA) *x509.Certificate {
+	t.Helper()
+	csrPEM, _, err := util.GenCSR(util.CertOptions{RSAKeySize: 2048})
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM, err := ca.Sign(csrPEM, []string{"spiffe://example.com/ns/foo/sa/bar"}, 30*time.Minute, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := util.ParsePemEncodedCertificate(certPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func parseAndVerifyCRL(t *testing.T, ca *IstioCA, crlPEM []byte) map[string]bool {
+	t.Helper()
+	block, _ := pem.Decode(crlPEM)
+	if block == nil || block.Type != blockTypeCRL {
+		t.Fatalf("the CRL is not PEM-encoded: %s", crlPEM)
+	}
+	crl, err := x509.ParseCRL(block.Bytes)
+	if err != nil {
+		t.Fatalf("failed to parse the CRL: %v", err)
+	}
+	signingCert, _, _, _ := ca.GetCAKeyCertBundle().GetAll()
+	if err := signingCert.CheckCRLSignature(crl); err != nil {
+		t.Fatalf("the CRL is not signed by the CA: %v", err)
+	}
+	serials := map[string]bool{}
+	for _, entry := range crl.TBSCertList.RevokedCertificates {
+		serials[entry.SerialNumber.Text(16)] = true
+	}
+	return serials
+}
+
+func TestRevoke(t *testing.T) {
+	for _, sigAlg := range []util.SupportedECSignatureAlgorithms{"", util.EcdsaSigAlg} {
+		ca, err := createCAWithKeyType(time.Hour, sigAlg)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		crlPEM, err := ca.GetCRL()
+		if err != nil {
+			t.Fatalf("failed to get the CRL: %v", err)
+		}
+		if revoked := parseAndVerifyCRL(t, ca, crlPEM); len(revoked) != 0 {
+			t.Errorf("expected an empty CRL, got %v", revoked)
+		}
+
+		revokedCert := signWorkloadCert(t, ca)
+		validCert := signWorkloadCert(t, ca)
+		if expiry, ok := ca.revocations.issued[revokedCert.SerialNumber.Text(16)]; !ok || !expiry.Equal(revokedCert.NotAfter) {
+			t.Errorf("the issued certificate is not tracked")
+		}
+
+		unknownSerial := big.NewInt(12345)
+		crlPEM, err = ca.Revoke([]*big.Int{revokedCert.SerialNumber, unknownSerial})
+		if err != nil {
+			t.Fatalf("failed to revoke: %v", err)
+		}
+		revoked := parseAndVerifyCRL(t, ca, crlPEM)
+		if !revoked[revokedCert.SerialNumber.Text(16)] || !revoked[unknownSerial.Text(16)] {
+			t.Errorf("revoked certificates are missing from the CRL: %v", revoked)
+		}
+		if revoked[validCert.SerialNumber.Text(16)] {
+			t.Errorf("the CRL contains a certificate that was not revoked")
+		}
+		if got := ca.revocations.revoked[revokedCert.SerialNumber.Text(16)].expiry; !got.Equal(revokedCert.NotAfter) {
+			t.Errorf("unexpected expiry of the CRL entry: %v VS (expected) %v", got, revokedCert.NotAfter)
+		}
+
+		// Revoking a certificate again does not change the CRL entry.
+		if _, err = ca.Revoke([]*big.Int{revokedCert.SerialNumber}); err != nil {
+			t.Fatalf("failed to revoke: %v", err)
+		}
+		if n := len(ca.revocations.revoked); n != 2 {
+			t.Errorf("unexpected number of CRL entries %d", n)
+		}
+	}
+}
+
+func TestCRLDropsExpiredEntries(t *testing.T) {
+	ca, err := createCA(time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = ca.Revoke([]*big.Int{big.NewInt(1), big.NewInt(2)}); err != nil {
+		t.Fatalf("failed to revoke: %v", err)
+	}
+
+	// Pretend that the first certificate has expired.
+	entry := ca.revocations.revoked["1"]
+	entry.expiry = time.Now().Add(-time.Minute)
+	ca.revocations.revoked["1"] = entry
+	ca.revocations.crlThisUpdate = time.Now().Add(-crlTTL)
+
+	crlPEM, err := ca.GetCRL()
+	if err != nil {
+		t.Fatalf("failed to get the CRL: %v", err)
+	}
+	revoked := parseAndVerifyCRL(t, ca, crlPEM)
+	if revoked["1"] || !revoked["2"] {
+		t.Errorf("unexpected CRL entries %v", revoked)
+	}
+}
+
+func TestCRLPublishedInConfigMap(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	newCA := func() *IstioCA {
+		caopts, err := NewSelfSignedIstioCAOptions(context.Background(), time.Hour, time.Hour, time.Hour,
+			"test.com", false, "default", -1, client.CoreV1(), "", "")
+		if err != nil {
+			t.Fatalf("failed to create a self-signed CA Options: %v", err)
+		}
+		ca, err := NewIstioCA(caopts)
+		if err != nil {
+			t.Fatalf("failed to create a self-signed CA: %v", err)
+		}
+		return ca
+	}
+	getPublishedCRL := func() []byte {
+		crl, err := configmap.NewController("default", client.CoreV1()).GetCRL()
+		if err != nil {
+			t.Fatalf("failed to get the CRL from the configmap: %v", err)
+		}
+		return []byte(crl)
+	}
+
+	ca := newCA()
+	if revoked := parseAndVerifyCRL(t, ca, getPublishedCRL()); len(revoked) != 0 {
+		t.Errorf("expected an empty CRL to be published at startup, got %v", revoked)
+	}
+
+	cert := signWorkloadCert(t, ca)
+	if _, err := ca.Revoke([]*big.Int{cert.SerialNumber}); err != nil {
+		t.Fatalf("failed to revoke: %v", err)
+	}
+	if revoked := parseAndVerifyCRL(t, ca, getPublishedCRL()); !revoked[cert.SerialNumber.Text(16)] {
+		t.Errorf("the revoked certificate is missing from the published CRL")
+	}
+
+	// A restarted CA, reusing the signing key from the CA secret, keeps the revocations.
+	restarted := newCA()
+	if _, ok := restarted.revocations.revoked[cert.SerialNumber.Text(16)]; !ok {
+		t.Errorf("the revoked certificate is not restored from the published CRL")
+	}
+	if revoked := parseAndVerifyCRL(t, restarted, getPublishedCRL()); !revoked[cert.SerialNumber.Text(16)] {
+		t.Errorf("the revoked certificate is missing from the CRL published after restart")
+	}
+}
+
+func TestCRLMergedAcrossReplicas(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	newCA := func() *IstioCA {
+		caopts, err := NewSelfSignedIstioCAOptions(context.Background(), time.Hour, time.Hour, time.Hour,
+			"test.com", false, "default", -1, client.CoreV1(), "", "")
+		if err != nil {
+			t.Fatalf("failed to create a self-signed CA Options: %v", err)
+		}
+		ca, err := NewIstioCA(caopts)
+		if err != nil {
+			t.Fatalf("failed to create a self-signed CA: %v", err)
+		}
+		return ca
+	}
+
+	// Both replicas share the signing key from the CA secret.
+	replica1 := newCA()
+	replica2 := newCA()
+	if _, err := replica1.Revoke([]*big.Int{big.NewInt(1)}); err != nil {
+		t.Fatalf("failed to revoke: %v", err)
+	}
+	crlPEM, err := replica2.Revoke([]*big.Int{big.NewInt(2)})
+	if err != nil {
+		t.Fatalf("failed to revoke: %v", err)
+	}
+	if revoked := parseAndVerifyCRL(t, replica2, crlPEM); !revoked["1"] || !revoked["2"] {
+		t.Errorf("the CRL published by a replica drops the revocations of the other: %v", revoked)
+	}
+
+	// The refresh of the first replica keeps the revocation made by the second one.
+	replica1.revocations.mutex.Lock()
+	crlPEM, err = replica1.publishCRLLocked(time.Now())
+	replica1.revocations.mutex.Unlock()
+	if err != nil {
+		t.Fatalf("failed to publish the CRL: %v", err)
+	}
+	if revoked := parseAndVerifyCRL(t, replica1, crlPEM); !revoked["1"] || !revoked["2"] {
+		t.Errorf("the refreshed CRL drops the revocations of the other replica: %v", revoked)
+	}
+}
@@ -28,6 +28,8 @@ const (
 	TTLError
 	// CertGenError means an error happened during the certificate generation.
 	CertGenError
+	// CRLGenError means an error happened during the generation or publishing of the CRL.
+	CRLGenError
 )
 
 // Error encapsulates the short and long errors.
@@ -52,6 +54,8 @@ func (e Error) ErrorType() string {
 		return "TTL_ERROR"
 	case CertGenError:
 		return "CERT_GEN_ERROR"
+	case CRLGenError:
+		return "CRL_GEN_ERROR"
 	}
 	return "UNKNOWN"
 }
@@ -63,6 +67,8 @@ func (e Error) HTTPErrorCode() codes.Code {
 		return codes.Internal
 	case CertGenError:
 		return codes.Internal
+	case CRLGenError:
+		return codes.Internal
 	case CSRError:
 		return codes.InvalidArgument
 	case TTLError:
@@ -52,6 +52,12 @@ func TestError(t *testing.T) {
 			message: "CERT_GEN_ERROR",
 			code:    codes.Internal,
 		},
+		"CRL_GEN_ERROR": {
+			eType:   CRLGenError,
+			err:     fmt.Errorf("test error6"),
+			message: "CRL_GEN_ERROR",
+			code:    codes.Internal,
+		},
 		"UNKNOWN": {
 			eType:   -1,
 			err:     fmt.Errorf("test error5"),
@@ -15,6 +15,7 @@
 package mock
 
 import (
+	"math/big"
 	"time"
 
 	"istio.io/istio/security/pkg/pki/ca"
@@ -28,6 +29,10 @@ type FakeCA struct {
 	SignErr       *ca.Error
 	KeyCertBundle util.KeyCertBundle
 	ReceivedIDs   []string
+
+	CRL            []byte
+	RevokeErr      *ca.Error
+	RevokedSerials []*big.Int
 }
 
 // Sign returns the SignErr if SignErr is not nil, otherwise, it returns SignedCert.
@@ -47,3 +52,17 @@ func (ca *FakeCA) GetCAKeyCertBundle() util.KeyCertBundle {
 	}
 	return ca.KeyCertBundle
 }
+
+// Revoke records the serial numbers and returns the RevokeErr if RevokeErr is not nil, otherwise, it returns CRL.
+func (ca *FakeCA) Revoke(serialNumbers []*big.Int) ([]byte, error) {
+	if ca.RevokeErr != nil {
+		return nil, ca.RevokeErr
+	}
+	ca.RevokedSerials = append(ca.RevokedSerials, serialNumbers...)
+	return ca.CRL, nil
+}
+
+// GetCRL returns CRL.
+func (ca *FakeCA) GetCRL() ([]byte, error) {
+	return ca.CRL, nil
+}
@@ -0,0 +1,269 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ca
+
+import (
+	"bytes"
+	"crypto/x509"
+	"fmt"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"istio.io/istio/security/pkg/pki/util"
+	"istio.io/pkg/log"
+)
+
+// The rotation of the root certificate of a self-signed CA is driven through the CA secret, which is the
+// source of truth of all the Citadel instances:
+//
+//   - Idle: the root in ca-cert.pem signs, and is the only trusted root.
+//   - TrustNewRoot: a new root is added to the secret. The old root still signs, both roots are trusted.
+//   - SignWithNewRoot: the new root signs, both roots are still trusted.
+//   - Retiring the old root replaces it with the new root, which is then the only trusted root (Idle).
+//
+// Each phase changes the published trust bundle, so the workload certificates and roots in the istio.*
+// secrets are refreshed. Before moving to the next phase, the trust bundle of the current phase must have
+// reached all the workloads, and before retiring the old root, the certificates it signed must have expired.
+const (
+	// RootRotationPhaseAnnotation is the annotation of the CA secret holding the phase of the root rotation.
+	RootRotationPhaseAnnotation = "istio.io/root-rotation-phase"
+
+	// newCACertID is the root certificate replacing caCertID during a root rotation.
+	newCACertID = "new-ca-cert.pem"
+	// newCAPrivateKeyID is the private key of the root certificate replacing caCertID.
+	newCAPrivateKeyID = "new-ca-key.pem"
+
+	// rootRotationCheckInterval is the interval at which Citadel checks the CA secret for a new rotation phase.
+	rootRotationCheckInterval = time.Minute
+)
+
+// RootRotationPhase is a phase of the rotation of the root certificate of a self-signed CA.
+type RootRotationPhase string
+
+const (
+	// RootRotationIdle means that no rotation is in progress.
+	RootRotationIdle RootRotationPhase = "Idle"
+	// RootRotationTrustNewRoot means that the new root is trusted, and the old root still signs.
+	RootRotationTrustNewRoot RootRotationPhase = "TrustNewRoot"
+	// RootRotationSignWithNewRoot means that the new root signs, and the old root is still trusted.
+	RootRotationSignWithNewRoot RootRotationPhase = "SignWithNewRoot"
+)
+
+// RootRotationStatus is the state of the rotation of the root certificate of a self-signed CA.
+type RootRotationStatus struct {
+	Phase RootRotationPhase
+	// OldRoot is the root being replaced, or the only root when no rotation is in progress.
+	OldRoot *x509.Certificate
+	// NewRoot is the root replacing OldRoot, it is nil when no rotation is in progress.
+	NewRoot *x509.Certificate
+}
+
+// StartRootRotation adds a new root to the CA secret, which Citadel trusts along with the current root.
+// The new root is generated from the options, unless its PEM encoded cert and key are given.
+func StartRootRotation(client corev1.CoreV1Interface, namespace string, newCert, newKey []byte,
+	options util.CertOptions) error {
+	secret, err := getCASecret(client, namespace)
+	if err != nil {
+		return err
+	}
+	if phase := getRootRotationPhase(secret); phase != RootRotationIdle {
+		return fmt.Errorf("a root rotation is already in progress (phase %s)", phase)
+	}
+
+	if len(newCert) == 0 {
+		if newCert, newKey, err = util.GenCertKeyFromOptions(options); err != nil {
+			return fmt.Errorf("failed to generate the new root (%v)", err)
+		}
+	} else if err = verifyRootKeyCert(newCert, newKey); err != nil {
+		return fmt.Errorf("invalid new root (%v)", err)
+	}
+
+	secret.Data[newCACertID] = newCert
+	secret.Data[newCAPrivateKeyID] = newKey
+	return updateRootRotationPhase(client, secret, RootRotationTrustNewRoot)
+}
+
+// ActivateNewRoot makes Citadel sign with the new root of the rotation in progress.
+func ActivateNewRoot(client corev1.CoreV1Interface, namespace string) error {
+	secret, err := getCASecret(client, namespace)
+	if err != nil {
+		return err
+	}
+	if phase := getRootRotationPhase(secret); phase != RootRotationTrustNewRoot {
+		return fmt.Errorf("the new root can only be activated in phase %s, the current phase is %s",
+			RootRotationTrustNewRoot, phase)
+	}
+	return updateRootRotationPhase(client, secret, RootRotationSignWithNewRoot)
+}
+
+// RetireOldRoot replaces the old root with the new root of the rotation in progress, which completes it.
+func RetireOldRoot(client corev1.CoreV1Interface, namespace string) error {
+	secret, err := getCASecret(client, namespace)
+	if err != nil {
+		return err
+	}
+	if phase := getRootRotationPhase(secret); phase != RootRotationSignWithNewRoot {
+		return fmt.Errorf("the old root can only be retired in phase %s, the current phase is %s",
+			RootRotationSignWithNewRoot, phase)
+	}
+
+	secret.Data[caCertID] = secret.Data[newCACertID]
+	secret.Data[caPrivateKeyID] = secret.Data[newCAPrivateKeyID]
+	delete(secret.Data, newCACertID)
+	delete(secret.Data, newCAPrivateKeyID)
+	return updateRootRotationPhase(client, secret, RootRotationIdle)
+}
+
+// GetRootRotationStatus returns the roots in the CA secret and the phase of their rotation.
+func GetRootRotationStatus(client corev1.CoreV1Interface, namespace string) (*RootRotationStatus, error) {
+	secret, err := getCASecret(client, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &RootRotationStatus{Phase: getRootRotationPhase(secret)}
+	if status.OldRoot, err = util.ParsePemEncodedCertificate(secret.Data[caCertID]); err != nil {
+		return nil, fmt.Errorf("failed to parse the root (%v)", err)
+	}
+	if status.Phase != RootRotationIdle {
+		if status.NewRoot, err = util.ParsePemEncodedCertificate(secret.Data[newCACertID]); err != nil {
+			return nil, fmt.Errorf("failed to parse the new root (%v)", err)
+		}
+	}
+	return status, nil
+}
+
+// RunRootRotationWatcher checks the CA secret periodically. When the phase of the root rotation changed, it
+// reloads the signing key and cert and the trusted roots, and publishes the trusted roots.
+func (ca *IstioCA) RunRootRotationWatcher(client corev1.CoreV1Interface, namespace, rootCertFile string,
+	stopCh <-chan struct{}) {
+	ticker := time.NewTicker(rootRotationCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := ca.reloadCASecret(client, namespace, rootCertFile); err != nil {
+				log.Errorf("Failed to reload the CA secret: %v", err)
+			}
+		case <-stopCh:
+			return
+		}
+	}
+}
+
+// reloadCASecret updates the KeyCertBundle of the CA from the CA secret if it changed.
+func (ca *IstioCA) reloadCASecret(client corev1.CoreV1Interface, namespace, rootCertFile string) error {
+	secret, err := getCASecret(client, namespace)
+	if err != nil {
+		return err
+	}
+	signingCert, signingKey, rootCerts, err := keyCertFromCASecret(secret)
+	if err != nil {
+		return err
+	}
+	if rootCerts, err = appendRootCerts(rootCerts, rootCertFile); err != nil {
+		return fmt.Errorf("failed to append root certificates (%v)", err)
+	}
+
+	currentCert, _, _, currentRootCerts := ca.keyCertBundle.GetAllPem()
+	if bytes.Equal(currentCert, signingCert) && bytes.Equal(currentRootCerts, rootCerts) {
+		return nil
+	}
+	if err = ca.keyCertBundle.VerifyAndSetAll(signingCert, signingKey, nil, rootCerts); err != nil {
+		return fmt.Errorf("failed to update the CA KeyCertBundle (%v)", err)
+	}
+	log.Infof("Loaded the signing key and cert and the trusted roots of root rotation phase %s",
+		getRootRotationPhase(secret))
+
+	return updateCertInConfigmap(namespace, client, rootCerts)
+}
+
+// keyCertFromCASecret returns the signing key and cert, and the trusted roots, of the root rotation phase of
+// the CA secret.
+func keyCertFromCASecret(secret *v1.Secret) (signingCert, signingKey, rootCerts []byte, err error) {
+	oldCert, oldKey := secret.Data[caCertID], secret.Data[caPrivateKeyID]
+	newCert, newKey := secret.Data[newCACertID], secret.Data[newCAPrivateKeyID]
+
+	phase := getRootRotationPhase(secret)
+	if phase != RootRotationIdle && (len(newCert) == 0 || len(newKey) == 0) {
+		return nil, nil, nil, fmt.Errorf("the new root is missing from the CA secret in phase %s", phase)
+	}
+	switch phase {
+	case RootRotationIdle:
+		return oldCert, oldKey, oldCert, nil
+	case RootRotationTrustNewRoot:
+		return oldCert, oldKey, joinPem(oldCert, newCert), nil
+	case RootRotationSignWithNewRoot:
+		// The new root goes first, so that the trust bundle changes and the workload certificates signed by
+		// the old root are replaced.
+		return newCert, newKey, joinPem(newCert, oldCert), nil
+	default:
+		return nil, nil, nil, fmt.Errorf("unknown root rotation phase %q", phase)
+	}
+}
+
+func getCASecret(client corev1.CoreV1Interface, namespace string) (*v1.Secret, error) {
+	secret, err := client.Secrets(namespace).Get(CASecret, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the CA secret %s/%s (%v)", namespace, CASecret, err)
+	}
+	return secret, nil
+}
+
+func getRootRotationPhase(secret *v1.Secret) RootRotationPhase {
+	if phase := secret.Annotations[RootRotationPhaseAnnotation]; phase != "" {
+		return RootRotationPhase(phase)
+	}
+	return RootRotationIdle
+}
+
+func updateRootRotationPhase(client corev1.CoreV1Interface, secret *v1.Secret, phase RootRotationPhase) error {
+	if secret.Annotations == nil {
+		secret.Annotations = make(map[string]string)
+	}
+	secret.Annotations[RootRotationPhaseAnnotation] = string(phase)
+	if _, err := client.Secrets(secret.Namespace).Update(secret); err != nil {
+		return fmt.Errorf("failed to update the CA secret %s/%s (%v)", secret.Namespace, secret.Name, err)
+	}
+	return nil
+}
+
+// verifyRootKeyCert verifies that the PEM encoded cert is a self-signed CA certificate for the key.
+func verifyRootKeyCert(certPem, keyPem []byte) error {
+	cert, err := util.ParsePemEncodedCertificate(certPem)
+	if err != nil {
+		return err
+	}
+	if !cert.IsCA {
+		return fmt.Errorf("certificate is not authorized to sign other certificates")
+	}
+	return util.Verify(certPem, keyPem, nil, certPem)
+}
+
+// joinPem concatenates PEM encoded certificates, one per line.
+func joinPem(certs ...[]byte) []byte {
+	var joined []byte
+	for _, cert := range certs {
+		if len(joined) > 0 {
+			joined = []byte(strings.TrimSuffix(string(joined), "\n") + "\n")
+		}
+		joined = append(joined, cert...)
+	}
+	return joined
+}
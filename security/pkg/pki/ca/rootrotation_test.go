@@ -0,0 +1,184 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ca
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"istio.io/istio/security/pkg/k8s/configmap"
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+func TestRootRotation(t *testing.T) {
+	const namespace = "default"
+	client := fake.NewSimpleClientset()
+	oldRoot := []byte(cert1Pem)
+	initSecret := BuildSecret("", CASecret, namespace, nil, nil, nil, oldRoot, []byte(key1Pem), istioCASecretType)
+	if _, err := client.CoreV1().Secrets(namespace).Create(initSecret); err != nil {
+		t.Fatalf("Failed to create secret (error: %s)", err)
+	}
+
+	caopts, err := NewSelfSignedIstioCAOptions(context.Background(), time.Hour, 30*time.Minute, time.Hour,
+		"test.ca.org", false, namespace, -1, client.CoreV1(), "", "")
+	if err != nil {
+		t.Fatalf("Failed to create a self-signed CA Options: %v", err)
+	}
+	ca, err := NewIstioCA(caopts)
+	if err != nil {
+		t.Fatalf("Failed to create a self-signed CA: %v", err)
+	}
+
+	// checkPhase reloads the CA secret and verifies the phase, the signing cert and the published roots.
+	checkPhase := func(phase RootRotationPhase, signingCert []byte, rootCerts []byte) {
+		t.Helper()
+		if err := ca.reloadCASecret(client.CoreV1(), namespace, ""); err != nil {
+			t.Fatalf("Failed to reload the CA secret: %v", err)
+		}
+		status, err := GetRootRotationStatus(client.CoreV1(), namespace)
+		if err != nil {
+			t.Fatalf("Failed to get the root rotation status: %v", err)
+		}
+		if status.Phase != phase {
+			t.Errorf("Unexpected phase (expecting %s, actual %s)", phase, status.Phase)
+		}
+		if (status.NewRoot == nil) != (phase == RootRotationIdle) {
+			t.Errorf("Unexpected new root in phase %s: %v", phase, status.NewRoot)
+		}
+
+		certBytes, _, _, rootCertBytes := ca.GetCAKeyCertBundle().GetAllPem()
+		if !bytes.Equal(certBytes, signingCert) {
+			t.Errorf("Unexpected signing cert in phase %s", phase)
+		}
+		if !bytes.Equal(rootCertBytes, rootCerts) {
+			t.Errorf("Unexpected root certs in phase %s: %s", phase, rootCertBytes)
+		}
+
+		strCertFromConfigMap, err := configmap.NewController(namespace, client.CoreV1()).GetCATLSRootCert()
+		if err != nil {
+			t.Fatalf("Cannot get the CA cert from configmap (%v)", err)
+		}
+		if certFromConfigMap, _ := base64.StdEncoding.DecodeString(strCertFromConfigMap); !bytes.Equal(certFromConfigMap, rootCerts) {
+			t.Errorf("Unexpected root certs in configmap in phase %s: %s", phase, certFromConfigMap)
+		}
+	}
+
+	checkPhase(RootRotationIdle, oldRoot, oldRoot)
+	if err := ActivateNewRoot(client.CoreV1(), namespace); err == nil {
+		t.Error("Expected an error when activating a new root without a rotation in progress")
+	}
+
+	options := util.CertOptions{
+		TTL:          time.Hour,
+		Org:          "test.ca.org",
+		IsCA:         true,
+		IsSelfSigned: true,
+		RSAKeySize:   caKeySize,
+	}
+	if err := StartRootRotation(client.CoreV1(), namespace, nil, nil, options); err != nil {
+		t.Fatalf("Failed to start the root rotation: %v", err)
+	}
+	if err := StartRootRotation(client.CoreV1(), namespace, nil, nil, options); err == nil {
+		t.Error("Expected an error when starting a root rotation twice")
+	}
+	secret, _ := client.CoreV1().Secrets(namespace).Get(CASecret, metav1.GetOptions{})
+	newRoot := secret.Data[newCACertID]
+	checkPhase(RootRotationTrustNewRoot, oldRoot, joinPem(oldRoot, newRoot))
+
+	if err := RetireOldRoot(client.CoreV1(), namespace); err == nil {
+		t.Error("Expected an error when retiring the old root before the new root is activated")
+	}
+	if err := ActivateNewRoot(client.CoreV1(), namespace); err != nil {
+		t.Fatalf("Failed to activate the new root: %v", err)
+	}
+	checkPhase(RootRotationSignWithNewRoot, newRoot, joinPem(newRoot, oldRoot))
+
+	// Workload certificates are signed by the new root, and verified with the trust bundle.
+	csrPEM, _, err := util.GenCSR(util.CertOptions{Host: "spiffe://example.com/ns/foo/sa/bar", RSAKeySize: 2048})
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM, err := ca.Sign(csrPEM, []string{"spiffe://example.com/ns/foo/sa/bar"}, 30*time.Minute, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := util.ParsePemEncodedCertificate(certPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := x509.NewCertPool()
+	roots.AppendCertsFromPEM(newRoot)
+	if _, err := cert.Verify(x509.VerifyOptions{Roots: roots, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny}}); err != nil {
+		t.Errorf("Workload certificate is not signed by the new root: %v", err)
+	}
+
+	if err := RetireOldRoot(client.CoreV1(), namespace); err != nil {
+		t.Fatalf("Failed to retire the old root: %v", err)
+	}
+	checkPhase(RootRotationIdle, newRoot, newRoot)
+}
+
+func TestStartRootRotationWithInvalidRoot(t *testing.T) {
+	const namespace = "default"
+	client := fake.NewSimpleClientset()
+	initSecret := BuildSecret("", CASecret, namespace, nil, nil, nil, []byte(cert1Pem), []byte(key1Pem), istioCASecretType)
+	if _, err := client.CoreV1().Secrets(namespace).Create(initSecret); err != nil {
+		t.Fatalf("Failed to create secret (error: %s)", err)
+	}
+
+	// A workload certificate cannot be a root.
+	certPEM, keyPEM, err := util.GenCertKeyFromOptions(util.CertOptions{
+		Host:         "spiffe://example.com/ns/foo/sa/bar",
+		TTL:          time.Hour,
+		IsSelfSigned: true,
+		RSAKeySize:   2048,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := StartRootRotation(client.CoreV1(), namespace, certPEM, keyPEM, util.CertOptions{}); err == nil {
+		t.Error("Expected an error when starting a root rotation with a non CA certificate")
+	}
+
+	// The key must match the certificate.
+	certPEM, _, err = util.GenCertKeyFromOptions(util.CertOptions{
+		TTL:          time.Hour,
+		Org:          "test.ca.org",
+		IsCA:         true,
+		IsSelfSigned: true,
+		RSAKeySize:   2048,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := StartRootRotation(client.CoreV1(), namespace, certPEM, keyPEM, util.CertOptions{}); err == nil {
+		t.Error("Expected an error when starting a root rotation with a mismatched key")
+	}
+
+	status, err := GetRootRotationStatus(client.CoreV1(), namespace)
+	if err != nil {
+		t.Fatalf("Failed to get the root rotation status: %v", err)
+	}
+	if status.Phase != RootRotationIdle {
+		t.Errorf("Unexpected phase %s after failed root rotations", status.Phase)
+	}
+}
@@ -0,0 +1,301 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ca
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"istio.io/istio/security/pkg/k8s/configmap"
+	"istio.io/istio/security/pkg/pki/util"
+	"istio.io/pkg/log"
+)
+
+const (
+	// intermediateRenewalRatio is the ratio of the lifetime of the intermediate CA certificate after which it
+	// is renewed.
+	intermediateRenewalRatio = 0.5
+
+	// intermediateRenewalCheckInterval is the interval at which Citadel checks if its intermediate CA
+	// certificate must be renewed.
+	intermediateRenewalCheckInterval = time.Minute
+)
+
+// UpstreamAuthority signs the intermediate CA certificate of Citadel, e.g. Vault.
+type UpstreamAuthority interface {
+	// SignIntermediate signs the PEM encoded CSR of an intermediate CA certificate. It returns the certificate
+	// chain, starting with the signed certificate and excluding the root, and the root certificate.
+	SignIntermediate(csrPEM []byte, ttl time.Duration) (certChain, rootCert []byte, err error)
+}
+
+// upstreamSigner gets the intermediate CA certificates of Citadel signed by an UpstreamAuthority, and persists
+// them in the CA secret, so that they are shared by the Citadel replicas and kept across restarts.
+type upstreamSigner struct {
+	authority    UpstreamAuthority
+	options      util.CertOptions
+	rootCertFile string
+
+	client    corev1.CoreV1Interface
+	namespace string
+}
+
+// upstreamKeyCert is a CA key and its certificate signed by the upstream authority.
+type upstreamKeyCert struct {
+	signingCert []byte
+	signingKey  []byte
+	certChain   []byte
+	// rootCert is the root of the upstream authority, without the roots of the root cert file.
+	rootCert []byte
+}
+
+// NewUpstreamIstioCAOptions returns a new IstioCAOptions instance using an intermediate CA certificate signed
+// by the upstream authority. The key of the intermediate CA is generated by Citadel, and only stored in the CA
+// secret.
+func NewUpstreamIstioCAOptions(upstream UpstreamAuthority, caCertTTL, certTTL, maxCertTTL time.Duration,
+	org string, keySize int, namespace string, client corev1.CoreV1Interface, rootCertFile string) (
+	caOpts *IstioCAOptions, err error) {
+	signer := &upstreamSigner{
+		authority: upstream,
+		options: util.CertOptions{
+			TTL:        caCertTTL,
+			Org:        org,
+			IsCA:       true,
+			RSAKeySize: keySize,
+		},
+		rootCertFile: rootCertFile,
+		client:       client,
+		namespace:    namespace,
+	}
+
+	caOpts = &IstioCAOptions{
+		CAType:     upstreamCA,
+		CertTTL:    certTTL,
+		MaxCertTTL: maxCertTTL,
+		crlStore:   configmap.NewController(namespace, client),
+		upstream:   signer,
+	}
+	keyCert, err := signer.current(time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the CA certificate signed by the upstream authority (%v)", err)
+	}
+	rootCerts, err := appendRootCerts(keyCert.rootCert, rootCertFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to append root certificates (%v)", err)
+	}
+	if caOpts.KeyCertBundle, err = util.NewVerifiedKeyCertBundleFromPem(keyCert.signingCert, keyCert.signingKey,
+		keyCert.certChain, rootCerts); err != nil {
+		return nil, fmt.Errorf("failed to create CA KeyCertBundle (%v)", err)
+	}
+	if cert, _, _, _ := caOpts.KeyCertBundle.GetAll(); cert.NotAfter.Sub(cert.NotBefore) < 2*maxCertTTL {
+		log.Warnf("The CA certificate signed by the upstream authority expires at %v, the workload certificates "+
+			"may expire after it is renewed", cert.NotAfter)
+	}
+	log.Infof("Using the CA certificate signed by the upstream authority, with root: %v", string(rootCerts))
+
+	if err = updateCertInConfigmap(namespace, client, rootCerts); err != nil {
+		log.Errorf("Failed to write Citadel cert to configmap (%v). Node agents will not be able to connect.", err)
+	}
+	return caOpts, nil
+}
+
+// current returns the CA key and cert persisted in the CA secret. If there are none, or if they are past their
+// renewal time, a new CA key and cert are signed by the upstream authority and persisted first, unless another
+// Citadel replica persists its own in the meantime.
+func (s *upstreamSigner) current(now time.Time) (*upstreamKeyCert, error) {
+	secret, keyCert, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	if keyCert != nil {
+		cert, err := util.ParsePemEncodedCertificate(keyCert.signingCert)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the CA certificate of the CA secret (%v)", err)
+		}
+		if now.Before(intermediateRenewalTime(cert)) {
+			return keyCert, nil
+		}
+	}
+
+	signed, err := s.sign()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.write(secret, signed); err != nil {
+		if !errors.IsAlreadyExists(err) && !errors.IsConflict(err) {
+			return nil, fmt.Errorf("failed to write the CA secret (%v)", err)
+		}
+		// Another replica has persisted its CA key and cert first.
+		if _, keyCert, err = s.read(); err != nil || keyCert == nil {
+			return nil, fmt.Errorf("failed to read the CA secret written by another replica (%v)", err)
+		}
+		return keyCert, nil
+	}
+	log.Infof("Persisted the CA certificate signed by the upstream authority in secret %s/%s", s.namespace, CASecret)
+	return signed, nil
+}
+
+// read returns the CA secret and the CA key and cert it holds, or nil if the secret doesn't exist yet.
+func (s *upstreamSigner) read() (*v1.Secret, *upstreamKeyCert, error) {
+	secret, err := s.client.Secrets(s.namespace).Get(CASecret, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil, nil
+		}
+		return nil, nil, fmt.Errorf("failed to get the CA secret %s/%s (%v)", s.namespace, CASecret, err)
+	}
+	keyCert := &upstreamKeyCert{
+		signingCert: secret.Data[caCertID],
+		signingKey:  secret.Data[caPrivateKeyID],
+		certChain:   secret.Data[CertChainID],
+		rootCert:    secret.Data[RootCertID],
+	}
+	// The secret of a self-signed CA is not overwritten, as its key would be lost.
+	if len(keyCert.signingCert) == 0 || len(keyCert.signingKey) == 0 || len(keyCert.certChain) == 0 ||
+		len(keyCert.rootCert) == 0 {
+		return nil, nil, fmt.Errorf("the CA secret %s/%s does not hold a CA certificate signed by the upstream "+
+			"authority", s.namespace, CASecret)
+	}
+	return secret, keyCert, nil
+}
+
+// write persists the CA key and cert in the CA secret, creating it if secret is nil. The update fails if the
+// secret has changed since it was read.
+func (s *upstreamSigner) write(secret *v1.Secret, keyCert *upstreamKeyCert) error {
+	var err error
+	if secret == nil {
+		_, err = s.client.Secrets(s.namespace).Create(BuildSecret("", CASecret, s.namespace, keyCert.certChain, nil,
+			keyCert.rootCert, keyCert.signingCert, keyCert.signingKey, istioCASecretType))
+		return err
+	}
+	secret.Data[caCertID] = keyCert.signingCert
+	secret.Data[caPrivateKeyID] = keyCert.signingKey
+	secret.Data[CertChainID] = keyCert.certChain
+	secret.Data[RootCertID] = keyCert.rootCert
+	_, err = s.client.Secrets(s.namespace).Update(secret)
+	return err
+}
+
+// sign generates a new CA key, and gets its certificate signed by the upstream authority.
+func (s *upstreamSigner) sign() (*upstreamKeyCert, error) {
+	csrPEM, keyPEM, err := util.GenCSR(s.options)
+	if err != nil {
+		return nil, err
+	}
+	certChain, rootCert, err := s.authority.SignIntermediate(csrPEM, s.options.TTL)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(certChain)
+	if block == nil {
+		return nil, fmt.Errorf("invalid PEM encoded certificate chain")
+	}
+	signingCert := pem.EncodeToMemory(block)
+	cert, err := util.ParsePemEncodedCertificate(signingCert)
+	if err != nil {
+		return nil, err
+	}
+	if !cert.IsCA {
+		return nil, fmt.Errorf("certificate is not authorized to sign other certificates")
+	}
+	return &upstreamKeyCert{
+		signingCert: signingCert,
+		signingKey:  keyPEM,
+		certChain:   certChain,
+		rootCert:    rootCert,
+	}, nil
+}
+
+// intermediateRenewalTime returns the time after which the CA certificate is renewed.
+func intermediateRenewalTime(cert *x509.Certificate) time.Time {
+	lifetime := cert.NotAfter.Sub(cert.NotBefore)
+	return cert.NotBefore.Add(time.Duration(float64(lifetime) * intermediateRenewalRatio))
+}
+
+// RunIntermediateRenewal checks the CA certificate signed by the upstream authority periodically, and renews it
+// after half of its lifetime. The CA certificates that were issued by the previous CA certificate are still
+// valid, as long as it doesn't expire.
+func (ca *IstioCA) RunIntermediateRenewal(client corev1.CoreV1Interface, namespace string, stopCh <-chan struct{}) {
+	if ca.upstream == nil {
+		return
+	}
+	ticker := time.NewTicker(intermediateRenewalCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := ca.renewIntermediate(client, namespace, time.Now()); err != nil {
+				log.Errorf("Failed to renew the CA certificate signed by the upstream authority: %v", err)
+			}
+		case <-stopCh:
+			return
+		}
+	}
+}
+
+// renewIntermediate renews the CA certificate signed by the upstream authority if it is past its renewal time.
+// The CA key and cert already renewed by another Citadel replica are used if any.
+func (ca *IstioCA) renewIntermediate(client corev1.CoreV1Interface, namespace string, now time.Time) error {
+	cert, _, _, currentRootCerts := ca.keyCertBundle.GetAll()
+	if now.Before(intermediateRenewalTime(cert)) {
+		return nil
+	}
+
+	keyCert, err := ca.upstream.current(now)
+	if err != nil {
+		return err
+	}
+	rootCerts, err := appendRootCerts(keyCert.rootCert, ca.upstream.rootCertFile)
+	if err != nil {
+		return fmt.Errorf("failed to append root certificates (%v)", err)
+	}
+	if err = ca.setSigningKeyCert(keyCert.signingCert, keyCert.signingKey, keyCert.certChain, rootCerts); err != nil {
+		return err
+	}
+	log.Infof("Renewed the CA certificate signed by the upstream authority")
+
+	if bytes.Equal(currentRootCerts, rootCerts) {
+		return nil
+	}
+	return updateCertInConfigmap(namespace, client, rootCerts)
+}
+
+// setSigningKeyCert replaces the signing key and cert of the CA, and re-signs the CRL with the new key. The CRL
+// published with the previous key is merged first, as it is ignored once the key has changed.
+func (ca *IstioCA) setSigningKeyCert(signingCert, signingKey, certChain, rootCerts []byte) error {
+	previousCert, _, _, _ := ca.keyCertBundle.GetAll()
+	rl := ca.revocations
+	rl.mutex.Lock()
+	defer rl.mutex.Unlock()
+	if err := ca.mergeStoredCRLLocked(previousCert); err != nil {
+		log.Warnf("Failed to read the CRL published with the previous CA key: %v", err)
+	}
+
+	if err := ca.keyCertBundle.VerifyAndSetAll(signingCert, signingKey, certChain, rootCerts); err != nil {
+		return fmt.Errorf("failed to update the CA KeyCertBundle (%v)", err)
+	}
+	if _, err := ca.publishCRLLocked(time.Now()); err != nil {
+		return fmt.Errorf("failed to re-sign the CRL with the new CA key (%v)", err)
+	}
+	return nil
+}
@@ -0,0 +1,229 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ca
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+// fakeUpstream signs the intermediate CA certificates with a self-signed root.
+type fakeUpstream struct {
+	rootCert *x509.Certificate
+	rootKey  interface{}
+	rootPem  []byte
+	err      error
+	signed   int
+}
+
+func newFakeUpstream(t *testing.T) *fakeUpstream {
+	rootPem, rootKeyPem, err := util.GenCertKeyFromOptions(util.CertOptions{
+		TTL:          24 * time.Hour,
+		Org:          "upstream.org",
+		IsCA:         true,
+		IsSelfSigned: true,
+		RSAKeySize:   2048,
+	})
+	if err != nil {
+		t.Fatalf("Failed to generate the upstream root: %v", err)
+	}
+	rootCert, err := util.ParsePemEncodedCertificate(rootPem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rootKey, err := util.ParsePemEncodedKey(rootKeyPem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &fakeUpstream{rootCert: rootCert, rootKey: rootKey, rootPem: rootPem}
+}
+
+func (u *fakeUpstream) SignIntermediate(csrPEM []byte, ttl time.Duration) ([]byte, []byte, error) {
+	if u.err != nil {
+		return nil, nil, u.err
+	}
+	csr, err := util.ParsePemEncodedCSR(csrPEM)
+	if err != nil {
+		return nil, nil, err
+	}
+	der, err := util.GenCertFromCSR(csr, u.rootCert, csr.PublicKey, u.rootKey, nil, ttl, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	u.signed++
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), u.rootPem, nil
+}
+
+func TestUpstreamIstioCA(t *testing.T) {
+	const namespace = "default"
+	client := fake.NewSimpleClientset()
+	upstream := newFakeUpstream(t)
+
+	caopts, err := NewUpstreamIstioCAOptions(upstream, time.Hour, 10*time.Minute, 20*time.Minute,
+		"test.ca.org", 2048, namespace, client.CoreV1(), "")
+	if err != nil {
+		t.Fatalf("Failed to create upstream CA Options: %v", err)
+	}
+	ca, err := NewIstioCA(caopts)
+	if err != nil {
+		t.Fatalf("Failed to create upstream CA: %v", err)
+	}
+
+	signWorkload := func() {
+		t.Helper()
+		csrPEM, keyPEM, err := util.GenCSR(util.CertOptions{Host: "spiffe://test.com/ns/default/sa/foo", RSAKeySize: 2048})
+		if err != nil {
+			t.Fatal(err)
+		}
+		certPEM, err := ca.Sign(csrPEM, []string{"spiffe://test.com/ns/default/sa/foo"}, time.Minute, false)
+		if err != nil {
+			t.Fatalf("Failed to sign the workload certificate: %v", err)
+		}
+		certChainPEM := append(certPEM, ca.GetCAKeyCertBundle().GetCertChainPem()...)
+		if err := util.VerifyCertificate(keyPEM, certChainPEM, upstream.rootPem, &util.VerifyFields{
+			ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+			KeyUsage:    x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+			Host:        "spiffe://test.com/ns/default/sa/foo",
+		}); err != nil {
+			t.Errorf("Failed to verify the workload certificate against the upstream root: %v", err)
+		}
+	}
+	signWorkload()
+
+	signingCert, _, _, rootCerts := ca.GetCAKeyCertBundle().GetAllPem()
+	if !bytes.Equal(rootCerts, upstream.rootPem) {
+		t.Errorf("Unexpected root certs: %s", rootCerts)
+	}
+
+	// The CA key and cert are persisted, and used by the other replicas and after a restart.
+	secret, err := client.CoreV1().Secrets(namespace).Get(CASecret, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("The CA certificate is not persisted: %v", err)
+	}
+	if !bytes.Equal(secret.Data[caCertID], signingCert) || !bytes.Equal(secret.Data[RootCertID], upstream.rootPem) {
+		t.Errorf("Unexpected CA secret: %v", secret.Data)
+	}
+	replicaOpts, err := NewUpstreamIstioCAOptions(upstream, time.Hour, 10*time.Minute, 20*time.Minute,
+		"test.ca.org", 2048, namespace, client.CoreV1(), "")
+	if err != nil {
+		t.Fatalf("Failed to create upstream CA Options from the CA secret: %v", err)
+	}
+	if cert, _, _, _ := replicaOpts.KeyCertBundle.GetAllPem(); !bytes.Equal(cert, signingCert) {
+		t.Error("The persisted CA certificate is not reused")
+	}
+
+	// No renewal before half of the lifetime of the CA certificate.
+	if err := ca.renewIntermediate(client.CoreV1(), namespace, time.Now()); err != nil {
+		t.Fatalf("Failed to renew the CA certificate: %v", err)
+	}
+	if upstream.signed != 1 {
+		t.Errorf("Unexpected renewal (%d signed CA certificates)", upstream.signed)
+	}
+
+	// A failed renewal keeps the current CA certificate.
+	upstream.err = fmt.Errorf("upstream unavailable")
+	if err := ca.renewIntermediate(client.CoreV1(), namespace, time.Now().Add(time.Hour)); err == nil {
+		t.Error("Expecting an error when the upstream authority is unavailable")
+	}
+	if cert, _, _, _ := ca.GetCAKeyCertBundle().GetAllPem(); !bytes.Equal(cert, signingCert) {
+		t.Error("The CA certificate is replaced after a failed renewal")
+	}
+
+	// The revocations made with the previous CA key are carried over to the CRL signed with the new one.
+	revoked := big.NewInt(42)
+	if _, err := ca.Revoke([]*big.Int{revoked}); err != nil {
+		t.Fatalf("Failed to revoke: %v", err)
+	}
+
+	// The renewed CA certificate lives longer, so that it is not past its renewal time when the replica renews.
+	upstream.err = nil
+	ca.upstream.options.TTL = 4 * time.Hour
+	if err := ca.renewIntermediate(client.CoreV1(), namespace, time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("Failed to renew the CA certificate: %v", err)
+	}
+	renewedCert, _, _, _ := ca.GetCAKeyCertBundle().GetAllPem()
+	if bytes.Equal(renewedCert, signingCert) {
+		t.Error("The CA certificate is not renewed")
+	}
+	signWorkload()
+
+	crlPEM, err := ca.GetCRL()
+	if err != nil {
+		t.Fatalf("Failed to get the CRL: %v", err)
+	}
+	if serials := parseAndVerifyCRL(t, ca, crlPEM); !serials[revoked.Text(16)] {
+		t.Errorf("The revocation is dropped by the renewal: %v", serials)
+	}
+	restarted, err := NewIstioCA(caopts)
+	if err != nil {
+		t.Fatalf("Failed to create upstream CA: %v", err)
+	}
+	if _, ok := restarted.revocations.revoked[revoked.Text(16)]; !ok {
+		t.Errorf("The revocation is not restored from the CRL signed with the renewed CA key")
+	}
+
+	// The renewed CA key and cert are persisted, and used by the replicas renewing after.
+	if secret, err = client.CoreV1().Secrets(namespace).Get(CASecret, metav1.GetOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(secret.Data[caCertID], renewedCert) {
+		t.Error("The renewed CA certificate is not persisted")
+	}
+	replica, err := NewIstioCA(replicaOpts)
+	if err != nil {
+		t.Fatalf("Failed to create upstream CA: %v", err)
+	}
+	signed := upstream.signed
+	if err := replica.renewIntermediate(client.CoreV1(), namespace, time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("Failed to renew the CA certificate of the replica: %v", err)
+	}
+	if cert, _, _, _ := replica.GetCAKeyCertBundle().GetAllPem(); !bytes.Equal(cert, renewedCert) {
+		t.Error("The replica does not use the renewed CA certificate")
+	}
+	if upstream.signed != signed {
+		t.Errorf("The replica gets another CA certificate signed")
+	}
+}
+
+func TestUpstreamIstioCAErrors(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	upstream := newFakeUpstream(t)
+	upstream.err = fmt.Errorf("upstream unavailable")
+	if _, err := NewUpstreamIstioCAOptions(upstream, time.Hour, 10*time.Minute, 20*time.Minute,
+		"test.ca.org", 2048, "default", client.CoreV1(), ""); err == nil {
+		t.Error("Expecting an error when the upstream authority is unavailable")
+	}
+
+	// The secret of a self-signed CA is not overwritten.
+	upstream.err = nil
+	if _, err := client.CoreV1().Secrets("default").Create(BuildSecret("", CASecret, "default", nil, nil, nil,
+		upstream.rootPem, []byte("key"), istioCASecretType)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewUpstreamIstioCAOptions(upstream, time.Hour, 10*time.Minute, 20*time.Minute,
+		"test.ca.org", 2048, "default", client.CoreV1(), ""); err == nil {
+		t.Error("Expecting an error when the CA secret holds a self-signed CA")
+	}
+}
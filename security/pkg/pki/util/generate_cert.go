@@ -20,6 +20,8 @@ package util
 
 import (
 	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
@@ -34,6 +36,15 @@ import (
 	"istio.io/pkg/log"
 )
 
+// SupportedECSignatureAlgorithms are the types of EC signature algorithms
+// to be used in key generation.
+type SupportedECSignatureAlgorithms string
+
+const (
+	// EcdsaSigAlg generates ECDSA keys on the P-256 curve.
+	EcdsaSigAlg SupportedECSignatureAlgorithms = "ECDSA"
+)
+
 // CertOptions contains options for generating a new certificate.
 type CertOptions struct {
 	// Comma-separated hostnames and IPs to generate a certificate for.
@@ -59,6 +70,10 @@ type CertOptions struct {
 	// The size of RSA private key to be generated.
 	RSAKeySize int
 
+	// The EC signature algorithm of the private key to be generated. If empty,
+	// an RSA key of RSAKeySize is generated instead.
+	ECSigAlg SupportedECSignatureAlgorithms
+
 	// Whether this certificate is used as signing cert for CA.
 	IsCA bool
 
@@ -80,14 +95,14 @@ type CertOptions struct {
 
 // GenCertKeyFromOptions generates a X.509 certificate and a private key with the given options.
 func GenCertKeyFromOptions(options CertOptions) (pemCert []byte, pemKey []byte, err error) {
-	// Generate a RSA private&public key pair.
+	// Generate a RSA or EC private&public key pair.
 	// The public key will be bound to the certificate generated below. The
 	// private key will be used to sign this certificate in the self-signed
 	// case, otherwise the certificate is signed by the signer private key
 	// as specified in the CertOptions.
-	priv, err := rsa.GenerateKey(rand.Reader, options.RSAKeySize)
+	priv, err := genPrivateKey(options)
 	if err != nil {
-		return nil, nil, fmt.Errorf("cert generation fails at RSA key generation (%v)", err)
+		return nil, nil, fmt.Errorf("cert generation fails at key generation (%v)", err)
 	}
 	template, err := genCertTemplateFromOptions(options)
 	if err != nil {
@@ -97,7 +112,7 @@ func GenCertKeyFromOptions(options CertOptions) (pemCert []byte, pemKey []byte,
 	if !options.IsSelfSigned {
 		signerCert, signerKey = options.SignerCert, options.SignerPriv
 	}
-	certBytes, err := x509.CreateCertificate(rand.Reader, template, signerCert, &priv.PublicKey, signerKey)
+	certBytes, err := x509.CreateCertificate(rand.Reader, template, signerCert, priv.Public(), signerKey)
 	if err != nil {
 		return nil, nil, fmt.Errorf("cert generation fails at X509 cert creation (%v)", err)
 	}
@@ -154,8 +169,8 @@ func genCertTemplateFromCSR(csr *x509.CertificateRequest, subjectIDs []string, t
 	var keyUsage x509.KeyUsage
 	extKeyUsages := []x509.ExtKeyUsage{}
 	if isCA {
-		// If the cert is a CA cert, the private key is allowed to sign other certificates.
-		keyUsage = x509.KeyUsageCertSign
+		// If the cert is a CA cert, the private key is allowed to sign other certificates and CRLs.
+		keyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign
 	} else {
 		// Otherwise the private key is allowed for digital signature and key encipherment.
 		keyUsage = x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment
@@ -198,16 +213,17 @@ func genCertTemplateFromCSR(csr *x509.CertificateRequest, subjectIDs []string, t
 		ExtKeyUsage:           extKeyUsages,
 		IsCA:                  isCA,
 		BasicConstraintsValid: true,
-		ExtraExtensions:       exts,
-		SignatureAlgorithm:    csr.SignatureAlgorithm}, nil
+		// The signature algorithm is derived from the signing key, which does not need to be of the
+		// same type as the key in the CSR.
+		ExtraExtensions: exts}, nil
 }
 
 // genCertTemplateFromoptions generates a certificate template with the given options.
 func genCertTemplateFromOptions(options CertOptions) (*x509.Certificate, error) {
 	var keyUsage x509.KeyUsage
 	if options.IsCA {
-		// If the cert is a CA cert, the private key is allowed to sign other certificates.
-		keyUsage = x509.KeyUsageCertSign
+		// If the cert is a CA cert, the private key is allowed to sign other certificates and CRLs.
+		keyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign
 	} else {
 		// Otherwise the private key is allowed for digital signature and key encipherment.
 		keyUsage = x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment
@@ -274,7 +290,20 @@ func genSerialNum() (*big.Int, error) {
 	return serialNum, nil
 }
 
-func encodePem(isCSR bool, csrOrCert []byte, priv *rsa.PrivateKey, pkcs8 bool) (
+// genPrivateKey generates an ECDSA key if an EC signature algorithm is set in the options, otherwise
+// it generates an RSA key.
+func genPrivateKey(options CertOptions) (crypto.Signer, error) {
+	switch options.ECSigAlg {
+	case "":
+		return rsa.GenerateKey(rand.Reader, options.RSAKeySize)
+	case EcdsaSigAlg:
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	default:
+		return nil, fmt.Errorf("unsupported EC signature algorithm: %s", options.ECSigAlg)
+	}
+}
+
+func encodePem(isCSR bool, csrOrCert []byte, priv crypto.PrivateKey, pkcs8 bool) (
 	csrOrCertPem []byte, privPem []byte, err error) {
 	encodeMsg := "CERTIFICATE"
 	if isCSR {
@@ -289,8 +318,18 @@ func encodePem(isCSR bool, csrOrCert []byte, priv *rsa.PrivateKey, pkcs8 bool) (
 		}
 		privPem = pem.EncodeToMemory(&pem.Block{Type: blockTypePKCS8PrivateKey, Bytes: encodedKey})
 	} else {
-		encodedKey = x509.MarshalPKCS1PrivateKey(priv)
-		privPem = pem.EncodeToMemory(&pem.Block{Type: blockTypeRSAPrivateKey, Bytes: encodedKey})
+		switch k := priv.(type) {
+		case *rsa.PrivateKey:
+			encodedKey = x509.MarshalPKCS1PrivateKey(k)
+			privPem = pem.EncodeToMemory(&pem.Block{Type: blockTypeRSAPrivateKey, Bytes: encodedKey})
+		case *ecdsa.PrivateKey:
+			if encodedKey, err = x509.MarshalECPrivateKey(k); err != nil {
+				return nil, nil, err
+			}
+			privPem = pem.EncodeToMemory(&pem.Block{Type: blockTypeECPrivateKey, Bytes: encodedKey})
+		default:
+			return nil, nil, fmt.Errorf("unsupported private key type %T", priv)
+		}
 	}
 	err = nil
 	return
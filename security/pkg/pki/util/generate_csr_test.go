@@ -15,6 +15,7 @@
 package util
 
 import (
+	"crypto/ecdsa"
 	"crypto/x509"
 	"encoding/pem"
 	"strings"
@@ -54,6 +55,41 @@ func TestGenCSR(t *testing.T) {
 	}
 }
 
+func TestGenCSRWithECDSA(t *testing.T) {
+	csrOptions := CertOptions{
+		Host:     "test_ca.com",
+		Org:      "MyOrg",
+		ECSigAlg: EcdsaSigAlg,
+	}
+
+	csrPem, keyPem, err := GenCSR(csrOptions)
+	if err != nil {
+		t.Fatalf("failed to gen CSR: %v", err)
+	}
+
+	pemBlock, _ := pem.Decode(csrPem)
+	if pemBlock == nil {
+		t.Fatalf("failed to decode csr")
+	}
+	csr, err := x509.ParseCertificateRequest(pemBlock.Bytes)
+	if err != nil {
+		t.Fatalf("failed to parse csr: %v", err)
+	}
+	if err = csr.CheckSignature(); err != nil {
+		t.Errorf("csr signature is invalid: %v", err)
+	}
+	if csr.PublicKeyAlgorithm != x509.ECDSA {
+		t.Errorf("unexpected public key algorithm %v", csr.PublicKeyAlgorithm)
+	}
+	key, err := ParsePemEncodedKey(keyPem)
+	if err != nil {
+		t.Fatalf("failed to parse key: %v", err)
+	}
+	if _, ok := key.(*ecdsa.PrivateKey); !ok {
+		t.Errorf("expected an ECDSA private key, got %T", key)
+	}
+}
+
 func TestGenCSRWithInvalidOption(t *testing.T) {
 	// Options with invalid Key size.
 	csrOptions := CertOptions{
@@ -67,6 +103,19 @@ func TestGenCSRWithInvalidOption(t *testing.T) {
 	if err == nil || csr != nil || priv != nil {
 		t.Errorf("Should have failed")
 	}
+
+	// Options with an unsupported EC signature algorithm.
+	csrOptions = CertOptions{
+		Host:     "test_ca.com",
+		Org:      "MyOrg",
+		ECSigAlg: "ED25519",
+	}
+
+	csr, priv, err = GenCSR(csrOptions)
+
+	if err == nil || csr != nil || priv != nil {
+		t.Errorf("Should have failed")
+	}
 }
 
 func TestGenCSRTemplateForDualUse(t *testing.T) {
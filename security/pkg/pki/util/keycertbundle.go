@@ -20,6 +20,7 @@ package util
 
 import (
 	"crypto"
+	"crypto/ecdsa"
 	"crypto/tls"
 	"crypto/x509"
 	"fmt"
@@ -186,18 +187,24 @@ func (b *KeyCertBundleImpl) CertOptions() (*CertOptions, error) {
 	if len(ids) != 1 {
 		return nil, fmt.Errorf("expect single id from the cert, found %v", ids)
 	}
-	size, err := GetRSAKeySize(*b.privKey)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get RSA key size: %v", err)
-	}
-	return &CertOptions{
-		Host:       ids[0],
-		Org:        b.cert.Issuer.Organization[0],
-		IsCA:       b.cert.IsCA,
-		TTL:        b.cert.NotAfter.Sub(b.cert.NotBefore),
-		RSAKeySize: size,
-		IsDualUse:  ids[0] == b.cert.Subject.CommonName,
-	}, nil
+	opts := &CertOptions{
+		Host:      ids[0],
+		Org:       b.cert.Issuer.Organization[0],
+		IsCA:      b.cert.IsCA,
+		TTL:       b.cert.NotAfter.Sub(b.cert.NotBefore),
+		IsDualUse: ids[0] == b.cert.Subject.CommonName,
+	}
+	switch (*b.privKey).(type) {
+	case *ecdsa.PrivateKey:
+		opts.ECSigAlg = EcdsaSigAlg
+	default:
+		size, err := GetRSAKeySize(*b.privKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get RSA key size: %v", err)
+		}
+		opts.RSAKeySize = size
+	}
+	return opts, nil
 }
 
 // Verify that the cert chain, root cert and key/cert match.
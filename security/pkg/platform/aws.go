@@ -15,13 +15,17 @@
 package platform
 
 import (
+	"crypto/rand"
 	"crypto/x509"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"os"
 
 	"github.com/aws/aws-sdk-go/aws/ec2metadata"
 	"github.com/aws/aws-sdk-go/aws/session"
+	"golang.org/x/net/context"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
 
@@ -49,20 +53,27 @@ BQADgYEAFYcz1OgEhQBXIwIdsgCOS8vEtiJYF+j9uO6jz7VOmJqO+pRlAbRlvY8T
 C1haGgSI/A1uZUKs/Zfnph0oEI0/hu1IIJ/SKBDtN5lvmZ/IzbOPIJWirlsllQIQ
 7zvWbGd9c9+Rm3p04oTvhup99la7kZqevJK0QRdD/6NpCKsqP/0=
 -----END CERTIFICATE-----`
+
+	// awsNonceSize is the size of the nonce the instance binds its identity to on its first authentication.
+	awsNonceSize = 32
 )
 
 // AwsClientImpl is the implementation of AWS metadata client.
 type AwsClientImpl struct {
 	// Root CA cert file to validate the gRPC service in CA.
 	rootCertFile string
+	// File of the nonce the instance is bound to by the CA. It is created on the first authentication, and must
+	// only be readable by the node agent.
+	nonceFile string
 
 	client *ec2metadata.EC2Metadata
 }
 
 // NewAwsClientImpl creates a new AwsClientImpl.
-func NewAwsClientImpl(rootCert string) *AwsClientImpl {
+func NewAwsClientImpl(rootCert, nonceFile string) *AwsClientImpl {
 	return &AwsClientImpl{
 		rootCertFile: rootCert,
+		nonceFile:    nonceFile,
 		client:       ec2metadata.New(session.Must(session.NewSession())),
 	}
 }
@@ -74,10 +85,38 @@ func (ci *AwsClientImpl) GetDialOptions() ([]grpc.DialOption, error) {
 		return nil, err
 	}
 
-	options := []grpc.DialOption{grpc.WithTransportCredentials(creds)}
+	options := []grpc.DialOption{grpc.WithPerRPCCredentials(&awsInstanceIdentity{ci}),
+		grpc.WithTransportCredentials(creds)}
 	return options, nil
 }
 
+// awsInstanceIdentity authenticates the calls to the CA with the signed instance identity document, which is
+// sent as a bearer token: the base64url encoded document, signature and nonce, separated by ".". As the document
+// never expires, the CA binds the instance to the nonce on its first authentication.
+type awsInstanceIdentity struct {
+	client *AwsClientImpl
+}
+
+func (a *awsInstanceIdentity) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	doc, signature, err := a.client.getSignedInstanceIdentityDocument()
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := a.client.getNonce()
+	if err != nil {
+		return nil, err
+	}
+	token := base64.RawURLEncoding.EncodeToString(doc) + "." + base64.RawURLEncoding.EncodeToString(signature) +
+		"." + base64.RawURLEncoding.EncodeToString(nonce)
+	return map[string]string{
+		httpAuthHeader: fmt.Sprintf("%s %s", bearerTokenScheme, token),
+	}, nil
+}
+
+func (a *awsInstanceIdentity) RequireTransportSecurity() bool {
+	return true
+}
+
 // IsProperPlatform returns whether the AWS platform client is available.
 func (ci *AwsClientImpl) IsProperPlatform() bool {
 	return ci.client.Available()
@@ -89,32 +128,58 @@ func (ci *AwsClientImpl) GetServiceIdentity() (string, error) {
 	return "", nil
 }
 
+// getNonce returns the nonce of the instance, generating it if it does not exist yet.
+func (ci *AwsClientImpl) getNonce() ([]byte, error) {
+	nonce, err := ioutil.ReadFile(ci.nonceFile)
+	if err == nil {
+		return nonce, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read the instance nonce: %v", err)
+	}
+
+	nonce = make([]byte, awsNonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate the instance nonce: %v", err)
+	}
+	if err := ioutil.WriteFile(ci.nonceFile, nonce, 0600); err != nil {
+		return nil, fmt.Errorf("failed to write the instance nonce: %v", err)
+	}
+	return nonce, nil
+}
+
 func (ci *AwsClientImpl) getInstanceIdentityDocument() ([]byte, error) {
+	doc, _, err := ci.getSignedInstanceIdentityDocument()
+	return doc, err
+}
+
+// getSignedInstanceIdentityDocument returns the instance identity document and its signature.
+func (ci *AwsClientImpl) getSignedInstanceIdentityDocument() ([]byte, []byte, error) {
 	cert, err := util.ParsePemEncodedCertificate([]byte(AWSCertificatePem))
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse AWS public certificate: %v", err)
+		return nil, nil, fmt.Errorf("failed to parse AWS public certificate: %v", err)
 	}
 
 	doc, err := ci.client.GetDynamicData("instance-identity/document")
 	if err != nil {
-		return nil, fmt.Errorf("failed to get EC2 instance identity document: %v", err)
+		return nil, nil, fmt.Errorf("failed to get EC2 instance identity document: %v", err)
 	}
 
 	resp, err := ci.client.GetDynamicData("instance-identity/signature")
 	if err != nil {
-		return nil, fmt.Errorf("failed to get EC2 instance identity signature: %v", err)
+		return nil, nil, fmt.Errorf("failed to get EC2 instance identity signature: %v", err)
 	}
 
 	dec, err := base64.StdEncoding.DecodeString(resp)
 	if err != nil {
-		return nil, fmt.Errorf("failed to decode EC2 instance identity signature: %v", err)
+		return nil, nil, fmt.Errorf("failed to decode EC2 instance identity signature: %v", err)
 	}
 
 	if err := cert.CheckSignature(x509.SHA256WithRSA, []byte(doc), dec); err != nil {
-		return nil, fmt.Errorf("failed to verify PKCS7 signature: %v", err)
+		return nil, nil, fmt.Errorf("failed to verify PKCS7 signature: %v", err)
 	}
 
-	return []byte(doc), nil
+	return []byte(doc), dec, nil
 }
 
 // GetAgentCredential retrieves the instance identity document as the
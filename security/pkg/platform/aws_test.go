@@ -21,6 +21,8 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -81,7 +83,7 @@ func TestIsProperPlatform(t *testing.T) {
 }
 
 func TestNewAwsClientImpl(t *testing.T) {
-	client := NewAwsClientImpl("")
+	client := NewAwsClientImpl("", "")
 	if client == nil {
 		t.Errorf("NewAwsClientImpl should not return nil")
 	}
@@ -267,6 +269,7 @@ func TestAwsGetDialOptions(t *testing.T) {
 			expectedErr:  "",
 			rootCertFile: "testdata/cert-chain-good.pem",
 			expectedOptions: []grpc.DialOption{
+				grpc.WithPerRPCCredentials(&awsInstanceIdentity{}),
 				grpc.WithTransportCredentials(creds),
 			},
 		},
@@ -322,3 +325,41 @@ func TestAwsGetCredentialTypes(t *testing.T) {
 		}
 	}
 }
+
+func TestAwsGetNonce(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "aws_nonce")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	awsc := &AwsClientImpl{nonceFile: filepath.Join(tmpdir, "nonce")}
+	nonce, err := awsc.getNonce()
+	if err != nil {
+		t.Fatalf("failed to generate the nonce: %v", err)
+	}
+	if len(nonce) != awsNonceSize {
+		t.Errorf("unexpected nonce size: want %d but got %d", awsNonceSize, len(nonce))
+	}
+	info, err := os.Stat(awsc.nonceFile)
+	if err != nil {
+		t.Fatalf("the nonce is not persisted: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("unexpected permissions of the nonce file: %v", info.Mode().Perm())
+	}
+
+	// The instance keeps presenting the nonce it is bound to.
+	persisted, err := awsc.getNonce()
+	if err != nil {
+		t.Fatalf("failed to read the nonce: %v", err)
+	}
+	if !bytes.Equal(nonce, persisted) {
+		t.Errorf("the nonce changed between the authentications")
+	}
+
+	awsc.nonceFile = filepath.Join(tmpdir, "missing", "nonce")
+	if _, err := awsc.getNonce(); err == nil {
+		t.Errorf("expecting an error when the nonce can not be written")
+	}
+}
@@ -0,0 +1,380 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authenticate
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	oidc "github.com/coreos/go-oidc"
+	"github.com/ghodss/yaml"
+	"golang.org/x/net/context"
+
+	"istio.io/istio/security/pkg/platform"
+)
+
+const (
+	InstanceIdentityAuthenticatorType = "InstanceIdentityAuthenticator"
+
+	// PlatformAWS is the platform of the EC2 instances, authenticated by their signed instance identity document.
+	PlatformAWS = "aws"
+	// PlatformGCP is the platform of the GCE instances, authenticated by their instance identity token.
+	PlatformGCP = "gcp"
+
+	gcpInstanceIdentityIssuer = "https://accounts.google.com"
+	gcpCertsURL               = "https://www.googleapis.com/oauth2/v3/certs"
+
+	// awsMinNonceSize is the minimum size of the nonce the AWS instances bind their identity to.
+	awsMinNonceSize = 16
+)
+
+// InstanceIdentityConfig is the configuration of the InstanceIdentityAuthenticator. The instances of a platform
+// are only authenticated if the platform is configured.
+type InstanceIdentityConfig struct {
+	AWS *AWSInstanceIdentityConfig `json:"aws,omitempty"`
+	GCP *GCPInstanceIdentityConfig `json:"gcp,omitempty"`
+
+	// Mappings map the instances to the service accounts of their workloads. The first matching mapping is used.
+	Mappings []InstanceIdentityMapping `json:"mappings"`
+}
+
+// AWSInstanceIdentityConfig configures the authentication of the EC2 instances.
+type AWSInstanceIdentityConfig struct {
+	// CertificateFile is the file of the PEM encoded AWS certificates verifying the signature of the instance
+	// identity documents. It defaults to the AWS certificate of the public regions.
+	CertificateFile string `json:"certificateFile,omitempty"`
+}
+
+// GCPInstanceIdentityConfig configures the authentication of the GCE instances.
+type GCPInstanceIdentityConfig struct {
+	// Audience is the audience of the instance identity tokens, e.g. "grpc://istio-citadel:8060".
+	Audience string `json:"audience"`
+
+	// CertsURL is the URL of the JWK set verifying the signature of the instance identity tokens. It defaults to
+	// the Google OAuth2 certificates.
+	CertsURL string `json:"certsURL,omitempty"`
+}
+
+// InstanceIdentityMapping maps the instances of a cloud account to a Kubernetes service account. The mapping
+// can be narrowed down to some instances of the account, or to the instances running as some roles. Instance
+// tags and labels can not be matched, since they are not part of the signed instance identity.
+type InstanceIdentityMapping struct {
+	// Platform is either "aws" or "gcp".
+	Platform string `json:"platform"`
+
+	// Account is the AWS account ID or the GCP project ID of the instances.
+	Account string `json:"account"`
+
+	// InstanceIDs, if not empty, restricts the mapping to these instances.
+	InstanceIDs []string `json:"instanceIDs,omitempty"`
+
+	// Roles, if not empty, restricts the mapping to the instances running as these GCP service accounts, given by
+	// their email. It is not supported on AWS, whose instance identity document does not carry the instance
+	// profile.
+	Roles []string `json:"roles,omitempty"`
+
+	Namespace      string `json:"namespace"`
+	ServiceAccount string `json:"serviceAccount"`
+}
+
+// InstanceIdentity is the identity of an instance, as signed by its cloud platform.
+type InstanceIdentity struct {
+	Platform   string
+	Account    string
+	InstanceID string
+	// Role is the email of the service account of the GCP instances.
+	Role string
+}
+
+// InstanceBindingStore persists the bindings of the AWS instances to the nonce presented on their first
+// authentication. It is shared by the Citadel replicas.
+type InstanceBindingStore interface {
+	// BindInstance binds the instance unless it is already bound, and returns the binding of the instance. The
+	// check and the insertion are atomic: if another replica binds the instance concurrently, an error is returned.
+	BindInstance(instance, binding string) (string, error)
+}
+
+// InstanceIdentityAuthenticator authenticates the VM workloads by the instance identity documents signed by their
+// cloud platform, and maps them to service accounts. The document is transmitted using the "Bearer"
+// authentication scheme:
+//   - on GCP, it is the instance identity token, requested with the "full" format,
+//   - on AWS, it is the base64url encoded instance identity document, its base64url encoded signature and a
+//     base64url encoded nonce kept secret by the instance, separated by ".". Unlike the GCP token, the AWS
+//     document does not expire and can be read by any process of the instance. The instance is thus bound to
+//     the nonce presented on its first authentication, and the document is only accepted along with that nonce.
+//     The bindings are never removed: an instance that lost its nonce, e.g. when it is re-imaged, must be unbound
+//     by deleting its binding from the store.
+type InstanceIdentityAuthenticator struct {
+	awsCerts    []*x509.Certificate
+	gcpVerifier *oidc.IDTokenVerifier
+	mappings    []InstanceIdentityMapping
+	trustDomain string
+	bindings    InstanceBindingStore
+}
+
+// NewInstanceIdentityAuthenticator creates a new InstanceIdentityAuthenticator from the configuration file. The
+// bindings of the AWS instances are persisted in the store.
+func NewInstanceIdentityAuthenticator(configFile, trustDomain string,
+	bindings InstanceBindingStore) (*InstanceIdentityAuthenticator, error) {
+	data, err := ioutil.ReadFile(configFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the instance identity configuration: %v", err)
+	}
+	config := &InstanceIdentityConfig{}
+	if err := yaml.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("failed to parse the instance identity configuration: %v", err)
+	}
+	return newInstanceIdentityAuthenticator(config, trustDomain, bindings)
+}
+
+func newInstanceIdentityAuthenticator(config *InstanceIdentityConfig, trustDomain string,
+	bindings InstanceBindingStore) (*InstanceIdentityAuthenticator, error) {
+	a := &InstanceIdentityAuthenticator{
+		mappings:    config.Mappings,
+		trustDomain: trustDomain,
+		bindings:    bindings,
+	}
+
+	if config.AWS != nil {
+		if bindings == nil {
+			return nil, errors.New("the AWS instance identity documents require an instance binding store")
+		}
+		certsPEM := []byte(platform.AWSCertificatePem)
+		if config.AWS.CertificateFile != "" {
+			var err error
+			if certsPEM, err = ioutil.ReadFile(config.AWS.CertificateFile); err != nil {
+				return nil, fmt.Errorf("failed to read the AWS certificates: %v", err)
+			}
+		}
+		for rest := certsPEM; ; {
+			var block *pem.Block
+			if block, rest = pem.Decode(rest); block == nil {
+				break
+			}
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse the AWS certificates: %v", err)
+			}
+			a.awsCerts = append(a.awsCerts, cert)
+		}
+		if len(a.awsCerts) == 0 {
+			return nil, errors.New("no AWS certificate found")
+		}
+	}
+
+	if config.GCP != nil {
+		if config.GCP.Audience == "" {
+			return nil, errors.New("the audience of the GCP instance identity tokens must be specified")
+		}
+		certsURL := config.GCP.CertsURL
+		if certsURL == "" {
+			certsURL = gcpCertsURL
+		}
+		a.gcpVerifier = oidc.NewVerifier(gcpInstanceIdentityIssuer, oidc.NewRemoteKeySet(context.Background(), certsURL),
+			&oidc.Config{ClientID: config.GCP.Audience})
+	}
+
+	for i, m := range config.Mappings {
+		if m.Platform != PlatformAWS && m.Platform != PlatformGCP {
+			return nil, fmt.Errorf("mapping %d: unsupported platform %q", i, m.Platform)
+		}
+		if m.Account == "" || m.Namespace == "" || m.ServiceAccount == "" {
+			return nil, fmt.Errorf("mapping %d: the account, namespace and service account must be specified", i)
+		}
+		if m.Platform == PlatformAWS && len(m.Roles) > 0 {
+			return nil, fmt.Errorf("mapping %d: roles are not supported on AWS", i)
+		}
+	}
+	return a, nil
+}
+
+func (a *InstanceIdentityAuthenticator) AuthenticatorType() string {
+	return InstanceIdentityAuthenticatorType
+}
+
+// Authenticate authenticates the call using the instance identity document from the context.
+// The returned Caller.Identities is the SPIFFE identity of the service account the instance is mapped to.
+func (a *InstanceIdentityAuthenticator) Authenticate(ctx context.Context) (*Caller, error) {
+	token, err := extractBearerToken(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("instance identity extraction error: %v", err)
+	}
+
+	var identity *InstanceIdentity
+	if isJWT(token) {
+		identity, err = a.verifyGCPToken(ctx, token)
+	} else {
+		identity, err = a.verifyAWSToken(token)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, m := range a.mappings {
+		if m.matches(identity) {
+			return &Caller{
+				AuthSource: AuthSourceIDToken,
+				Identities: []string{fmt.Sprintf(identityTemplate, a.trustDomain, m.Namespace, m.ServiceAccount)},
+			}, nil
+		}
+	}
+	return nil, fmt.Errorf("no service account is mapped to the %s account %q of instance %q",
+		identity.Platform, identity.Account, identity.InstanceID)
+}
+
+func (m *InstanceIdentityMapping) matches(identity *InstanceIdentity) bool {
+	if m.Platform != identity.Platform || m.Account != identity.Account {
+		return false
+	}
+	if len(m.InstanceIDs) > 0 && !containsString(m.InstanceIDs, identity.InstanceID) {
+		return false
+	}
+	return len(m.Roles) == 0 || containsString(m.Roles, identity.Role)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// isJWT returns whether the token is a JWT, i.e. its first part is a JOSE header. The AWS token starts with the
+// instance identity document instead.
+func isJWT(token string) bool {
+	parts := strings.SplitN(token, ".", 2)
+	data, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return false
+	}
+	var header struct {
+		Algorithm string `json:"alg"`
+	}
+	return json.Unmarshal(data, &header) == nil && header.Algorithm != ""
+}
+
+func (a *InstanceIdentityAuthenticator) verifyGCPToken(ctx context.Context, token string) (*InstanceIdentity, error) {
+	if a.gcpVerifier == nil {
+		return nil, errors.New("GCP instance identity tokens are not accepted")
+	}
+	idToken, err := a.gcpVerifier.Verify(ctx, token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify the GCP instance identity token: %v", err)
+	}
+
+	var claims struct {
+		Email  string `json:"email"`
+		Google struct {
+			ComputeEngine struct {
+				ProjectID  string `json:"project_id"`
+				InstanceID string `json:"instance_id"`
+			} `json:"compute_engine"`
+		} `json:"google"`
+	}
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, fmt.Errorf("failed to extract the claims of the GCP instance identity token: %v", err)
+	}
+	if claims.Google.ComputeEngine.ProjectID == "" {
+		return nil, errors.New("no project in the GCP instance identity token, it must be requested with the full format")
+	}
+	return &InstanceIdentity{
+		Platform:   PlatformGCP,
+		Account:    claims.Google.ComputeEngine.ProjectID,
+		InstanceID: claims.Google.ComputeEngine.InstanceID,
+		Role:       claims.Email,
+	}, nil
+}
+
+func (a *InstanceIdentityAuthenticator) verifyAWSToken(token string) (*InstanceIdentity, error) {
+	if len(a.awsCerts) == 0 {
+		return nil, errors.New("AWS instance identity documents are not accepted")
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed AWS instance identity document")
+	}
+	doc, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode the AWS instance identity document: %v", err)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode the AWS instance identity signature: %v", err)
+	}
+	nonce, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode the AWS instance nonce: %v", err)
+	}
+	if len(nonce) < awsMinNonceSize {
+		return nil, fmt.Errorf("the AWS instance nonce must be at least %d bytes", awsMinNonceSize)
+	}
+
+	verified := false
+	for _, cert := range a.awsCerts {
+		if cert.CheckSignature(x509.SHA256WithRSA, doc, signature) == nil {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return nil, errors.New("failed to verify the signature of the AWS instance identity document")
+	}
+
+	var document struct {
+		AccountID  string `json:"accountId"`
+		InstanceID string `json:"instanceId"`
+	}
+	if err := json.Unmarshal(doc, &document); err != nil {
+		return nil, fmt.Errorf("failed to parse the AWS instance identity document: %v", err)
+	}
+	if document.AccountID == "" || document.InstanceID == "" {
+		return nil, errors.New("no account or instance in the AWS instance identity document")
+	}
+	if err := a.bindAWSInstance(document.InstanceID, nonce); err != nil {
+		return nil, err
+	}
+	return &InstanceIdentity{
+		Platform:   PlatformAWS,
+		Account:    document.AccountID,
+		InstanceID: document.InstanceID,
+	}, nil
+}
+
+// bindAWSInstance binds the instance to the nonce on its first authentication, and then verifies that the
+// instance presents the same nonce, so that the document read by another process of the instance or leaked
+// afterwards can not be replayed.
+func (a *InstanceIdentityAuthenticator) bindAWSInstance(instanceID string, nonce []byte) error {
+	digest := sha256.Sum256(nonce)
+	binding := base64.RawURLEncoding.EncodeToString(digest[:])
+	key := PlatformAWS + "." + instanceID
+	bound, err := a.bindings.BindInstance(key, binding)
+	if err != nil {
+		return err
+	}
+	if subtle.ConstantTimeCompare([]byte(bound), []byte(binding)) != 1 {
+		return fmt.Errorf("the AWS instance %q is bound to another nonce", instanceID)
+	}
+	return nil
+}
@@ -0,0 +1,433 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authenticate
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/metadata"
+	jose "gopkg.in/square/go-jose.v2"
+
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+const (
+	testGCPAudience = "grpc://istio-citadel:8060"
+	testGCPKeyID    = "test-key"
+	testAWSInstance = "i-1234567890abcdef0"
+)
+
+// fakeInstanceBindings keeps the instance bindings in memory.
+type fakeInstanceBindings map[string]string
+
+func (b fakeInstanceBindings) BindInstance(instance, binding string) (string, error) {
+	if bound, ok := b[instance]; ok {
+		return bound, nil
+	}
+	b[instance] = binding
+	return binding, nil
+}
+
+// fakeCloud signs the instance identity documents with locally generated keys.
+type fakeCloud struct {
+	awsKey      *rsa.PrivateKey
+	awsCertFile string
+	gcpKey      *rsa.PrivateKey
+	gcpCerts    *httptest.Server
+}
+
+func newFakeCloud(t *testing.T, dir string) *fakeCloud {
+	t.Helper()
+	certPEM, keyPEM, err := util.GenCertKeyFromOptions(util.CertOptions{
+		Host:         "ec2.amazonaws.com",
+		TTL:          time.Hour,
+		IsSelfSigned: true,
+		RSAKeySize:   2048,
+	})
+	if err != nil {
+		t.Fatalf("failed to generate the AWS certificate: %v", err)
+	}
+	awsKey, err := util.ParsePemEncodedKey(keyPEM)
+	if err != nil {
+		t.Fatalf("failed to parse the AWS key: %v", err)
+	}
+	awsCertFile := filepath.Join(dir, "aws.pem")
+	if err := ioutil.WriteFile(awsCertFile, certPEM, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	gcpKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate the GCP key: %v", err)
+	}
+	jwks, err := json.Marshal(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{
+		Key:       gcpKey.Public(),
+		KeyID:     testGCPKeyID,
+		Algorithm: string(jose.RS256),
+		Use:       "sig",
+	}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	gcpCerts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(jwks)
+	}))
+
+	return &fakeCloud{
+		awsKey:      awsKey.(*rsa.PrivateKey),
+		awsCertFile: awsCertFile,
+		gcpKey:      gcpKey,
+		gcpCerts:    gcpCerts,
+	}
+}
+
+func (c *fakeCloud) awsToken(t *testing.T, key *rsa.PrivateKey, accountID, instanceID string, nonce []byte) string {
+	t.Helper()
+	doc := []byte(`{
+  "accountId" : "` + accountID + `",
+  "instanceId" : "` + instanceID + `",
+  "region" : "us-west-2"
+}`)
+	digest := sha256.Sum256(doc)
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatalf("failed to sign the AWS instance identity document: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(doc) + "." + base64.RawURLEncoding.EncodeToString(signature) +
+		"." + base64.RawURLEncoding.EncodeToString(nonce)
+}
+
+func (c *fakeCloud) gcpToken(t *testing.T, audience, projectID, email string, exp time.Time) string {
+	t.Helper()
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: c.gcpKey},
+		(&jose.SignerOptions{}).WithType("JWT").WithHeader("kid", testGCPKeyID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims := map[string]interface{}{
+		"iss": gcpInstanceIdentityIssuer,
+		"aud": audience,
+		"sub": "1234567890",
+		"iat": time.Now().Unix(),
+		"exp": exp.Unix(),
+	}
+	if email != "" {
+		claims["email"] = email
+	}
+	if projectID != "" {
+		claims["google"] = map[string]interface{}{
+			"compute_engine": map[string]string{
+				"project_id":  projectID,
+				"instance_id": "1234567890",
+				"zone":        "us-central1-a",
+			},
+		}
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jws, err := signer.Sign(payload)
+	if err != nil {
+		t.Fatalf("failed to sign the GCP instance identity token: %v", err)
+	}
+	token, err := jws.CompactSerialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestNewInstanceIdentityAuthenticator(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "instance_identity")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	testCases := map[string]struct {
+		config         string
+		expectedErrMsg string
+	}{
+		"Valid config": {
+			config: `
+aws: {}
+gcp:
+  audience: grpc://istio-citadel:8060
+mappings:
+- platform: aws
+  account: "123456789012"
+  namespace: vm
+  serviceAccount: db
+`,
+		},
+		"GCP without audience": {
+			config: `
+gcp: {}
+`,
+			expectedErrMsg: "the audience of the GCP instance identity tokens must be specified",
+		},
+		"Unsupported platform": {
+			config: `
+mappings:
+- platform: azure
+  account: foo
+  namespace: vm
+  serviceAccount: db
+`,
+			expectedErrMsg: `mapping 0: unsupported platform "azure"`,
+		},
+		"Incomplete mapping": {
+			config: `
+mappings:
+- platform: gcp
+  account: my-project
+`,
+			expectedErrMsg: "mapping 0: the account, namespace and service account must be specified",
+		},
+		"AWS mapping with roles": {
+			config: `
+mappings:
+- platform: aws
+  account: "123456789012"
+  roles: [admin]
+  namespace: vm
+  serviceAccount: db
+`,
+			expectedErrMsg: "mapping 0: roles are not supported on AWS",
+		},
+		"Invalid AWS certificate file": {
+			config: `
+aws:
+  certificateFile: /invalid/path
+`,
+			expectedErrMsg: "failed to read the AWS certificates: open /invalid/path: no such file or directory",
+		},
+	}
+
+	for id, tc := range testCases {
+		configFile := filepath.Join(tmpdir, "config.yaml")
+		if err := ioutil.WriteFile(configFile, []byte(tc.config), 0644); err != nil {
+			t.Fatal(err)
+		}
+		authenticator, err := NewInstanceIdentityAuthenticator(configFile, "cluster.local", fakeInstanceBindings{})
+		if tc.expectedErrMsg != "" {
+			if err == nil {
+				t.Errorf("Case %s: succeeded. Error expected: %s", id, tc.expectedErrMsg)
+			} else if err.Error() != tc.expectedErrMsg {
+				t.Errorf("Case %s: incorrect error message: want %s but got %s", id, tc.expectedErrMsg, err.Error())
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Case %s: unexpected error: %v", id, err)
+			continue
+		}
+		if len(authenticator.awsCerts) != 1 || authenticator.gcpVerifier == nil || len(authenticator.mappings) != 1 {
+			t.Errorf("Case %s: unexpected authenticator: %+v", id, authenticator)
+		}
+	}
+
+	if _, err := NewInstanceIdentityAuthenticator("/invalid/path", "cluster.local", fakeInstanceBindings{}); err == nil {
+		t.Errorf("expecting an error for a missing configuration file")
+	}
+	if _, err := newInstanceIdentityAuthenticator(&InstanceIdentityConfig{AWS: &AWSInstanceIdentityConfig{}},
+		"cluster.local", nil); err == nil {
+		t.Errorf("expecting an error for AWS without instance binding store")
+	}
+}
+
+func TestInstanceIdentityAuthenticate(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "instance_identity")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+	cloud := newFakeCloud(t, tmpdir)
+	defer cloud.gcpCerts.Close()
+
+	// The test instance is already bound to its nonce.
+	nonce := []byte("0123456789abcdef0123456789abcdef")
+	digest := sha256.Sum256(nonce)
+	bindings := fakeInstanceBindings{
+		"aws." + testAWSInstance: base64.RawURLEncoding.EncodeToString(digest[:]),
+	}
+	adminRole := "admin@my-project.iam.gserviceaccount.com"
+	authenticator, err := newInstanceIdentityAuthenticator(&InstanceIdentityConfig{
+		AWS: &AWSInstanceIdentityConfig{CertificateFile: cloud.awsCertFile},
+		GCP: &GCPInstanceIdentityConfig{Audience: testGCPAudience, CertsURL: cloud.gcpCerts.URL},
+		Mappings: []InstanceIdentityMapping{
+			{Platform: PlatformAWS, Account: "123456789012", InstanceIDs: []string{"i-0aaaaaaaaaaaaaaaa"},
+				Namespace: "vm", ServiceAccount: "admin"},
+			{Platform: PlatformAWS, Account: "123456789012", Namespace: "vm", ServiceAccount: "db"},
+			{Platform: PlatformGCP, Account: "my-project", Roles: []string{adminRole}, Namespace: "vm",
+				ServiceAccount: "admin"},
+			{Platform: PlatformGCP, Account: "my-project", Namespace: "vm", ServiceAccount: "web"},
+		},
+	}, "cluster.local", bindings)
+	if err != nil {
+		t.Fatalf("failed to create the authenticator: %v", err)
+	}
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := map[string]struct {
+		token          string
+		expectedID     string
+		expectedErrMsg string
+	}{
+		"No bearer token": {
+			expectedErrMsg: "instance identity extraction error: no bearer token exists in HTTP authorization header",
+		},
+		"Valid AWS document": {
+			token:      cloud.awsToken(t, cloud.awsKey, "123456789012", testAWSInstance, nonce),
+			expectedID: "spiffe://cluster.local/ns/vm/sa/db",
+		},
+		"AWS document of a mapped instance": {
+			token:      cloud.awsToken(t, cloud.awsKey, "123456789012", "i-0aaaaaaaaaaaaaaaa", nonce),
+			expectedID: "spiffe://cluster.local/ns/vm/sa/admin",
+		},
+		"AWS document replayed with another nonce": {
+			token: cloud.awsToken(t, cloud.awsKey, "123456789012", testAWSInstance,
+				[]byte("replayed-nonce-0123456789")),
+			expectedErrMsg: `the AWS instance "i-1234567890abcdef0" is bound to another nonce`,
+		},
+		"AWS document with a short nonce": {
+			token:          cloud.awsToken(t, cloud.awsKey, "123456789012", testAWSInstance, []byte("short")),
+			expectedErrMsg: "the AWS instance nonce must be at least 16 bytes",
+		},
+		"AWS document of an unmapped account": {
+			token: cloud.awsToken(t, cloud.awsKey, "210987654321", testAWSInstance, nonce),
+			expectedErrMsg: `no service account is mapped to the aws account "210987654321" of instance ` +
+				`"i-1234567890abcdef0"`,
+		},
+		"AWS document with invalid signature": {
+			token:          cloud.awsToken(t, otherKey, "123456789012", testAWSInstance, nonce),
+			expectedErrMsg: "failed to verify the signature of the AWS instance identity document",
+		},
+		"Malformed AWS document": {
+			token:          "not-a-document",
+			expectedErrMsg: "malformed AWS instance identity document",
+		},
+		"Valid GCP token": {
+			token:      cloud.gcpToken(t, testGCPAudience, "my-project", "", time.Now().Add(time.Hour)),
+			expectedID: "spiffe://cluster.local/ns/vm/sa/web",
+		},
+		"GCP token of a mapped role": {
+			token:      cloud.gcpToken(t, testGCPAudience, "my-project", adminRole, time.Now().Add(time.Hour)),
+			expectedID: "spiffe://cluster.local/ns/vm/sa/admin",
+		},
+		"GCP token for another audience": {
+			token:          cloud.gcpToken(t, "grpc://other:8060", "my-project", "", time.Now().Add(time.Hour)),
+			expectedErrMsg: "failed to verify the GCP instance identity token",
+		},
+		"Expired GCP token": {
+			token:          cloud.gcpToken(t, testGCPAudience, "my-project", "", time.Now().Add(-time.Hour)),
+			expectedErrMsg: "failed to verify the GCP instance identity token",
+		},
+		"GCP token without the full format": {
+			token:          cloud.gcpToken(t, testGCPAudience, "", "", time.Now().Add(time.Hour)),
+			expectedErrMsg: "no project in the GCP instance identity token, it must be requested with the full format",
+		},
+	}
+
+	for id, tc := range testCases {
+		ctx := context.Background()
+		if tc.token != "" {
+			ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", "Bearer "+tc.token))
+		} else {
+			ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", "Basic foo"))
+		}
+
+		caller, err := authenticator.Authenticate(ctx)
+		if tc.expectedErrMsg != "" {
+			if err == nil {
+				t.Errorf("Case %s: succeeded. Error expected: %s", id, tc.expectedErrMsg)
+			} else if !strings.HasPrefix(err.Error(), tc.expectedErrMsg) {
+				t.Errorf("Case %s: incorrect error message: want %s but got %s", id, tc.expectedErrMsg, err.Error())
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Case %s: unexpected error: %v", id, err)
+			continue
+		}
+		expectedCaller := &Caller{
+			AuthSource: AuthSourceIDToken,
+			Identities: []string{tc.expectedID},
+		}
+		if !reflect.DeepEqual(caller, expectedCaller) {
+			t.Errorf("Case %s: unexpected caller: want %+v but got %+v", id, expectedCaller, caller)
+		}
+	}
+}
+
+func TestInstanceIdentityBindOnFirstUse(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "instance_identity")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+	cloud := newFakeCloud(t, tmpdir)
+	defer cloud.gcpCerts.Close()
+
+	bindings := fakeInstanceBindings{}
+	authenticator, err := newInstanceIdentityAuthenticator(&InstanceIdentityConfig{
+		AWS: &AWSInstanceIdentityConfig{CertificateFile: cloud.awsCertFile},
+		Mappings: []InstanceIdentityMapping{
+			{Platform: PlatformAWS, Account: "123456789012", Namespace: "vm", ServiceAccount: "db"},
+		},
+	}, "cluster.local", bindings)
+	if err != nil {
+		t.Fatalf("failed to create the authenticator: %v", err)
+	}
+	authenticate := func(nonce string) error {
+		token := cloud.awsToken(t, cloud.awsKey, "123456789012", testAWSInstance, []byte(nonce))
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+token))
+		_, err := authenticator.Authenticate(ctx)
+		return err
+	}
+
+	if err := authenticate("first-nonce-0123456789"); err != nil {
+		t.Fatalf("the first authentication failed: %v", err)
+	}
+	if _, ok := bindings["aws."+testAWSInstance]; !ok {
+		t.Fatalf("the instance is not bound on its first authentication: %v", bindings)
+	}
+	if err := authenticate("first-nonce-0123456789"); err != nil {
+		t.Errorf("the instance failed to authenticate again with its nonce: %v", err)
+	}
+	if err := authenticate("other-nonce-0123456789"); err == nil {
+		t.Errorf("the document is accepted with another nonce")
+	}
+}
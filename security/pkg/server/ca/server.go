@@ -18,7 +18,9 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"fmt"
+	"math/big"
 	"net"
+	"strings"
 	"time"
 
 	grpc_prometheus "github.com/grpc-ecosystem/go-grpc-prometheus"
@@ -50,8 +52,8 @@ type authenticator interface {
 	AuthenticatorType() string
 }
 
-// Server implements IstioCAService and IstioCertificateService and provides the services on the
-// specified port.
+// Server implements IstioCAService, IstioCertificateService and IstioCertificateRevocationService and
+// provides the services on the specified port.
 type Server struct {
 	monitoring     monitoringMetrics
 	authenticators []authenticator
@@ -62,6 +64,8 @@ type Server struct {
 	certificate    *tls.Certificate
 	port           int
 	forCA          bool
+	// revocationAdmins are the identities allowed to revoke certificates.
+	revocationAdmins map[string]bool
 }
 
 // CreateCertificate handles an incoming certificate signing request (CSR). It does
@@ -102,6 +106,70 @@ func (s *Server) CreateCertificate(ctx context.Context, request *pb.IstioCertifi
 	return response, nil
 }
 
+// RevokeCertificate handles an incoming certificate revocation request. Only callers authenticated as
+// one of the revocation admin identities are allowed to revoke certificates. The certificates are
+// added to the CRL published by the CA, which is returned in the response.
+func (s *Server) RevokeCertificate(ctx context.Context, request *pb.IstioCertificateRevocationRequest) (
+	*pb.IstioCertificateRevocationResponse, error) {
+	caller := s.authenticate(ctx)
+	if caller == nil {
+		log.Warn("request authentication failure")
+		s.monitoring.AuthnError.Inc()
+		return nil, status.Error(codes.Unauthenticated, "request authenticate failure")
+	}
+	if !s.isRevocationAdmin(caller) {
+		log.Warnf("caller %v is not allowed to revoke certificates", caller.Identities)
+		return nil, status.Error(codes.PermissionDenied, "caller is not allowed to revoke certificates")
+	}
+
+	revoker, ok := s.ca.(ca.CertificateRevoker)
+	if !ok {
+		return nil, status.Error(codes.Unimplemented, "the CA does not support certificate revocation")
+	}
+	if len(request.SerialNumbers) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "no serial number to revoke")
+	}
+	serialNumbers := make([]*big.Int, 0, len(request.SerialNumbers))
+	for _, sn := range request.SerialNumbers {
+		serialNumber, err := parseSerialNumber(sn)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid serial number %q (%v)", sn, err)
+		}
+		serialNumbers = append(serialNumbers, serialNumber)
+	}
+
+	crl, err := revoker.Revoke(serialNumbers)
+	if err != nil {
+		log.Errorf("certificate revocation error (%v)", err)
+		if caErr, ok := err.(*ca.Error); ok {
+			return nil, status.Errorf(caErr.HTTPErrorCode(), "certificate revocation error (%v)", caErr)
+		}
+		return nil, status.Errorf(codes.Internal, "certificate revocation error (%v)", err)
+	}
+	log.Infof("%d certificate(s) revoked by %v", len(serialNumbers), caller.Identities)
+	return &pb.IstioCertificateRevocationResponse{Crl: string(crl)}, nil
+}
+
+func (s *Server) isRevocationAdmin(caller *authenticate.Caller) bool {
+	for _, id := range caller.Identities {
+		if s.revocationAdmins[id] {
+			return true
+		}
+	}
+	return false
+}
+
+// parseSerialNumber parses a hex-encoded certificate serial number. The colon-separated format
+// printed by openssl is accepted as well.
+func parseSerialNumber(sn string) (*big.Int, error) {
+	sn = strings.TrimPrefix(strings.ToLower(strings.Replace(sn, ":", "", -1)), "0x")
+	serialNumber, ok := new(big.Int).SetString(sn, 16)
+	if !ok || serialNumber.Sign() <= 0 {
+		return nil, fmt.Errorf("not a positive hex-encoded number")
+	}
+	return serialNumber, nil
+}
+
 // extractRootCertExpiryTimestamp returns the unix timestamp when the root becomes expires.
 func extractRootCertExpiryTimestamp(ca ca.CertificateAuthority) float64 {
 	rb := ca.GetCAKeyCertBundle().GetRootCertPem()
@@ -180,6 +248,7 @@ func (s *Server) Run() error {
 	grpcServer := grpc.NewServer(grpcOptions...)
 	pb.RegisterIstioCAServiceServer(grpcServer, s)
 	pb.RegisterIstioCertificateServiceServer(grpcServer, s)
+	pb.RegisterIstioCertificateRevocationServiceServer(grpcServer, s)
 
 	grpc_prometheus.EnableHandlingTimeHistogram()
 	grpc_prometheus.Register(grpcServer)
@@ -197,9 +266,12 @@ func (s *Server) Run() error {
 	return nil
 }
 
-// New creates a new instance of `IstioCAServiceServer`.
+// New creates a new instance of `IstioCAServiceServer`. The VM workloads are authenticated by their cloud
+// instance identity documents if instanceIdentityConfigFile is not empty, the instances being bound on first use
+// in instanceBindings.
 func New(ca ca.CertificateAuthority, ttl time.Duration, forCA bool, hostlist []string, port int,
-	trustDomain string, sdsEnabled bool) (*Server, error) {
+	trustDomain string, sdsEnabled bool, revocationAdmins []string, instanceIdentityConfigFile string,
+	instanceBindings authenticate.InstanceBindingStore) (*Server, error) {
 
 	if len(hostlist) == 0 {
 		return nil, fmt.Errorf("failed to create grpc server hostlist empty")
@@ -222,6 +294,16 @@ func New(ca ca.CertificateAuthority, ttl time.Duration, forCA bool, hostlist []s
 		}
 	}
 
+	if instanceIdentityConfigFile != "" {
+		authenticator, err := authenticate.NewInstanceIdentityAuthenticator(instanceIdentityConfigFile, trustDomain,
+			instanceBindings)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create the instance identity authenticator: %v", err)
+		}
+		authenticators = append(authenticators, authenticator)
+		log.Info("added instance identity authenticator")
+	}
+
 	// Temporarily disable ID token authenticator by resetting the hostlist.
 	// [TODO](myidpt): enable ID token authenticator when the CSR API authz can work correctly.
 	hostlistForJwtAuth := make([]string, 0)
@@ -238,15 +320,21 @@ func New(ca ca.CertificateAuthority, ttl time.Duration, forCA bool, hostlist []s
 	version.Info.RecordComponentBuildTag("citadel")
 	rootCertExpiryTimestamp.Set(extractRootCertExpiryTimestamp(ca))
 
+	admins := make(map[string]bool, len(revocationAdmins))
+	for _, id := range revocationAdmins {
+		admins[id] = true
+	}
+
 	server := &Server{
-		authenticators: authenticators,
-		authorizer:     &registryAuthorizor{registry.GetIdentityRegistry()},
-		serverCertTTL:  ttl,
-		ca:             ca,
-		hostnames:      hostlist,
-		forCA:          forCA,
-		port:           port,
-		monitoring:     newMonitoringMetrics(),
+		authenticators:   authenticators,
+		authorizer:       &registryAuthorizor{registry.GetIdentityRegistry()},
+		serverCertTTL:    ttl,
+		ca:               ca,
+		hostnames:        hostlist,
+		forCA:            forCA,
+		port:             port,
+		monitoring:       newMonitoringMetrics(),
+		revocationAdmins: admins,
 	}
 	return server, nil
 }
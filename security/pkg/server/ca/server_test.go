@@ -19,7 +19,9 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"fmt"
+	"math/big"
 	"os"
+	"reflect"
 	"testing"
 	"time"
 
@@ -240,6 +242,77 @@ func TestCreateCertificate(t *testing.T) {
 	}
 }
 
+func TestRevokeCertificate(t *testing.T) {
+	testCases := map[string]struct {
+		authenticators []authenticator
+		ca             ca.CertificateAuthority
+		serialNumbers  []string
+		expectedSerial []*big.Int
+		code           codes.Code
+	}{
+		"Unauthenticated request": {
+			authenticators: []authenticator{&mockAuthenticator{errMsg: "Not authorized"}},
+			ca:             &mockca.FakeCA{},
+			serialNumbers:  []string{"1a"},
+			code:           codes.Unauthenticated,
+		},
+		"Caller is not a revocation admin": {
+			authenticators: []authenticator{&mockAuthenticator{identities: []string{"spiffe://test.com/ns/foo/sa/bar"}}},
+			ca:             &mockca.FakeCA{},
+			serialNumbers:  []string{"1a"},
+			code:           codes.PermissionDenied,
+		},
+		"No serial number": {
+			authenticators: []authenticator{&mockAuthenticator{identities: []string{"admin"}}},
+			ca:             &mockca.FakeCA{},
+			code:           codes.InvalidArgument,
+		},
+		"Invalid serial number": {
+			authenticators: []authenticator{&mockAuthenticator{identities: []string{"admin"}}},
+			ca:             &mockca.FakeCA{},
+			serialNumbers:  []string{"not-a-serial"},
+			code:           codes.InvalidArgument,
+		},
+		"Failed to publish CRL": {
+			authenticators: []authenticator{&mockAuthenticator{identities: []string{"admin"}}},
+			ca:             &mockca.FakeCA{RevokeErr: ca.NewError(ca.CRLGenError, fmt.Errorf("cannot publish"))},
+			serialNumbers:  []string{"1a"},
+			code:           codes.Internal,
+		},
+		"Successful revocation": {
+			authenticators: []authenticator{&mockAuthenticator{identities: []string{"admin"}}},
+			ca:             &mockca.FakeCA{CRL: []byte("crl")},
+			serialNumbers:  []string{"1a", "01:2C", "0xff"},
+			expectedSerial: []*big.Int{big.NewInt(0x1a), big.NewInt(0x12c), big.NewInt(0xff)},
+			code:           codes.OK,
+		},
+	}
+
+	for id, c := range testCases {
+		server := &Server{
+			ca:               c.ca,
+			authenticators:   c.authenticators,
+			monitoring:       newMonitoringMetrics(),
+			revocationAdmins: map[string]bool{"admin": true},
+		}
+		request := &pb.IstioCertificateRevocationRequest{SerialNumbers: c.serialNumbers}
+
+		response, err := server.RevokeCertificate(context.Background(), request)
+		s, _ := status.FromError(err)
+		if code := s.Code(); c.code != code {
+			t.Errorf("Case %s: expecting code to be (%d) but got (%d): %s", id, c.code, code, s.Message())
+		} else if c.code == codes.OK {
+			if response.Crl != "crl" {
+				t.Errorf("Case %s: unexpected CRL %q", id, response.Crl)
+			}
+			revoked := c.ca.(*mockca.FakeCA).RevokedSerials
+			if !reflect.DeepEqual(revoked, c.expectedSerial) {
+				t.Errorf("Case %s: revoked serial numbers %v VS (expected) %v", id, revoked, c.expectedSerial)
+			}
+		}
+	}
+}
+
 func TestHandleCSR(t *testing.T) {
 	testCases := map[string]struct {
 		authenticators []authenticator
@@ -453,7 +526,7 @@ func TestRun(t *testing.T) {
 			// K8s JWT authenticator is added in k8s env.
 			tc.expectedAuthenticatorsLen++
 		}
-		server, err := New(tc.ca, time.Hour, false, tc.hostname, tc.port, "testdomain.com", true)
+		server, err := New(tc.ca, time.Hour, false, tc.hostname, tc.port, "testdomain.com", true, nil, "", nil)
 		if err == nil {
 			err = server.Run()
 		}
@@ -137,34 +137,131 @@ func (m *IstioCertificateResponse) GetCertChain() []string {
 	return nil
 }
 
+// Certificate revocation request message.
+type IstioCertificateRevocationRequest struct {
+	// Hex-encoded serial numbers of the certificates to revoke.
+	SerialNumbers []string `protobuf:"bytes,1,rep,name=serial_numbers,json=serialNumbers,proto3" json:"serial_numbers,omitempty"`
+}
+
+func (m *IstioCertificateRevocationRequest) Reset()      { *m = IstioCertificateRevocationRequest{} }
+func (*IstioCertificateRevocationRequest) ProtoMessage() {}
+func (*IstioCertificateRevocationRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_9eff2d2b4471d6ff, []int{2}
+}
+func (m *IstioCertificateRevocationRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *IstioCertificateRevocationRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_IstioCertificateRevocationRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *IstioCertificateRevocationRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_IstioCertificateRevocationRequest.Merge(m, src)
+}
+func (m *IstioCertificateRevocationRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *IstioCertificateRevocationRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_IstioCertificateRevocationRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_IstioCertificateRevocationRequest proto.InternalMessageInfo
+
+func (m *IstioCertificateRevocationRequest) GetSerialNumbers() []string {
+	if m != nil {
+		return m.SerialNumbers
+	}
+	return nil
+}
+
+// Certificate revocation response message.
+type IstioCertificateRevocationResponse struct {
+	// PEM-encoded certificate revocation list, including the revoked certificates.
+	Crl string `protobuf:"bytes,1,opt,name=crl,proto3" json:"crl,omitempty"`
+}
+
+func (m *IstioCertificateRevocationResponse) Reset()      { *m = IstioCertificateRevocationResponse{} }
+func (*IstioCertificateRevocationResponse) ProtoMessage() {}
+func (*IstioCertificateRevocationResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_9eff2d2b4471d6ff, []int{3}
+}
+func (m *IstioCertificateRevocationResponse) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *IstioCertificateRevocationResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_IstioCertificateRevocationResponse.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *IstioCertificateRevocationResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_IstioCertificateRevocationResponse.Merge(m, src)
+}
+func (m *IstioCertificateRevocationResponse) XXX_Size() int {
+	return m.Size()
+}
+func (m *IstioCertificateRevocationResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_IstioCertificateRevocationResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_IstioCertificateRevocationResponse proto.InternalMessageInfo
+
+func (m *IstioCertificateRevocationResponse) GetCrl() string {
+	if m != nil {
+		return m.Crl
+	}
+	return ""
+}
+
 func init() {
 	proto.RegisterType((*IstioCertificateRequest)(nil), "istio.v1.auth.IstioCertificateRequest")
 	proto.RegisterType((*IstioCertificateResponse)(nil), "istio.v1.auth.IstioCertificateResponse")
+	proto.RegisterType((*IstioCertificateRevocationRequest)(nil), "istio.v1.auth.IstioCertificateRevocationRequest")
+	proto.RegisterType((*IstioCertificateRevocationResponse)(nil), "istio.v1.auth.IstioCertificateRevocationResponse")
 }
 
 func init() { proto.RegisterFile("security/proto/istioca.proto", fileDescriptor_9eff2d2b4471d6ff) }
 
 var fileDescriptor_9eff2d2b4471d6ff = []byte{
-	// 294 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x84, 0x91, 0xbd, 0x4a, 0x03, 0x41,
-	0x14, 0x85, 0xe7, 0xba, 0x20, 0x64, 0x40, 0x48, 0xb6, 0x71, 0x11, 0xbd, 0x84, 0x14, 0x1a, 0x10,
-	0x36, 0xf8, 0xd3, 0xd8, 0x1a, 0x9b, 0xb4, 0xf1, 0x01, 0xc2, 0x64, 0xf6, 0x86, 0x8c, 0x48, 0x36,
-	0xce, 0xdc, 0x8d, 0xa4, 0xd3, 0x37, 0xf0, 0x31, 0x7c, 0x14, 0xcb, 0x94, 0x29, 0xcd, 0x6c, 0x63,
-	0x99, 0x47, 0x90, 0x59, 0x23, 0x28, 0x41, 0xec, 0xe6, 0x7c, 0x67, 0xe0, 0x9c, 0xc3, 0x95, 0x87,
-	0x8e, 0x74, 0x61, 0x0d, 0xcf, 0x3b, 0x53, 0x9b, 0x73, 0xde, 0x31, 0x8e, 0x4d, 0xae, 0x55, 0x5a,
-	0xa9, 0x78, 0xaf, 0x92, 0xe9, 0xec, 0x2c, 0x55, 0x05, 0x8f, 0x5b, 0x8f, 0x72, 0xbf, 0x17, 0x40,
-	0x97, 0x2c, 0x9b, 0x91, 0xd1, 0x8a, 0xa9, 0x4f, 0x0f, 0x05, 0x39, 0x8e, 0xeb, 0x32, 0xd2, 0xce,
-	0x26, 0xd0, 0x84, 0x76, 0xad, 0x1f, 0x9e, 0xf1, 0x91, 0x94, 0xae, 0x18, 0xde, 0x91, 0xe6, 0x81,
-	0xc9, 0x92, 0x9d, 0xca, 0xa8, 0x6d, 0x48, 0x2f, 0x8b, 0x4f, 0x65, 0x63, 0xa6, 0xee, 0x4d, 0x66,
-	0x78, 0x3e, 0xc8, 0x0a, 0xab, 0xd8, 0xe4, 0x93, 0x24, 0x6a, 0x42, 0x3b, 0xea, 0xd7, 0xbf, 0x8d,
-	0x9b, 0x0d, 0x6f, 0x5d, 0xc9, 0x64, 0x3b, 0xd8, 0x4d, 0xf3, 0x89, 0xa3, 0x90, 0xa3, 0xc9, 0xf2,
-	0x40, 0x8f, 0x95, 0x99, 0x24, 0xd0, 0x8c, 0x42, 0x4e, 0x20, 0xdd, 0x00, 0xce, 0x9f, 0x61, 0xbb,
-	0xf4, 0x2d, 0xd9, 0x99, 0xd1, 0x14, 0x8f, 0x64, 0xa3, 0x6b, 0x49, 0x31, 0xfd, 0xf0, 0xe2, 0xe3,
-	0xf4, 0xd7, 0xe8, 0xf4, 0x8f, 0xc5, 0x07, 0x27, 0xff, 0xfe, 0xfb, 0x2a, 0xd8, 0x12, 0xd7, 0x97,
-	0x8b, 0x15, 0x8a, 0xe5, 0x0a, 0xc5, 0x7a, 0x85, 0xf0, 0xe4, 0x11, 0x5e, 0x3d, 0xc2, 0x9b, 0x47,
-	0x58, 0x78, 0x84, 0x77, 0x8f, 0xf0, 0xe1, 0x51, 0xac, 0x3d, 0xc2, 0x4b, 0x89, 0x62, 0x51, 0xa2,
-	0x58, 0x96, 0x28, 0x86, 0xbb, 0xd5, 0x0d, 0x2e, 0x3e, 0x03, 0x00, 0x00, 0xff, 0xff, 0x23, 0xaa,
-	0x00, 0x39, 0xa3, 0x01, 0x00, 0x00,
+	// 371 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x92, 0xc1, 0x4e, 0xfa, 0x30,
+	0x1c, 0xc7, 0xd7, 0xff, 0x92, 0x7f, 0x42, 0x13, 0x0c, 0xec, 0xe2, 0x42, 0xb4, 0xc1, 0x25, 0x2a,
+	0x89, 0xc9, 0x10, 0x34, 0x26, 0x5e, 0xc5, 0x0b, 0x1e, 0x3c, 0xe0, 0x03, 0x2c, 0xa5, 0x2b, 0xa1,
+	0x3a, 0x37, 0x6c, 0xbb, 0x19, 0x3c, 0xe9, 0x1b, 0xf8, 0x06, 0x5e, 0x7d, 0x14, 0x8f, 0x1c, 0x39,
+	0x4a, 0xb9, 0x78, 0xe4, 0x11, 0x4c, 0xc7, 0x66, 0x44, 0x22, 0xc4, 0xdb, 0xfa, 0xf9, 0xad, 0xbf,
+	0xef, 0x37, 0x9f, 0x14, 0x6e, 0x09, 0x4a, 0x62, 0xce, 0xe4, 0xb0, 0x3e, 0xe0, 0x91, 0x8c, 0xea,
+	0x4c, 0x48, 0x16, 0x11, 0xec, 0xa6, 0x27, 0xab, 0x98, 0x1e, 0xdd, 0xa4, 0xe1, 0xe2, 0x58, 0xf6,
+	0x9d, 0x7b, 0xb8, 0xd9, 0xd6, 0xa0, 0x45, 0xb9, 0x64, 0x3d, 0x46, 0xb0, 0xa4, 0x1d, 0x7a, 0x17,
+	0x53, 0x21, 0xad, 0x12, 0x34, 0x89, 0xe0, 0x36, 0xa8, 0x82, 0x5a, 0xa1, 0xa3, 0x3f, 0xad, 0x6d,
+	0x08, 0x45, 0xdc, 0xbd, 0xa6, 0x44, 0x7a, 0xcc, 0xb7, 0xff, 0xa5, 0x83, 0x42, 0x46, 0xda, 0xbe,
+	0x75, 0x00, 0xcb, 0x09, 0x0e, 0x98, 0xcf, 0xe4, 0xd0, 0xf3, 0x63, 0x8e, 0x25, 0x8b, 0x42, 0xdb,
+	0xac, 0x82, 0x9a, 0xd9, 0x29, 0xe5, 0x83, 0xf3, 0x8c, 0x3b, 0xa7, 0xd0, 0x5e, 0x0e, 0x16, 0x83,
+	0x28, 0x14, 0x54, 0xe7, 0x10, 0xca, 0xa5, 0x47, 0xfa, 0x98, 0x85, 0x36, 0xa8, 0x9a, 0x3a, 0x47,
+	0x93, 0x96, 0x06, 0xce, 0x05, 0xdc, 0x59, 0xbe, 0x9a, 0x44, 0x24, 0x5d, 0x9c, 0xb7, 0xdf, 0x85,
+	0x1b, 0x82, 0x72, 0x86, 0x03, 0x2f, 0x8c, 0x6f, 0xbb, 0x94, 0x8b, 0x6c, 0x4f, 0x71, 0x4e, 0x2f,
+	0xe7, 0xd0, 0x39, 0x81, 0xce, 0xaa, 0x5d, 0x59, 0x21, 0xad, 0x82, 0x07, 0x5f, 0x2a, 0x78, 0xd0,
+	0x7c, 0x02, 0xcb, 0xe2, 0xae, 0x28, 0x4f, 0x18, 0xa1, 0x56, 0x0f, 0x96, 0x5b, 0x9c, 0x62, 0x49,
+	0xbf, 0xcd, 0xac, 0x3d, 0x77, 0x41, 0xbc, 0xfb, 0x8b, 0xf5, 0xca, 0xfe, 0xda, 0xff, 0xe6, 0x9d,
+	0x1c, 0xa3, 0xf9, 0x02, 0x56, 0x89, 0xc8, 0xdb, 0x3c, 0xc0, 0xb2, 0x86, 0x37, 0x0b, 0x6d, 0x0e,
+	0xd7, 0xa6, 0xfc, 0xf0, 0x59, 0x69, 0xfc, 0xe1, 0x46, 0xde, 0xf0, 0xec, 0x78, 0x34, 0x41, 0xc6,
+	0x78, 0x82, 0x8c, 0xd9, 0x04, 0x81, 0x47, 0x85, 0xc0, 0xab, 0x42, 0xe0, 0x4d, 0x21, 0x30, 0x52,
+	0x08, 0xbc, 0x2b, 0x04, 0x3e, 0x14, 0x32, 0x66, 0x0a, 0x81, 0xe7, 0x29, 0x32, 0x46, 0x53, 0x64,
+	0x8c, 0xa7, 0xc8, 0xe8, 0xfe, 0x4f, 0x5f, 0xea, 0xd1, 0xe7, 0x00, 0xca, 0xe8, 0x07, 0xad, 0xc9,
+	0x02, 0x00, 0x00,
 }
 
 func (this *IstioCertificateRequest) Equal(that interface{}) bool {
@@ -226,6 +323,59 @@ func (this *IstioCertificateResponse) Equal(that interface{}) bool {
 	}
 	return true
 }
+func (this *IstioCertificateRevocationRequest) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*IstioCertificateRevocationRequest)
+	if !ok {
+		that2, ok := that.(IstioCertificateRevocationRequest)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if len(this.SerialNumbers) != len(that1.SerialNumbers) {
+		return false
+	}
+	for i := range this.SerialNumbers {
+		if this.SerialNumbers[i] != that1.SerialNumbers[i] {
+			return false
+		}
+	}
+	return true
+}
+func (this *IstioCertificateRevocationResponse) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*IstioCertificateRevocationResponse)
+	if !ok {
+		that2, ok := that.(IstioCertificateRevocationResponse)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.Crl != that1.Crl {
+		return false
+	}
+	return true
+}
 func (this *IstioCertificateRequest) GoString() string {
 	if this == nil {
 		return "nil"
@@ -248,6 +398,26 @@ func (this *IstioCertificateResponse) GoString() string {
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
+func (this *IstioCertificateRevocationRequest) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 5)
+	s = append(s, "&istio_v1_auth.IstioCertificateRevocationRequest{")
+	s = append(s, "SerialNumbers: "+fmt.Sprintf("%#v", this.SerialNumbers)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *IstioCertificateRevocationResponse) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 5)
+	s = append(s, "&istio_v1_auth.IstioCertificateRevocationResponse{")
+	s = append(s, "Crl: "+fmt.Sprintf("%#v", this.Crl)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
 func valueToGoStringIstioca(v interface{}, typ string) string {
 	rv := reflect.ValueOf(v)
 	if rv.IsNil() {
@@ -339,6 +509,80 @@ var _IstioCertificateService_serviceDesc = grpc.ServiceDesc{
 	Metadata: "security/proto/istioca.proto",
 }
 
+// IstioCertificateRevocationServiceClient is the client API for IstioCertificateRevocationService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type IstioCertificateRevocationServiceClient interface {
+	// Revokes the certificates with the given serial numbers, and returns the updated CRL.
+	RevokeCertificate(ctx context.Context, in *IstioCertificateRevocationRequest, opts ...grpc.CallOption) (*IstioCertificateRevocationResponse, error)
+}
+
+type istioCertificateRevocationServiceClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewIstioCertificateRevocationServiceClient(cc *grpc.ClientConn) IstioCertificateRevocationServiceClient {
+	return &istioCertificateRevocationServiceClient{cc}
+}
+
+func (c *istioCertificateRevocationServiceClient) RevokeCertificate(ctx context.Context, in *IstioCertificateRevocationRequest, opts ...grpc.CallOption) (*IstioCertificateRevocationResponse, error) {
+	out := new(IstioCertificateRevocationResponse)
+	err := c.cc.Invoke(ctx, "/istio.v1.auth.IstioCertificateRevocationService/RevokeCertificate", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// IstioCertificateRevocationServiceServer is the server API for IstioCertificateRevocationService service.
+type IstioCertificateRevocationServiceServer interface {
+	// Revokes the certificates with the given serial numbers, and returns the updated CRL.
+	RevokeCertificate(context.Context, *IstioCertificateRevocationRequest) (*IstioCertificateRevocationResponse, error)
+}
+
+// UnimplementedIstioCertificateRevocationServiceServer can be embedded to have forward compatible implementations.
+type UnimplementedIstioCertificateRevocationServiceServer struct {
+}
+
+func (*UnimplementedIstioCertificateRevocationServiceServer) RevokeCertificate(ctx context.Context, req *IstioCertificateRevocationRequest) (*IstioCertificateRevocationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RevokeCertificate not implemented")
+}
+
+func RegisterIstioCertificateRevocationServiceServer(s *grpc.Server, srv IstioCertificateRevocationServiceServer) {
+	s.RegisterService(&_IstioCertificateRevocationService_serviceDesc, srv)
+}
+
+func _IstioCertificateRevocationService_RevokeCertificate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IstioCertificateRevocationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IstioCertificateRevocationServiceServer).RevokeCertificate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/istio.v1.auth.IstioCertificateRevocationService/RevokeCertificate",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IstioCertificateRevocationServiceServer).RevokeCertificate(ctx, req.(*IstioCertificateRevocationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _IstioCertificateRevocationService_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "istio.v1.auth.IstioCertificateRevocationService",
+	HandlerType: (*IstioCertificateRevocationServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "RevokeCertificate",
+			Handler:    _IstioCertificateRevocationService_RevokeCertificate_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "security/proto/istioca.proto",
+}
+
 func (m *IstioCertificateRequest) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
@@ -413,6 +657,68 @@ func (m *IstioCertificateResponse) MarshalToSizedBuffer(dAtA []byte) (int, error
 	return len(dAtA) - i, nil
 }
 
+func (m *IstioCertificateRevocationRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *IstioCertificateRevocationRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *IstioCertificateRevocationRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.SerialNumbers) > 0 {
+		for iNdEx := len(m.SerialNumbers) - 1; iNdEx >= 0; iNdEx-- {
+			i -= len(m.SerialNumbers[iNdEx])
+			copy(dAtA[i:], m.SerialNumbers[iNdEx])
+			i = encodeVarintIstioca(dAtA, i, uint64(len(m.SerialNumbers[iNdEx])))
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *IstioCertificateRevocationResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *IstioCertificateRevocationResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *IstioCertificateRevocationResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Crl) > 0 {
+		i -= len(m.Crl)
+		copy(dAtA[i:], m.Crl)
+		i = encodeVarintIstioca(dAtA, i, uint64(len(m.Crl)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
 func encodeVarintIstioca(dAtA []byte, offset int, v uint64) int {
 	offset -= sovIstioca(v)
 	base := offset
@@ -459,6 +765,34 @@ func (m *IstioCertificateResponse) Size() (n int) {
 	return n
 }
 
+func (m *IstioCertificateRevocationRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.SerialNumbers) > 0 {
+		for _, s := range m.SerialNumbers {
+			l = len(s)
+			n += 1 + l + sovIstioca(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *IstioCertificateRevocationResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Crl)
+	if l > 0 {
+		n += 1 + l + sovIstioca(uint64(l))
+	}
+	return n
+}
+
 func sovIstioca(x uint64) (n int) {
 	return (math_bits.Len64(x|1) + 6) / 7
 }
@@ -487,6 +821,26 @@ func (this *IstioCertificateResponse) String() string {
 	}, "")
 	return s
 }
+func (this *IstioCertificateRevocationRequest) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&IstioCertificateRevocationRequest{`,
+		`SerialNumbers:` + fmt.Sprintf("%v", this.SerialNumbers) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *IstioCertificateRevocationResponse) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&IstioCertificateRevocationResponse{`,
+		`Crl:` + fmt.Sprintf("%v", this.Crl) + `,`,
+		`}`,
+	}, "")
+	return s
+}
 func valueToStringIstioca(v interface{}) string {
 	rv := reflect.ValueOf(v)
 	if rv.IsNil() {
@@ -716,6 +1070,176 @@ func (m *IstioCertificateResponse) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
+func (m *IstioCertificateRevocationRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowIstioca
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: IstioCertificateRevocationRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: IstioCertificateRevocationRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SerialNumbers", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIstioca
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthIstioca
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthIstioca
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.SerialNumbers = append(m.SerialNumbers, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipIstioca(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthIstioca
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthIstioca
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *IstioCertificateRevocationResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowIstioca
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: IstioCertificateRevocationResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: IstioCertificateRevocationResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Crl", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIstioca
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthIstioca
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthIstioca
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Crl = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipIstioca(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthIstioca
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthIstioca
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
 func skipIstioca(dAtA []byte) (n int, err error) {
 	l := len(dAtA)
 	iNdEx := 0
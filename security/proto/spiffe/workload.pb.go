@@ -0,0 +1,3445 @@
+// Code generated by protoc-gen-gogo. DO NOT EDIT.
+// source: security/proto/spiffe/workload.proto
+
+package spiffe
+
+import (
+	bytes "bytes"
+	context "context"
+	fmt "fmt"
+	proto "github.com/gogo/protobuf/proto"
+	github_com_gogo_protobuf_sortkeys "github.com/gogo/protobuf/sortkeys"
+	types "github.com/gogo/protobuf/types"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	io "io"
+	math "math"
+	math_bits "math/bits"
+	reflect "reflect"
+	strings "strings"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.GoGoProtoPackageIsVersion2 // please upgrade the proto package
+
+// The X509SVIDRequest message conveys parameters for requesting an X.509-SVID.
+// There are currently no request parameters.
+type X509SVIDRequest struct {
+}
+
+func (m *X509SVIDRequest) Reset()      { *m = X509SVIDRequest{} }
+func (*X509SVIDRequest) ProtoMessage() {}
+func (*X509SVIDRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_458b05d58e5482b8, []int{0}
+}
+func (m *X509SVIDRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *X509SVIDRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_X509SVIDRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *X509SVIDRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_X509SVIDRequest.Merge(m, src)
+}
+func (m *X509SVIDRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *X509SVIDRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_X509SVIDRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_X509SVIDRequest proto.InternalMessageInfo
+
+// The X509SVIDResponse message carries a set of X.509 SVIDs and their
+// associated information. It also carries a set of global CRLs, and a
+// TTL to inform the workload when it should check back next.
+type X509SVIDResponse struct {
+	// A list of X509SVID messages, each of which includes a single
+	// SPIFFE Verifiable Identity Document, along with its private key
+	// and bundle.
+	Svids []*X509SVID `protobuf:"bytes,1,rep,name=svids,proto3" json:"svids,omitempty"`
+	// ASN.1 DER encoded
+	Crl [][]byte `protobuf:"bytes,2,rep,name=crl,proto3" json:"crl,omitempty"`
+	// CA certificate bundles belonging to foreign Trust Domains that the
+	// workload should trust, keyed by the SPIFFE ID of the foreign
+	// domain. Bundles are ASN.1 DER encoded.
+	FederatedBundles map[string][]byte `protobuf:"bytes,3,rep,name=federated_bundles,json=federatedBundles,proto3" json:"federated_bundles,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+}
+
+func (m *X509SVIDResponse) Reset()      { *m = X509SVIDResponse{} }
+func (*X509SVIDResponse) ProtoMessage() {}
+func (*X509SVIDResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_458b05d58e5482b8, []int{1}
+}
+func (m *X509SVIDResponse) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *X509SVIDResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_X509SVIDResponse.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *X509SVIDResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_X509SVIDResponse.Merge(m, src)
+}
+func (m *X509SVIDResponse) XXX_Size() int {
+	return m.Size()
+}
+func (m *X509SVIDResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_X509SVIDResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_X509SVIDResponse proto.InternalMessageInfo
+
+func (m *X509SVIDResponse) GetSvids() []*X509SVID {
+	if m != nil {
+		return m.Svids
+	}
+	return nil
+}
+
+func (m *X509SVIDResponse) GetCrl() [][]byte {
+	if m != nil {
+		return m.Crl
+	}
+	return nil
+}
+
+func (m *X509SVIDResponse) GetFederatedBundles() map[string][]byte {
+	if m != nil {
+		return m.FederatedBundles
+	}
+	return nil
+}
+
+// The X509SVID message carries a single SVID and all associated
+// information, including CA bundles.
+type X509SVID struct {
+	// The SPIFFE ID of the SVID in this entry
+	SpiffeId string `protobuf:"bytes,1,opt,name=spiffe_id,json=spiffeId,proto3" json:"spiffe_id,omitempty"`
+	// ASN.1 DER encoded certificate chain. MAY include intermediates,
+	// the leaf certificate (or SVID itself) MUST come first.
+	X509Svid []byte `protobuf:"bytes,2,opt,name=x509_svid,json=x509Svid,proto3" json:"x509_svid,omitempty"`
+	// ASN.1 DER encoded PKCS#8 private key. MUST be unencrypted.
+	X509SvidKey []byte `protobuf:"bytes,3,opt,name=x509_svid_key,json=x509SvidKey,proto3" json:"x509_svid_key,omitempty"`
+	// CA certificates belonging to the Trust Domain
+	// ASN.1 DER encoded
+	Bundle []byte `protobuf:"bytes,4,opt,name=bundle,proto3" json:"bundle,omitempty"`
+}
+
+func (m *X509SVID) Reset()      { *m = X509SVID{} }
+func (*X509SVID) ProtoMessage() {}
+func (*X509SVID) Descriptor() ([]byte, []int) {
+	return fileDescriptor_458b05d58e5482b8, []int{2}
+}
+func (m *X509SVID) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *X509SVID) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_X509SVID.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *X509SVID) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_X509SVID.Merge(m, src)
+}
+func (m *X509SVID) XXX_Size() int {
+	return m.Size()
+}
+func (m *X509SVID) XXX_DiscardUnknown() {
+	xxx_messageInfo_X509SVID.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_X509SVID proto.InternalMessageInfo
+
+func (m *X509SVID) GetSpiffeId() string {
+	if m != nil {
+		return m.SpiffeId
+	}
+	return ""
+}
+
+func (m *X509SVID) GetX509Svid() []byte {
+	if m != nil {
+		return m.X509Svid
+	}
+	return nil
+}
+
+func (m *X509SVID) GetX509SvidKey() []byte {
+	if m != nil {
+		return m.X509SvidKey
+	}
+	return nil
+}
+
+func (m *X509SVID) GetBundle() []byte {
+	if m != nil {
+		return m.Bundle
+	}
+	return nil
+}
+
+// The JWTSVID message carries a single JWT-SVID.
+type JWTSVID struct {
+	// The SPIFFE ID of the JWT-SVID.
+	SpiffeId string `protobuf:"bytes,1,opt,name=spiffe_id,json=spiffeId,proto3" json:"spiffe_id,omitempty"`
+	// Encoded using JWS Compact Serialization
+	Svid string `protobuf:"bytes,2,opt,name=svid,proto3" json:"svid,omitempty"`
+}
+
+func (m *JWTSVID) Reset()      { *m = JWTSVID{} }
+func (*JWTSVID) ProtoMessage() {}
+func (*JWTSVID) Descriptor() ([]byte, []int) {
+	return fileDescriptor_458b05d58e5482b8, []int{3}
+}
+func (m *JWTSVID) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *JWTSVID) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_JWTSVID.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *JWTSVID) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_JWTSVID.Merge(m, src)
+}
+func (m *JWTSVID) XXX_Size() int {
+	return m.Size()
+}
+func (m *JWTSVID) XXX_DiscardUnknown() {
+	xxx_messageInfo_JWTSVID.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_JWTSVID proto.InternalMessageInfo
+
+func (m *JWTSVID) GetSpiffeId() string {
+	if m != nil {
+		return m.SpiffeId
+	}
+	return ""
+}
+
+func (m *JWTSVID) GetSvid() string {
+	if m != nil {
+		return m.Svid
+	}
+	return ""
+}
+
+// The JWTSVIDRequest message conveys parameters for requesting JWT-SVIDs.
+type JWTSVIDRequest struct {
+	// The audience(s) the workload intends to authenticate against.
+	Audience []string `protobuf:"bytes,1,rep,name=audience,proto3" json:"audience,omitempty"`
+	// The requested SPIFFE ID for the JWT-SVID. If unset, all JWT-SVIDs
+	// for the workload are returned.
+	SpiffeId string `protobuf:"bytes,2,opt,name=spiffe_id,json=spiffeId,proto3" json:"spiffe_id,omitempty"`
+}
+
+func (m *JWTSVIDRequest) Reset()      { *m = JWTSVIDRequest{} }
+func (*JWTSVIDRequest) ProtoMessage() {}
+func (*JWTSVIDRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_458b05d58e5482b8, []int{4}
+}
+func (m *JWTSVIDRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *JWTSVIDRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_JWTSVIDRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *JWTSVIDRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_JWTSVIDRequest.Merge(m, src)
+}
+func (m *JWTSVIDRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *JWTSVIDRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_JWTSVIDRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_JWTSVIDRequest proto.InternalMessageInfo
+
+func (m *JWTSVIDRequest) GetAudience() []string {
+	if m != nil {
+		return m.Audience
+	}
+	return nil
+}
+
+func (m *JWTSVIDRequest) GetSpiffeId() string {
+	if m != nil {
+		return m.SpiffeId
+	}
+	return ""
+}
+
+// The JWTSVIDResponse message conveys JWT-SVIDs.
+type JWTSVIDResponse struct {
+	// The list of returned JWT-SVIDs.
+	Svids []*JWTSVID `protobuf:"bytes,1,rep,name=svids,proto3" json:"svids,omitempty"`
+}
+
+func (m *JWTSVIDResponse) Reset()      { *m = JWTSVIDResponse{} }
+func (*JWTSVIDResponse) ProtoMessage() {}
+func (*JWTSVIDResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_458b05d58e5482b8, []int{5}
+}
+func (m *JWTSVIDResponse) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *JWTSVIDResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_JWTSVIDResponse.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *JWTSVIDResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_JWTSVIDResponse.Merge(m, src)
+}
+func (m *JWTSVIDResponse) XXX_Size() int {
+	return m.Size()
+}
+func (m *JWTSVIDResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_JWTSVIDResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_JWTSVIDResponse proto.InternalMessageInfo
+
+func (m *JWTSVIDResponse) GetSvids() []*JWTSVID {
+	if m != nil {
+		return m.Svids
+	}
+	return nil
+}
+
+// The JWTBundlesRequest message conveys parameters for requesting JWT bundles.
+// There are currently no such parameters.
+type JWTBundlesRequest struct {
+}
+
+func (m *JWTBundlesRequest) Reset()      { *m = JWTBundlesRequest{} }
+func (*JWTBundlesRequest) ProtoMessage() {}
+func (*JWTBundlesRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_458b05d58e5482b8, []int{6}
+}
+func (m *JWTBundlesRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *JWTBundlesRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_JWTBundlesRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *JWTBundlesRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_JWTBundlesRequest.Merge(m, src)
+}
+func (m *JWTBundlesRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *JWTBundlesRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_JWTBundlesRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_JWTBundlesRequest proto.InternalMessageInfo
+
+// The JWTBundlesReponse conveys JWT bundles.
+type JWTBundlesResponse struct {
+	// JWK sets, keyed by trust domain URI
+	Bundles map[string][]byte `protobuf:"bytes,1,rep,name=bundles,proto3" json:"bundles,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+}
+
+func (m *JWTBundlesResponse) Reset()      { *m = JWTBundlesResponse{} }
+func (*JWTBundlesResponse) ProtoMessage() {}
+func (*JWTBundlesResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_458b05d58e5482b8, []int{7}
+}
+func (m *JWTBundlesResponse) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *JWTBundlesResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_JWTBundlesResponse.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *JWTBundlesResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_JWTBundlesResponse.Merge(m, src)
+}
+func (m *JWTBundlesResponse) XXX_Size() int {
+	return m.Size()
+}
+func (m *JWTBundlesResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_JWTBundlesResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_JWTBundlesResponse proto.InternalMessageInfo
+
+func (m *JWTBundlesResponse) GetBundles() map[string][]byte {
+	if m != nil {
+		return m.Bundles
+	}
+	return nil
+}
+
+// The ValidateJWTSVIDRequest message conveys request parameters for
+// JWT-SVID validation.
+type ValidateJWTSVIDRequest struct {
+	// The audience of the validating party. The JWT-SVID must
+	// contain this audience to be valid.
+	Audience string `protobuf:"bytes,1,opt,name=audience,proto3" json:"audience,omitempty"`
+	// The JWT-SVID to validate, encoded using JWS Compact Serialization.
+	Svid string `protobuf:"bytes,2,opt,name=svid,proto3" json:"svid,omitempty"`
+}
+
+func (m *ValidateJWTSVIDRequest) Reset()      { *m = ValidateJWTSVIDRequest{} }
+func (*ValidateJWTSVIDRequest) ProtoMessage() {}
+func (*ValidateJWTSVIDRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_458b05d58e5482b8, []int{8}
+}
+func (m *ValidateJWTSVIDRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *ValidateJWTSVIDRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_ValidateJWTSVIDRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *ValidateJWTSVIDRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ValidateJWTSVIDRequest.Merge(m, src)
+}
+func (m *ValidateJWTSVIDRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *ValidateJWTSVIDRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ValidateJWTSVIDRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ValidateJWTSVIDRequest proto.InternalMessageInfo
+
+func (m *ValidateJWTSVIDRequest) GetAudience() string {
+	if m != nil {
+		return m.Audience
+	}
+	return ""
+}
+
+func (m *ValidateJWTSVIDRequest) GetSvid() string {
+	if m != nil {
+		return m.Svid
+	}
+	return ""
+}
+
+// The ValidateJWTSVIDReponse message conveys the JWT-SVID validation results.
+type ValidateJWTSVIDResponse struct {
+	// The SPIFFE ID of the validated JWT-SVID.
+	SpiffeId string `protobuf:"bytes,1,opt,name=spiffe_id,json=spiffeId,proto3" json:"spiffe_id,omitempty"`
+	// Arbitrary claims contained within the payload of the validated JWT-SVID.
+	Claims *types.Struct `protobuf:"bytes,2,opt,name=claims,proto3" json:"claims,omitempty"`
+}
+
+func (m *ValidateJWTSVIDResponse) Reset()      { *m = ValidateJWTSVIDResponse{} }
+func (*ValidateJWTSVIDResponse) ProtoMessage() {}
+func (*ValidateJWTSVIDResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_458b05d58e5482b8, []int{9}
+}
+func (m *ValidateJWTSVIDResponse) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *ValidateJWTSVIDResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_ValidateJWTSVIDResponse.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *ValidateJWTSVIDResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ValidateJWTSVIDResponse.Merge(m, src)
+}
+func (m *ValidateJWTSVIDResponse) XXX_Size() int {
+	return m.Size()
+}
+func (m *ValidateJWTSVIDResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ValidateJWTSVIDResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ValidateJWTSVIDResponse proto.InternalMessageInfo
+
+func (m *ValidateJWTSVIDResponse) GetSpiffeId() string {
+	if m != nil {
+		return m.SpiffeId
+	}
+	return ""
+}
+
+func (m *ValidateJWTSVIDResponse) GetClaims() *types.Struct {
+	if m != nil {
+		return m.Claims
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterType((*X509SVIDRequest)(nil), "X509SVIDRequest")
+	proto.RegisterType((*X509SVIDResponse)(nil), "X509SVIDResponse")
+	proto.RegisterMapType((map[string][]byte)(nil), "X509SVIDResponse.FederatedBundlesEntry")
+	proto.RegisterType((*X509SVID)(nil), "X509SVID")
+	proto.RegisterType((*JWTSVID)(nil), "JWTSVID")
+	proto.RegisterType((*JWTSVIDRequest)(nil), "JWTSVIDRequest")
+	proto.RegisterType((*JWTSVIDResponse)(nil), "JWTSVIDResponse")
+	proto.RegisterType((*JWTBundlesRequest)(nil), "JWTBundlesRequest")
+	proto.RegisterType((*JWTBundlesResponse)(nil), "JWTBundlesResponse")
+	proto.RegisterMapType((map[string][]byte)(nil), "JWTBundlesResponse.BundlesEntry")
+	proto.RegisterType((*ValidateJWTSVIDRequest)(nil), "ValidateJWTSVIDRequest")
+	proto.RegisterType((*ValidateJWTSVIDResponse)(nil), "ValidateJWTSVIDResponse")
+}
+
+func init() {
+	proto.RegisterFile("security/proto/spiffe/workload.proto", fileDescriptor_458b05d58e5482b8)
+}
+
+var fileDescriptor_458b05d58e5482b8 = []byte{
+	// 607 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x54, 0x4d, 0x6f, 0xd3, 0x40,
+	0x10, 0xf5, 0x26, 0x6d, 0x9a, 0x4c, 0x53, 0x92, 0x6c, 0xa1, 0xb5, 0x0c, 0x5a, 0x22, 0x0b, 0x89,
+	0x9c, 0x36, 0x69, 0x51, 0x11, 0x8d, 0x7a, 0xa1, 0x94, 0x8a, 0x94, 0x0b, 0x72, 0xaa, 0x16, 0x71,
+	0x89, 0x5c, 0x7b, 0x53, 0xac, 0x9a, 0xb8, 0xf8, 0xa3, 0x90, 0x5b, 0xc5, 0x99, 0x03, 0x3f, 0x83,
+	0x9f, 0xc2, 0x31, 0xc7, 0x1e, 0x1b, 0xe7, 0xc2, 0xb1, 0x3f, 0x01, 0xd9, 0xbb, 0x36, 0x38, 0x09,
+	0x45, 0xe2, 0xb6, 0x3b, 0xf3, 0xe6, 0xed, 0xcc, 0x7b, 0x1e, 0xc3, 0x23, 0x8f, 0x19, 0x81, 0x6b,
+	0xf9, 0xc3, 0xe6, 0xb9, 0xeb, 0xf8, 0x4e, 0xd3, 0x3b, 0xb7, 0xfa, 0x7d, 0xd6, 0xfc, 0xe4, 0xb8,
+	0x67, 0xb6, 0xa3, 0x9b, 0x34, 0x8e, 0x2a, 0x0f, 0x4e, 0x1d, 0xe7, 0xd4, 0x66, 0x1c, 0x73, 0x12,
+	0xf4, 0x9b, 0x9e, 0xef, 0x06, 0x86, 0xcf, 0xb3, 0x6a, 0x0d, 0x2a, 0x6f, 0xb7, 0x5a, 0xdb, 0xdd,
+	0xa3, 0xce, 0x9e, 0xc6, 0x3e, 0x06, 0xcc, 0xf3, 0xd5, 0x6b, 0x04, 0xd5, 0xdf, 0x31, 0xef, 0xdc,
+	0x19, 0x78, 0x0c, 0x3f, 0x84, 0x45, 0xef, 0xc2, 0x32, 0x3d, 0x19, 0xd5, 0xf3, 0x8d, 0xe5, 0xcd,
+	0x12, 0x4d, 0x11, 0x3c, 0x8e, 0xab, 0x90, 0x37, 0x5c, 0x5b, 0xce, 0xd5, 0xf3, 0x8d, 0xb2, 0x16,
+	0x1d, 0xf1, 0x21, 0xd4, 0xfa, 0xcc, 0x64, 0xae, 0xee, 0x33, 0xb3, 0x77, 0x12, 0x0c, 0x4c, 0x9b,
+	0x79, 0x72, 0x3e, 0x2e, 0x7f, 0x4c, 0xa7, 0x1f, 0xa0, 0xfb, 0x09, 0x74, 0x97, 0x23, 0x5f, 0x0e,
+	0x7c, 0x77, 0xa8, 0x55, 0xfb, 0x53, 0x61, 0xe5, 0x05, 0xdc, 0x9b, 0x0b, 0x8d, 0x1a, 0x38, 0x63,
+	0x43, 0x19, 0xd5, 0x51, 0xa3, 0xa4, 0x45, 0x47, 0x7c, 0x17, 0x16, 0x2f, 0x74, 0x3b, 0x60, 0x72,
+	0xae, 0x8e, 0x1a, 0x65, 0x8d, 0x5f, 0xda, 0xb9, 0x67, 0x48, 0xbd, 0x44, 0x50, 0x4c, 0x3a, 0xc0,
+	0xf7, 0xa1, 0xc4, 0x95, 0xeb, 0x59, 0xa6, 0x28, 0x2f, 0xf2, 0x40, 0xc7, 0x8c, 0x92, 0x9f, 0xb7,
+	0x5a, 0xdb, 0xbd, 0x68, 0x48, 0xc1, 0x53, 0x8c, 0x02, 0xdd, 0x0b, 0xcb, 0xc4, 0x2a, 0xac, 0xa4,
+	0xc9, 0x5e, 0xf4, 0x78, 0x3e, 0x06, 0x2c, 0x27, 0x80, 0xd7, 0x6c, 0x88, 0xd7, 0xa0, 0xc0, 0x67,
+	0x97, 0x17, 0xe2, 0xa4, 0xb8, 0xa9, 0x6d, 0x58, 0x3a, 0x38, 0x3e, 0xfc, 0x77, 0x03, 0x18, 0x16,
+	0xd2, 0xb7, 0x4b, 0x5a, 0x7c, 0x56, 0x3b, 0x70, 0x47, 0xd4, 0x0a, 0xcf, 0xb0, 0x02, 0x45, 0x3d,
+	0x30, 0x2d, 0x36, 0x30, 0x58, 0xec, 0x50, 0x49, 0x4b, 0xef, 0x59, 0xfa, 0x5c, 0x96, 0x5e, 0xdd,
+	0x80, 0x4a, 0x4a, 0x25, 0xac, 0x26, 0x59, 0xab, 0x8b, 0x34, 0x01, 0xf0, 0xb0, 0xba, 0x0a, 0xb5,
+	0x83, 0xe3, 0x43, 0xa1, 0x7d, 0xf2, 0xd1, 0x7c, 0x45, 0x80, 0xff, 0x8c, 0x0a, 0xae, 0x36, 0x2c,
+	0x25, 0xce, 0x73, 0xb6, 0x3a, 0x9d, 0x45, 0xd1, 0x8c, 0xe5, 0x49, 0x81, 0xd2, 0x86, 0xf2, 0x7f,
+	0x1b, 0xfc, 0x0a, 0xd6, 0x8e, 0x74, 0xdb, 0x32, 0x75, 0x9f, 0xdd, 0xaa, 0x14, 0xca, 0x28, 0x35,
+	0x4f, 0xeb, 0x53, 0x58, 0x9f, 0x61, 0x12, 0xc3, 0xdd, 0xea, 0x5b, 0x13, 0x0a, 0x86, 0xad, 0x5b,
+	0x1f, 0xbc, 0x98, 0x6d, 0x79, 0x73, 0x9d, 0xf2, 0x3d, 0xa4, 0xc9, 0x1e, 0xd2, 0x6e, 0xbc, 0x87,
+	0x9a, 0x80, 0x6d, 0x7e, 0xc9, 0x41, 0xad, 0x1b, 0x57, 0x1f, 0x8b, 0x05, 0x7e, 0xfe, 0xa6, 0x83,
+	0x37, 0xa0, 0xbc, 0xcf, 0x7c, 0xe3, 0x7d, 0xf2, 0xad, 0x54, 0x68, 0x76, 0x1e, 0xa5, 0x4a, 0xa7,
+	0xdb, 0xda, 0x81, 0x4a, 0x52, 0x22, 0xf4, 0xc3, 0x98, 0xce, 0x38, 0xa6, 0xac, 0xce, 0x71, 0xa2,
+	0x85, 0xf0, 0x1e, 0x54, 0xa6, 0xe6, 0xc5, 0xeb, 0x74, 0xbe, 0x96, 0x8a, 0x4c, 0xff, 0x26, 0xcd,
+	0x53, 0x58, 0x89, 0x7b, 0x48, 0x97, 0xac, 0x4a, 0xa7, 0x7e, 0x33, 0x4a, 0x6d, 0xe6, 0x1f, 0xd0,
+	0x42, 0xbb, 0x3b, 0xa3, 0x31, 0x91, 0xae, 0xc6, 0x44, 0xba, 0x19, 0x13, 0x74, 0x19, 0x12, 0xf4,
+	0x3d, 0x24, 0xe8, 0x47, 0x48, 0xd0, 0x28, 0x24, 0xe8, 0x3a, 0x24, 0xe8, 0x67, 0x48, 0xa4, 0x9b,
+	0x90, 0xa0, 0x6f, 0x13, 0x22, 0x8d, 0x26, 0x44, 0xba, 0x9a, 0x10, 0xe9, 0x5d, 0x81, 0xab, 0x7e,
+	0x52, 0x88, 0xb5, 0x7d, 0xf2, 0x6b, 0x00, 0xd4, 0xa9, 0x72, 0x3b, 0x19, 0x05, 0x00, 0x00,
+}
+
+func (this *X509SVIDRequest) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*X509SVIDRequest)
+	if !ok {
+		that2, ok := that.(X509SVIDRequest)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	return true
+}
+func (this *X509SVIDResponse) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*X509SVIDResponse)
+	if !ok {
+		that2, ok := that.(X509SVIDResponse)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if len(this.Svids) != len(that1.Svids) {
+		return false
+	}
+	for i := range this.Svids {
+		if !this.Svids[i].Equal(that1.Svids[i]) {
+			return false
+		}
+	}
+	if len(this.Crl) != len(that1.Crl) {
+		return false
+	}
+	for i := range this.Crl {
+		if !bytes.Equal(this.Crl[i], that1.Crl[i]) {
+			return false
+		}
+	}
+	if len(this.FederatedBundles) != len(that1.FederatedBundles) {
+		return false
+	}
+	for i := range this.FederatedBundles {
+		if !bytes.Equal(this.FederatedBundles[i], that1.FederatedBundles[i]) {
+			return false
+		}
+	}
+	return true
+}
+func (this *X509SVID) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*X509SVID)
+	if !ok {
+		that2, ok := that.(X509SVID)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.SpiffeId != that1.SpiffeId {
+		return false
+	}
+	if !bytes.Equal(this.X509Svid, that1.X509Svid) {
+		return false
+	}
+	if !bytes.Equal(this.X509SvidKey, that1.X509SvidKey) {
+		return false
+	}
+	if !bytes.Equal(this.Bundle, that1.Bundle) {
+		return false
+	}
+	return true
+}
+func (this *JWTSVID) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*JWTSVID)
+	if !ok {
+		that2, ok := that.(JWTSVID)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.SpiffeId != that1.SpiffeId {
+		return false
+	}
+	if this.Svid != that1.Svid {
+		return false
+	}
+	return true
+}
+func (this *JWTSVIDRequest) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*JWTSVIDRequest)
+	if !ok {
+		that2, ok := that.(JWTSVIDRequest)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if len(this.Audience) != len(that1.Audience) {
+		return false
+	}
+	for i := range this.Audience {
+		if this.Audience[i] != that1.Audience[i] {
+			return false
+		}
+	}
+	if this.SpiffeId != that1.SpiffeId {
+		return false
+	}
+	return true
+}
+func (this *JWTSVIDResponse) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*JWTSVIDResponse)
+	if !ok {
+		that2, ok := that.(JWTSVIDResponse)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if len(this.Svids) != len(that1.Svids) {
+		return false
+	}
+	for i := range this.Svids {
+		if !this.Svids[i].Equal(that1.Svids[i]) {
+			return false
+		}
+	}
+	return true
+}
+func (this *JWTBundlesRequest) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*JWTBundlesRequest)
+	if !ok {
+		that2, ok := that.(JWTBundlesRequest)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	return true
+}
+func (this *JWTBundlesResponse) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*JWTBundlesResponse)
+	if !ok {
+		that2, ok := that.(JWTBundlesResponse)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if len(this.Bundles) != len(that1.Bundles) {
+		return false
+	}
+	for i := range this.Bundles {
+		if !bytes.Equal(this.Bundles[i], that1.Bundles[i]) {
+			return false
+		}
+	}
+	return true
+}
+func (this *ValidateJWTSVIDRequest) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*ValidateJWTSVIDRequest)
+	if !ok {
+		that2, ok := that.(ValidateJWTSVIDRequest)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.Audience != that1.Audience {
+		return false
+	}
+	if this.Svid != that1.Svid {
+		return false
+	}
+	return true
+}
+func (this *ValidateJWTSVIDResponse) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*ValidateJWTSVIDResponse)
+	if !ok {
+		that2, ok := that.(ValidateJWTSVIDResponse)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.SpiffeId != that1.SpiffeId {
+		return false
+	}
+	if !this.Claims.Equal(that1.Claims) {
+		return false
+	}
+	return true
+}
+func (this *X509SVIDRequest) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 4)
+	s = append(s, "&spiffe.X509SVIDRequest{")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *X509SVIDResponse) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 7)
+	s = append(s, "&spiffe.X509SVIDResponse{")
+	if this.Svids != nil {
+		s = append(s, "Svids: "+fmt.Sprintf("%#v", this.Svids)+",\n")
+	}
+	s = append(s, "Crl: "+fmt.Sprintf("%#v", this.Crl)+",\n")
+	keysForFederatedBundles := make([]string, 0, len(this.FederatedBundles))
+	for k, _ := range this.FederatedBundles {
+		keysForFederatedBundles = append(keysForFederatedBundles, k)
+	}
+	github_com_gogo_protobuf_sortkeys.Strings(keysForFederatedBundles)
+	mapStringForFederatedBundles := "map[string][]byte{"
+	for _, k := range keysForFederatedBundles {
+		mapStringForFederatedBundles += fmt.Sprintf("%#v: %#v,", k, this.FederatedBundles[k])
+	}
+	mapStringForFederatedBundles += "}"
+	if this.FederatedBundles != nil {
+		s = append(s, "FederatedBundles: "+mapStringForFederatedBundles+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *X509SVID) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 8)
+	s = append(s, "&spiffe.X509SVID{")
+	s = append(s, "SpiffeId: "+fmt.Sprintf("%#v", this.SpiffeId)+",\n")
+	s = append(s, "X509Svid: "+fmt.Sprintf("%#v", this.X509Svid)+",\n")
+	s = append(s, "X509SvidKey: "+fmt.Sprintf("%#v", this.X509SvidKey)+",\n")
+	s = append(s, "Bundle: "+fmt.Sprintf("%#v", this.Bundle)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *JWTSVID) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 6)
+	s = append(s, "&spiffe.JWTSVID{")
+	s = append(s, "SpiffeId: "+fmt.Sprintf("%#v", this.SpiffeId)+",\n")
+	s = append(s, "Svid: "+fmt.Sprintf("%#v", this.Svid)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *JWTSVIDRequest) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 6)
+	s = append(s, "&spiffe.JWTSVIDRequest{")
+	s = append(s, "Audience: "+fmt.Sprintf("%#v", this.Audience)+",\n")
+	s = append(s, "SpiffeId: "+fmt.Sprintf("%#v", this.SpiffeId)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *JWTSVIDResponse) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 5)
+	s = append(s, "&spiffe.JWTSVIDResponse{")
+	if this.Svids != nil {
+		s = append(s, "Svids: "+fmt.Sprintf("%#v", this.Svids)+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *JWTBundlesRequest) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 4)
+	s = append(s, "&spiffe.JWTBundlesRequest{")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *JWTBundlesResponse) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 5)
+	s = append(s, "&spiffe.JWTBundlesResponse{")
+	keysForBundles := make([]string, 0, len(this.Bundles))
+	for k, _ := range this.Bundles {
+		keysForBundles = append(keysForBundles, k)
+	}
+	github_com_gogo_protobuf_sortkeys.Strings(keysForBundles)
+	mapStringForBundles := "map[string][]byte{"
+	for _, k := range keysForBundles {
+		mapStringForBundles += fmt.Sprintf("%#v: %#v,", k, this.Bundles[k])
+	}
+	mapStringForBundles += "}"
+	if this.Bundles != nil {
+		s = append(s, "Bundles: "+mapStringForBundles+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *ValidateJWTSVIDRequest) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 6)
+	s = append(s, "&spiffe.ValidateJWTSVIDRequest{")
+	s = append(s, "Audience: "+fmt.Sprintf("%#v", this.Audience)+",\n")
+	s = append(s, "Svid: "+fmt.Sprintf("%#v", this.Svid)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *ValidateJWTSVIDResponse) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 6)
+	s = append(s, "&spiffe.ValidateJWTSVIDResponse{")
+	s = append(s, "SpiffeId: "+fmt.Sprintf("%#v", this.SpiffeId)+",\n")
+	if this.Claims != nil {
+		s = append(s, "Claims: "+fmt.Sprintf("%#v", this.Claims)+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func valueToGoStringWorkload(v interface{}, typ string) string {
+	rv := reflect.ValueOf(v)
+	if rv.IsNil() {
+		return "nil"
+	}
+	pv := reflect.Indirect(rv).Interface()
+	return fmt.Sprintf("func(v %v) *%v { return &v } ( %#v )", typ, typ, pv)
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// SpiffeWorkloadAPIClient is the client API for SpiffeWorkloadAPI service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type SpiffeWorkloadAPIClient interface {
+	// JWT-SVID Profile
+	FetchJWTSVID(ctx context.Context, in *JWTSVIDRequest, opts ...grpc.CallOption) (*JWTSVIDResponse, error)
+	FetchJWTBundles(ctx context.Context, in *JWTBundlesRequest, opts ...grpc.CallOption) (SpiffeWorkloadAPI_FetchJWTBundlesClient, error)
+	ValidateJWTSVID(ctx context.Context, in *ValidateJWTSVIDRequest, opts ...grpc.CallOption) (*ValidateJWTSVIDResponse, error)
+	// X.509-SVID Profile
+	// Fetch all SPIFFE identities the workload is entitled to, as
+	// well as related information like trust bundles and CRLs. As
+	// this information changes, subsequent messages will be sent.
+	FetchX509SVID(ctx context.Context, in *X509SVIDRequest, opts ...grpc.CallOption) (SpiffeWorkloadAPI_FetchX509SVIDClient, error)
+}
+
+type spiffeWorkloadAPIClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewSpiffeWorkloadAPIClient(cc *grpc.ClientConn) SpiffeWorkloadAPIClient {
+	return &spiffeWorkloadAPIClient{cc}
+}
+
+func (c *spiffeWorkloadAPIClient) FetchJWTSVID(ctx context.Context, in *JWTSVIDRequest, opts ...grpc.CallOption) (*JWTSVIDResponse, error) {
+	out := new(JWTSVIDResponse)
+	err := c.cc.Invoke(ctx, "/SpiffeWorkloadAPI/FetchJWTSVID", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *spiffeWorkloadAPIClient) FetchJWTBundles(ctx context.Context, in *JWTBundlesRequest, opts ...grpc.CallOption) (SpiffeWorkloadAPI_FetchJWTBundlesClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_SpiffeWorkloadAPI_serviceDesc.Streams[0], "/SpiffeWorkloadAPI/FetchJWTBundles", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &spiffeWorkloadAPIFetchJWTBundlesClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type SpiffeWorkloadAPI_FetchJWTBundlesClient interface {
+	Recv() (*JWTBundlesResponse, error)
+	grpc.ClientStream
+}
+
+type spiffeWorkloadAPIFetchJWTBundlesClient struct {
+	grpc.ClientStream
+}
+
+func (x *spiffeWorkloadAPIFetchJWTBundlesClient) Recv() (*JWTBundlesResponse, error) {
+	m := new(JWTBundlesResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *spiffeWorkloadAPIClient) ValidateJWTSVID(ctx context.Context, in *ValidateJWTSVIDRequest, opts ...grpc.CallOption) (*ValidateJWTSVIDResponse, error) {
+	out := new(ValidateJWTSVIDResponse)
+	err := c.cc.Invoke(ctx, "/SpiffeWorkloadAPI/ValidateJWTSVID", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *spiffeWorkloadAPIClient) FetchX509SVID(ctx context.Context, in *X509SVIDRequest, opts ...grpc.CallOption) (SpiffeWorkloadAPI_FetchX509SVIDClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_SpiffeWorkloadAPI_serviceDesc.Streams[1], "/SpiffeWorkloadAPI/FetchX509SVID", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &spiffeWorkloadAPIFetchX509SVIDClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type SpiffeWorkloadAPI_FetchX509SVIDClient interface {
+	Recv() (*X509SVIDResponse, error)
+	grpc.ClientStream
+}
+
+type spiffeWorkloadAPIFetchX509SVIDClient struct {
+	grpc.ClientStream
+}
+
+func (x *spiffeWorkloadAPIFetchX509SVIDClient) Recv() (*X509SVIDResponse, error) {
+	m := new(X509SVIDResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// SpiffeWorkloadAPIServer is the server API for SpiffeWorkloadAPI service.
+type SpiffeWorkloadAPIServer interface {
+	// JWT-SVID Profile
+	FetchJWTSVID(context.Context, *JWTSVIDRequest) (*JWTSVIDResponse, error)
+	FetchJWTBundles(*JWTBundlesRequest, SpiffeWorkloadAPI_FetchJWTBundlesServer) error
+	ValidateJWTSVID(context.Context, *ValidateJWTSVIDRequest) (*ValidateJWTSVIDResponse, error)
+	// X.509-SVID Profile
+	// Fetch all SPIFFE identities the workload is entitled to, as
+	// well as related information like trust bundles and CRLs. As
+	// this information changes, subsequent messages will be sent.
+	FetchX509SVID(*X509SVIDRequest, SpiffeWorkloadAPI_FetchX509SVIDServer) error
+}
+
+// UnimplementedSpiffeWorkloadAPIServer can be embedded to have forward compatible implementations.
+type UnimplementedSpiffeWorkloadAPIServer struct {
+}
+
+func (*UnimplementedSpiffeWorkloadAPIServer) FetchJWTSVID(ctx context.Context, req *JWTSVIDRequest) (*JWTSVIDResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FetchJWTSVID not implemented")
+}
+func (*UnimplementedSpiffeWorkloadAPIServer) FetchJWTBundles(req *JWTBundlesRequest, srv SpiffeWorkloadAPI_FetchJWTBundlesServer) error {
+	return status.Errorf(codes.Unimplemented, "method FetchJWTBundles not implemented")
+}
+func (*UnimplementedSpiffeWorkloadAPIServer) ValidateJWTSVID(ctx context.Context, req *ValidateJWTSVIDRequest) (*ValidateJWTSVIDResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ValidateJWTSVID not implemented")
+}
+func (*UnimplementedSpiffeWorkloadAPIServer) FetchX509SVID(req *X509SVIDRequest, srv SpiffeWorkloadAPI_FetchX509SVIDServer) error {
+	return status.Errorf(codes.Unimplemented, "method FetchX509SVID not implemented")
+}
+
+func RegisterSpiffeWorkloadAPIServer(s *grpc.Server, srv SpiffeWorkloadAPIServer) {
+	s.RegisterService(&_SpiffeWorkloadAPI_serviceDesc, srv)
+}
+
+func _SpiffeWorkloadAPI_FetchJWTSVID_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(JWTSVIDRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SpiffeWorkloadAPIServer).FetchJWTSVID(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/SpiffeWorkloadAPI/FetchJWTSVID",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SpiffeWorkloadAPIServer).FetchJWTSVID(ctx, req.(*JWTSVIDRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SpiffeWorkloadAPI_FetchJWTBundles_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(JWTBundlesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(SpiffeWorkloadAPIServer).FetchJWTBundles(m, &spiffeWorkloadAPIFetchJWTBundlesServer{stream})
+}
+
+type SpiffeWorkloadAPI_FetchJWTBundlesServer interface {
+	Send(*JWTBundlesResponse) error
+	grpc.ServerStream
+}
+
+type spiffeWorkloadAPIFetchJWTBundlesServer struct {
+	grpc.ServerStream
+}
+
+func (x *spiffeWorkloadAPIFetchJWTBundlesServer) Send(m *JWTBundlesResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _SpiffeWorkloadAPI_ValidateJWTSVID_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ValidateJWTSVIDRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SpiffeWorkloadAPIServer).ValidateJWTSVID(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/SpiffeWorkloadAPI/ValidateJWTSVID",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SpiffeWorkloadAPIServer).ValidateJWTSVID(ctx, req.(*ValidateJWTSVIDRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SpiffeWorkloadAPI_FetchX509SVID_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(X509SVIDRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(SpiffeWorkloadAPIServer).FetchX509SVID(m, &spiffeWorkloadAPIFetchX509SVIDServer{stream})
+}
+
+type SpiffeWorkloadAPI_FetchX509SVIDServer interface {
+	Send(*X509SVIDResponse) error
+	grpc.ServerStream
+}
+
+type spiffeWorkloadAPIFetchX509SVIDServer struct {
+	grpc.ServerStream
+}
+
+func (x *spiffeWorkloadAPIFetchX509SVIDServer) Send(m *X509SVIDResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+var _SpiffeWorkloadAPI_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "SpiffeWorkloadAPI",
+	HandlerType: (*SpiffeWorkloadAPIServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "FetchJWTSVID",
+			Handler:    _SpiffeWorkloadAPI_FetchJWTSVID_Handler,
+		},
+		{
+			MethodName: "ValidateJWTSVID",
+			Handler:    _SpiffeWorkloadAPI_ValidateJWTSVID_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "FetchJWTBundles",
+			Handler:       _SpiffeWorkloadAPI_FetchJWTBundles_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "FetchX509SVID",
+			Handler:       _SpiffeWorkloadAPI_FetchX509SVID_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "security/proto/spiffe/workload.proto",
+}
+
+func (m *X509SVIDRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *X509SVIDRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *X509SVIDRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	return len(dAtA) - i, nil
+}
+
+func (m *X509SVIDResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *X509SVIDResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *X509SVIDResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.FederatedBundles) > 0 {
+		for k := range m.FederatedBundles {
+			v := m.FederatedBundles[k]
+			baseI := i
+			if len(v) > 0 {
+				i -= len(v)
+				copy(dAtA[i:], v)
+				i = encodeVarintWorkload(dAtA, i, uint64(len(v)))
+				i--
+				dAtA[i] = 0x12
+			}
+			i -= len(k)
+			copy(dAtA[i:], k)
+			i = encodeVarintWorkload(dAtA, i, uint64(len(k)))
+			i--
+			dAtA[i] = 0xa
+			i = encodeVarintWorkload(dAtA, i, uint64(baseI-i))
+			i--
+			dAtA[i] = 0x1a
+		}
+	}
+	if len(m.Crl) > 0 {
+		for iNdEx := len(m.Crl) - 1; iNdEx >= 0; iNdEx-- {
+			i -= len(m.Crl[iNdEx])
+			copy(dAtA[i:], m.Crl[iNdEx])
+			i = encodeVarintWorkload(dAtA, i, uint64(len(m.Crl[iNdEx])))
+			i--
+			dAtA[i] = 0x12
+		}
+	}
+	if len(m.Svids) > 0 {
+		for iNdEx := len(m.Svids) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Svids[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintWorkload(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *X509SVID) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *X509SVID) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *X509SVID) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Bundle) > 0 {
+		i -= len(m.Bundle)
+		copy(dAtA[i:], m.Bundle)
+		i = encodeVarintWorkload(dAtA, i, uint64(len(m.Bundle)))
+		i--
+		dAtA[i] = 0x22
+	}
+	if len(m.X509SvidKey) > 0 {
+		i -= len(m.X509SvidKey)
+		copy(dAtA[i:], m.X509SvidKey)
+		i = encodeVarintWorkload(dAtA, i, uint64(len(m.X509SvidKey)))
+		i--
+		dAtA[i] = 0x1a
+	}
+	if len(m.X509Svid) > 0 {
+		i -= len(m.X509Svid)
+		copy(dAtA[i:], m.X509Svid)
+		i = encodeVarintWorkload(dAtA, i, uint64(len(m.X509Svid)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if len(m.SpiffeId) > 0 {
+		i -= len(m.SpiffeId)
+		copy(dAtA[i:], m.SpiffeId)
+		i = encodeVarintWorkload(dAtA, i, uint64(len(m.SpiffeId)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *JWTSVID) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *JWTSVID) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *JWTSVID) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Svid) > 0 {
+		i -= len(m.Svid)
+		copy(dAtA[i:], m.Svid)
+		i = encodeVarintWorkload(dAtA, i, uint64(len(m.Svid)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if len(m.SpiffeId) > 0 {
+		i -= len(m.SpiffeId)
+		copy(dAtA[i:], m.SpiffeId)
+		i = encodeVarintWorkload(dAtA, i, uint64(len(m.SpiffeId)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *JWTSVIDRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *JWTSVIDRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *JWTSVIDRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.SpiffeId) > 0 {
+		i -= len(m.SpiffeId)
+		copy(dAtA[i:], m.SpiffeId)
+		i = encodeVarintWorkload(dAtA, i, uint64(len(m.SpiffeId)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if len(m.Audience) > 0 {
+		for iNdEx := len(m.Audience) - 1; iNdEx >= 0; iNdEx-- {
+			i -= len(m.Audience[iNdEx])
+			copy(dAtA[i:], m.Audience[iNdEx])
+			i = encodeVarintWorkload(dAtA, i, uint64(len(m.Audience[iNdEx])))
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *JWTSVIDResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *JWTSVIDResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *JWTSVIDResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Svids) > 0 {
+		for iNdEx := len(m.Svids) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Svids[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintWorkload(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *JWTBundlesRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *JWTBundlesRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *JWTBundlesRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	return len(dAtA) - i, nil
+}
+
+func (m *JWTBundlesResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *JWTBundlesResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *JWTBundlesResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Bundles) > 0 {
+		for k := range m.Bundles {
+			v := m.Bundles[k]
+			baseI := i
+			if len(v) > 0 {
+				i -= len(v)
+				copy(dAtA[i:], v)
+				i = encodeVarintWorkload(dAtA, i, uint64(len(v)))
+				i--
+				dAtA[i] = 0x12
+			}
+			i -= len(k)
+			copy(dAtA[i:], k)
+			i = encodeVarintWorkload(dAtA, i, uint64(len(k)))
+			i--
+			dAtA[i] = 0xa
+			i = encodeVarintWorkload(dAtA, i, uint64(baseI-i))
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *ValidateJWTSVIDRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ValidateJWTSVIDRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ValidateJWTSVIDRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Svid) > 0 {
+		i -= len(m.Svid)
+		copy(dAtA[i:], m.Svid)
+		i = encodeVarintWorkload(dAtA, i, uint64(len(m.Svid)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if len(m.Audience) > 0 {
+		i -= len(m.Audience)
+		copy(dAtA[i:], m.Audience)
+		i = encodeVarintWorkload(dAtA, i, uint64(len(m.Audience)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *ValidateJWTSVIDResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ValidateJWTSVIDResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ValidateJWTSVIDResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Claims != nil {
+		{
+			size, err := m.Claims.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintWorkload(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x12
+	}
+	if len(m.SpiffeId) > 0 {
+		i -= len(m.SpiffeId)
+		copy(dAtA[i:], m.SpiffeId)
+		i = encodeVarintWorkload(dAtA, i, uint64(len(m.SpiffeId)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func encodeVarintWorkload(dAtA []byte, offset int, v uint64) int {
+	offset -= sovWorkload(v)
+	base := offset
+	for v >= 1<<7 {
+		dAtA[offset] = uint8(v&0x7f | 0x80)
+		v >>= 7
+		offset++
+	}
+	dAtA[offset] = uint8(v)
+	return base
+}
+func (m *X509SVIDRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	return n
+}
+
+func (m *X509SVIDResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Svids) > 0 {
+		for _, e := range m.Svids {
+			l = e.Size()
+			n += 1 + l + sovWorkload(uint64(l))
+		}
+	}
+	if len(m.Crl) > 0 {
+		for _, b := range m.Crl {
+			l = len(b)
+			n += 1 + l + sovWorkload(uint64(l))
+		}
+	}
+	if len(m.FederatedBundles) > 0 {
+		for k, v := range m.FederatedBundles {
+			_ = k
+			_ = v
+			l = 0
+			if len(v) > 0 {
+				l = 1 + len(v) + sovWorkload(uint64(len(v)))
+			}
+			mapEntrySize := 1 + len(k) + sovWorkload(uint64(len(k))) + l
+			n += mapEntrySize + 1 + sovWorkload(uint64(mapEntrySize))
+		}
+	}
+	return n
+}
+
+func (m *X509SVID) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.SpiffeId)
+	if l > 0 {
+		n += 1 + l + sovWorkload(uint64(l))
+	}
+	l = len(m.X509Svid)
+	if l > 0 {
+		n += 1 + l + sovWorkload(uint64(l))
+	}
+	l = len(m.X509SvidKey)
+	if l > 0 {
+		n += 1 + l + sovWorkload(uint64(l))
+	}
+	l = len(m.Bundle)
+	if l > 0 {
+		n += 1 + l + sovWorkload(uint64(l))
+	}
+	return n
+}
+
+func (m *JWTSVID) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.SpiffeId)
+	if l > 0 {
+		n += 1 + l + sovWorkload(uint64(l))
+	}
+	l = len(m.Svid)
+	if l > 0 {
+		n += 1 + l + sovWorkload(uint64(l))
+	}
+	return n
+}
+
+func (m *JWTSVIDRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Audience) > 0 {
+		for _, s := range m.Audience {
+			l = len(s)
+			n += 1 + l + sovWorkload(uint64(l))
+		}
+	}
+	l = len(m.SpiffeId)
+	if l > 0 {
+		n += 1 + l + sovWorkload(uint64(l))
+	}
+	return n
+}
+
+func (m *JWTSVIDResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Svids) > 0 {
+		for _, e := range m.Svids {
+			l = e.Size()
+			n += 1 + l + sovWorkload(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *JWTBundlesRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	return n
+}
+
+func (m *JWTBundlesResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Bundles) > 0 {
+		for k, v := range m.Bundles {
+			_ = k
+			_ = v
+			l = 0
+			if len(v) > 0 {
+				l = 1 + len(v) + sovWorkload(uint64(len(v)))
+			}
+			mapEntrySize := 1 + len(k) + sovWorkload(uint64(len(k))) + l
+			n += mapEntrySize + 1 + sovWorkload(uint64(mapEntrySize))
+		}
+	}
+	return n
+}
+
+func (m *ValidateJWTSVIDRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Audience)
+	if l > 0 {
+		n += 1 + l + sovWorkload(uint64(l))
+	}
+	l = len(m.Svid)
+	if l > 0 {
+		n += 1 + l + sovWorkload(uint64(l))
+	}
+	return n
+}
+
+func (m *ValidateJWTSVIDResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.SpiffeId)
+	if l > 0 {
+		n += 1 + l + sovWorkload(uint64(l))
+	}
+	if m.Claims != nil {
+		l = m.Claims.Size()
+		n += 1 + l + sovWorkload(uint64(l))
+	}
+	return n
+}
+
+func sovWorkload(x uint64) (n int) {
+	return (math_bits.Len64(x|1) + 6) / 7
+}
+func sozWorkload(x uint64) (n int) {
+	return sovWorkload(uint64((x << 1) ^ uint64((int64(x) >> 63))))
+}
+func (this *X509SVIDRequest) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&X509SVIDRequest{`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *X509SVIDResponse) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForSvids := "[]*X509SVID{"
+	for _, f := range this.Svids {
+		repeatedStringForSvids += strings.Replace(f.String(), "X509SVID", "X509SVID", 1) + ","
+	}
+	repeatedStringForSvids += "}"
+	keysForFederatedBundles := make([]string, 0, len(this.FederatedBundles))
+	for k, _ := range this.FederatedBundles {
+		keysForFederatedBundles = append(keysForFederatedBundles, k)
+	}
+	github_com_gogo_protobuf_sortkeys.Strings(keysForFederatedBundles)
+	mapStringForFederatedBundles := "map[string][]byte{"
+	for _, k := range keysForFederatedBundles {
+		mapStringForFederatedBundles += fmt.Sprintf("%v: %v,", k, this.FederatedBundles[k])
+	}
+	mapStringForFederatedBundles += "}"
+	s := strings.Join([]string{`&X509SVIDResponse{`,
+		`Svids:` + repeatedStringForSvids + `,`,
+		`Crl:` + fmt.Sprintf("%v", this.Crl) + `,`,
+		`FederatedBundles:` + mapStringForFederatedBundles + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *X509SVID) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&X509SVID{`,
+		`SpiffeId:` + fmt.Sprintf("%v", this.SpiffeId) + `,`,
+		`X509Svid:` + fmt.Sprintf("%v", this.X509Svid) + `,`,
+		`X509SvidKey:` + fmt.Sprintf("%v", this.X509SvidKey) + `,`,
+		`Bundle:` + fmt.Sprintf("%v", this.Bundle) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *JWTSVID) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&JWTSVID{`,
+		`SpiffeId:` + fmt.Sprintf("%v", this.SpiffeId) + `,`,
+		`Svid:` + fmt.Sprintf("%v", this.Svid) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *JWTSVIDRequest) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&JWTSVIDRequest{`,
+		`Audience:` + fmt.Sprintf("%v", this.Audience) + `,`,
+		`SpiffeId:` + fmt.Sprintf("%v", this.SpiffeId) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *JWTSVIDResponse) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForSvids := "[]*JWTSVID{"
+	for _, f := range this.Svids {
+		repeatedStringForSvids += strings.Replace(f.String(), "JWTSVID", "JWTSVID", 1) + ","
+	}
+	repeatedStringForSvids += "}"
+	s := strings.Join([]string{`&JWTSVIDResponse{`,
+		`Svids:` + repeatedStringForSvids + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *JWTBundlesRequest) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&JWTBundlesRequest{`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *JWTBundlesResponse) String() string {
+	if this == nil {
+		return "nil"
+	}
+	keysForBundles := make([]string, 0, len(this.Bundles))
+	for k, _ := range this.Bundles {
+		keysForBundles = append(keysForBundles, k)
+	}
+	github_com_gogo_protobuf_sortkeys.Strings(keysForBundles)
+	mapStringForBundles := "map[string][]byte{"
+	for _, k := range keysForBundles {
+		mapStringForBundles += fmt.Sprintf("%v: %v,", k, this.Bundles[k])
+	}
+	mapStringForBundles += "}"
+	s := strings.Join([]string{`&JWTBundlesResponse{`,
+		`Bundles:` + mapStringForBundles + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *ValidateJWTSVIDRequest) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&ValidateJWTSVIDRequest{`,
+		`Audience:` + fmt.Sprintf("%v", this.Audience) + `,`,
+		`Svid:` + fmt.Sprintf("%v", this.Svid) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *ValidateJWTSVIDResponse) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&ValidateJWTSVIDResponse{`,
+		`SpiffeId:` + fmt.Sprintf("%v", this.SpiffeId) + `,`,
+		`Claims:` + strings.Replace(fmt.Sprintf("%v", this.Claims), "Struct", "types.Struct", 1) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func valueToStringWorkload(v interface{}) string {
+	rv := reflect.ValueOf(v)
+	if rv.IsNil() {
+		return "nil"
+	}
+	pv := reflect.Indirect(rv).Interface()
+	return fmt.Sprintf("*%v", pv)
+}
+func (m *X509SVIDRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowWorkload
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: X509SVIDRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: X509SVIDRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		default:
+			iNdEx = preIndex
+			skippy, err := skipWorkload(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthWorkload
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthWorkload
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *X509SVIDResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowWorkload
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: X509SVIDResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: X509SVIDResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Svids", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowWorkload
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthWorkload
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthWorkload
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Svids = append(m.Svids, &X509SVID{})
+			if err := m.Svids[len(m.Svids)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Crl", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowWorkload
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthWorkload
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthWorkload
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Crl = append(m.Crl, make([]byte, postIndex-iNdEx))
+			copy(m.Crl[len(m.Crl)-1], dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field FederatedBundles", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowWorkload
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthWorkload
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthWorkload
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.FederatedBundles == nil {
+				m.FederatedBundles = make(map[string][]byte)
+			}
+			var mapkey string
+			mapvalue := []byte{}
+			for iNdEx < postIndex {
+				entryPreIndex := iNdEx
+				var wire uint64
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowWorkload
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					wire |= uint64(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				fieldNum := int32(wire >> 3)
+				if fieldNum == 1 {
+					var stringLenmapkey uint64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowWorkload
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						stringLenmapkey |= uint64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					intStringLenmapkey := int(stringLenmapkey)
+					if intStringLenmapkey < 0 {
+						return ErrInvalidLengthWorkload
+					}
+					postStringIndexmapkey := iNdEx + intStringLenmapkey
+					if postStringIndexmapkey < 0 {
+						return ErrInvalidLengthWorkload
+					}
+					if postStringIndexmapkey > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapkey = string(dAtA[iNdEx:postStringIndexmapkey])
+					iNdEx = postStringIndexmapkey
+				} else if fieldNum == 2 {
+					var mapbyteLen uint64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowWorkload
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						mapbyteLen |= uint64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					intMapbyteLen := int(mapbyteLen)
+					if intMapbyteLen < 0 {
+						return ErrInvalidLengthWorkload
+					}
+					postbytesIndex := iNdEx + intMapbyteLen
+					if postbytesIndex < 0 {
+						return ErrInvalidLengthWorkload
+					}
+					if postbytesIndex > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapvalue = make([]byte, mapbyteLen)
+					copy(mapvalue, dAtA[iNdEx:postbytesIndex])
+					iNdEx = postbytesIndex
+				} else {
+					iNdEx = entryPreIndex
+					skippy, err := skipWorkload(dAtA[iNdEx:])
+					if err != nil {
+						return err
+					}
+					if skippy < 0 {
+						return ErrInvalidLengthWorkload
+					}
+					if (iNdEx + skippy) > postIndex {
+						return io.ErrUnexpectedEOF
+					}
+					iNdEx += skippy
+				}
+			}
+			m.FederatedBundles[mapkey] = mapvalue
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipWorkload(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthWorkload
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthWorkload
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *X509SVID) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowWorkload
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: X509SVID: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: X509SVID: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SpiffeId", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowWorkload
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthWorkload
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthWorkload
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.SpiffeId = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field X509Svid", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowWorkload
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthWorkload
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthWorkload
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.X509Svid = append(m.X509Svid[:0], dAtA[iNdEx:postIndex]...)
+			if m.X509Svid == nil {
+				m.X509Svid = []byte{}
+			}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field X509SvidKey", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowWorkload
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthWorkload
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthWorkload
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.X509SvidKey = append(m.X509SvidKey[:0], dAtA[iNdEx:postIndex]...)
+			if m.X509SvidKey == nil {
+				m.X509SvidKey = []byte{}
+			}
+			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Bundle", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowWorkload
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthWorkload
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthWorkload
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Bundle = append(m.Bundle[:0], dAtA[iNdEx:postIndex]...)
+			if m.Bundle == nil {
+				m.Bundle = []byte{}
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipWorkload(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthWorkload
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthWorkload
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *JWTSVID) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowWorkload
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: JWTSVID: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: JWTSVID: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SpiffeId", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowWorkload
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthWorkload
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthWorkload
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.SpiffeId = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Svid", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowWorkload
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthWorkload
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthWorkload
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Svid = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipWorkload(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthWorkload
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthWorkload
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *JWTSVIDRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowWorkload
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: JWTSVIDRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: JWTSVIDRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Audience", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowWorkload
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthWorkload
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthWorkload
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Audience = append(m.Audience, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SpiffeId", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowWorkload
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthWorkload
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthWorkload
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.SpiffeId = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipWorkload(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthWorkload
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthWorkload
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *JWTSVIDResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowWorkload
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: JWTSVIDResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: JWTSVIDResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Svids", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowWorkload
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthWorkload
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthWorkload
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Svids = append(m.Svids, &JWTSVID{})
+			if err := m.Svids[len(m.Svids)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipWorkload(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthWorkload
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthWorkload
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *JWTBundlesRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowWorkload
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: JWTBundlesRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: JWTBundlesRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		default:
+			iNdEx = preIndex
+			skippy, err := skipWorkload(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthWorkload
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthWorkload
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *JWTBundlesResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowWorkload
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: JWTBundlesResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: JWTBundlesResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Bundles", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowWorkload
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthWorkload
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthWorkload
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Bundles == nil {
+				m.Bundles = make(map[string][]byte)
+			}
+			var mapkey string
+			mapvalue := []byte{}
+			for iNdEx < postIndex {
+				entryPreIndex := iNdEx
+				var wire uint64
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowWorkload
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					wire |= uint64(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				fieldNum := int32(wire >> 3)
+				if fieldNum == 1 {
+					var stringLenmapkey uint64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowWorkload
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						stringLenmapkey |= uint64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					intStringLenmapkey := int(stringLenmapkey)
+					if intStringLenmapkey < 0 {
+						return ErrInvalidLengthWorkload
+					}
+					postStringIndexmapkey := iNdEx + intStringLenmapkey
+					if postStringIndexmapkey < 0 {
+						return ErrInvalidLengthWorkload
+					}
+					if postStringIndexmapkey > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapkey = string(dAtA[iNdEx:postStringIndexmapkey])
+					iNdEx = postStringIndexmapkey
+				} else if fieldNum == 2 {
+					var mapbyteLen uint64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowWorkload
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						mapbyteLen |= uint64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					intMapbyteLen := int(mapbyteLen)
+					if intMapbyteLen < 0 {
+						return ErrInvalidLengthWorkload
+					}
+					postbytesIndex := iNdEx + intMapbyteLen
+					if postbytesIndex < 0 {
+						return ErrInvalidLengthWorkload
+					}
+					if postbytesIndex > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapvalue = make([]byte, mapbyteLen)
+					copy(mapvalue, dAtA[iNdEx:postbytesIndex])
+					iNdEx = postbytesIndex
+				} else {
+					iNdEx = entryPreIndex
+					skippy, err := skipWorkload(dAtA[iNdEx:])
+					if err != nil {
+						return err
+					}
+					if skippy < 0 {
+						return ErrInvalidLengthWorkload
+					}
+					if (iNdEx + skippy) > postIndex {
+						return io.ErrUnexpectedEOF
+					}
+					iNdEx += skippy
+				}
+			}
+			m.Bundles[mapkey] = mapvalue
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipWorkload(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthWorkload
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthWorkload
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ValidateJWTSVIDRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowWorkload
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ValidateJWTSVIDRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ValidateJWTSVIDRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Audience", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowWorkload
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthWorkload
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthWorkload
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Audience = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Svid", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowWorkload
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthWorkload
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthWorkload
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Svid = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipWorkload(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthWorkload
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthWorkload
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ValidateJWTSVIDResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowWorkload
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ValidateJWTSVIDResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ValidateJWTSVIDResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SpiffeId", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowWorkload
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthWorkload
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthWorkload
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.SpiffeId = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Claims", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowWorkload
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthWorkload
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthWorkload
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Claims == nil {
+				m.Claims = &types.Struct{}
+			}
+			if err := m.Claims.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipWorkload(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthWorkload
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthWorkload
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func skipWorkload(dAtA []byte) (n int, err error) {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return 0, ErrIntOverflowWorkload
+			}
+			if iNdEx >= l {
+				return 0, io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		wireType := int(wire & 0x7)
+		switch wireType {
+		case 0:
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return 0, ErrIntOverflowWorkload
+				}
+				if iNdEx >= l {
+					return 0, io.ErrUnexpectedEOF
+				}
+				iNdEx++
+				if dAtA[iNdEx-1] < 0x80 {
+					break
+				}
+			}
+			return iNdEx, nil
+		case 1:
+			iNdEx += 8
+			return iNdEx, nil
+		case 2:
+			var length int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return 0, ErrIntOverflowWorkload
+				}
+				if iNdEx >= l {
+					return 0, io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				length |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if length < 0 {
+				return 0, ErrInvalidLengthWorkload
+			}
+			iNdEx += length
+			if iNdEx < 0 {
+				return 0, ErrInvalidLengthWorkload
+			}
+			return iNdEx, nil
+		case 3:
+			for {
+				var innerWire uint64
+				var start int = iNdEx
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return 0, ErrIntOverflowWorkload
+					}
+					if iNdEx >= l {
+						return 0, io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					innerWire |= (uint64(b) & 0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				innerWireType := int(innerWire & 0x7)
+				if innerWireType == 4 {
+					break
+				}
+				next, err := skipWorkload(dAtA[start:])
+				if err != nil {
+					return 0, err
+				}
+				iNdEx = start + next
+				if iNdEx < 0 {
+					return 0, ErrInvalidLengthWorkload
+				}
+			}
+			return iNdEx, nil
+		case 4:
+			return iNdEx, nil
+		case 5:
+			iNdEx += 4
+			return iNdEx, nil
+		default:
+			return 0, fmt.Errorf("proto: illegal wireType %d", wireType)
+		}
+	}
+	panic("unreachable")
+}
+
+var (
+	ErrInvalidLengthWorkload = fmt.Errorf("proto: negative length found during unmarshaling")
+	ErrIntOverflowWorkload   = fmt.Errorf("proto: integer overflow")
+)